@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// analyzedFields are the declaration fields `analyze` compares across
+// components sharing an input name.
+var analyzedFields = []string{"type", "description", "default"}
+
+// InputAnalysis is the `analyze --format json` output.
+type InputAnalysis struct {
+	Components   int           `json:"components"`
+	SharedInputs int           `json:"shared_inputs"` // names declared by several components
+	Inconsistent []SharedInput `json:"inconsistent"`
+}
+
+// SharedInput is an input name declared by several components whose
+// declarations differ.
+type SharedInput struct {
+	Name        string          `json:"name"`
+	Components  []string        `json:"components"`
+	Differences []InputVariants `json:"differences"`
+}
+
+// InputVariants lists the values a field of a shared input takes, the most
+// common first, each with the components declaring it.
+type InputVariants struct {
+	Field    string         `json:"field"`
+	Variants []InputVariant `json:"variants"`
+}
+
+type InputVariant struct {
+	Value      string   `json:"value"` // empty for no description, or no default
+	Components []string `json:"components"`
+}
+
+// runAnalyze implements the `analyze` subcommand: it reports the inputs
+// declared by several components with differing types, descriptions or
+// defaults, so catalog maintainers can converge on consistent interfaces.
+func runAnalyze(args []string, stdout io.Writer) error {
+	flags := newFlagSet("analyze")
+	format := flags.String("format", "text", "Output format: text or json")
+	strict := flags.Bool("strict", false, "Exit with code 6 when inconsistent inputs are found")
+	var ignored []string
+	flags.Var((*stringList)(&ignored), "ignore", "Do not compare this field: "+strings.Join(analyzedFields, ", ")+" (repeatable)")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.Var((*stringList)(&overrides.Include), "include", "Only analyze components matching this glob (repeatable)")
+	flags.Var((*stringList)(&overrides.Exclude), "exclude", "Skip components matching this glob (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if flags.NArg() > 0 {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+	if *format != "text" && *format != "json" {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported format %q (expected one of: text, json)", *format))
+	}
+	var fields []string
+	for _, field := range analyzedFields {
+		if !contains(ignored, field) {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range ignored {
+		if !contains(analyzedFields, field) {
+			return withExitCode(exitConfig, fmt.Errorf("unsupported --ignore %q (expected one of: %s)", field, strings.Join(analyzedFields, ", ")))
+		}
+	}
+	if *jobs < 1 {
+		return withExitCode(exitConfig, fmt.Errorf("invalid --jobs %d: must be at least 1", *jobs))
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	components, err := workingComponents(settings, *jobs)
+	if err != nil {
+		return err
+	}
+
+	analysis := analyzeInputs(components, fields)
+	if err := writeInputAnalysis(stdout, *format, analysis); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing the analysis: %w", err))
+	}
+	if *strict && len(analysis.Inconsistent) > 0 {
+		return withExitCode(exitInvalid, fmt.Errorf("%d input(s) declared inconsistently across components", len(analysis.Inconsistent)))
+	}
+	return nil
+}
+
+// analyzeInputs compares the declarations of the inputs several components
+// share, on the given fields.
+func analyzeInputs(components []ComponentData, fields []string) InputAnalysis {
+	analysis := InputAnalysis{Components: len(components), Inconsistent: []SharedInput{}}
+	declarations := make(map[string]map[string]InputData) // input -> component -> declaration
+	for _, c := range components {
+		for _, input := range c.Inputs {
+			if declarations[input.Name] == nil {
+				declarations[input.Name] = make(map[string]InputData)
+			}
+			declarations[input.Name][c.Name] = input
+		}
+	}
+	var names []string
+	for name, byComponent := range declarations {
+		if len(byComponent) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	analysis.SharedInputs = len(names)
+
+	for _, name := range names {
+		shared := SharedInput{Name: name}
+		for component := range declarations[name] {
+			shared.Components = append(shared.Components, component)
+		}
+		sort.Strings(shared.Components)
+		for _, field := range fields {
+			byValue := make(map[string][]string)
+			for _, component := range shared.Components {
+				value := inputField(declarations[name][component], field)
+				byValue[value] = append(byValue[value], component)
+			}
+			if len(byValue) < 2 {
+				continue
+			}
+			variants := InputVariants{Field: field}
+			for value, components := range byValue {
+				variants.Variants = append(variants.Variants, InputVariant{Value: value, Components: components})
+			}
+			sort.Slice(variants.Variants, func(i, j int) bool {
+				a, b := variants.Variants[i], variants.Variants[j]
+				if len(a.Components) != len(b.Components) {
+					return len(a.Components) > len(b.Components)
+				}
+				return a.Value < b.Value
+			})
+			shared.Differences = append(shared.Differences, variants)
+		}
+		if len(shared.Differences) > 0 {
+			analysis.Inconsistent = append(analysis.Inconsistent, shared)
+		}
+	}
+	return analysis
+}
+
+// inputField returns the value of a compared field of an input. A required
+// input has no default.
+func inputField(input InputData, field string) string {
+	switch field {
+	case "type":
+		return input.Type
+	case "description":
+		return strings.TrimSpace(input.Description)
+	case "default":
+		if input.Required {
+			return ""
+		}
+		return input.Default
+	}
+	return ""
+}
+
+// writeInputAnalysis prints the inconsistent inputs with the variants of
+// each differing field, or the analysis as JSON.
+func writeInputAnalysis(w io.Writer, format string, analysis InputAnalysis) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(analysis)
+	}
+	var b strings.Builder
+	for _, shared := range analysis.Inconsistent {
+		fmt.Fprintf(&b, "%s: declared by %s\n", shared.Name, strings.Join(shared.Components, ", "))
+		for _, d := range shared.Differences {
+			fmt.Fprintf(&b, "  %s differs:\n", d.Field)
+			values := make([]string, len(d.Variants))
+			width := 0
+			for i, v := range d.Variants {
+				values[i] = variantLabel(d.Field, v.Value)
+				width = max(width, min(len(values[i]), 40))
+			}
+			for i, v := range d.Variants {
+				fmt.Fprintf(&b, "    %-*s  %s\n", width, values[i], strings.Join(v.Components, ", "))
+			}
+		}
+	}
+	if len(analysis.Inconsistent) == 0 {
+		fmt.Fprintf(&b, "No inconsistent input: %d input(s) shared across %d components\n", analysis.SharedInputs, analysis.Components)
+	} else {
+		fmt.Fprintf(&b, "%d of %d shared input(s) declared inconsistently across %d components\n", len(analysis.Inconsistent), analysis.SharedInputs, analysis.Components)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// variantLabel formats a field value for the text output: descriptions are
+// quoted and shortened, and missing values named.
+func variantLabel(field, value string) string {
+	switch {
+	case value == "" && field == "default":
+		return "(required)"
+	case value == "":
+		return "(none)"
+	case field == "description":
+		if runes := []rune(value); len(runes) > 60 {
+			value = string(runes[:59]) + "…"
+		}
+		return strconv.Quote(value)
+	}
+	return value
+}
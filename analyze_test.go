@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeInputs(t *testing.T) {
+	components := []ComponentData{
+		{Name: "build", Inputs: []InputData{{Name: "stage", Type: "string", Description: "Stage of the job.", Default: "build"}, {Name: "image", Type: "string", Default: "alpine"}}},
+		{Name: "deploy", Inputs: []InputData{{Name: "stage", Type: "string", Description: "Deployment stage.", Default: "deploy"}, {Name: "image", Type: "string", Default: "alpine"}}},
+		{Name: "test", Inputs: []InputData{{Name: "stage", Type: "string", Description: "Stage of the job.", Required: true}, {Name: "only_test", Type: "string"}}},
+	}
+	analysis := analyzeInputs(components, analyzedFields)
+	if analysis.Components != 3 || analysis.SharedInputs != 2 || len(analysis.Inconsistent) != 1 {
+		t.Fatalf("expected one inconsistent input out of two shared, got %+v", analysis)
+	}
+	stage := analysis.Inconsistent[0]
+	if stage.Name != "stage" || len(stage.Differences) != 2 {
+		t.Fatalf("expected the description and default of stage to differ, got %+v", stage)
+	}
+	description := stage.Differences[0]
+	if description.Field != "description" || description.Variants[0].Value != "Stage of the job." || strings.Join(description.Variants[0].Components, ",") != "build,test" {
+		t.Errorf("expected the most common description first, got %+v", description)
+	}
+	if got := stage.Differences[1].Variants; len(got) != 3 || got[0].Value != "" || got[0].Components[0] != "test" {
+		t.Errorf("expected the required input to have no default, got %+v", got)
+	}
+
+	if analysis := analyzeInputs(components, []string{"type"}); len(analysis.Inconsistent) != 0 {
+		t.Errorf("expected no inconsistency on types, got %+v", analysis.Inconsistent)
+	}
+}
+
+func TestRun_Analyze(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    image:\n      default: alpine\n---\nbuild:\n  image: $[[ inputs.image ]]\n  script: make\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "test.yml"), []byte("spec:\n  inputs:\n    image:\n      type: number\n      default: 1\n---\ntest:\n  image: $[[ inputs.image ]]\n  script: make\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"analyze"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "image: declared by build, test\n  type differs:\n    number  test\n    string  build\n  default differs:\n    1       test\n    alpine  build\n1 of 1 shared input(s) declared inconsistently across 2 components\n"
+	if out.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out.String())
+	}
+
+	out.Reset()
+	if code := exitCode(run([]string{"analyze", "--strict", "--format", "json"}, &out)); code != exitInvalid {
+		t.Errorf("expected exit code %d with --strict, got %d", exitInvalid, code)
+	}
+	var analysis InputAnalysis
+	if err := json.Unmarshal(out.Bytes(), &analysis); err != nil || len(analysis.Inconsistent) != 1 {
+		t.Errorf("expected the analysis as JSON, got %s (%v)", out.String(), err)
+	}
+	if code := exitCode(run([]string{"analyze", "--ignore", "name"}, &out)); code != exitConfig {
+		t.Errorf("expected exit code %d for an unknown field, got %d", exitConfig, code)
+	}
+}
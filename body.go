@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// globalKeywords are top-level keys of a CI/CD configuration that are not jobs.
+var globalKeywords = map[string]bool{
+	"default":   true,
+	"include":   true,
+	"spec":      true,
+	"stages":    true,
+	"variables": true,
+	"workflow":  true,
+}
+
+// EnvVarData maps an input to the environment variable it populates.
+type EnvVarData struct {
+	Input    string `json:"input"`
+	Variable string `json:"variable"`
+	Scope    string `json:"scope"` // "global" or the job name
+}
+
+// JobData is a job defined by the component.
+type JobData struct {
+	Name  string `json:"name"`
+	Stage string `json:"stage"`
+	// Needs lists the jobs of the same pipeline this job needs
+	Needs []string `json:"needs,omitempty"`
+}
+
+// decodeBody decodes the YAML documents that follow the spec header. When the
+// file holds a single document without `spec`, that document is the body.
+func decodeBody(data []byte) ([]map[string]interface{}, error) {
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	resolveMergeKeys(file)
+	return bodyDocuments(file)
+}
+
+// bodyDocuments decodes the body documents of a parsed template (see
+// decodeBody). Empty and comment-only documents are skipped.
+func bodyDocuments(file *ast.File) ([]map[string]interface{}, error) {
+	var docs []map[string]interface{}
+	for _, d := range file.Docs {
+		if isEmptyDocument(d) {
+			continue
+		}
+		var doc map[string]interface{}
+		if err := yaml.NodeToValue(d.Body, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	if len(docs) > 0 {
+		if _, ok := docs[0]["spec"]; ok {
+			docs = docs[1:]
+		}
+	}
+	return docs, nil
+}
+
+// specDocument returns the body of the template's first non-empty document,
+// which holds the spec header. A leading comment-only document (e.g. a
+// license header followed by `---`) is skipped.
+func specDocument(file *ast.File) ast.Node {
+	for _, d := range file.Docs {
+		if !isEmptyDocument(d) {
+			return d.Body
+		}
+	}
+	return nil
+}
+
+// isEmptyDocument reports whether a document holds no content besides comments.
+func isEmptyDocument(d *ast.DocumentNode) bool {
+	if d.Body == nil {
+		return true
+	}
+	_, ok := d.Body.(*ast.CommentGroupNode)
+	return ok
+}
+
+// componentJobs lists the jobs defined in the body documents, sorted by name.
+// Hidden jobs are templates and are not listed, but a job inherits the stage
+// of the jobs it `extends`. Jobs without a stage run in GitLab's default
+// "test" stage.
+func componentJobs(body []map[string]interface{}) []JobData {
+	defs := jobDefinitions(body)
+
+	var jobs []JobData
+	for name := range defs {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		stage := "test"
+		if value := inheritedKey(defs, name, "stage"); value != nil {
+			stage = fmt.Sprintf("%v", value)
+		}
+		jobs = append(jobs, JobData{Name: name, Stage: stage, Needs: jobNeeds(inheritedKey(defs, name, "needs"))})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// jobNeeds returns the job names of a `needs:` value, given as names or as
+// {job:} maps. Needs of other projects or pipelines are left out.
+func jobNeeds(value interface{}) []string {
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var needs []string
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			needs = append(needs, e)
+		case map[string]interface{}:
+			_, project := e["project"]
+			_, pipeline := e["pipeline"]
+			if job, ok := e["job"].(string); ok && !project && !pipeline {
+				needs = append(needs, job)
+			}
+		}
+	}
+	return needs
+}
+
+// jobDefinitions maps the jobs of the body documents, hidden ones included,
+// to their definition.
+func jobDefinitions(body []map[string]interface{}) map[string]map[string]interface{} {
+	defs := make(map[string]map[string]interface{})
+	for _, doc := range body {
+		for key, value := range doc {
+			if job, ok := value.(map[string]interface{}); ok && !globalKeywords[key] {
+				defs[key] = job
+			}
+		}
+	}
+	return defs
+}
+
+// inheritedKey returns the value of a job's key, or the one it inherits
+// through `extends` (later entries override earlier ones). It returns nil
+// when neither the job nor its parents set the key.
+func inheritedKey(defs map[string]map[string]interface{}, name, key string) interface{} {
+	var lookup func(name string, seen map[string]bool) interface{}
+	lookup = func(name string, seen map[string]bool) interface{} {
+		job := defs[name]
+		if job == nil || seen[name] {
+			return nil
+		}
+		seen[name] = true
+		if value, ok := job[key]; ok && value != nil {
+			return value
+		}
+		var parents []string
+		switch extends := job["extends"].(type) {
+		case string:
+			parents = []string{extends}
+		case []interface{}:
+			for _, parent := range extends {
+				parents = append(parents, fmt.Sprintf("%v", parent))
+			}
+		}
+		for i := len(parents) - 1; i >= 0; i-- {
+			if value := lookup(parents[i], seen); value != nil {
+				return value
+			}
+		}
+		return nil
+	}
+	return lookup(name, make(map[string]bool))
+}
+
+// envVarMappings finds global and job-level `variables:` whose value
+// interpolates an input, sorted by input, variable and scope.
+func envVarMappings(body []map[string]interface{}) []EnvVarData {
+	var mappings []EnvVarData
+	collect := func(scope string, variables interface{}) {
+		vars, ok := variables.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for name, value := range vars {
+			// Variables can be plain values or {value:, description:} maps
+			if m, ok := value.(map[string]interface{}); ok {
+				value = m["value"]
+			}
+			for _, interp := range parseInterpolations(fmt.Sprintf("%v", value)) {
+				mappings = append(mappings, EnvVarData{Input: interp.Input, Variable: name, Scope: scope})
+			}
+		}
+	}
+
+	for _, doc := range body {
+		for key, value := range doc {
+			if key == "variables" {
+				collect("global", value)
+				continue
+			}
+			if globalKeywords[key] {
+				continue
+			}
+			if job, ok := value.(map[string]interface{}); ok {
+				collect(key, job["variables"])
+			}
+		}
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		a, b := mappings[i], mappings[j]
+		if a.Input != b.Input {
+			return a.Input < b.Input
+		}
+		if a.Variable != b.Variable {
+			return a.Variable < b.Variable
+		}
+		return a.Scope < b.Scope
+	})
+
+	// Drop duplicates from variables interpolating the same input twice
+	var unique []EnvVarData
+	for i, m := range mappings {
+		if i == 0 || m != mappings[i-1] {
+			unique = append(unique, m)
+		}
+	}
+	return unique
+}
+
+// walkInterpolations calls visit for every input interpolation of the body
+// documents, with its scope: the job name, or the global keyword (e.g.
+// "workflow") for interpolations outside jobs. Job names that are themselves
+// interpolated count as within that job.
+func walkInterpolations(body []map[string]interface{}, visit func(scope string, interp Interpolation)) {
+	record := func(scope, s string) {
+		for _, interp := range parseInterpolations(s) {
+			visit(scope, interp)
+		}
+	}
+
+	var walk func(scope string, value interface{})
+	walk = func(scope string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			record(scope, v)
+		case map[string]interface{}:
+			for key, item := range v {
+				record(scope, key)
+				walk(scope, item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(scope, item)
+			}
+		}
+	}
+
+	for _, doc := range body {
+		for key, value := range doc {
+			record(key, key)
+			walk(key, value)
+		}
+	}
+}
+
+// inputUsages returns, for each interpolated input, the sorted list of
+// places using it (see walkInterpolations).
+func inputUsages(body []map[string]interface{}) map[string][]string {
+	found := make(map[string]map[string]bool)
+	walkInterpolations(body, func(scope string, interp Interpolation) {
+		if found[interp.Input] == nil {
+			found[interp.Input] = make(map[string]bool)
+		}
+		found[interp.Input][scope] = true
+	})
+	return sortedSets(found)
+}
+
+// inputFunctions returns, for each input interpolated with functions, the
+// sorted list of function chains applied to it (e.g. "expand_vars" or
+// "expand_vars | truncate(0,8)").
+func inputFunctions(body []map[string]interface{}) map[string][]string {
+	found := make(map[string]map[string]bool)
+	walkInterpolations(body, func(scope string, interp Interpolation) {
+		if len(interp.Functions) == 0 {
+			return
+		}
+		if found[interp.Input] == nil {
+			found[interp.Input] = make(map[string]bool)
+		}
+		found[interp.Input][strings.Join(interp.Functions, " | ")] = true
+	})
+	return sortedSets(found)
+}
+
+// sortedSets turns a map of sets into a map of sorted lists.
+func sortedSets(sets map[string]map[string]bool) map[string][]string {
+	lists := make(map[string][]string, len(sets))
+	for key, set := range sets {
+		for item := range set {
+			lists[key] = append(lists[key], item)
+		}
+		sort.Strings(lists[key])
+	}
+	return lists
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{"spec header and body", "spec:\n  inputs: {}\n---\njob:\n  script: echo\n", 1},
+		{"spec only", "spec:\n  inputs: {}\n", 0},
+		{"body only", "job:\n  script: echo\n", 1},
+		{"jobs split across documents", "spec:\n  inputs: {}\n---\nbuild:\n  script: echo\n---\ntest:\n  script: echo\n", 2},
+		{"leading comment document", "# License header\n---\nspec:\n  inputs: {}\n---\njob:\n  script: echo\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := decodeBody([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(body) != tt.expected {
+				t.Errorf("expected %d body documents, got %d", tt.expected, len(body))
+			}
+		})
+	}
+}
+
+func TestComponentJobs(t *testing.T) {
+	content := `spec:
+  inputs:
+    stage:
+      default: build
+---
+.base:
+  stage: lint
+  script: echo
+variables:
+  STATIC: "value"
+build:
+  stage: $[[ inputs.stage ]]
+  script: echo
+---
+check:
+  extends: [.other, .base]
+  script: echo
+unit:
+  script: echo
+  needs:
+    - build
+    - job: check
+      artifacts: false
+    - project: group/other
+      job: package
+      ref: main
+`
+	body, err := decodeBody([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := componentJobs(body)
+	expected := []JobData{
+		{Name: "build", Stage: "$[[ inputs.stage ]]"},
+		{Name: "check", Stage: "lint"},
+		{Name: "unit", Stage: "test", Needs: []string{"build", "check"}},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d jobs, got %d: %+v", len(expected), len(got), got)
+	}
+	for i, exp := range expected {
+		if !reflect.DeepEqual(got[i], exp) {
+			t.Errorf("job[%d]: expected %+v, got %+v", i, exp, got[i])
+		}
+	}
+}
+
+func TestParseTemplate_MultiDocument(t *testing.T) {
+	dir := t.TempDir()
+	content := `# Copyright Example Corp.
+---
+spec:
+  inputs:
+    app_name:
+      description: Application name
+---
+build:
+  script: echo $[[ inputs.app_name ]]
+---
+deploy:
+  stage: deploy
+  script: echo
+`
+	path := filepath.Join(dir, "deploy.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	component, err := parseTemplate(path, ParseOptions{TemplatesDir: dir, DocsDir: filepath.Join(dir, "docs")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(component.Inputs) != 1 || component.Inputs[0].Description != "Application name" {
+		t.Fatalf("expected the spec from the first document, got %+v", component.Inputs)
+	}
+	if strings.Join(component.Inputs[0].UsedIn, ",") != "build" {
+		t.Errorf("expected app_name used in build, got %v", component.Inputs[0].UsedIn)
+	}
+	if len(component.Jobs) != 2 || component.Jobs[0].Name != "build" || component.Jobs[1].Stage != "deploy" {
+		t.Errorf("expected jobs from both body documents, got %+v", component.Jobs)
+	}
+	if component.Comment != "" {
+		t.Errorf("expected the separate license header not to be the description, got %q", component.Comment)
+	}
+}
+
+func TestEnvVarMappings(t *testing.T) {
+	content := `spec:
+  inputs:
+    app_name: {}
+    stage:
+      default: build
+---
+variables:
+  APP_NAME: $[[ inputs.app_name ]]
+  STATIC: "value"
+build:
+  stage: $[[ inputs.stage ]]
+  variables:
+    IMAGE: "registry/$[[ inputs.app_name | expand_vars ]]:$[[ inputs.app_name ]]"
+    STAGE_NAME:
+      value: "$[[ inputs.stage ]]"
+      description: "Stage"
+  script: echo
+workflow:
+  rules:
+    - when: always
+`
+	body, err := decodeBody([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := envVarMappings(body)
+	expected := []EnvVarData{
+		{Input: "app_name", Variable: "APP_NAME", Scope: "global"},
+		{Input: "app_name", Variable: "IMAGE", Scope: "build"},
+		{Input: "stage", Variable: "STAGE_NAME", Scope: "build"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d mappings, got %d: %+v", len(expected), len(got), got)
+	}
+	for i, exp := range expected {
+		if got[i] != exp {
+			t.Errorf("mapping[%d]: expected %+v, got %+v", i, exp, got[i])
+		}
+	}
+}
+
+func TestParseTemplate_EnvVars(t *testing.T) {
+	dir := t.TempDir()
+	content := "spec:\n  inputs:\n    app_name: {}\n---\njob:\n  variables:\n    APP: $[[ inputs.app_name ]]\n  script: echo\n"
+	path := filepath.Join(dir, "deploy.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	component, err := parseTemplate(path, ParseOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(component.EnvVars) != 1 || component.EnvVars[0].Variable != "APP" {
+		t.Errorf("expected a single APP mapping, got %+v", component.EnvVars)
+	}
+}
+
+func TestInputFunctions(t *testing.T) {
+	body, err := decodeBody([]byte(`build:
+  image: $[[ inputs.image | expand_vars ]]
+  script:
+    - echo "$[[ inputs.image ]] $[[ inputs.sha | expand_vars | truncate(0,8) ]]"
+test:
+  image: $[[ inputs.image | expand_vars ]]
+  variables:
+    SHA: $[[ inputs.sha | truncate(0,8) ]]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	functions := inputFunctions(body)
+	if len(functions) != 2 {
+		t.Errorf("expected functions for 2 inputs, got %v", functions)
+	}
+	if got := strings.Join(functions["image"], ","); got != "expand_vars" {
+		t.Errorf("functions[image] = %q", got)
+	}
+	if got := strings.Join(functions["sha"], ","); got != "expand_vars | truncate(0,8),truncate(0,8)" {
+		t.Errorf("functions[sha] = %q", got)
+	}
+}
+
+func TestInputUsages(t *testing.T) {
+	content := `spec:
+  inputs:
+    job_prefix: {}
+    stage: {}
+    image: {}
+---
+"$[[ inputs.job_prefix ]]-build":
+  stage: $[[ inputs.stage ]]
+  image: $[[ inputs.image | expand_vars ]]
+  script:
+    - echo "$[[ inputs.undeclared ]]"
+test:
+  stage: $[[ inputs.stage ]]
+  script: echo
+workflow:
+  rules:
+    - if: $[[ inputs.image ]]
+`
+	body, err := decodeBody([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	usages := inputUsages(body)
+	expected := map[string]string{
+		"job_prefix": "$[[ inputs.job_prefix ]]-build",
+		"stage":      "$[[ inputs.job_prefix ]]-build,test",
+		"image":      "$[[ inputs.job_prefix ]]-build,workflow",
+		"undeclared": "$[[ inputs.job_prefix ]]-build",
+	}
+	if len(usages) != len(expected) {
+		t.Errorf("expected %d used inputs, got %d: %v", len(expected), len(usages), usages)
+	}
+	for input, want := range expected {
+		if got := strings.Join(usages[input], ","); got != want {
+			t.Errorf("usages[%q] = %q, want %q", input, got, want)
+		}
+	}
+}
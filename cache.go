@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// cacheFile stores the parsed component models between runs.
+const cacheFile = ".gitlab-component-docs-gen.cache"
+
+// cacheVersion is bumped whenever parsing yields a different model, so entries
+// written by another release of the tool are ignored instead of reused.
+const cacheVersion = 15
+
+// parseCache maps template paths to the component parsed from them, keyed by
+// a hash of every file the component is built from. It is safe for
+// concurrent use by the parse workers.
+type parseCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cacheEntry
+	used    map[string]bool // entries looked up during this run
+	keepAll bool            // keep the entries not looked up when saving
+	dirty   bool
+}
+
+// cacheEntry is a cached component. ComponentData's JSON form omits the
+// fields only used internally, so they are stored alongside it.
+type cacheEntry struct {
+	Hash      string         `json:"hash"`
+	Component ComponentData  `json:"component"`
+	HasBody   bool           `json:"has_body"`
+	Lines     map[string]int `json:"lines,omitempty"`
+	Includes  []string       `json:"includes,omitempty"`
+}
+
+type cacheContent struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// loadParseCache reads the cache file at path. A missing, unreadable or
+// outdated cache is not an error: it simply starts empty.
+func loadParseCache(path string) *parseCache {
+	cache := &parseCache{path: path, entries: map[string]cacheEntry{}, used: map[string]bool{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	var content cacheContent
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers from front-matter and x- keys as written (no float64 rounding)
+	decoder.UseNumber()
+	if err := decoder.Decode(&content); err != nil || content.Version != cacheVersion {
+		return cache
+	}
+	if content.Entries != nil {
+		cache.entries = content.Entries
+	}
+	return cache
+}
+
+// parse returns the cached component for the template when none of its
+// files changed, and parses it (updating the cache) otherwise. A nil cache
+// always parses.
+func (c *parseCache) parse(path string, opts ParseOptions) (ComponentData, error) {
+	if c == nil {
+		return parseTemplate(path, opts)
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.used[path] = true
+	c.mu.Unlock()
+
+	// The files it includes are only known from the entry, or once parsed
+	hash, err := componentHash(path, opts, entry.Includes)
+	if err != nil {
+		return parseTemplate(path, opts)
+	}
+	if ok && entry.Hash == hash {
+		opts.Log.Debugf("Using cached %s", path)
+		component := entry.Component
+		component.HasBody = entry.HasBody
+		component.path = path
+		component.lines = entry.Lines
+		component.includes = entry.Includes
+		return component, nil
+	}
+
+	component, err := parseTemplate(path, opts)
+	if err != nil {
+		return component, err
+	}
+	if hash, err = componentHash(path, opts, component.includes); err != nil {
+		return component, nil
+	}
+	keep := cacheable(component)
+	c.mu.Lock()
+	if keep {
+		c.entries[path] = cacheEntry{Hash: hash, Component: component, HasBody: component.HasBody, Lines: component.lines, Includes: component.includes}
+		c.dirty = true
+	} else if _, ok := c.entries[path]; ok {
+		delete(c.entries, path)
+		c.dirty = true
+	}
+	c.mu.Unlock()
+	return component, nil
+}
+
+// cacheable reports whether a component reads back from the cache as it was
+// parsed. The values of registered extension decoders may not: a struct
+// reloads as a map, an int as a json.Number. Such components are parsed on
+// every run instead.
+func cacheable(component ComponentData) bool {
+	for _, input := range component.Inputs {
+		for name, value := range input.Extensions {
+			if _, ok := inputExtensions[name]; !ok {
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return false
+			}
+			var reloaded interface{}
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			if err := decoder.Decode(&reloaded); err != nil || !reflect.DeepEqual(value, reloaded) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// keepUnused keeps the entries that were not looked up when saving, for
+// runs that only parse part of the templates.
+func (c *parseCache) keepUnused() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.keepAll = true
+	c.mu.Unlock()
+}
+
+// save writes the cache back when it changed. Entries of templates that were
+// not looked up during the run (deleted or renamed) are dropped, unless
+// keepUnused was called.
+func (c *parseCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for path := range c.entries {
+		if !c.used[path] && !c.keepAll {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(cacheContent{Version: cacheVersion, Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("error encoding cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("error writing cache %s: %w", c.path, err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// componentHash hashes the parse options and every file a component is built
+// from: its template, the files it includes, description files and the files
+// in its docs and examples directories. Missing files are part of the hash
+// too, so adding a description invalidates the entry.
+func componentHash(path string, opts ParseOptions, includes []string) (string, error) {
+	name := componentName(opts.TemplatesDir, path)
+	files := append([]string{path}, descriptionPaths(opts.DocsDir, name, descriptionExtensions(opts.Format)...)...)
+	files = append(files, includes...)
+	for _, dir := range []string{filepath.Join(opts.DocsDir, name), filepath.Join(opts.ExamplesDir, name)} {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == dir && errors.Is(err, fs.ErrNotExist) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if !d.IsDir() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t\x00%s\x00", cacheVersion, opts.TemplatesDir, opts.DocsDir, opts.ExamplesDir, opts.SortOrder, opts.Format, opts.ResolveIncludes, opts.RootDir)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			fmt.Fprintf(h, "%s\x00-\x00", f)
+		case err != nil:
+			return "", err
+		default:
+			fmt.Fprintf(h, "%s\x00%d\x00", f, len(data))
+			h.Write(data)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCache(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	os.MkdirAll("templates", 0755)
+	os.MkdirAll("docs", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n---\njob:\n  stage: $[[ inputs.stage ]]\n  script: echo\n"), 0644)
+	os.WriteFile(filepath.Join("templates", "old.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	opts := ParseOptions{TemplatesDir: "templates", DocsDir: "docs", ExamplesDir: "examples"}
+
+	cache := loadParseCache(cacheFile)
+	for _, path := range []string{filepath.Join("templates", "build.yml"), filepath.Join("templates", "old.yml")} {
+		if _, err := cache.parse(path, opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := cache.save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Tamper with the cached model: an unchanged template must come from the cache
+	cache = loadParseCache(cacheFile)
+	entry := cache.entries[filepath.Join("templates", "build.yml")]
+	if entry.Hash == "" {
+		t.Fatalf("expected build.yml to be cached, got %+v", cache.entries)
+	}
+	entry.Component.Description = "from cache"
+	cache.entries[filepath.Join("templates", "build.yml")] = entry
+
+	component, err := cache.parse(filepath.Join("templates", "build.yml"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if component.Description != "from cache" {
+		t.Errorf("expected the cached component, got description %q", component.Description)
+	}
+	if !component.HasBody || component.path != filepath.Join("templates", "build.yml") {
+		t.Errorf("expected internal fields to be restored, got HasBody %v, path %q", component.HasBody, component.path)
+	}
+	if len(component.Inputs) != 1 || component.Inputs[0].UsedIn[0] != "job" {
+		t.Errorf("expected cached inputs, got %+v", component.Inputs)
+	}
+
+	// Adding a description invalidates the entry
+	os.WriteFile(filepath.Join("docs", "build.md"), []byte("Builds things.\n"), 0644)
+	component, err = cache.parse(filepath.Join("templates", "build.yml"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if component.Description != "Builds things." {
+		t.Errorf("expected a fresh parse after the description changed, got %q", component.Description)
+	}
+
+	// old.yml was not looked up during this run: saving drops it
+	if err := cache.save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := loadParseCache(cacheFile).entries[filepath.Join("templates", "old.yml")]; ok {
+		t.Error("expected the entry of a template not parsed anymore to be dropped")
+	}
+}
+
+func TestParseCache_DecodedExtensions(t *testing.T) {
+	type team struct{ Name string }
+	RegisterInputExtension("test-team", func(value interface{}) (interface{}, error) {
+		return team{Name: fmt.Sprint(value)}, nil
+	})
+	defer delete(inputExtensions, "test-team")
+
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      x-test-team: platform\n"), 0644)
+	os.WriteFile(filepath.Join("templates", "deploy.yml"), []byte("spec:\n  inputs:\n    token:\n      x-sensitive: true\n"), 0644)
+	opts := ParseOptions{TemplatesDir: "templates", DocsDir: "docs", ExamplesDir: "examples"}
+
+	// A warm run yields the components of a cold one
+	parse := func() []ComponentData {
+		t.Helper()
+		cache := loadParseCache(cacheFile)
+		var components []ComponentData
+		for _, name := range []string{"build.yml", "deploy.yml"} {
+			c, err := cache.parse(filepath.Join("templates", name), opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			components = append(components, c)
+		}
+		if err := cache.save(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return components
+	}
+	cold, warm := parse(), parse()
+	if !reflect.DeepEqual(cold, warm) {
+		t.Errorf("expected the same components from the cache, got:\n%+v\nwant:\n%+v", warm, cold)
+	}
+	if got := warm[0].Inputs[0].Extensions["test-team"]; got != (team{Name: "platform"}) {
+		t.Errorf("expected the decoded extension, got %#v", got)
+	}
+
+	// Only the component whose decoded values don't survive the cache is parsed again
+	entries := loadParseCache(cacheFile).entries
+	if _, ok := entries[filepath.Join("templates", "build.yml")]; ok {
+		t.Error("expected build.yml not to be cached")
+	}
+	if _, ok := entries[filepath.Join("templates", "deploy.yml")]; !ok {
+		t.Error("expected deploy.yml to be cached")
+	}
+}
+
+func TestLoadParseCache_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"garbage":       "not json",
+		"other version": `{"version": 999, "entries": {"templates/a.yml": {"hash": "x"}}}`,
+	} {
+		path := filepath.Join(dir, "cache")
+		os.WriteFile(path, []byte(content), 0644)
+		if cache := loadParseCache(path); len(cache.entries) != 0 {
+			t.Errorf("%s: expected an empty cache, got %+v", name, cache.entries)
+		}
+	}
+}
+
+func TestRun_NoCache(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--no-cache"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
+		t.Errorf("expected no cache file with --no-cache, got %v", err)
+	}
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(cacheFile); err != nil {
+		t.Errorf("expected the cache file to be written: %v", err)
+	}
+}
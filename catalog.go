@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// CatalogEntry is a project of the aggregated catalog index.
+type CatalogEntry struct {
+	Project     string   `json:"project"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Components  []string `json:"components"`
+	Docs        string   `json:"docs"` // project docs, relative to the index
+}
+
+// catalogExtensions maps each format to the extension of the files it writes.
+var catalogExtensions = map[string]string{
+	"markdown": ".md",
+	"asciidoc": ".adoc",
+	"json":     ".json",
+}
+
+// runCatalog implements the `catalog` subcommand: it documents every project
+// listed under `catalog.projects` in the config file, one file per project,
+// and writes an index of all of them (project, components, version and
+// description), the basis of a component marketplace page.
+func runCatalog(args []string, stdout io.Writer) error {
+	flags := newFlagSet("catalog")
+	outputDir := flags.String("output-dir", "", "Directory receiving one documentation file per project (default \"catalog\")")
+	index := flags.String("index", "", "Index file (default: README, or index.json, in --output-dir)")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	verbose := flags.Bool("verbose", false, "Also log which files are fetched, read or skipped, and why")
+	debug := flags.Bool("debug", false, "Log every step of the run (implies --verbose)")
+	logFormat := flags.String("log-format", "text", "Log format: "+strings.Join(logFormats, ", "))
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "README template path used for each project (default depends on --format)")
+	flags.StringVar(&overrides.Format, "format", "", "Output format: "+strings.Join(outputFormats, ", ")+" (default \"markdown\")")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if !contains(logFormats, *logFormat) {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported log format %q (expected one of: %s)", *logFormat, strings.Join(logFormats, ", ")))
+	}
+	log := newLogger(stdout, logLevelFor(*quiet, *verbose, *debug), *logFormat)
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if len(config.Catalog.Projects) == 0 {
+		return withExitCode(exitConfig, fmt.Errorf("no catalog projects: list them under catalog.projects in %s", configFile))
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if _, ok := catalogExtensions[settings.Format]; !ok {
+		return withExitCode(exitConfig, fmt.Errorf("catalog does not support the %s format", settings.Format))
+	}
+	var sources []remoteSource
+	for _, p := range config.Catalog.Projects {
+		src, err := parseSource(p.Source)
+		if err != nil {
+			return withExitCode(exitConfig, err)
+		}
+		sources = append(sources, src)
+	}
+
+	dir := *outputDir
+	if dir == "" {
+		dir = config.Catalog.OutputDir
+	}
+	if dir == "" {
+		dir = "catalog"
+	}
+	indexPath := *index
+	if indexPath == "" {
+		indexPath = config.Catalog.Index
+	}
+	if indexPath == "" {
+		name := defaultOutputs[settings.Format]
+		if settings.Format == "json" {
+			name = "index.json"
+		}
+		indexPath = filepath.Join(dir, name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error creating %s: %w", dir, err))
+	}
+
+	var entries []CatalogEntry
+	for _, src := range sources {
+		entry, err := documentCatalogProject(src, settings, dir, filepath.Dir(indexPath), *jobs, log)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	out, err := renderCatalogIndex(settings.Format, entries)
+	if err != nil {
+		return withExitCode(exitFailure, err)
+	}
+	if err := os.WriteFile(indexPath, out, 0644); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", indexPath, err))
+	}
+	log.Infof("Catalog of %d project(s) written to %s", len(entries), indexPath)
+	return nil
+}
+
+// documentCatalogProject fetches a project's component files, writes its
+// documentation to dir and returns its index entry. Errors carry their exit
+// code.
+func documentCatalogProject(src remoteSource, settings Settings, dir, indexDir string, jobs int, log *logger) (CatalogEntry, error) {
+	tmp, err := os.MkdirTemp("", "gitlab-component-docs-gen-")
+	if err != nil {
+		return CatalogEntry{}, withExitCode(exitFailure, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	remote := resolveRemote("")
+	fetched, project, err := fetchSource(src, settings, remote, tmp, log)
+	if err != nil {
+		return CatalogEntry{}, withExitCode(exitParse, err)
+	}
+	projectPath := src.Project
+	if project.Path != "" {
+		projectPath = project.Path
+	}
+	version := src.Ref
+	if version == "" {
+		version = project.LatestRelease
+	}
+	if version == "" {
+		version = project.DefaultBranch
+	}
+
+	data, err := collectTemplateData(fetched, nil, projectPath, version, remote, jobs, log)
+	if err != nil {
+		return CatalogEntry{}, err
+	}
+	data.DefaultBranch = project.DefaultBranch
+	doc, err := renderDocument(fetched, data)
+	if err != nil {
+		return CatalogEntry{}, withExitCode(exitTemplate, err)
+	}
+	output := filepath.Join(dir, strings.ReplaceAll(projectPath, "/", "-")+catalogExtensions[settings.Format])
+	if err := writeFileAtomic(output, doc); err != nil {
+		return CatalogEntry{}, withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", output, err))
+	}
+	log.Verbosef("Documented %s (%d components) in %s", projectPath, len(data.Components), output)
+
+	entry := CatalogEntry{Project: projectPath, Version: data.Version, Description: project.Description, Docs: output}
+	if rel, err := filepath.Rel(indexDir, output); err == nil {
+		entry.Docs = filepath.ToSlash(rel)
+	}
+	for _, c := range data.Components {
+		entry.Components = append(entry.Components, c.Name)
+	}
+	return entry, nil
+}
+
+// renderCatalogIndex renders the catalog index as a table linking each
+// project's documentation, or as a JSON array.
+func renderCatalogIndex(format string, entries []CatalogEntry) ([]byte, error) {
+	var b strings.Builder
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error encoding JSON: %w", err)
+		}
+		return append(out, '\n'), nil
+	case "asciidoc":
+		b.WriteString("= Component catalog\n\n[options=\"header\"]\n|===\n| Project | Components | Version | Description\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "\n| xref:%s[%s] | %s | %s | %s\n", e.Docs, e.Project, asciidocCell(codeList(e.Components)), asciidocCell(e.Version), asciidocCell(e.Description))
+		}
+		b.WriteString("|===\n")
+	default:
+		b.WriteString("# Component catalog\n\n| Project | Components | Version | Description |\n|---------|------------|---------|-------------|\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "| [%s](%s) | %s | %s | %s |\n", e.Project, e.Docs, markdownCell(codeList(e.Components)), markdownCell(e.Version), markdownCell(e.Description))
+		}
+	}
+	return []byte(b.String()), nil
+}
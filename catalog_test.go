@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCatalog(t *testing.T) {
+	server := sourceTestServer(t, map[string]string{
+		"templates/build.yml":  "spec:\n  inputs: {}\n",
+		"templates/deploy.yml": "spec:\n  inputs: {}\n",
+	}, "")
+	setGitLabEnv(t, server.URL)
+
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if got := exitCode(run([]string{"catalog", "--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without catalog projects, got %d", exitConfig, got)
+	}
+
+	os.WriteFile(configFile, []byte("catalog:\n  projects:\n    - source: gitlab://platform/catalog\n"), 0644)
+	if err := run([]string{"catalog", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	docs, err := os.ReadFile(filepath.Join("catalog", "platform-catalog.md"))
+	if err != nil {
+		t.Fatalf("expected the project docs to be written: %v", err)
+	}
+	if !strings.Contains(string(docs), "$CI_SERVER_FQDN/platform/catalog/deploy@v3.0.0") {
+		t.Errorf("expected the project docs at its latest release, got:\n%s", docs)
+	}
+	index, err := os.ReadFile(filepath.Join("catalog", "README.md"))
+	if err != nil {
+		t.Fatalf("expected the index to be written: %v", err)
+	}
+	expected := "| [platform/catalog](platform-catalog.md) | `build`, `deploy` | v3.0.0 |  |\n"
+	if !strings.Contains(string(index), expected) {
+		t.Errorf("expected index row %q, got:\n%s", expected, index)
+	}
+
+	if err := run([]string{"catalog", "--quiet", "--format", "json", "--output-dir", "out"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var entries []CatalogEntry
+	data, _ := os.ReadFile(filepath.Join("out", "index.json"))
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("invalid JSON index: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Docs != "platform-catalog.json" || len(entries[0].Components) != 2 {
+		t.Errorf("unexpected JSON index: %+v", entries)
+	}
+}
+
+func TestRenderCatalogIndex(t *testing.T) {
+	entries := []CatalogEntry{{Project: "g/p", Version: "1.0.0", Description: "Builds | deploys", Components: []string{"build"}, Docs: "g-p.adoc"}}
+	out, err := renderCatalogIndex("asciidoc", entries)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(out), `| xref:g-p.adoc[g/p] | `+"`build`"+` | 1.0.0 | Builds \| deploys`) {
+		t.Errorf("unexpected AsciiDoc index:\n%s", out)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// affectedComponents maps the changed files onto component templates. A
+// component is affected when its template, description, per-component
+// template, docs directory or examples changed; when a shared file (config,
+// README template, header or footer file) changed, all components are
+// affected.
+func affectedComponents(settings Settings, templates, changed []string) (affected map[string]bool, all bool) {
+	clean := func(path string) string { return filepath.ToSlash(filepath.Clean(path)) }
+
+	changedSet := make(map[string]bool, len(changed))
+	for _, f := range changed {
+		changedSet[clean(f)] = true
+	}
+
+	for _, shared := range []string{configFile, settings.Template, settings.HeaderFile, settings.FooterFile} {
+		if shared != "" && changedSet[clean(shared)] {
+			return nil, true
+		}
+	}
+
+	affected = make(map[string]bool)
+	for _, t := range templates {
+		name := componentName(settings.TemplatesDir, t)
+		candidates := append([]string{t}, descriptionPaths(settings.DocsDir, name, descriptionExtensions(settings.Format)...)...)
+		candidates = append(candidates, overridePaths(settings, name)...)
+		for _, c := range candidates {
+			if changedSet[clean(c)] {
+				affected[t] = true
+				break
+			}
+		}
+		// Any file in the component's docs directory (e.g. images) or examples
+		for _, dir := range []string{settings.DocsDir, settings.ExamplesDir} {
+			prefix := clean(filepath.Join(dir, name)) + "/"
+			for f := range changedSet {
+				if strings.HasPrefix(f, prefix) {
+					affected[t] = true
+				}
+			}
+		}
+	}
+	return affected, false
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAffectedComponents(t *testing.T) {
+	settings := Settings{Template: "README.md.tmpl", TemplatesDir: "templates", DocsDir: "docs", Format: "markdown"}
+	templates := []string{"templates/build.yml", "templates/deploy.yml", "templates/lint.yml"}
+
+	affected, all := affectedComponents(settings, templates, []string{"templates/build.yml", "docs/deploy.md", "src/main.go"})
+	if all {
+		t.Fatal("expected only some components to be affected")
+	}
+	if !affected["templates/build.yml"] || !affected["templates/deploy.yml"] || affected["templates/lint.yml"] {
+		t.Errorf("unexpected affected set: %v", affected)
+	}
+
+	affected, _ = affectedComponents(settings, templates, []string{"docs/lint/index.md", "docs/build/diagram.png"})
+	if !affected["templates/lint.yml"] || !affected["templates/build.yml"] || affected["templates/deploy.yml"] {
+		t.Errorf("expected docs directory changes to affect their component, got %v", affected)
+	}
+
+	affected, _ = affectedComponents(settings, templates, []string{"templates/lint.md.tmpl"})
+	if !affected["templates/lint.yml"] || len(affected) != 1 {
+		t.Errorf("expected a per-component template change to affect its component, got %v", affected)
+	}
+
+	if _, all := affectedComponents(settings, templates, []string{"README.md.tmpl"}); !all {
+		t.Error("expected a README template change to affect all components")
+	}
+	if _, all := affectedComponents(settings, templates, []string{".gitlab-component-docs-gen.yml"}); !all {
+		t.Error("expected a config change to affect all components")
+	}
+	settings.FooterFile = "docs/CONTRIBUTING.md"
+	if _, all := affectedComponents(settings, templates, []string{"docs/CONTRIBUTING.md"}); !all {
+		t.Error("expected a footer file change to affect all components")
+	}
+}
+
+func TestResolveDiffBase(t *testing.T) {
+	t.Setenv("CI_MERGE_REQUEST_DIFF_BASE_SHA", "")
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "")
+	t.Setenv("CI_DEFAULT_BRANCH", "")
+
+	if got := resolveDiffBase(""); got != "origin/main" {
+		t.Errorf("expected 'origin/main', got %q", got)
+	}
+	t.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "develop")
+	if got := resolveDiffBase(""); got != "origin/develop" {
+		t.Errorf("expected 'origin/develop', got %q", got)
+	}
+	t.Setenv("CI_MERGE_REQUEST_DIFF_BASE_SHA", "abc123")
+	if got := resolveDiffBase(""); got != "abc123" {
+		t.Errorf("expected 'abc123', got %q", got)
+	}
+	if got := resolveDiffBase("main"); got != "main" {
+		t.Errorf("expected 'main', got %q", got)
+	}
+}
+
+func TestChangedFiles_Subdirectory(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git("init", "-q")
+	os.MkdirAll("ci/templates", 0755)
+	os.WriteFile("ci/templates/build.yml", []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile("other.txt", []byte("a\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	// Run from the subdirectory: paths are relative to it, like the settings'
+	os.WriteFile("ci/templates/build.yml", []byte("spec:\n  inputs:\n    app: {}\n"), 0644)
+	os.WriteFile("ci/templates/deploy.yml", []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile("other.txt", []byte("b\n"), 0644)
+	os.Chdir("ci")
+	changed, err := changedFiles("HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(changed, ",") != "templates/build.yml,templates/deploy.yml" {
+		t.Errorf("unexpected changed files %v", changed)
+	}
+}
+
+func TestRun_ChangedOnly(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git("init", "-q")
+	os.MkdirAll("templates", 0755)
+	os.WriteFile("templates/build.yml", []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile("templates/deploy.yml", []byte("spec:\n  inputs:\n    level:\n      default: info\n      options: [info]\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	// Nothing changed: nothing to do
+	if err := run([]string{"--changed-only", "--base", "HEAD", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected no README.md when no component changed")
+	}
+
+	// An unchanged broken component is not validated, a changed one is
+	os.WriteFile("templates/deploy.yml", []byte("spec:\n  inputs:\n    level:\n      default: trace\n      options: [info]\n"), 0644)
+	git("commit", "-q", "-am", "break deploy")
+	os.WriteFile("templates/build.yml", []byte("spec:\n  inputs:\n    app: {}\n"), 0644)
+	if err := run([]string{"--changed-only", "--base", "HEAD", "--validate", "--quiet"}, io.Discard); err != nil {
+		t.Errorf("expected only build to be validated, got %v", err)
+	}
+
+	// A changed component triggers a full regeneration
+	if err := run([]string{"--changed-only", "--base", "HEAD", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readme, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatalf("expected README.md to be generated: %v", err)
+	}
+	if !strings.Contains(string(readme), "## build") || !strings.Contains(string(readme), "## deploy") {
+		t.Errorf("expected all components in README.md, got:\n%s", readme)
+	}
+}
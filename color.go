@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// ANSI styles of the terminal output: diffs, the terminal viewer and the
+// status lines of a run.
+const (
+	ansiReset     = "\033[0m"
+	ansiBold      = "\033[1m"
+	ansiItalic    = "\033[3m"
+	ansiUnderline = "\033[4m"
+	ansiStrike    = "\033[9m"
+	ansiRed       = "\033[31m"
+	ansiGreen     = "\033[32m"
+	ansiYellow    = "\033[33m"
+	ansiBlue      = "\033[34m"
+	ansiMagenta   = "\033[35m"
+	ansiCyan      = "\033[36m"
+	ansiMuted     = "\033[90m"
+)
+
+// colorEnabled reports whether output written to w should be colorized: only
+// for terminals, and never with --no-color (noColor) or when NO_COLOR is set
+// (https://no-color.org).
+func colorEnabled(w io.Writer, noColor bool) bool {
+	f, ok := w.(*os.File)
+	return ok && !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+// paint wraps s in an ANSI style when color is set.
+func paint(color bool, style, s string) string {
+	if !color || style == "" || s == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestColorEnabled(t *testing.T) {
+	if colorEnabled(&bytes.Buffer{}, false) {
+		t.Error("expected no color when writing to a buffer")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if colorEnabled(f, false) {
+		t.Error("expected no color when writing to a regular file")
+	}
+	if colorEnabled(os.Stdout, true) {
+		t.Error("expected --no-color to disable colors")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(os.Stdout, false) {
+		t.Error("expected NO_COLOR to disable colors")
+	}
+}
+
+func TestPaint(t *testing.T) {
+	if got := paint(false, ansiGreen, "created"); got != "created" {
+		t.Errorf("expected plain text without color, got %q", got)
+	}
+	if got := paint(true, ansiGreen, "created"); got != ansiGreen+"created"+ansiReset {
+		t.Errorf("expected green text, got %q", got)
+	}
+	if got := paint(true, "", "created"); got != "created" {
+		t.Errorf("expected plain text without a style, got %q", got)
+	}
+}
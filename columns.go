@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// inputColumns lists the columns of the inputs table, in their default order.
+var inputColumns = []string{"name", "description", "type", "required", "default", "options", "used_in"}
+
+// validateColumns checks the `columns` setting: known names, each at most once.
+func validateColumns(columns []string) error {
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if !contains(inputColumns, column) {
+			return fmt.Errorf("unknown column %q (expected one of: %s)", column, strings.Join(inputColumns, ", "))
+		}
+		if seen[column] {
+			return fmt.Errorf("duplicate column %q", column)
+		}
+		seen[column] = true
+	}
+	return nil
+}
+
+// componentColumns returns the inputs table columns of a component: the
+// configured ones (all by default), without "options" when no input has
+// options and without "used_in" when the template defines no jobs.
+func componentColumns(columns []string, c ComponentData) []string {
+	if len(columns) == 0 {
+		columns = inputColumns
+	}
+	var shown []string
+	for _, column := range columns {
+		if (column == "options" && !c.HasOptions()) || (column == "used_in" && !c.HasBody) {
+			continue
+		}
+		shown = append(shown, column)
+	}
+	return shown
+}
+
+// tableHeader returns the header row of a table whose columns are titled by
+// the given message ids, followed in Markdown by the separator row.
+func tableHeader(format string, msgs messages, ids []string) string {
+	var titles, rules []string
+	for _, id := range ids {
+		titles = append(titles, msgs.text(id))
+		rules = append(rules, columnRule(msgs.text(id)))
+	}
+	if format == "asciidoc" {
+		return "| " + strings.Join(titles, " | ")
+	}
+	return "| " + strings.Join(titles, " | ") + " |\n|" + strings.Join(rules, "|") + "|"
+}
+
+// columnRule returns the Markdown header separator of a column titled title.
+func columnRule(title string) string {
+	return strings.Repeat("-", utf8.RuneCountInString(title)+2)
+}
+
+// inputCell returns the content of an input's column, escaped with cell. The
+// name links to the input's declaration when source links are enabled. A
+// deprecated input's name is struck through and its description starts with
+// the migration note, as supported by the dialect. The places using an input
+// are followed by the functions applied to it, if any. A masked default is
+// followed by a warning badge, and a default rendered below the table is
+// replaced by its footnote reference.
+func inputCell(input InputData, column string, cell func(string) string, dialect string, msgs messages) string {
+	switch column {
+	case "name":
+		if input.Deprecated {
+			return sourceLink(dialect, strikethrough(dialect, input.Name), input.SourceURL)
+		}
+		return sourceLink(dialect, input.Name, input.SourceURL)
+	case "description":
+		if input.Deprecated {
+			return strings.TrimSpace(deprecationLabel(dialect, msgs.text("deprecated"), cell(input.DeprecationNote)) + " " + cell(input.Description))
+		}
+		return cell(input.Description)
+	case "type":
+		return input.Type
+	case "required":
+		return fmt.Sprint(input.Required)
+	case "default":
+		if input.Sensitive && input.Default == maskedDefault {
+			return cell(input.Default) + " " + sensitiveBadge(dialect, msgs.text("sensitive"))
+		}
+		if input.defaultNote > 0 {
+			return noteRef(dialect, input.defaultNote)
+		}
+		return cell(input.Default)
+	case "options":
+		return cell(codeList(input.Options))
+	case "used_in":
+		if len(input.Functions) > 0 {
+			return cell(codeList(input.UsedIn) + " (" + codeList(input.Functions) + ")")
+		}
+		return cell(codeList(input.UsedIn))
+	}
+	return ""
+}
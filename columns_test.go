@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateColumns(t *testing.T) {
+	if err := validateColumns([]string{"name", "required", "type", "default", "description"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateColumns([]string{"name", "flavor"}); err == nil || !strings.Contains(err.Error(), `unknown column "flavor"`) {
+		t.Errorf("expected an unknown column error, got %v", err)
+	}
+	if err := validateColumns([]string{"name", "name"}); err == nil {
+		t.Error("expected an error for a duplicate column")
+	}
+}
+
+func TestComponentColumns(t *testing.T) {
+	plain := ComponentData{Inputs: []InputData{{Name: "stage"}}}
+	if got := strings.Join(componentColumns(nil, plain), ","); got != "name,description,type,required,default" {
+		t.Errorf("expected the default columns without options and usage, got %s", got)
+	}
+
+	rich := ComponentData{Inputs: []InputData{{Name: "stage", Options: []string{"build"}}}, HasBody: true}
+	if got := strings.Join(componentColumns(nil, rich), ","); got != strings.Join(inputColumns, ",") {
+		t.Errorf("expected all columns, got %s", got)
+	}
+	if got := strings.Join(componentColumns([]string{"options", "name"}, plain), ","); got != "name" {
+		t.Errorf("expected configured order without empty columns, got %s", got)
+	}
+}
+
+func TestRun_Columns(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      description: Stage\n      default: build\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	os.WriteFile(configFile, []byte("columns: [name, required, type, default, description]\n"), 0644)
+	for _, format := range []string{"markdown", "asciidoc"} {
+		if err := run([]string{"--quiet", "--format", format, "--output", "out"}, io.Discard); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile("out")
+		expected := map[string][]string{
+			"markdown": {"| Name | Required | Type | Default | Description |\n|------|----------|------|---------|-------------|\n| stage | false | string | build | Stage |\n"},
+			"asciidoc": {"| Name | Required | Type | Default | Description\n\n| stage | false | string | build | Stage\n"},
+		}[format]
+		for _, want := range expected {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s: expected %q in:\n%s", format, want, data)
+			}
+		}
+	}
+
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n---\nbuild:\n  stage: $[[ inputs.stage | expand_vars ]]\n  script: echo\n"), 0644)
+	os.WriteFile(configFile, []byte("columns: [name, used_in]\n"), 0644)
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); !strings.Contains(string(data), "| stage | `build` (`expand_vars`) |\n") {
+		t.Errorf("expected the applied functions next to the usages, got:\n%s", data)
+	}
+
+	os.WriteFile(configFile, []byte("columns: [name, flavor]\n"), 0644)
+	if got := exitCode(run([]string{"--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown column, got %d", exitConfig, got)
+	}
+}
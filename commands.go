@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// usageOutput receives the help of the commands.
+var usageOutput io.Writer = os.Stderr
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	usage   string // arguments shown after the command name
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+// commandList returns the subcommands, in the order `help` lists them.
+func commandList() []command {
+	return []command{
+		{"generate", "[flags]", "Generate the documentation of the component templates (the default command)",
+			func(args []string, stdout io.Writer) error { return runGenerate("generate", args, stdout) }},
+		{"check", "[flags]", "Fail if the output file is not up to date, without writing it",
+			func(args []string, stdout io.Writer) error { return runGenerate("check", args, stdout) }},
+		{"validate", "[flags]", "Validate the component templates and report issues, without writing any file",
+			func(args []string, stdout io.Writer) error { return runGenerate("validate", args, stdout) }},
+		{"init", "[flags]", "Scaffold a component repository", runInit},
+		{"catalog", "[flags]", "Document the projects listed under catalog.projects and write an aggregated index", runCatalog},
+		{"publish", "--merge-request | --pages-fragment [flags]", "Post the documentation diff as a merge request note, or upload HTML fragments to a docs portal", runPublish},
+		{"release", "<version|major|minor|patch> [flags]", "Bump the version, regenerate the docs, commit and tag (optionally push and create a GitLab release)", runRelease},
+		{"diff", "[flags] [BASE [HEAD]]", "Report the changes of the components' inputs between two git refs, or a ref and the working tree", runDiff},
+		{"analyze", "[flags]", "Report the inputs several components declare with differing types, descriptions or defaults", runAnalyze},
+		{"migrate", "[flags]", "Propose spec:inputs for variable-driven templates, as a patch to review", runMigrate},
+		{"sbom", "[flags]", "Write a CycloneDX inventory of what the components pull in", runSBOM},
+		{"schema", "export [--output-dir DIR] [" + strings.Join(schemaNames, "|") + "...]", "Export the JSON schemas of the machine-readable outputs", runSchema},
+		{"template", "lint [flags] [FILE...]", "Check the README and per-component templates for fields the data model does not have", runTemplate},
+		{"serve", "--json-rpc | --http ADDRESS [flags]", "Answer editor requests about the components, or serve a live preview of the documentation", runServe},
+		{"component", "export [flags]", "Print a GitLab CI/CD component running the docs check, or regenerating and committing the docs, in pipelines", runComponent},
+		{"show", "<component> [flags]", "Print the docs of a component in the terminal, through $PAGER", runShow},
+		{"use", "<component> [flags]", "Ask for a component's inputs and print the include block using it", runUse},
+		{"hook", "install [flags]", "Write a .pre-commit-hooks.yaml declaring the pre-commit hook", runHook},
+		{"version", "", "Print the version of gitlab-component-docs-gen", runVersion},
+	}
+}
+
+// newFlagSet returns the flag set of a subcommand, whose help shows the
+// command's usage and summary before its flags. The help of `generate` also
+// lists the other commands, since it is what a bare --help shows. Parse
+// errors are not printed by the flag set: they are returned, and main
+// prints them once.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("gitlab-component-docs-gen "+name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {
+		flags.SetOutput(usageOutput)
+		defer flags.SetOutput(io.Discard)
+		w := flags.Output()
+		for _, cmd := range commandList() {
+			if cmd.name != name {
+				continue
+			}
+			fmt.Fprintf(w, "Usage: gitlab-component-docs-gen %s %s\n\n%s.\n", cmd.name, cmd.usage, cmd.summary)
+		}
+		if name == "generate" {
+			printCommands(w)
+		}
+		fmt.Fprintf(w, "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// printCommands lists the subcommands with their summary.
+func printCommands(w io.Writer) {
+	fmt.Fprintf(w, "\nCommands:\n")
+	for _, cmd := range commandList() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun gitlab-component-docs-gen help <command> for the flags of a command.\n")
+}
+
+// isHelpFlag reports whether arg asks for a command's help.
+func isHelpFlag(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--h", "--help":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Commands(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	flagsArgs := []string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}
+
+	if got := exitCode(run(append([]string{"check"}, flagsArgs...), io.Discard)); got != exitOutdated {
+		t.Errorf("expected check to fail with exit code %d before generating, got %d", exitOutdated, got)
+	}
+	if err := run(append([]string{"generate"}, flagsArgs...), io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("README.md"); err != nil {
+		t.Errorf("expected generate to write README.md: %v", err)
+	}
+	if err := run(append([]string{"check"}, flagsArgs...), io.Discard); err != nil {
+		t.Errorf("expected check to pass after generating, got %v", err)
+	}
+	// Flags without a command still generate
+	if err := run(flagsArgs, io.Discard); err != nil {
+		t.Errorf("expected a bare run to generate, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"validate"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Validation passed") {
+		t.Errorf("expected validation to run, got %q", out.String())
+	}
+
+	// Each command only accepts its own flags
+	for _, args := range [][]string{
+		{"validate", "--output", "out.md"},
+		{"check", "--dry-run"},
+		{"generate", "stray"},
+		{"no-such-command"},
+	} {
+		if got := exitCode(run(args, io.Discard)); got != exitConfig {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitConfig, got)
+		}
+	}
+}
+
+func TestRun_Help(t *testing.T) {
+	for _, args := range [][]string{{"help"}, {"--help"}, {"help", "check"}, {"help", "schema"}, {"help", "hook"}, {"init", "-h"}} {
+		if err := run(args, io.Discard); err != nil {
+			t.Errorf("%v: unexpected error: %v", args, err)
+		}
+	}
+}
+
+func TestNewFlagSet_Usage(t *testing.T) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { usageOutput = w }(usageOutput)
+	usageOutput = &out
+	flags := newFlagSet("generate")
+	flags.Bool("quiet", false, "Suppress informational output")
+	flags.Usage()
+
+	for _, want := range []string{"Usage: gitlab-component-docs-gen generate [flags]", "Commands:", "  validate ", "-quiet"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected usage to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	// A parse error is returned for main to print, not printed by the flag set
+	out.Reset()
+	err := run([]string{"check", "--no-such-flag"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "-no-such-flag") {
+		t.Fatalf("expected the parse error, got %v", err)
+	}
+	if strings.Contains(out.String(), err.Error()) || !strings.Contains(out.String(), "Usage: gitlab-component-docs-gen check") {
+		t.Errorf("expected the usage alone, got:\n%s", out.String())
+	}
+}
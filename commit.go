@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CommitConfig configures --commit: the message of the commits and the bot
+// identity they are made with.
+type CommitConfig struct {
+	Message string `yaml:"message"`
+	Author  string `yaml:"author"` // "Name <email>"
+}
+
+// Defaults of CommitConfig. [skip ci] keeps the push from starting another
+// pipeline.
+const (
+	defaultCommitMessage = "docs: update the CI/CD component documentation [skip ci]"
+	defaultCommitAuthor  = "gitlab-component-docs-gen <gitlab-component-docs-gen@noreply.gitlab.com>"
+)
+
+// resolveCommit fills in the defaults of --commit and validates the author.
+func resolveCommit(config, overrides CommitConfig) (CommitConfig, error) {
+	commit := CommitConfig{Message: overrides.Message, Author: config.Author}
+	if commit.Message == "" {
+		commit.Message = config.Message
+	}
+	if commit.Message == "" {
+		commit.Message = defaultCommitMessage
+	}
+	if commit.Author == "" {
+		commit.Author = defaultCommitAuthor
+	}
+	if _, err := mail.ParseAddress(commit.Author); err != nil {
+		return commit, fmt.Errorf("invalid commit.author %q: expected \"Name <email>\"", commit.Author)
+	}
+	return commit, nil
+}
+
+// commitIdentity returns the name and email of a "Name <email>" author.
+func commitIdentity(author string) (name, email string) {
+	addr, err := mail.ParseAddress(author)
+	if err != nil {
+		return author, ""
+	}
+	return addr.Name, addr.Address
+}
+
+// lastCommitByBot reports whether HEAD was committed by the bot, so a
+// pipeline started by its own push doesn't commit again.
+func lastCommitByBot(author string) (bool, error) {
+	out, err := runGit("log", "-1", "--format=%ae")
+	if err != nil {
+		return false, fmt.Errorf("--commit needs a git repository with a commit: %w", err)
+	}
+	_, email := commitIdentity(author)
+	return strings.EqualFold(out, email), nil
+}
+
+// pushToken returns the token --commit pushes with, and the user name GitLab
+// expects with it: DOCS_PUSH_TOKEN (a project or personal access token with
+// the write_repository scope), then CI_JOB_TOKEN.
+func pushToken() (user, token string) {
+	if token := os.Getenv("DOCS_PUSH_TOKEN"); token != "" {
+		return "oauth2", token
+	}
+	if token := os.Getenv("CI_JOB_TOKEN"); token != "" {
+		return "gitlab-ci-token", token
+	}
+	return "", ""
+}
+
+// pushTarget returns where --commit pushes: the project's URL with the token
+// in CI (CI_SERVER_URL and CI_PROJECT_PATH set), the git remote otherwise.
+// The branch is CI_COMMIT_BRANCH, since CI jobs check out a detached HEAD,
+// or the current branch.
+func pushTarget(remote string) (target, branch, token string, err error) {
+	branch = os.Getenv("CI_COMMIT_BRANCH")
+	if branch == "" {
+		if branch, err = runGit("symbolic-ref", "--short", "-q", "HEAD"); err != nil || branch == "" {
+			return "", "", "", errors.New("--commit needs a branch to push to: run it in a branch pipeline or on a checked-out branch")
+		}
+	}
+	target = remote
+	user, token := pushToken()
+	server, project := os.Getenv("CI_SERVER_URL"), os.Getenv("CI_PROJECT_PATH")
+	if token != "" && server != "" && project != "" {
+		u, err := url.Parse(strings.TrimRight(server, "/") + "/" + project + ".git")
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid CI_SERVER_URL %q: %w", server, err)
+		}
+		u.User = url.UserPassword(user, token)
+		target = u.String()
+	}
+	return target, branch, token, nil
+}
+
+// commitDocs commits the given files, and only them, as the bot, then
+// pushes the commit. It returns false, without committing, when they did
+// not change.
+func commitDocs(paths []string, commit CommitConfig, remote string) (bool, error) {
+	if _, err := runGit(append([]string{"add", "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	if _, err := runGit(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		return false, nil
+	}
+	target, branch, token, err := pushTarget(remote)
+	if err != nil {
+		return false, err
+	}
+	name, email := commitIdentity(commit.Author)
+	args := append([]string{"-c", "user.name=" + name, "-c", "user.email=" + email, "commit", "-m", commit.Message, "--"}, paths...)
+	if _, err := runGit(args...); err != nil {
+		return false, err
+	}
+	out, err := exec.Command("git", "push", target, "HEAD:refs/heads/"+branch).CombinedOutput()
+	if err != nil {
+		return true, fmt.Errorf("error pushing the documentation to %s: %v: %s", branch, err, maskToken(strings.TrimSpace(string(out)), token))
+	}
+	return true, nil
+}
+
+// maskToken keeps the push token out of logs: git may print the target URL
+// holding it, where it is percent-encoded, or the token as given.
+func maskToken(message, token string) string {
+	if token == "" {
+		return message
+	}
+	encoded := strings.TrimPrefix(url.UserPassword("", token).String(), ":")
+	return strings.NewReplacer(token, "[MASKED]", encoded, "[MASKED]").Replace(message)
+}
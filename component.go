@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// toolImage is the image published for the tool. Its `-ci` variant adds a
+// shell and git to the binary, which CI jobs need.
+const toolImage = "ghcr.io/filippolmt/gitlab-component-docs-gen"
+
+// exportModes are the modes of the exported component: `check` fails when
+// the documentation is outdated, `commit` regenerates it and pushes it back.
+var exportModes = []string{"check", "commit"}
+
+// exportedComponent is the component written by `component export`. It is a
+// regular component, documented like any other; the %s are filled in by
+// exportComponent.
+const exportedComponent = `# Keeps the documentation of the project's CI/CD components up to date.
+# In check mode, the job fails when the generated documentation is outdated.
+# In commit mode, it regenerates the documentation and pushes it back to the
+# branch, with a project access token with the write_repository scope in the
+# DOCS_PUSH_TOKEN CI/CD variable (masked and protected), or the job token
+# when the project allows pushes with it.
+spec:
+  inputs:
+    stage:
+      description: Stage of the docs job
+      default: %s
+    mode:
+      description: "` + "`check`" + ` fails when the documentation is outdated, ` + "`commit`" + ` regenerates it and pushes it back"
+      options: [check, commit]
+      default: %s
+    image:
+      description: Image running gitlab-component-docs-gen, with a shell and git
+      default: %s
+    args:
+      description: Extra flags of gitlab-component-docs-gen, e.g. --templates-dir ci/templates
+      default: ""
+    commit_message:
+      description: Message of the commit pushing the regenerated documentation
+      default: "docs: update the CI/CD component documentation [skip ci]"
+---
+docs:
+  stage: $[[ inputs.stage ]]
+  image:
+    name: $[[ inputs.image ]]
+    entrypoint: [""]
+  variables:
+    DOCS_MODE: $[[ inputs.mode ]]
+    DOCS_ARGS: $[[ inputs.args ]]
+    DOCS_GEN_COMMIT_MESSAGE: $[[ inputs.commit_message ]]
+    GIT_DEPTH: 0
+  script:
+    - |
+      if [ "$DOCS_MODE" = check ]; then
+        exec gitlab-component-docs-gen check $DOCS_ARGS
+      fi
+      if [ -z "$CI_COMMIT_BRANCH" ]; then
+        echo "commit mode only runs in branch pipelines"
+        exit 0
+      fi
+      exec gitlab-component-docs-gen generate --commit --no-prompt $DOCS_ARGS
+`
+
+// stagePattern matches valid stage names; they are written unquoted.
+var stagePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// defaultExportImage is the `-ci` image of this release of the tool, or of the
+// latest release for development builds.
+func defaultExportImage() string {
+	version, _, _ := buildInfo()
+	if !releaseVersionPattern.MatchString(version) {
+		return toolImage + ":latest-ci"
+	}
+	return toolImage + ":" + strings.TrimPrefix(version, "v") + "-ci"
+}
+
+// exportComponent returns the component template running the tool in mode.
+func exportComponent(stage, mode, image string) string {
+	return fmt.Sprintf(exportedComponent, stage, mode, strconv.Quote(image))
+}
+
+// runComponent implements the `component` subcommand. `component export`
+// prints, or writes, a component running the tool in a pipeline.
+func runComponent(args []string, stdout io.Writer) error {
+	if len(args) == 0 || (args[0] != "export" && !isHelpFlag(args[0])) {
+		return withExitCode(exitConfig, errors.New("usage: gitlab-component-docs-gen component export [flags]"))
+	}
+
+	flags := newFlagSet("component")
+	mode := flags.String("mode", "check", "Default mode of the component: "+strings.Join(exportModes, " or "))
+	stage := flags.String("stage", "test", "Default stage of the docs job")
+	image := flags.String("image", defaultExportImage(), "Default image of the docs job")
+	output := flags.String("output", "-", "Write the component to this file (e.g. templates/docs.yml) instead of stdout")
+	if args[0] == "export" {
+		args = args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if flags.NArg() > 0 {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+	if !contains(exportModes, *mode) {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported mode %q (expected one of: %s)", *mode, strings.Join(exportModes, ", ")))
+	}
+	if !stagePattern.MatchString(*stage) {
+		return withExitCode(exitConfig, fmt.Errorf("invalid stage %q", *stage))
+	}
+	if *image == "" {
+		return withExitCode(exitConfig, errors.New("--image cannot be empty"))
+	}
+
+	component := exportComponent(*stage, *mode, *image)
+	if *output == "-" {
+		if _, err := io.WriteString(stdout, component); err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing component: %w", err))
+		}
+		return nil
+	}
+	if err := writeFileAtomic(*output, []byte(component)); err != nil {
+		return withExitCode(exitWrite, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultExportImage(t *testing.T) {
+	defer func(version string) { buildVersion = version }(buildVersion)
+
+	buildVersion = "v1.4.0"
+	if got := defaultExportImage(); got != toolImage+":1.4.0-ci" {
+		t.Errorf("got %q", got)
+	}
+	buildVersion = "dev"
+	if got := defaultExportImage(); got != toolImage+":latest-ci" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestExportComponent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "templates", "docs.yml")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(exportComponent("docs", "commit", "registry.example.com/tools/docs-gen:1.0.0-ci")), 0644)
+
+	c, err := parseTemplate(path, ParseOptions{TemplatesDir: filepath.Join(dir, "templates")})
+	if err != nil {
+		t.Fatalf("expected a valid component, got %v", err)
+	}
+	defaults := map[string]string{}
+	for _, input := range c.Inputs {
+		defaults[input.Name] = input.Default
+	}
+	if defaults["stage"] != "docs" || defaults["mode"] != "commit" || defaults["image"] != "registry.example.com/tools/docs-gen:1.0.0-ci" || len(defaults) != 5 {
+		t.Errorf("unexpected inputs %v", defaults)
+	}
+	if !strings.HasPrefix(c.Comment, "Keeps the documentation") {
+		t.Errorf("expected the header comment to describe the component, got %q", c.Comment)
+	}
+	if len(c.Jobs) != 1 || c.Jobs[0].Name != "docs" {
+		t.Errorf("unexpected jobs %+v", c.Jobs)
+	}
+}
+
+func TestExportComponent_Commands(t *testing.T) {
+	// Every command line of the script must be accepted by the command it runs
+	var commands int
+	for _, line := range strings.Split(exportComponent("test", "check", "image"), "\n") {
+		_, command, ok := strings.Cut(line, "exec gitlab-component-docs-gen ")
+		if !ok {
+			continue
+		}
+		commands++
+		fields := strings.Fields(strings.ReplaceAll(command, "$DOCS_ARGS", ""))
+		if err := runGenerate(fields[0], append(fields[1:], "--help"), io.Discard); err != nil {
+			t.Errorf("%s: %v", command, err)
+		}
+	}
+	if commands != 2 {
+		t.Errorf("expected the check and commit command lines, found %d", commands)
+	}
+}
+
+func TestRun_ComponentExport(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"component", "export"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "      default: check\n") {
+		t.Errorf("expected check mode by default, got:\n%s", out.String())
+	}
+
+	if err := run([]string{"component", "export", "--mode", "commit", "--output", "docs.yml"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, err := os.ReadFile("docs.yml"); err != nil || !strings.Contains(string(data), "      default: commit\n") {
+		t.Errorf("expected the component in docs.yml, got %q (%v)", data, err)
+	}
+
+	for _, args := range [][]string{
+		{"component"},
+		{"component", "import"},
+		{"component", "export", "--mode", "push"},
+		{"component", "export", "--stage", "a: b"},
+		{"component", "export", "extra"},
+	} {
+		if got := exitCode(run(args, io.Discard)); got != exitConfig {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitConfig, got)
+		}
+	}
+}
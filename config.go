@@ -0,0 +1,595 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// configFile is the optional per-repository configuration file.
+const configFile = ".gitlab-component-docs-gen.yml"
+
+// ProjectConfig represents the optional config file .gitlab-component-docs-gen.yml
+type ProjectConfig struct {
+	ProjectPath      string             `yaml:"project_path"`
+	Version          string             `yaml:"version"`
+	Remote           string             `yaml:"remote"`
+	Mirrors          []MirrorConfig     `yaml:"mirrors"`
+	Template         string             `yaml:"template"`
+	PartialsDir      string             `yaml:"partials_dir"`
+	TemplatesDir     string             `yaml:"templates_dir"`
+	DocsDir          string             `yaml:"docs_dir"`
+	ExamplesDir      string             `yaml:"examples_dir"`
+	Output           string             `yaml:"output"`
+	Format           string             `yaml:"format"`
+	Sort             string             `yaml:"sort"`
+	Include          []string           `yaml:"include"`
+	Exclude          []string           `yaml:"exclude"`
+	Ignore           []string           `yaml:"ignore"`
+	ResolveIncludes  *bool              `yaml:"resolve_includes"` // unset means true
+	Footer           bool               `yaml:"footer"`
+	SourceLinks      bool               `yaml:"source_links"`
+	PipelineDiagrams bool               `yaml:"pipeline_diagrams"`
+	Backup           bool               `yaml:"backup"`
+	Columns          []string           `yaml:"columns"`
+	Dialect          string             `yaml:"markdown_dialect"`
+	ComplexDefaults  string             `yaml:"complex_defaults"`
+	Locale           string             `yaml:"locale"`
+	Locales          []string           `yaml:"locales"` // README.<lang> variants; the first is the README's unless locale is set
+	Translations     map[string]string  `yaml:"translations"`
+	Collapse         CollapseConfig     `yaml:"collapse"`
+	Deprecations     DeprecationsConfig `yaml:"deprecations"`
+	Groups           GroupsConfig       `yaml:"groups"`
+	SensitiveInputs  []string           `yaml:"sensitive_inputs"`
+	// RequiredVariables maps components to the CI/CD variables they need
+	RequiredVariables map[string][]RequiredVariableConfig `yaml:"required_variables"`
+	DescriptionLint   DescriptionLintConfig               `yaml:"description_lint"`
+	SecretScan        SecretScanConfig                    `yaml:"secret_scan"`
+	Pages             PagesConfig                         `yaml:"pages"`
+	HeaderFile        string                              `yaml:"header_file"`
+	FooterFile        string                              `yaml:"footer_file"`
+	Catalog           CatalogConfig                       `yaml:"catalog"`
+	Units             []UnitConfig                        `yaml:"units"`
+	Webhook           WebhookConfig                       `yaml:"webhook"`
+	Commit            CommitConfig                        `yaml:"commit"`
+	Fragments         FragmentsConfig                     `yaml:"fragments"`
+}
+
+// CollapseConfig controls the collapsible sections of the default templates.
+type CollapseConfig struct {
+	// Threshold is the number of inputs above which the inputs table is
+	// collapsed; 0 never collapses it. Unset means defaultCollapseThreshold.
+	Threshold *int `yaml:"threshold"`
+	Open      bool `yaml:"open"` // start expanded
+}
+
+// defaultCollapseThreshold is the number of inputs above which inputs tables
+// are collapsed when `collapse.threshold` is unset.
+const defaultCollapseThreshold = 10
+
+// CatalogConfig lists the component projects aggregated by the `catalog`
+// subcommand, and where their docs and the index are written.
+type CatalogConfig struct {
+	Projects  []CatalogProject `yaml:"projects"`
+	OutputDir string           `yaml:"output_dir"`
+	Index     string           `yaml:"index"`
+}
+
+// CatalogProject is a component project of the catalog.
+type CatalogProject struct {
+	Source string `yaml:"source"` // gitlab://group/project[@ref]
+}
+
+// MirrorConfig describes an additional location (e.g. a public mirror) the
+// components are published to. Server and project path are taken from the
+// git remote when not set explicitly.
+type MirrorConfig struct {
+	Name        string `yaml:"name"`
+	Remote      string `yaml:"remote"`
+	Server      string `yaml:"server"`
+	ProjectPath string `yaml:"project_path"`
+}
+
+// loadProjectConfig reads .gitlab-component-docs-gen.yml, returning an empty
+// config when the file is missing or invalid.
+func loadProjectConfig() ProjectConfig {
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return ProjectConfig{}
+	}
+	return config
+}
+
+// readProjectConfig reads and validates a config file, then applies the
+// DOCS_GEN_* environment variables over it. A missing file yields the config
+// of the environment alone; unknown keys are reported as an error.
+func readProjectConfig(path string) (ProjectConfig, error) {
+	config, err := readConfigFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := applyEnvConfig(&config, os.Environ()); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// readConfigFile reads and validates a config file. A missing file yields an
+// empty config.
+func readConfigFile(path string) (ProjectConfig, error) {
+	var config ProjectConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+	if data, err = decodeSource(path, data); err != nil {
+		return config, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return config, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	if unknown := unknownKeys(raw, reflect.TypeOf(config), ""); len(unknown) > 0 {
+		return config, fmt.Errorf("unknown key(s) in config file %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// unknownKeys returns the keys of raw (recursing into nested structs and
+// slices of structs) that have no matching yaml tag in t, sorted.
+func unknownKeys(raw map[string]interface{}, t reflect.Type, prefix string) []string {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			fields[tag] = t.Field(i).Type
+		}
+	}
+
+	var unknown []string
+	for key, value := range raw {
+		fieldType, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		switch {
+		case fieldType.Kind() == reflect.Struct:
+			if m, ok := value.(map[string]interface{}); ok {
+				unknown = append(unknown, unknownKeys(m, fieldType, prefix+key+".")...)
+			}
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct:
+			items, _ := value.([]interface{})
+			for i, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					unknown = append(unknown, unknownKeys(m, fieldType.Elem(), fmt.Sprintf("%s%s[%d].", prefix, key, i))...)
+				}
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// Supported values for the `format` and `sort` settings.
+var (
+	outputFormats = []string{"markdown", "asciidoc", "json", "pdf"}
+	sortOrders    = []string{"required", "name", "source"}
+)
+
+// defaultOutputs maps each format to the file written when `output` is unset.
+var defaultOutputs = map[string]string{
+	"markdown": "README.md",
+	"asciidoc": "README.adoc",
+	"json":     "components.json",
+	"pdf":      "components.pdf",
+}
+
+// defaultTemplatePaths maps each template-based format to the template used
+// when `template` is unset.
+var defaultTemplatePaths = map[string]string{
+	"markdown": "README.md.tmpl",
+	"asciidoc": "README.adoc.tmpl",
+	"pdf":      "README.md.tmpl", // rendered as Markdown, then converted
+}
+
+// Settings holds the effective locations and rendering options for a run.
+type Settings struct {
+	Template         string
+	PartialsDir      string // partials replacing the built-in sections of the templates
+	TemplatesDir     string
+	DocsDir          string
+	ExamplesDir      string
+	Output           string
+	Format           string
+	SortOrder        string
+	Include          []string
+	Exclude          []string
+	Ignore           []string // template files skipped when scanning
+	NoIgnore         bool     // scan .gitignored and ignore-listed files too
+	NoIncludes       bool     // leave the local includes of templates unresolved
+	RootDir          string   // project root local includes are read from; "" is the working directory
+	Footer           bool     // append a "generated by" line to Markdown and AsciiDoc output
+	SourceLinks      bool     // link input names to their declaration in the templates
+	PipelineDiagrams bool     // embed a Mermaid diagram of each component's jobs
+	Backup           bool     // keep the previous output as <output>.bak
+	Columns          []string // inputs table columns, in order; empty means all
+	Dialect          string   // Markdown dialect: gitlab, github or commonmark
+	ComplexDefaults  string   // how array and map defaults render: json (inline) or yaml (below the table)
+	Locale           string   // language of the default templates' strings
+	Messages         messages // the default templates' strings, translations applied
+	Locales          []string // languages of the README.<lang> variants, the README's excluded
+	Collapse         int      // inputs above which the inputs table is collapsed; 0 never
+	CollapseOpen     bool     // collapsible sections start expanded
+	Deprecations     DeprecationsConfig
+	Groups           GroupsConfig
+	SensitiveInputs  []string // names of the inputs whose defaults are masked
+	DescriptionLint  DescriptionLint
+	SecretScan       SecretScan
+	// RequiredVariables maps components to the CI/CD variables they need
+	RequiredVariables map[string][]RequiredVariableConfig
+	HeaderFile        string
+	FooterFile        string
+	Webhook           WebhookConfig   // notification of contract changes; no URL disables it
+	Commit            CommitConfig    // message and author of --commit
+	Pages             PagesConfig     // per-component pages for a static site generator; no front-matter writes none
+	Fragments         FragmentsConfig // docs portal of publish --pages-fragment
+}
+
+// parseOptions returns the options used to parse component templates.
+func (s Settings) parseOptions() ParseOptions {
+	return ParseOptions{TemplatesDir: s.TemplatesDir, DocsDir: s.DocsDir, ExamplesDir: s.ExamplesDir, SortOrder: s.SortOrder, Format: s.Format, ResolveIncludes: !s.NoIncludes, RootDir: s.RootDir}
+}
+
+// applyBoolFlags lets the boolean flags given on the command line win over
+// the config even when false: resolveSettings can't tell an unset flag from
+// `--footer=false`, so the config takes the flag's value.
+func applyBoolFlags(config *ProjectConfig, overrides Settings, flags *flag.FlagSet) {
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "footer":
+			config.Footer = overrides.Footer
+		case "source-links":
+			config.SourceLinks = overrides.SourceLinks
+		case "pipeline-diagrams":
+			config.PipelineDiagrams = overrides.PipelineDiagrams
+		case "backup":
+			config.Backup = overrides.Backup
+		}
+	})
+}
+
+// resolveSettings merges built-in defaults, the config (the config file and
+// DOCS_GEN_* environment variables) and overrides (from command-line flags;
+// empty values mean "not set"). Flags always win.
+func resolveSettings(config ProjectConfig, overrides Settings) (Settings, error) {
+	pick := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	settings := Settings{
+		TemplatesDir:      pick(overrides.TemplatesDir, config.TemplatesDir, "templates"),
+		DocsDir:           pick(overrides.DocsDir, config.DocsDir, "docs"),
+		ExamplesDir:       pick(overrides.ExamplesDir, config.ExamplesDir, "examples"),
+		Format:            pick(overrides.Format, config.Format, "markdown"),
+		SortOrder:         pick(overrides.SortOrder, config.Sort, "required"),
+		Include:           config.Include,
+		Exclude:           config.Exclude,
+		Ignore:            config.Ignore,
+		NoIgnore:          overrides.NoIgnore,
+		NoIncludes:        overrides.NoIncludes || (config.ResolveIncludes != nil && !*config.ResolveIncludes),
+		Footer:            overrides.Footer || config.Footer,
+		SourceLinks:       overrides.SourceLinks || config.SourceLinks,
+		PipelineDiagrams:  overrides.PipelineDiagrams || config.PipelineDiagrams,
+		Backup:            overrides.Backup || config.Backup,
+		Columns:           config.Columns,
+		Dialect:           pick(overrides.Dialect, config.Dialect, "gitlab"),
+		ComplexDefaults:   pick(overrides.ComplexDefaults, config.ComplexDefaults, "json"),
+		Locale:            pick(overrides.Locale, config.Locale, firstLocale(config.Locales), "en"),
+		Collapse:          defaultCollapseThreshold,
+		CollapseOpen:      config.Collapse.Open,
+		Deprecations:      config.Deprecations,
+		Groups:            config.Groups,
+		SensitiveInputs:   config.SensitiveInputs,
+		RequiredVariables: config.RequiredVariables,
+		HeaderFile:        pick(overrides.HeaderFile, config.HeaderFile),
+		Pages: PagesConfig{
+			FrontMatter: pick(overrides.Pages.FrontMatter, config.Pages.FrontMatter),
+			OutputDir:   pick(config.Pages.OutputDir, defaultPagesDir),
+			Weights:     config.Pages.Weights,
+		},
+		FooterFile: pick(overrides.FooterFile, config.FooterFile),
+		Webhook: WebhookConfig{
+			URL:    pick(overrides.Webhook.URL, config.Webhook.URL),
+			Format: pick(config.Webhook.Format, "slack"),
+			Base:   pick(overrides.Webhook.Base, config.Webhook.Base),
+		},
+		Fragments: FragmentsConfig{
+			URL:    pick(overrides.Fragments.URL, config.Fragments.URL),
+			Header: config.Fragments.Header,
+		},
+	}
+	if config.Collapse.Threshold != nil {
+		settings.Collapse = *config.Collapse.Threshold
+	}
+	if settings.Collapse < 0 {
+		return settings, fmt.Errorf("invalid collapse.threshold %d: must be 0 (never) or more", settings.Collapse)
+	}
+	lint, err := resolveDescriptionLint(config.DescriptionLint, overrides.DescriptionLint.Enabled)
+	if err != nil {
+		return settings, err
+	}
+	settings.DescriptionLint = lint
+	if settings.SecretScan, err = resolveSecretScan(config.SecretScan); err != nil {
+		return settings, err
+	}
+	if settings.Commit, err = resolveCommit(config.Commit, overrides.Commit); err != nil {
+		return settings, err
+	}
+	if overrides.Include != nil {
+		settings.Include = overrides.Include
+	}
+	if overrides.Exclude != nil {
+		settings.Exclude = overrides.Exclude
+	}
+	for _, pattern := range append(append([]string{}, settings.Include...), settings.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return settings, fmt.Errorf("invalid include/exclude pattern %q: %w", pattern, err)
+		}
+	}
+	if settings.SensitiveInputs == nil {
+		settings.SensitiveInputs = defaultSensitivePatterns
+	}
+	for _, pattern := range settings.SensitiveInputs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return settings, fmt.Errorf("invalid sensitive_inputs pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range settings.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return settings, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+
+	if !contains(outputFormats, settings.Format) {
+		return settings, fmt.Errorf("unsupported format %q (expected one of: %s)", settings.Format, strings.Join(outputFormats, ", "))
+	}
+	if !contains(markdownDialects, settings.Dialect) {
+		return settings, fmt.Errorf("unsupported Markdown dialect %q (expected one of: %s)", settings.Dialect, strings.Join(markdownDialects, ", "))
+	}
+	if !contains(complexDefaultModes, settings.ComplexDefaults) {
+		return settings, fmt.Errorf("unsupported complex_defaults %q (expected one of: %s)", settings.ComplexDefaults, strings.Join(complexDefaultModes, ", "))
+	}
+	if err := validateColumns(settings.Columns); err != nil {
+		return settings, err
+	}
+	if settings.Messages, err = resolveMessages(settings.Locale, config.Translations); err != nil {
+		return settings, err
+	}
+	settings.Locales = variantLocales(config.Locales, settings.Locale)
+	for _, lang := range settings.Locales {
+		if _, err := resolveMessages(lang, nil); err != nil {
+			return settings, err
+		}
+	}
+	if err := validateRequiredVariables(settings.RequiredVariables); err != nil {
+		return settings, err
+	}
+	if !contains(webhookFormats, settings.Webhook.Format) {
+		return settings, fmt.Errorf("unsupported webhook format %q (expected one of: %s)", settings.Webhook.Format, strings.Join(webhookFormats, ", "))
+	}
+	if settings.Pages.FrontMatter != "" {
+		if !contains(frontMatterFormats, settings.Pages.FrontMatter) {
+			return settings, fmt.Errorf("unsupported front-matter %q (expected one of: %s)", settings.Pages.FrontMatter, strings.Join(frontMatterFormats, ", "))
+		}
+		if settings.Format != "markdown" {
+			return settings, fmt.Errorf("pages with front-matter need the markdown format, not %s", settings.Format)
+		}
+	}
+	if !contains(sortOrders, settings.SortOrder) {
+		return settings, fmt.Errorf("unsupported sort order %q (expected one of: %s)", settings.SortOrder, strings.Join(sortOrders, ", "))
+	}
+	// The header is checked as it is sent: a variable may hold all of it
+	if settings.Fragments.Header != "" {
+		if _, _, ok := splitHeader(os.ExpandEnv(settings.Fragments.Header)); !ok {
+			return settings, fmt.Errorf("invalid fragments header %q (expected \"Name: value\")", settings.Fragments.Header)
+		}
+	}
+
+	settings.Template = pick(overrides.Template, config.Template, defaultTemplatePaths[settings.Format])
+	settings.PartialsDir = pick(overrides.PartialsDir, config.PartialsDir, defaultPartialsDirs[settings.Format])
+	settings.Output = pick(overrides.Output, config.Output, defaultOutputs[settings.Format])
+	return settings, nil
+}
+
+// componentSelected reports whether a component passes the include/exclude
+// filters. Patterns are globs matched against the component name and against
+// its template path (e.g. "_internal-*" or "templates/_internal-*.yml").
+// With include patterns set, a component must match at least one of them.
+func componentSelected(settings Settings, name, templatePath string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			for _, candidate := range []string{name, filepath.ToSlash(templatePath)} {
+				if ok, _ := path.Match(pattern, candidate); ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if len(settings.Include) > 0 && !matches(settings.Include) {
+		return false
+	}
+	return !matches(settings.Exclude)
+}
+
+// stringList is a repeatable flag that also accepts comma-separated values.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveProjectPath determines the project path using priority:
+// 1. CLI flag --project-path
+// 2. Env var PROJECT_PATH
+// 3. Config file .gitlab-component-docs-gen.yml
+// 4. GitLab API, when GITLAB_TOKEN or CI_JOB_TOKEN is set
+// 5. Git remote auto-detect (using the given remote)
+// 6. Fallback placeholder
+func resolveProjectPath(flagValue, remote string) string {
+	// 1. CLI flag
+	if flagValue != "" {
+		return flagValue
+	}
+
+	// 2. Env var
+	if envPath := os.Getenv("PROJECT_PATH"); envPath != "" {
+		return envPath
+	}
+
+	// 3. Config file
+	if configPath := readConfigProjectPath(); configPath != "" {
+		return configPath
+	}
+
+	// 4. GitLab API (canonical path, when a token is available)
+	if project, _ := lookupGitLabProject(remote); project.Path != "" {
+		return project.Path
+	}
+
+	// 5. Git remote
+	if gitPath := detectGitProjectPath(remote); gitPath != "" {
+		return gitPath
+	}
+
+	// 6. Fallback
+	return projectPathPlaceholder
+}
+
+func readConfigProjectPath() string {
+	return loadProjectConfig().ProjectPath
+}
+
+// resolveRemote determines which git remote drives project path detection:
+// flag --remote, env GIT_REMOTE, config `remote`, then "origin".
+func resolveRemote(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if envRemote := os.Getenv("GIT_REMOTE"); envRemote != "" {
+		return envRemote
+	}
+	if configRemote := loadProjectConfig().Remote; configRemote != "" {
+		return configRemote
+	}
+	return "origin"
+}
+
+// resolveMirrors turns the configured mirrors into template data, filling in
+// server and project path from the mirror's git remote when omitted.
+func resolveMirrors(mirrors []MirrorConfig) ([]MirrorData, error) {
+	var resolved []MirrorData
+	for i, m := range mirrors {
+		var url string
+		if m.Remote != "" && (m.Server == "" || m.ProjectPath == "") {
+			url = gitRemoteURL(m.Remote)
+		}
+
+		data := MirrorData{
+			Name:        m.Name,
+			Server:      m.Server,
+			ProjectPath: m.ProjectPath,
+		}
+		if data.Name == "" {
+			data.Name = m.Remote
+		}
+		if data.Server == "" {
+			data.Server = parseGitRemoteHost(url)
+		}
+		if data.ProjectPath == "" {
+			data.ProjectPath = parseGitRemoteURL(url)
+		}
+
+		if data.Server == "" || data.ProjectPath == "" {
+			return nil, fmt.Errorf("mirror #%d (%s): cannot determine server and project_path; set them explicitly or point `remote` to an existing git remote", i+1, data.Name)
+		}
+		if data.Name == "" {
+			data.Name = data.Server
+		}
+		resolved = append(resolved, data)
+	}
+	return resolved, nil
+}
+
+// resolveVersion determines the version using priority:
+// 1. CLI flag --version
+// 2. Env var VERSION
+// 3. Config file .gitlab-component-docs-gen.yml
+// 4. Latest release from the GitLab API, when GITLAB_TOKEN or CI_JOB_TOKEN is set
+// 5. Git tag auto-detect
+// 6. Fallback placeholder
+func resolveVersion(flagValue, remote string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	if envVersion := os.Getenv("VERSION"); envVersion != "" {
+		return envVersion
+	}
+
+	if configVersion := readConfigVersion(); configVersion != "" {
+		return configVersion
+	}
+
+	if project, _ := lookupGitLabProject(remote); project.LatestRelease != "" {
+		return project.LatestRelease
+	}
+
+	if gitVersion := detectGitVersion(); gitVersion != "" {
+		return gitVersion
+	}
+
+	return "<version>"
+}
+
+func readConfigVersion() string {
+	return loadProjectConfig().Version
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadProjectConfig_FullSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitlab-component-docs-gen.yml")
+	configContent := `project_path: group/project
+version: "1.2.3"
+template: docs/README.md.tmpl
+templates_dir: ci/templates
+docs_dir: ci/docs
+output: CATALOG.md
+format: markdown
+sort: name
+exclude:
+  - internal
+`
+	if err := os.WriteFile(path, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := readProjectConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.TemplatesDir != "ci/templates" || config.DocsDir != "ci/docs" || config.Output != "CATALOG.md" {
+		t.Errorf("unexpected directories/output: %+v", config)
+	}
+	if config.Sort != "name" || len(config.Exclude) != 1 || config.Exclude[0] != "internal" {
+		t.Errorf("unexpected sort/exclude: %+v", config)
+	}
+}
+
+func TestReadProjectConfig_UnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitlab-component-docs-gen.yml")
+	configContent := `project_path: group/project
+ouput: README.md
+mirrors:
+  - name: public
+    hostname: gitlab.com
+`
+	if err := os.WriteFile(path, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := readProjectConfig(path)
+	if err == nil {
+		t.Fatal("expected error for unknown keys, got nil")
+	}
+	for _, key := range []string{"ouput", "mirrors[0].hostname"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected error to mention %q, got %q", key, err.Error())
+		}
+	}
+}
+
+func TestReadProjectConfig_Missing(t *testing.T) {
+	config, err := readProjectConfig(filepath.Join(t.TempDir(), "missing.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.ProjectPath != "" || config.Output != "" {
+		t.Errorf("expected empty config, got %+v", config)
+	}
+}
+
+func TestResolveSettings(t *testing.T) {
+	// Defaults
+	settings, err := resolveSettings(ProjectConfig{}, Settings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Settings{
+		Template:     "README.md.tmpl",
+		TemplatesDir: "templates",
+		DocsDir:      "docs",
+		Output:       "README.md",
+		Format:       "markdown",
+		SortOrder:    "required",
+	}
+	if settings.Template != expected.Template || settings.TemplatesDir != expected.TemplatesDir ||
+		settings.DocsDir != expected.DocsDir || settings.Output != expected.Output ||
+		settings.Format != expected.Format || settings.SortOrder != expected.SortOrder {
+		t.Errorf("expected defaults %+v, got %+v", expected, settings)
+	}
+
+	// Config overrides defaults, flags override config
+	config := ProjectConfig{TemplatesDir: "from-config", Output: "CONFIG.md", Sort: "name"}
+	settings, err = resolveSettings(config, Settings{Output: "FLAG.md"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings.TemplatesDir != "from-config" {
+		t.Errorf("expected templates dir 'from-config', got %q", settings.TemplatesDir)
+	}
+	if settings.Output != "FLAG.md" {
+		t.Errorf("expected output 'FLAG.md', got %q", settings.Output)
+	}
+	if settings.SortOrder != "name" {
+		t.Errorf("expected sort order 'name', got %q", settings.SortOrder)
+	}
+
+	// Output defaults follow the format
+	settings, _ = resolveSettings(ProjectConfig{Format: "json"}, Settings{})
+	if settings.Output != "components.json" {
+		t.Errorf("expected output 'components.json' for json format, got %q", settings.Output)
+	}
+	settings, _ = resolveSettings(ProjectConfig{}, Settings{Format: "asciidoc"})
+	if settings.Output != "README.adoc" || settings.Template != "README.adoc.tmpl" {
+		t.Errorf("expected README.adoc/README.adoc.tmpl for asciidoc format, got %q/%q", settings.Output, settings.Template)
+	}
+
+	// Invalid values are rejected
+	if _, err := resolveSettings(ProjectConfig{Format: "html"}, Settings{}); err == nil {
+		t.Error("expected error for unsupported format, got nil")
+	}
+	if _, err := resolveSettings(ProjectConfig{}, Settings{SortOrder: "random"}); err == nil {
+		t.Error("expected error for unsupported sort order, got nil")
+	}
+}
+
+func TestApplyBoolFlags(t *testing.T) {
+	var overrides Settings
+	flags := newFlagSet("generate")
+	flags.BoolVar(&overrides.Footer, "footer", false, "")
+	flags.BoolVar(&overrides.Backup, "backup", false, "")
+	if err := flags.Parse([]string{"--footer=false"}); err != nil {
+		t.Fatal(err)
+	}
+	config := ProjectConfig{Footer: true, Backup: true}
+	applyBoolFlags(&config, overrides, flags)
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings.Footer || !settings.Backup {
+		t.Errorf("expected --footer=false to beat the config, and the unset --backup not to, got footer %v, backup %v", settings.Footer, settings.Backup)
+	}
+}
+
+func TestComponentSelected(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected map[string]bool
+	}{
+		{
+			name:     "no filters",
+			expected: map[string]bool{"build": true, "_internal-lint": true},
+		},
+		{
+			name:     "exclude by template path",
+			exclude:  []string{"templates/_internal-*.yml"},
+			expected: map[string]bool{"build": true, "_internal-lint": false},
+		},
+		{
+			name:     "exclude by name",
+			exclude:  []string{"_*"},
+			expected: map[string]bool{"build": true, "_internal-lint": false},
+		},
+		{
+			name:     "include only",
+			include:  []string{"build", "deploy-*"},
+			expected: map[string]bool{"build": true, "deploy-aws": true, "_internal-lint": false},
+		},
+		{
+			name:     "exclude wins over include",
+			include:  []string{"deploy-*"},
+			exclude:  []string{"deploy-legacy"},
+			expected: map[string]bool{"deploy-aws": true, "deploy-legacy": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Settings{Include: tt.include, Exclude: tt.exclude}
+			for name, want := range tt.expected {
+				if got := componentSelected(settings, name, filepath.Join("templates", name+".yml")); got != want {
+					t.Errorf("componentSelected(%q) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveSettings_Filters(t *testing.T) {
+	config := ProjectConfig{Include: []string{"from-config"}, Exclude: []string{"_*"}}
+
+	settings, err := resolveSettings(config, Settings{Exclude: []string{"legacy-*"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(settings.Include) != 1 || settings.Include[0] != "from-config" {
+		t.Errorf("expected include from config, got %v", settings.Include)
+	}
+	if len(settings.Exclude) != 1 || settings.Exclude[0] != "legacy-*" {
+		t.Errorf("expected --exclude to replace config exclude, got %v", settings.Exclude)
+	}
+
+	if _, err := resolveSettings(ProjectConfig{Exclude: []string{"[unclosed"}}, Settings{}); err == nil {
+		t.Error("expected error for malformed pattern, got nil")
+	}
+}
+
+func TestStringList(t *testing.T) {
+	var list stringList
+	list.Set("a, b")
+	list.Set("c")
+	if list.String() != "a,b,c" {
+		t.Errorf("expected 'a,b,c', got %q", list.String())
+	}
+}
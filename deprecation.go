@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// deprecationExtension is the input extension marking an input as deprecated,
+// with a migration note (`x-deprecated: "use foo instead"`) or without one
+// (`x-deprecated: true`).
+const deprecationExtension = "deprecated"
+
+func init() {
+	RegisterInputExtension(deprecationExtension, decodeDeprecation)
+}
+
+// Deprecation is the decoded value of `x-deprecated`.
+type Deprecation struct {
+	Deprecated bool
+	Note       string // migration note, may be empty
+}
+
+// decodeDeprecation accepts a migration note or a boolean.
+func decodeDeprecation(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return Deprecation{Deprecated: true, Note: strings.TrimSpace(v)}, nil
+	case bool:
+		return Deprecation{Deprecated: v}, nil
+	}
+	return nil, errors.New("expected a migration note or a boolean")
+}
+
+// DeprecationsConfig marks components and inputs as deprecated from the
+// config file, with their migration note (which may be empty).
+type DeprecationsConfig struct {
+	Components map[string]string            `yaml:"components"` // component -> note
+	Inputs     map[string]map[string]string `yaml:"inputs"`     // component -> input -> note
+}
+
+// applyDeprecations marks the components and inputs listed in the config as
+// deprecated. Entries naming unknown components or inputs are returned as
+// warnings, so typos don't go unnoticed.
+func applyDeprecations(config DeprecationsConfig, components []ComponentData) []string {
+	byName := make(map[string]*ComponentData, len(components))
+	for i := range components {
+		byName[components[i].Name] = &components[i]
+	}
+
+	var warnings []string
+	for name, note := range config.Components {
+		c := byName[name]
+		if c == nil {
+			warnings = append(warnings, fmt.Sprintf("deprecations: unknown component %q", name))
+			continue
+		}
+		c.Deprecated, c.DeprecationNote = true, strings.TrimSpace(note)
+	}
+	for name, inputs := range config.Inputs {
+		c := byName[name]
+		if c == nil {
+			warnings = append(warnings, fmt.Sprintf("deprecations: unknown component %q", name))
+			continue
+		}
+		for input, note := range inputs {
+			found := false
+			for i := range c.Inputs {
+				if c.Inputs[i].Name == input {
+					c.Inputs[i].Deprecated, c.Inputs[i].DeprecationNote = true, strings.TrimSpace(note)
+					found = true
+				}
+			}
+			if !found {
+				warnings = append(warnings, fmt.Sprintf("deprecations: component %q has no input %q", name, input))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// DeprecationNotice returns the notice shown below a deprecated component's heading.
+func (c ComponentData) DeprecationNotice() string {
+	if c.DeprecationNote == "" {
+		return "This component is deprecated."
+	}
+	return "This component is deprecated: " + c.DeprecationNote
+}
+
+// HasDeprecatedInputs reports whether any input is deprecated.
+func (c ComponentData) HasDeprecatedInputs() bool {
+	for _, input := range c.Inputs {
+		if input.Deprecated {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecatedUsage reports the examples that use a deprecated input, or a
+// deprecated component other than the one they document. They are warnings,
+// or errors when strict (--fail-on-deprecated-usage).
+func deprecatedUsage(components []ComponentData, strict bool) []Issue {
+	severity := severityWarning
+	if strict {
+		severity = severityError
+	}
+	byName := make(map[string]ComponentData, len(components))
+	for _, c := range components {
+		byName[c.Name] = c
+	}
+
+	var issues []Issue
+	for _, c := range components {
+		for i, example := range c.Examples {
+			label := fmt.Sprintf("example %d", i+1)
+			if example.Title != "" {
+				label = fmt.Sprintf("example %q", example.Title)
+			}
+			for _, inc := range exampleIncludes(example.Code, byName) {
+				used := byName[inc.component]
+				if used.Deprecated && used.Name != c.Name {
+					issues = append(issues, Issue{
+						Severity:  severity,
+						Component: c.Name,
+						Message:   fmt.Sprintf("%s includes deprecated component %s%s", label, used.Name, noteSuffix(used.DeprecationNote)),
+						File:      c.path,
+					})
+				}
+				for _, input := range used.Inputs {
+					if input.Deprecated && contains(inc.inputs, input.Name) {
+						issues = append(issues, Issue{
+							Severity:  severity,
+							Component: c.Name,
+							Input:     input.Name,
+							Message:   fmt.Sprintf("%s sets deprecated input of %s%s", label, used.Name, noteSuffix(input.DeprecationNote)),
+							File:      c.path,
+						})
+					}
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// noteSuffix formats a migration note for an issue message.
+func noteSuffix(note string) string {
+	if note == "" {
+		return ""
+	}
+	return " (" + note + ")"
+}
+
+// exampleInclude is a component included by an example, with the inputs it sets.
+type exampleInclude struct {
+	component string
+	inputs    []string
+}
+
+// exampleIncludes returns the known components an example includes. A
+// `component:` reference matches the longest component name it ends with
+// (e.g. `$CI_SERVER_FQDN/group/project/aws/deploy@1.0` matches aws/deploy).
+// Examples that are not valid YAML are skipped.
+func exampleIncludes(code string, known map[string]ComponentData) []exampleInclude {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(code), &doc); err != nil {
+		return nil
+	}
+	var entries []interface{}
+	switch v := doc["include"].(type) {
+	case []interface{}:
+		entries = v
+	case map[string]interface{}:
+		entries = []interface{}{v}
+	}
+
+	var includes []exampleInclude
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ref, _ := m["component"].(string)
+		if at := strings.LastIndex(ref, "@"); at >= 0 {
+			ref = ref[:at]
+		}
+		match := ""
+		for name := range known {
+			if (ref == name || strings.HasSuffix(ref, "/"+name)) && len(name) > len(match) {
+				match = name
+			}
+		}
+		if match == "" {
+			continue
+		}
+		inc := exampleInclude{component: match}
+		if inputs, ok := m["inputs"].(map[string]interface{}); ok {
+			for name := range inputs {
+				inc.inputs = append(inc.inputs, name)
+			}
+			sort.Strings(inc.inputs)
+		}
+		includes = append(includes, inc)
+	}
+	return includes
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDeprecation(t *testing.T) {
+	got, err := decodeDeprecation(" use target instead ")
+	if err != nil || got != (Deprecation{Deprecated: true, Note: "use target instead"}) {
+		t.Errorf("unexpected result for a note: %v, %v", got, err)
+	}
+	got, err = decodeDeprecation(true)
+	if err != nil || got != (Deprecation{Deprecated: true}) {
+		t.Errorf("unexpected result for true: %v, %v", got, err)
+	}
+	if _, err := decodeDeprecation(1); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
+
+func TestApplyDeprecations(t *testing.T) {
+	components := []ComponentData{
+		{Name: "build", Inputs: []InputData{{Name: "stage"}, {Name: "target"}}},
+		{Name: "deploy"},
+	}
+	warnings := applyDeprecations(DeprecationsConfig{
+		Components: map[string]string{"deploy": "use release", "unknown": ""},
+		Inputs:     map[string]map[string]string{"build": {"stage": "", "missing": ""}},
+	}, components)
+
+	if !components[1].Deprecated || components[1].DeprecationNote != "use release" {
+		t.Errorf("expected deploy to be deprecated, got %+v", components[1])
+	}
+	if !components[0].Inputs[0].Deprecated || components[0].Inputs[1].Deprecated || components[0].Deprecated {
+		t.Errorf("expected only build's stage input to be deprecated, got %+v", components[0])
+	}
+	want := []string{`deprecations: component "build" has no input "missing"`, `deprecations: unknown component "unknown"`}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected warnings: %q", warnings)
+	}
+}
+
+func TestDeprecatedUsage(t *testing.T) {
+	components := []ComponentData{
+		{Name: "build", Inputs: []InputData{{Name: "stage", Deprecated: true, DeprecationNote: "use target"}, {Name: "target"}}},
+		{Name: "deploy", Deprecated: true, Examples: []ExampleData{
+			{Title: "Self", Code: "include:\n  - component: $CI_SERVER_FQDN/group/project/deploy@1.0\n"},
+		}},
+		{Name: "release", Examples: []ExampleData{
+			{Code: "include:\n  - component: $CI_SERVER_FQDN/group/project/build@1.0\n    inputs:\n      stage: test\n  - component: $CI_SERVER_FQDN/group/project/deploy@1.0\n"},
+			{Code: "not: [valid"},
+		}},
+	}
+
+	issues := deprecatedUsage(components, false)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", issues)
+	}
+	if issues[0].Input != "stage" || issues[0].Severity != severityWarning || !strings.Contains(issues[0].Message, "(use target)") {
+		t.Errorf("unexpected input issue: %+v", issues[0])
+	}
+	if issues[1].Component != "release" || !strings.Contains(issues[1].Message, "deprecated component deploy") {
+		t.Errorf("unexpected component issue: %+v", issues[1])
+	}
+	if issues := deprecatedUsage(components, true); countErrors(issues) != 2 {
+		t.Errorf("expected errors when strict, got %v", issues)
+	}
+}
+
+func TestRun_Deprecations(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "examples", "build"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      description: Build stage\n      x-deprecated: use target instead\n    target:\n      default: build\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "examples", "build", "basic.yml"), []byte("include:\n  - component: $CI_SERVER_FQDN/group/project/build@1.0\n    inputs:\n      stage: test\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("deprecations:\n  components:\n    deploy: Use the release component\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	for _, want := range []string{
+		"| ~~stage~~ | **Deprecated:** use target instead. Build stage |",
+		"## deploy\n\n> [!CAUTION]\n> This component is deprecated: Use the release component\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+
+	if err := run([]string{"--quiet", "--format", "asciidoc"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.Contains(string(data), "| [.line-through]#stage# | *Deprecated:* use target instead. Build stage") {
+		t.Errorf("expected a struck-through input, got:\n%s", data)
+	}
+
+	if err := run([]string{"validate", "--quiet"}, io.Discard); err != nil {
+		t.Errorf("expected deprecated usage to be a warning, got %v", err)
+	}
+	if got := exitCode(run([]string{"validate", "--quiet", "--fail-on-deprecated-usage"}, io.Discard)); got != exitInvalid {
+		t.Errorf("expected exit code %d with --fail-on-deprecated-usage, got %d", exitInvalid, got)
+	}
+}
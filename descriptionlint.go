@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DescriptionLintConfig enables the input description lint (`description_lint`).
+type DescriptionLintConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MinLength is the minimum length of a description, in characters; 0
+	// disables the check. Unset means defaultDescriptionMinLength.
+	MinLength *int   `yaml:"min_length"`
+	Severity  string `yaml:"severity"` // warning (default) or error
+}
+
+// defaultDescriptionMinLength is the minimum description length when
+// `description_lint.min_length` is unset.
+const defaultDescriptionMinLength = 10
+
+// DescriptionLint is the resolved description lint of a run.
+type DescriptionLint struct {
+	Enabled   bool
+	MinLength int
+	Severity  string
+}
+
+// descriptionPunctuation lists the characters a description may end with.
+const descriptionPunctuation = ".!?"
+
+// resolveDescriptionLint applies the defaults of the description lint and
+// checks its settings. enabled turns it on regardless of the config
+// (--lint-descriptions).
+func resolveDescriptionLint(config DescriptionLintConfig, enabled bool) (DescriptionLint, error) {
+	lint := DescriptionLint{
+		Enabled:   enabled || config.Enabled,
+		MinLength: defaultDescriptionMinLength,
+		Severity:  config.Severity,
+	}
+	if config.MinLength != nil {
+		lint.MinLength = *config.MinLength
+	}
+	if lint.MinLength < 0 {
+		return lint, fmt.Errorf("invalid description_lint.min_length %d: must be 0 (no minimum) or more", lint.MinLength)
+	}
+	if lint.Severity == "" {
+		lint.Severity = severityWarning
+	}
+	if lint.Severity != severityWarning && lint.Severity != severityError {
+		return lint, fmt.Errorf("invalid description_lint.severity %q (expected %s or %s)", lint.Severity, severityWarning, severityError)
+	}
+	return lint, nil
+}
+
+// lintDescriptions reports the inputs of a component whose description is
+// missing, too short, not ending with punctuation, or the same as another
+// input's. It reports nothing when the lint is disabled.
+func lintDescriptions(c ComponentData, lint DescriptionLint) []Issue {
+	if !lint.Enabled {
+		return nil
+	}
+	var issues []Issue
+	report := func(input, message string) {
+		issues = append(issues, Issue{
+			Severity:  lint.Severity,
+			Component: c.Name,
+			Input:     input,
+			Message:   message,
+			File:      c.path,
+			Line:      c.lines[input],
+		})
+	}
+
+	seen := make(map[string]string) // normalized description -> first input
+	for _, input := range c.Inputs {
+		description := strings.TrimSpace(input.Description)
+		if description == "" {
+			report(input.Name, "has no description")
+			continue
+		}
+		if n := utf8.RuneCountInString(description); n < lint.MinLength {
+			report(input.Name, fmt.Sprintf("description is shorter than %d characters (%d)", lint.MinLength, n))
+		}
+		if !strings.ContainsAny(description[len(description)-1:], descriptionPunctuation) {
+			report(input.Name, "description does not end with punctuation ("+strings.Join(strings.Split(descriptionPunctuation, ""), " ")+")")
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(description), " "))
+		if first, ok := seen[key]; ok {
+			report(input.Name, fmt.Sprintf("same description as input %q", first))
+		} else {
+			seen[key] = input.Name
+		}
+	}
+	return issues
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintDescriptions(t *testing.T) {
+	c := ComponentData{Name: "build", Inputs: []InputData{
+		{Name: "empty"},
+		{Name: "short", Description: "Stage."},
+		{Name: "bare", Description: "The stage of the build job"},
+		{Name: "stage", Description: "The stage of the build job."},
+		{Name: "copy", Description: "the stage of the  build job."},
+		{Name: "fine", Description: "Image used by the build job!"},
+	}}
+	c.lines = map[string]int{"empty": 3}
+
+	if issues := lintDescriptions(c, DescriptionLint{MinLength: 10, Severity: severityWarning}); issues != nil {
+		t.Errorf("expected no issues when disabled, got %v", issues)
+	}
+
+	issues := lintDescriptions(c, DescriptionLint{Enabled: true, MinLength: 10, Severity: severityError})
+	var got []string
+	for _, issue := range issues {
+		if issue.Severity != severityError {
+			t.Errorf("expected the configured severity, got %+v", issue)
+		}
+		got = append(got, issue.Input+": "+issue.Message)
+	}
+	want := []string{
+		"empty: has no description",
+		"short: description is shorter than 10 characters (6)",
+		"bare: description does not end with punctuation (. ! ?)",
+		`copy: same description as input "stage"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected issues:\n%s", strings.Join(got, "\n"))
+	}
+	if issues[0].Line != 3 {
+		t.Errorf("expected the line of the input declaration, got %d", issues[0].Line)
+	}
+
+	if issues := lintDescriptions(c, DescriptionLint{Enabled: true, Severity: severityWarning}); len(issues) != 3 {
+		t.Errorf("expected min_length 0 to disable the length check, got %v", issues)
+	}
+}
+
+func TestResolveDescriptionLint(t *testing.T) {
+	lint, err := resolveDescriptionLint(DescriptionLintConfig{}, false)
+	if err != nil || lint != (DescriptionLint{MinLength: defaultDescriptionMinLength, Severity: severityWarning}) {
+		t.Errorf("unexpected defaults: %+v, %v", lint, err)
+	}
+	if lint, _ := resolveDescriptionLint(DescriptionLintConfig{}, true); !lint.Enabled {
+		t.Error("expected --lint-descriptions to enable the lint")
+	}
+	negative := -1
+	if _, err := resolveDescriptionLint(DescriptionLintConfig{MinLength: &negative}, false); err == nil {
+		t.Error("expected an error for a negative min_length")
+	}
+	if _, err := resolveDescriptionLint(DescriptionLintConfig{Severity: "fatal"}, false); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+func TestRun_LintDescriptions(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      description: Build stage\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out strings.Builder
+	if err := run([]string{"validate", "--quiet"}, &out); err != nil || out.Len() > 0 {
+		t.Fatalf("expected no lint without --lint-descriptions, got %v:\n%s", err, out.String())
+	}
+	if err := run([]string{"validate", "--quiet", "--lint-descriptions"}, &out); err != nil {
+		t.Fatalf("expected lint warnings not to fail validation, got %v", err)
+	}
+	if want := "warning: build: input \"stage\": description does not end with punctuation (. ! ?)\n"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	os.WriteFile(configFile, []byte("description_lint:\n  enabled: true\n  severity: error\n"), 0644)
+	out.Reset()
+	if got := exitCode(run([]string{"validate", "--porcelain"}, &out)); got != exitInvalid {
+		t.Errorf("expected exit code %d with severity error, got %d", exitInvalid, got)
+	}
+	var report RunReport
+	if err := json.Unmarshal([]byte(out.String()), &report); err != nil || len(report.Issues) != 1 {
+		t.Errorf("expected one issue in the report, got %v: %s", err, out.String())
+	}
+
+	os.WriteFile(configFile, []byte("description_lint:\n  severity: fatal\n"), 0644)
+	if got := exitCode(run([]string{"validate", "--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown severity, got %d", exitConfig, got)
+	}
+}
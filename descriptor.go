@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// descriptorVersion is the version of the interface descriptor format,
+// bumped when a field changes meaning.
+const descriptorVersion = 1
+
+// InterfaceDescriptor is the interface of a release's components, written by
+// --emit: what callers pass in (inputs) and what they get (jobs, outputs),
+// normalized so two releases' descriptors diff cleanly.
+type InterfaceDescriptor struct {
+	DescriptorVersion int                   `json:"descriptor_version"`
+	ProjectPath       string                `json:"project_path"`
+	Version           string                `json:"version"`
+	Components        []ComponentDescriptor `json:"components"`
+}
+
+// ComponentDescriptor is the interface of one component.
+type ComponentDescriptor struct {
+	Name            string             `json:"name"`
+	Description     string             `json:"description,omitempty"`
+	Deprecated      bool               `json:"deprecated,omitempty"`
+	DeprecationNote string             `json:"deprecation_note,omitempty"`
+	Inputs          []InputDescriptor  `json:"inputs"`
+	Jobs            []JobData          `json:"jobs"`
+	Outputs         []OutputDescriptor `json:"outputs"`
+}
+
+// InputDescriptor is an input with its default and options as declared,
+// not formatted for the docs.
+type InputDescriptor struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	Required    bool          `json:"required"`
+	Default     interface{}   `json:"default,omitempty"`
+	Options     []interface{} `json:"options,omitempty"`
+	Regex       string        `json:"regex,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Deprecated  bool          `json:"deprecated,omitempty"`
+	// Sensitive inputs have no default in the descriptor
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// OutputDescriptor is what a job leaves for later jobs and users: the
+// artifacts it uploads.
+type OutputDescriptor struct {
+	Job      string   `json:"job"`
+	Paths    []string `json:"paths"`
+	ExpireIn string   `json:"expire_in,omitempty"` // empty means the instance default
+}
+
+// buildDescriptor returns the interface descriptor of the components. Every
+// list is sorted by name, whatever the templates' order, and the template is
+// read again for the declared defaults, options and regex.
+func buildDescriptor(data TemplateData) (InterfaceDescriptor, error) {
+	descriptor := InterfaceDescriptor{
+		DescriptorVersion: descriptorVersion,
+		ProjectPath:       data.ProjectPath,
+		Version:           data.Version,
+		Components:        []ComponentDescriptor{},
+	}
+	for _, c := range data.Components {
+		decls, err := readInputDeclarations(c.path)
+		if err != nil {
+			return descriptor, err
+		}
+		component := ComponentDescriptor{
+			Name:            c.Name,
+			Description:     strings.TrimSpace(c.Description),
+			Deprecated:      c.Deprecated,
+			DeprecationNote: c.DeprecationNote,
+			Inputs:          []InputDescriptor{},
+			Jobs:            []JobData{},
+			Outputs:         []OutputDescriptor{},
+		}
+		for _, input := range c.Inputs {
+			decl := decls[input.Name]
+			in := InputDescriptor{
+				Name:        input.Name,
+				Type:        input.Type,
+				Required:    input.Required,
+				Default:     decl.Default,
+				Options:     decl.Options,
+				Regex:       decl.Regex,
+				Description: strings.TrimSpace(input.Description),
+				Deprecated:  input.Deprecated,
+				Sensitive:   input.Sensitive,
+			}
+			if input.Sensitive {
+				in.Default = nil
+			}
+			component.Inputs = append(component.Inputs, in)
+		}
+		sort.Slice(component.Inputs, func(i, j int) bool { return component.Inputs[i].Name < component.Inputs[j].Name })
+		for _, job := range c.Jobs {
+			job.Needs = append([]string(nil), job.Needs...)
+			sort.Strings(job.Needs)
+			component.Jobs = append(component.Jobs, job)
+		}
+		sort.Slice(component.Jobs, func(i, j int) bool { return component.Jobs[i].Name < component.Jobs[j].Name })
+		for _, a := range c.Artifacts {
+			paths := append([]string(nil), a.Paths...)
+			sort.Strings(paths)
+			component.Outputs = append(component.Outputs, OutputDescriptor{Job: a.Job, Paths: paths, ExpireIn: a.ExpireIn})
+		}
+		sort.Slice(component.Outputs, func(i, j int) bool { return component.Outputs[i].Job < component.Outputs[j].Job })
+		descriptor.Components = append(descriptor.Components, component)
+	}
+	sort.Slice(descriptor.Components, func(i, j int) bool { return descriptor.Components[i].Name < descriptor.Components[j].Name })
+	return descriptor, nil
+}
+
+// writeDescriptor writes the interface descriptor of the components to path,
+// as JSON for a .json file and as YAML otherwise, and returns the write's
+// status. Errors carry their exit code.
+func writeDescriptor(path string, data TemplateData) (fileStatus, error) {
+	descriptor, err := buildDescriptor(data)
+	if err != nil {
+		return "", withExitCode(exitParse, err)
+	}
+	var out []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		out, err = json.MarshalIndent(descriptor, "", "  ")
+		out = append(out, '\n')
+	} else {
+		out, err = yaml.Marshal(descriptor)
+	}
+	if err != nil {
+		return "", withExitCode(exitFailure, fmt.Errorf("error encoding the interface descriptor: %w", err))
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", withExitCode(exitWrite, fmt.Errorf("error creating %s: %w", dir, err))
+		}
+	}
+	status := writeStatus(path, out)
+	if err := writeFileAtomic(path, out); err != nil {
+		return "", withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", path, err))
+	}
+	return status, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildDescriptor(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.yml")
+	os.WriteFile(path, []byte(`spec:
+  inputs:
+    stage:
+      default: deploy
+    replicas:
+      type: number
+      default: 2
+    env:
+      options: [staging, production]
+      regex: /^[a-z]+$/
+    token:
+      default: s3cr3t
+---
+verify:
+  stage: test
+  script: make verify
+deploy:
+  stage: $[[ inputs.stage ]]
+  needs: [verify, build]
+  script: make deploy
+  artifacts:
+    paths: [reports/, out/]
+    expire_in: 1 week
+`), 0644)
+	c, err := parseTemplate(path, ParseOptions{TemplatesDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range c.Inputs {
+		if c.Inputs[i].Name == "token" {
+			c.Inputs[i].Sensitive = true
+		}
+	}
+	descriptor, err := buildDescriptor(TemplateData{ProjectPath: "g/p", Version: "1.0.0", Components: []ComponentData{c}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if descriptor.DescriptorVersion != descriptorVersion || descriptor.ProjectPath != "g/p" || len(descriptor.Components) != 1 {
+		t.Fatalf("unexpected descriptor: %+v", descriptor)
+	}
+	component := descriptor.Components[0]
+	var names []string
+	for _, input := range component.Inputs {
+		names = append(names, input.Name)
+	}
+	if strings.Join(names, ",") != "env,replicas,stage,token" {
+		t.Errorf("expected the inputs sorted by name, got %v", names)
+	}
+	env, replicas, token := component.Inputs[0], component.Inputs[1], component.Inputs[3]
+	if !env.Required || env.Default != nil || len(env.Options) != 2 || env.Regex != "/^[a-z]+$/" {
+		t.Errorf("expected the declared options and regex, got %+v", env)
+	}
+	if replicas.Type != "number" || replicas.Default != uint64(2) {
+		t.Errorf("expected a typed default, got %#v", replicas.Default)
+	}
+	if !token.Sensitive || token.Default != nil {
+		t.Errorf("expected no default for a sensitive input, got %+v", token)
+	}
+	if component.Jobs[0].Name != "deploy" || strings.Join(component.Jobs[0].Needs, ",") != "build,verify" || component.Jobs[1].Name != "verify" {
+		t.Errorf("expected the jobs and needs sorted by name, got %+v", component.Jobs)
+	}
+	if len(component.Outputs) != 1 || strings.Join(component.Outputs[0].Paths, ",") != "out/,reports/" || component.Outputs[0].ExpireIn != "1 week" {
+		t.Errorf("expected the artifacts as outputs, got %+v", component.Outputs)
+	}
+}
+
+func TestRun_EmitDescriptor(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n---\nbuild:\n  stage: $[[ inputs.stage ]]\n  script: make\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--emit", "api/descriptor.yml"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join("api", "descriptor.yml"))
+	if err != nil {
+		t.Fatalf("expected the descriptor to be written: %v", err)
+	}
+	want := `descriptor_version: 1
+project_path: g/p
+version: 1.0.0
+components:
+- name: build
+  inputs:
+  - name: stage
+    type: string
+    required: false
+    default: build
+  jobs:
+  - name: build
+    stage: $[[ inputs.stage ]]
+  outputs: []
+`
+	if string(data) != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, data)
+	}
+
+	if code := exitCode(run([]string{"--check", "--emit", "descriptor.yml"}, io.Discard)); code != exitConfig {
+		t.Errorf("expected exit code %d with --check, got %d", exitConfig, code)
+	}
+}
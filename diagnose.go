@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diagnoseNoTemplates explains why no component template was found in the
+// templates directory and what to do about it.
+func diagnoseNoTemplates(settings Settings) error {
+	dir := settings.TemplatesDir
+
+	var problem string
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		problem = fmt.Sprintf("templates directory %s/ does not exist", dir)
+	case err != nil:
+		problem = fmt.Sprintf("cannot access templates directory %s/: %v", dir, err)
+	case !info.IsDir():
+		problem = fmt.Sprintf("%s is not a directory", dir)
+	default:
+		problem = fmt.Sprintf("no component templates (*.yml) found in %s/", dir)
+		if yamlFiles, _ := filepath.Glob(filepath.Join(dir, "*.yaml")); len(yamlFiles) > 0 {
+			problem += fmt.Sprintf(" (found %s: GitLab components must use the .yml extension)", strings.Join(yamlFiles, ", "))
+		}
+	}
+
+	// Signs that this is a component catalog whose templates went missing,
+	// rather than the tool being run from the wrong directory
+	var evidence []string
+	if _, err := os.Stat(configFile); err == nil {
+		evidence = append(evidence, configFile)
+	}
+	if docs, _ := filepath.Glob(filepath.Join(settings.DocsDir, "*.md")); len(docs) > 0 {
+		evidence = append(evidence, settings.DocsDir+"/")
+	}
+	if _, err := os.Stat(settings.Template); err == nil {
+		evidence = append(evidence, settings.Template)
+	}
+
+	hint := "run the tool from the root of a GitLab CI/CD component repository, or point --templates-dir (or `templates_dir` in " + configFile + ") at the component templates"
+	if len(evidence) > 0 {
+		hint = fmt.Sprintf("this looks like a component catalog (found %s); if the templates were moved or renamed, set `templates_dir` in %s or pass --templates-dir", strings.Join(evidence, ", "), configFile)
+	}
+	return fmt.Errorf("%s: %s", problem, hint)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseNoTemplates(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	settings, _ := resolveSettings(ProjectConfig{}, Settings{})
+
+	// Wrong directory: nothing catalog-like around
+	err := diagnoseNoTemplates(settings)
+	if !strings.Contains(err.Error(), "does not exist") || !strings.Contains(err.Error(), "root of a GitLab CI/CD component repository") {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	// Catalog with its templates gone
+	os.WriteFile(configFile, []byte("project_path: g/p\n"), 0644)
+	os.MkdirAll("docs", 0755)
+	os.WriteFile(filepath.Join("docs", "build.md"), []byte("Build."), 0644)
+	err = diagnoseNoTemplates(settings)
+	if !strings.Contains(err.Error(), "looks like a component catalog") || !strings.Contains(err.Error(), "docs/") {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	// Templates with the wrong extension
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yaml"), []byte("spec: {}\n"), 0644)
+	err = diagnoseNoTemplates(settings)
+	if !strings.Contains(err.Error(), "no component templates") || !strings.Contains(err.Error(), ".yml extension") {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestRun_NoTemplates(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if got := exitCode(run([]string{"--quiet"}, io.Discard)); got != exitNoInput {
+		t.Errorf("expected exit code %d, got %d", exitNoInput, got)
+	}
+}
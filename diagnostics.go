@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// diagnosticFormats are the values accepted by --diagnostics.
+var diagnosticFormats = []string{"text", "json"}
+
+// watchInterval is how often --watch polls the files for changes.
+var watchInterval = time.Second
+
+// FileDiagnostics is one line of --diagnostics json output: every problem
+// found in a template file. An empty list means the file is clean, so editors
+// can clear previously reported problems.
+type FileDiagnostics struct {
+	File        string  `json:"file"`
+	Diagnostics []Issue `json:"diagnostics"`
+}
+
+// inputLines maps each input to the line declaring it in spec:inputs and
+// each undeclared input to the first line interpolating it.
+func inputLines(file *ast.File, data []byte, undeclared []string) map[string]int {
+	lines := make(map[string]int)
+	for input, location := range inputLocations(file) {
+		lines[input] = location.Line
+	}
+	if len(undeclared) == 0 {
+		return lines
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		for _, interp := range parseInterpolations(line) {
+			if _, seen := lines[interp.Input]; !seen && contains(undeclared, interp.Input) {
+				lines[interp.Input] = i + 1
+			}
+		}
+	}
+	return lines
+}
+
+// diagnoseTemplate parses and validates one template. Parse errors are
+// reported as a diagnostic (with the line of the offending token when known)
+// instead of aborting, so one broken file doesn't hide the others.
+func diagnoseTemplate(path string, opts ParseOptions, lint DescriptionLint, scan SecretScan) (FileDiagnostics, bool) {
+	result := FileDiagnostics{File: path, Diagnostics: []Issue{}}
+	component, err := parseTemplate(path, opts)
+	if err != nil {
+		issue := Issue{Severity: severityError, Component: componentName(opts.TemplatesDir, path), Message: err.Error(), File: path}
+		var located *YAMLError
+		if errors.As(err, &located) {
+			issue.Line = located.Line
+			issue.Message = located.Message
+		}
+		result.Diagnostics = append(result.Diagnostics, issue)
+		return result, false
+	}
+	result.Diagnostics = append(result.Diagnostics, validateComponent(component)...)
+	result.Diagnostics = append(result.Diagnostics, lintDescriptions(component, lint)...)
+	result.Diagnostics = append(result.Diagnostics, scanSecrets(component, scan)...)
+	return result, true
+}
+
+// diagnoseAll diagnoses the selected templates in the templates directory,
+// in path order.
+func diagnoseAll(settings Settings) ([]FileDiagnostics, error) {
+	templates, err := findTemplates(settings.TemplatesDir, settings.ignoreRules())
+	if err != nil {
+		return nil, err
+	}
+	opts := settings.parseOptions()
+	var results []FileDiagnostics
+	for _, t := range templates {
+		if componentSelected(settings, componentName(settings.TemplatesDir, t), t) {
+			result, _ := diagnoseTemplate(t, opts, settings.DescriptionLint, settings.SecretScan)
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// writeDiagnostics prints diagnostics as JSON lines (one per file) or as the
+// plain `file:line: severity: ...` lines understood by most editors.
+func writeDiagnostics(w io.Writer, format string, results []FileDiagnostics) error {
+	for _, r := range results {
+		if format == "json" {
+			line, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, d := range r.Diagnostics {
+			if _, err := fmt.Fprintf(w, "%s:%d: %s\n", r.File, d.Line, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filesSnapshot fingerprints the files that affect diagnostics (templates,
+// descriptions, examples and the config file, including subdirectories) by
+// path, size and modification time.
+func filesSnapshot(settings Settings) string {
+	var b strings.Builder
+	add := func(path string, info fs.FileInfo) {
+		fmt.Fprintf(&b, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	for _, dir := range []string{settings.TemplatesDir, settings.DocsDir, settings.ExamplesDir} {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				add(path, info)
+			}
+			return nil
+		})
+	}
+	if info, err := os.Stat(configFile); err == nil {
+		add(configFile, info)
+	}
+	return b.String()
+}
+
+// watchDiagnostics reports diagnostics for every template, then polls the
+// files and, on each change, reports the files whose diagnostics changed
+// (including files that were removed, with an empty list). It runs until
+// stop is closed (forever when stop is nil).
+func watchDiagnostics(settings Settings, format string, w io.Writer, stop <-chan struct{}) error {
+	previous := make(map[string]string)
+	snapshot := ""
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		if current := filesSnapshot(settings); current != snapshot {
+			snapshot = current
+			results, err := diagnoseAll(settings)
+			if err != nil {
+				return err
+			}
+
+			seen := make(map[string]bool)
+			var changed []FileDiagnostics
+			for _, r := range results {
+				seen[r.File] = true
+				key, _ := json.Marshal(r.Diagnostics)
+				if previous[r.File] != string(key) {
+					previous[r.File] = string(key)
+					changed = append(changed, r)
+				}
+			}
+			var removed []string
+			for file := range previous {
+				if !seen[file] {
+					removed = append(removed, file)
+				}
+			}
+			sort.Strings(removed)
+			for _, file := range removed {
+				delete(previous, file)
+				changed = append(changed, FileDiagnostics{File: file, Diagnostics: []Issue{}})
+			}
+			if err := writeDiagnostics(w, format, changed); err != nil {
+				return fmt.Errorf("error writing diagnostics: %w", err)
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
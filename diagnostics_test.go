@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use by a writer goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRun_DiagnosticsJSON(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "bad.yml"), []byte(`spec:
+  inputs:
+    level:
+      default: trace
+      options: [debug, info]
+---
+job:
+  script: echo $[[ inputs.level ]] $[[ inputs.nope ]]
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "broken.yml"), []byte("spec:\n  inputs:\n    a: [\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "good.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if got := exitCode(run([]string{"--diagnostics", "json"}, &out)); got != exitInvalid {
+		t.Errorf("expected exit code %d, got %d", exitInvalid, got)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per template, got %q", out.String())
+	}
+
+	var results []FileDiagnostics
+	for _, line := range lines {
+		var r FileDiagnostics
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		results = append(results, r)
+	}
+
+	bad := results[0]
+	lineOf := make(map[string]int)
+	for _, d := range bad.Diagnostics {
+		lineOf[d.Input] = d.Line
+	}
+	if lineOf["level"] != 3 || lineOf["nope"] != 8 {
+		t.Errorf("expected lines 3 (declaration) and 8 (first use), got %v", lineOf)
+	}
+	if broken := results[1]; len(broken.Diagnostics) != 1 || broken.Diagnostics[0].Line != 3 {
+		t.Errorf("expected the syntax error on line 3, got %+v", broken.Diagnostics)
+	}
+	if good := results[2]; good.Diagnostics == nil || len(good.Diagnostics) != 0 {
+		t.Errorf("expected an empty diagnostics list for a clean file, got %+v", good.Diagnostics)
+	}
+
+	if got := exitCode(run([]string{"--diagnostics", "xml"}, &out)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown format, got %d", exitConfig, got)
+	}
+}
+
+func TestWatchDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	templatesDir := filepath.Join(dir, "templates")
+	os.MkdirAll(templatesDir, 0755)
+	path := filepath.Join(templatesDir, "build.yml")
+	os.WriteFile(path, []byte("spec:\n  inputs: {}\n"), 0644)
+
+	defer func(interval time.Duration) { watchInterval = interval }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	settings := Settings{TemplatesDir: templatesDir, DocsDir: filepath.Join(dir, "docs"), Format: "markdown", SortOrder: "required"}
+	var out syncBuffer
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- watchDiagnostics(settings, "json", &out, stop) }()
+
+	waitFor := func(substr string, count int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for strings.Count(out.String(), substr) < count {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %d x %q, got %q", count, substr, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	waitFor(`"diagnostics":[]`, 1)
+
+	// Breaking the file reports it; an unrelated change reports nothing new
+	os.WriteFile(path, []byte("spec:\n  inputs: {}\n---\njob:\n  script: echo $[[ inputs.missing ]]\n"), 0644)
+	waitFor("not declared", 1)
+	os.WriteFile(filepath.Join(templatesDir, "other.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	waitFor("other.yml", 1)
+
+	// Removing a file clears its diagnostics
+	os.Remove(path)
+	waitFor(`"diagnostics":[]`, 3)
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 4 {
+		t.Errorf("expected 4 reports (initial, broken, new file, removed), got %d: %q", n, out.String())
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// markdownDialects lists the supported values of the `markdown_dialect` setting.
+var markdownDialects = []string{"gitlab", "github", "commonmark"}
+
+// alertKinds lists the alert kinds shared by GitLab, GitHub and AsciiDoc.
+var alertKinds = []string{"note", "tip", "important", "warning", "caution"}
+
+// dialectFuncs returns the template functions whose output depends on the
+// Markdown dialect (or on the AsciiDoc format): GitLab and GitHub render
+// alerts, task lists and collapsible sections, while plain CommonMark falls
+// back to a bold label, a regular list and always expanded content.
+func dialectFuncs(settings Settings) template.FuncMap {
+	dialect := templateDialect(settings)
+	return template.FuncMap{
+		"dialect": func() string { return dialect },
+		"alert": func(kind string, lines ...string) (string, error) {
+			return alertBlock(dialect, kind, lines)
+		},
+		"bullets": func(items []string) string {
+			marker := "- "
+			if dialect == "asciidoc" {
+				marker = "* "
+			}
+			return marker + strings.Join(items, "\n"+marker)
+		},
+		"strike": func(text string) string { return strikethrough(dialect, text) },
+		"collapsed": func(c ComponentData) bool {
+			return settings.Collapse > 0 && len(c.Inputs) > settings.Collapse
+		},
+		"task": func(text string) string {
+			switch dialect {
+			case "commonmark":
+				return "- " + text
+			case "asciidoc":
+				return "* [ ] " + text
+			}
+			return "- [ ] " + text
+		},
+		"details": func(summary string) string {
+			switch dialect {
+			case "commonmark":
+				return ""
+			case "asciidoc":
+				if settings.CollapseOpen {
+					return "." + summary + "\n[%collapsible%open]\n===="
+				}
+				return "." + summary + "\n[%collapsible]\n===="
+			}
+			if settings.CollapseOpen {
+				return "<details open>\n<summary>" + summary + "</summary>"
+			}
+			return "<details>\n<summary>" + summary + "</summary>"
+		},
+		"endDetails": func() string {
+			switch dialect {
+			case "commonmark":
+				return ""
+			case "asciidoc":
+				return "===="
+			}
+			return "</details>"
+		},
+	}
+}
+
+// templateDialect returns the Markdown dialect of a run, or "asciidoc".
+func templateDialect(settings Settings) string {
+	if settings.Format == "asciidoc" {
+		return "asciidoc"
+	}
+	return settings.Dialect
+}
+
+// strikethrough strikes text through: `~~text~~` on GitLab and GitHub, a
+// line-through role in AsciiDoc. CommonMark has no strikethrough, so the text
+// is marked "(deprecated)" instead.
+func strikethrough(dialect, text string) string {
+	switch dialect {
+	case "commonmark":
+		return text + " (deprecated)"
+	case "asciidoc":
+		return "[.line-through]#" + text + "#"
+	}
+	return "~~" + text + "~~"
+}
+
+// deprecationLabel returns label (e.g. "Deprecated") in bold followed by the
+// migration note, if any, ending with a period.
+func deprecationLabel(dialect, label, note string) string {
+	bold := "**"
+	if dialect == "asciidoc" {
+		bold = "*"
+	}
+	if note == "" {
+		return bold + label + "." + bold
+	}
+	if !strings.ContainsAny(note[len(note)-1:], ".!?") {
+		note += "."
+	}
+	return bold + label + ":" + bold + " " + note
+}
+
+// sensitiveBadge returns the badge following a masked default.
+func sensitiveBadge(dialect, label string) string {
+	if dialect == "asciidoc" {
+		return "⚠️ *" + label + "*"
+	}
+	return "⚠️ **" + label + "**"
+}
+
+// alertBlock renders an alert (note, tip, important, warning or caution)
+// holding the given lines: a `> [!WARNING]` blockquote on GitLab and GitHub,
+// a blockquote with a bold label in CommonMark, an admonition block in
+// AsciiDoc.
+func alertBlock(dialect, kind string, lines []string) (string, error) {
+	kind = strings.ToLower(kind)
+	if !contains(alertKinds, kind) {
+		return "", fmt.Errorf("unknown alert kind %q (expected one of: %s)", kind, strings.Join(alertKinds, ", "))
+	}
+	text := strings.Join(lines, "\n")
+	switch dialect {
+	case "asciidoc":
+		return "[" + strings.ToUpper(kind) + "]\n====\n" + text + "\n====", nil
+	case "commonmark":
+		label := strings.ToUpper(kind[:1]) + kind[1:]
+		return "> **" + label + "**\n>\n> " + strings.ReplaceAll(text, "\n", "\n> "), nil
+	}
+	return "> [!" + strings.ToUpper(kind) + "]\n> " + strings.ReplaceAll(text, "\n", "\n> "), nil
+}
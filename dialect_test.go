@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAlertBlock(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		expected string
+	}{
+		{"gitlab", "> [!WARNING]\n> - a\n> - b"},
+		{"github", "> [!WARNING]\n> - a\n> - b"},
+		{"commonmark", "> **Warning**\n>\n> - a\n> - b"},
+		{"asciidoc", "[WARNING]\n====\n- a\n- b\n===="},
+	}
+	for _, tt := range tests {
+		got, err := alertBlock(tt.dialect, "Warning", []string{"- a", "- b"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.dialect, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.dialect, tt.expected, got)
+		}
+	}
+	if _, err := alertBlock("gitlab", "danger", nil); err == nil {
+		t.Error("expected an error for an unknown alert kind")
+	}
+}
+
+func TestDialectFuncs(t *testing.T) {
+	call := func(settings Settings, name string, args ...string) string {
+		switch fn := dialectFuncs(settings)[name].(type) {
+		case func(string) string:
+			return fn(args[0])
+		case func() string:
+			return fn()
+		}
+		t.Fatalf("unexpected function %s", name)
+		return ""
+	}
+	gitlab := Settings{Format: "markdown", Dialect: "gitlab"}
+	commonmark := Settings{Format: "markdown", Dialect: "commonmark"}
+	asciidoc := Settings{Format: "asciidoc", Dialect: "gitlab"}
+
+	if got := call(gitlab, "task", "x"); got != "- [ ] x" {
+		t.Errorf("expected a task list item, got %q", got)
+	}
+	if got := call(commonmark, "task", "x"); got != "- x" {
+		t.Errorf("expected a plain list item, got %q", got)
+	}
+	if got := call(asciidoc, "task", "x"); got != "* [ ] x" {
+		t.Errorf("expected an AsciiDoc checklist item, got %q", got)
+	}
+	if got := call(gitlab, "details", "More") + call(gitlab, "endDetails"); got != "<details>\n<summary>More</summary></details>" {
+		t.Errorf("unexpected collapsible section: %q", got)
+	}
+	if got := call(commonmark, "details", "More") + call(commonmark, "endDetails"); got != "" {
+		t.Errorf("expected no collapsible section in CommonMark, got %q", got)
+	}
+	if got := call(asciidoc, "dialect"); got != "asciidoc" {
+		t.Errorf("expected the asciidoc dialect, got %q", got)
+	}
+}
+
+func TestRun_MarkdownDialect(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n---\nbuild:\n  script: echo $TOKEN\n  artifacts:\n    expire_in: never\n    paths: [out/]\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	for _, want := range []string{"- [ ] `TOKEN`\n", "> [!WARNING]\n> - `build` artifacts never expire"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+
+	os.WriteFile(configFile, []byte("markdown_dialect: commonmark\n"), 0644)
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile("README.md")
+	for _, want := range []string{"- `TOKEN`\n", "> **Warning**\n>\n> - `build` artifacts never expire"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+
+	if got := exitCode(run([]string{"--quiet", "--markdown-dialect", "markdown"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown dialect, got %d", exitConfig, got)
+	}
+}
+
+func TestRun_CollapsedInputs(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "small.yml"), []byte("spec:\n  inputs:\n    a: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "large.yml"), []byte("spec:\n  inputs:\n    a: {}\n    b: {}\n    c: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); strings.Contains(string(data), "<details") {
+		t.Errorf("expected no collapsed table below the default threshold, got:\n%s", data)
+	}
+
+	os.WriteFile(configFile, []byte("collapse:\n  threshold: 2\n  open: true\n"), 0644)
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	if strings.Count(string(data), "<details open>\n<summary>3 inputs</summary>\n\n| Name |") != 1 || !strings.Contains(string(data), "| c |  | string | true |  |\n\n</details>\n") {
+		t.Errorf("expected only the large inputs table to be collapsed, got:\n%s", data)
+	}
+
+	if err := run([]string{"--quiet", "--format", "asciidoc"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.Contains(string(data), ".3 inputs\n[%collapsible%open]\n====\n") {
+		t.Errorf("expected a collapsible AsciiDoc block, got:\n%s", data)
+	}
+
+	os.WriteFile(configFile, []byte("collapse:\n  threshold: -1\n"), 0644)
+	if got := exitCode(run([]string{"--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for a negative threshold, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp is a single line of an edit script.
+type diffOp struct {
+	kind byte // ' ' (keep), '-' (delete) or '+' (insert)
+	line string
+}
+
+// diffLines computes a shortest edit script turning a into b (Myers' algorithm).
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the saved Myers frontiers back to build the edit script.
+func backtrack(trace [][]int, a, b []string, offset int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{'+', b[y]})
+			} else {
+				x--
+				ops = append(ops, diffOp{'-', a[x]})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff renders the differences between two texts in unified format
+// with three lines of context, optionally colorized. It returns an empty
+// string when the texts are equal.
+func unifiedDiff(oldText, newText, oldName, newName string, color bool) string {
+	if oldText == newText {
+		return ""
+	}
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	const context = 3
+	var out strings.Builder
+	out.WriteString(paint(color, ansiRed, "--- "+oldName) + "\n")
+	out.WriteString(paint(color, ansiGreen, "+++ "+newName) + "\n")
+
+	// Line numbers (1-based) of each op in the old and new text
+	oldLine, newLine := make([]int, len(ops)), make([]int, len(ops))
+	for i, o, n := 0, 1, 1; i < len(ops); i++ {
+		oldLine[i], newLine[i] = o, n
+		if ops[i].kind != '+' {
+			o++
+		}
+		if ops[i].kind != '-' {
+			n++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Extend the hunk while changes are closer than twice the context
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		end += context
+		if end >= len(ops) {
+			end = len(ops) - 1
+		}
+
+		var oldCount, newCount int
+		for _, op := range ops[start : end+1] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		// An empty range refers to the line before it, as in diff(1)
+		oldStart, newStart := oldLine[start], newLine[start]
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		out.WriteString(paint(color, ansiCyan, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount)) + "\n")
+		for _, op := range ops[start : end+1] {
+			line := string(op.kind) + op.line
+			switch op.kind {
+			case '-':
+				line = paint(color, ansiRed, line)
+			case '+':
+				line = paint(color, ansiGreen, line)
+			}
+			out.WriteString(line + "\n")
+		}
+		i = end + 1
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines, without a trailing empty element.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
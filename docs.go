@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ExampleData is a usage example shown in a component's documentation.
+type ExampleData struct {
+	Title string `yaml:"title" json:"title,omitempty"`
+	Code  string `yaml:"code" json:"code"`
+}
+
+// frontMatter holds the known keys of a description file's front-matter.
+type frontMatter struct {
+	Title       string        `yaml:"title"`
+	Maintainers []string      `yaml:"maintainers"`
+	Examples    []ExampleData `yaml:"examples"`
+}
+
+// componentDocs is everything read from a component's description file.
+type componentDocs struct {
+	Description string
+	frontMatter
+	// Meta holds the front-matter keys not listed in frontMatter
+	Meta map[string]interface{}
+
+	path string // file read, empty when there is none
+}
+
+// descriptionPaths lists the description files looked up for a component, in
+// order: <docsDir>/<name><ext>, then <docsDir>/<name>/index<ext>, for each
+// extension.
+func descriptionPaths(docsDir, name string, exts ...string) []string {
+	if len(exts) == 0 {
+		exts = []string{".md"}
+	}
+	var paths []string
+	for _, ext := range exts {
+		paths = append(paths,
+			filepath.Join(docsDir, name+ext),
+			filepath.Join(docsDir, name, "index"+ext))
+	}
+	return paths
+}
+
+// loadComponentDocs reads the first existing description file of a component
+// and splits off its optional YAML front-matter (delimited by `---` lines).
+// A missing file is not an error; an invalid front-matter is.
+func loadComponentDocs(docsDir, name string, exts ...string) (componentDocs, error) {
+	for _, path := range descriptionPaths(docsDir, name, exts...) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if data, err = decodeSource(path, data); err != nil {
+			return componentDocs{}, err
+		}
+		front, body := splitFrontMatter(data)
+		docs := componentDocs{Description: strings.TrimSpace(body), path: path}
+		if front == nil {
+			return docs, nil
+		}
+		if err := yaml.Unmarshal(front, &docs.frontMatter); err != nil {
+			return docs, fmt.Errorf("error parsing front-matter of %s: %w", path, err)
+		}
+		for i := range docs.Examples {
+			docs.Examples[i].Code = strings.TrimRight(docs.Examples[i].Code, "\n")
+		}
+		var all map[string]interface{}
+		if err := yaml.Unmarshal(front, &all); err != nil {
+			return docs, fmt.Errorf("error parsing front-matter of %s: %w", path, err)
+		}
+		for key, value := range all {
+			switch key {
+			case "title", "maintainers", "examples":
+				continue
+			}
+			if docs.Meta == nil {
+				docs.Meta = make(map[string]interface{})
+			}
+			docs.Meta[key] = value
+		}
+		return docs, nil
+	}
+	return componentDocs{}, nil
+}
+
+// splitFrontMatter separates a leading `---` delimited YAML block from the
+// rest of a document. It returns nil front-matter when there is none.
+func splitFrontMatter(data []byte) (front []byte, body string) {
+	content := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(content, []byte("---\n")) {
+		return nil, string(data)
+	}
+	rest := content[len("---\n"):]
+	if bytes.HasPrefix(rest, []byte("---\n")) || bytes.Equal(rest, []byte("---")) {
+		return []byte{}, string(bytes.TrimPrefix(rest, []byte("---")))
+	}
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if !bytes.HasSuffix(rest, []byte("\n---")) {
+			return nil, string(data)
+		}
+		end = len(rest) - len("\n---")
+	}
+	front = rest[:end+1]
+	body = string(rest[min(end+len("\n---\n"), len(rest)):])
+	return front, body
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantFront string
+		wantBody  string
+		hasFront  bool
+	}{
+		{"none", "Just text.\n", "", "Just text.\n", false},
+		{"front-matter", "---\ntitle: Build\n---\nBody.\n", "title: Build\n", "Body.\n", true},
+		{"crlf", "---\r\ntitle: Build\r\n---\r\nBody.\r\n", "title: Build\n", "Body.\n", true},
+		{"empty front-matter", "---\n---\nBody.\n", "", "\nBody.\n", true},
+		{"front-matter only", "---\ntitle: Build\n---", "title: Build\n", "", true},
+		{"unterminated", "---\ntitle: Build\n", "", "---\ntitle: Build\n", false},
+		{"horizontal rule later", "Intro\n---\nMore\n", "", "Intro\n---\nMore\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			front, body := splitFrontMatter([]byte(tt.content))
+			if (front != nil) != tt.hasFront {
+				t.Fatalf("expected front-matter presence %v, got %q", tt.hasFront, front)
+			}
+			if string(front) != tt.wantFront {
+				t.Errorf("expected front-matter %q, got %q", tt.wantFront, front)
+			}
+			if body != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestLoadComponentDocs_IndexAndFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "deploy"), 0755)
+	os.WriteFile(filepath.Join(dir, "deploy", "index.md"), []byte(`---
+title: Deploy to Kubernetes
+maintainers: ["@alice"]
+team: platform
+examples:
+  - title: Production
+    code: |
+      include:
+        - component: x
+---
+Deploys the app.
+`), 0644)
+
+	docs, err := loadComponentDocs(dir, "deploy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if docs.Description != "Deploys the app." || docs.Title != "Deploy to Kubernetes" {
+		t.Errorf("unexpected docs: %+v", docs)
+	}
+	if strings.Join(docs.Maintainers, ",") != "@alice" {
+		t.Errorf("expected maintainers [@alice], got %v", docs.Maintainers)
+	}
+	if len(docs.Examples) != 1 || docs.Examples[0].Title != "Production" || docs.Examples[0].Code != "include:\n  - component: x" {
+		t.Errorf("unexpected examples: %+v", docs.Examples)
+	}
+	if docs.Meta["team"] != "platform" || len(docs.Meta) != 1 {
+		t.Errorf("expected meta {team: platform}, got %v", docs.Meta)
+	}
+
+	// A flat file wins over the directory
+	os.WriteFile(filepath.Join(dir, "deploy.md"), []byte("Flat."), 0644)
+	if got := loadComponentDescription(dir, "deploy"); got != "Flat." {
+		t.Errorf("expected flat file to win, got %q", got)
+	}
+}
+
+func TestParseTemplate_InvalidFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "build.md"), []byte("---\ntitle: [unclosed\n---\nBody\n"), 0644)
+	path := filepath.Join(dir, "build.yml")
+	os.WriteFile(path, []byte("spec:\n  inputs: {}\n"), 0644)
+
+	_, err := parseTemplate(path, ParseOptions{DocsDir: filepath.Join(dir, "docs")})
+	if err == nil || !strings.Contains(err.Error(), "front-matter") {
+		t.Errorf("expected front-matter error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// decodeSource puts the content of a file the tool parses (a component
+// template, a file it includes, a description, an example, the config) in
+// the form the parsers expect, whatever the editor that saved it: UTF-16
+// with a byte order mark is decoded, a UTF-8 byte order mark is dropped, and
+// CRLF and CR line endings become LF. Content that is still not valid UTF-8
+// (e.g. Latin-1) is an error located at the first invalid byte, rather than
+// garbled text in the docs.
+func decodeSource(path string, data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return decodeUTF16(path, data[len(utf16LEBOM):], binary.LittleEndian)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return decodeUTF16(path, data[len(utf16BEBOM):], binary.BigEndian)
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if bytes.IndexByte(data, '\r') >= 0 {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	}
+	if !utf8.Valid(data) {
+		line, column := invalidUTF8Position(data)
+		return nil, locatedError(path, data, line, column, "invalid UTF-8 (save the file as UTF-8)")
+	}
+	return data, nil
+}
+
+// decodeUTF16 decodes UTF-16 content, without its byte order mark, to UTF-8
+// and normalizes it like decodeSource.
+func decodeUTF16(path string, data []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("error decoding %s: truncated UTF-16 content", path)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return decodeSource(path, []byte(string(utf16.Decode(units))))
+}
+
+// invalidUTF8Position returns the line and column (1-based, in characters)
+// of the first byte of data that is not valid UTF-8.
+func invalidUTF8Position(data []byte) (line, column int) {
+	line, column = 1, 1
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		if r == '\n' {
+			line, column = line+1, 1
+		} else {
+			column++
+		}
+		data = data[size:]
+	}
+	return line, column
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16Bytes encodes s as UTF-16 with a byte order mark.
+func utf16Bytes(s string, bigEndian bool) []byte {
+	out := []byte{0xff, 0xfe}
+	if bigEndian {
+		out = []byte{0xfe, 0xff}
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(unit>>8), byte(unit))
+		} else {
+			out = append(out, byte(unit), byte(unit>>8))
+		}
+	}
+	return out
+}
+
+func TestDecodeSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"utf-8", []byte("spec:\n  inputs: {}\n# café\n")},
+		{"byte order mark", []byte("\ufeffspec:\n  inputs: {}\n# café\n")},
+		{"crlf", []byte("spec:\r\n  inputs: {}\r\n# café\r\n")},
+		{"cr", []byte("spec:\r  inputs: {}\r# café\r")},
+		{"byte order mark and crlf", []byte("\ufeffspec:\r\n  inputs: {}\r\n# café\r\n")},
+		{"utf-16le", utf16Bytes("spec:\r\n  inputs: {}\r\n# café\r\n", false)},
+		{"utf-16be", utf16Bytes("spec:\n  inputs: {}\n# café\n", true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSource("build.yml", tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := "spec:\n  inputs: {}\n# café\n"; string(got) != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		})
+	}
+
+	_, err := decodeSource("build.yml", []byte("spec:\n  inputs:\n    stage:\n      description: Caf\xe9\n"))
+	var located *YAMLError
+	if !errors.As(err, &located) || located.Line != 4 || located.Column != 23 || !strings.Contains(err.Error(), "invalid UTF-8") {
+		t.Errorf("expected an invalid UTF-8 error at 4:23, got %v", err)
+	}
+	if _, err := decodeSource("build.yml", []byte{0xff, 0xfe, 's'}); err == nil {
+		t.Error("expected an error for truncated UTF-16 content")
+	}
+}
+
+func TestRun_SourceEncodings(t *testing.T) {
+	const template = "spec:\n  inputs:\n    stage:\n      default: build\n      description: |\n        Stage\n        of the job.\n---\nbuild:\n  stage: $[[ inputs.stage ]]\n  script: make\n"
+	crlf := strings.ReplaceAll(template, "\n", "\r\n")
+
+	args := []string{"--quiet", "--no-cache", "--project-path", "g/p", "--version", "1.0.0", "--dry-run"}
+	render := func(t *testing.T, content []byte, description string) string {
+		t.Helper()
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+		os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+		os.WriteFile(filepath.Join(dir, "templates", "build.yml"), content, 0644)
+		os.WriteFile(filepath.Join(dir, "docs", "build.md"), []byte(description), 0644)
+
+		origDir, _ := os.Getwd()
+		os.Chdir(dir)
+		defer os.Chdir(origDir)
+
+		var out bytes.Buffer
+		if err := run(args, &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return out.String()
+	}
+
+	want := render(t, []byte(template), "Builds the project.\n")
+	if !strings.Contains(want, "| build | $[[ inputs.stage ]] |\n\n") {
+		t.Fatalf("expected only the build job, got:\n%s", want)
+	}
+	for name, content := range map[string][]byte{
+		"byte order mark and crlf": []byte("\ufeff" + crlf),
+		"utf-16le":                 utf16Bytes(crlf, false),
+	} {
+		if got := render(t, content, "\ufeffBuilds the project.\r\n"); got != want {
+			t.Errorf("expected the %s template documented like the UTF-8 one, got:\n%s", name, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// envPrefix starts the environment variables that set config keys: the key
+// in upper case, with nested keys joined by underscores (DOCS_GEN_OUTPUT,
+// DOCS_GEN_COLLAPSE_THRESHOLD).
+const envPrefix = "DOCS_GEN_"
+
+// envConfigVar returns the environment variable setting a config key, e.g.
+// "collapse.threshold".
+func envConfigVar(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnvConfig sets the config keys given as DOCS_GEN_* environment
+// variables, over the values of the config file. Lists are comma-separated;
+// lists of mappings and mappings (mirrors, translations...) are YAML, e.g.
+// DOCS_GEN_TRANSLATIONS='{inputs: Parameters}'. Unknown DOCS_GEN_* variables
+// are an error, like unknown keys.
+func applyEnvConfig(config *ProjectConfig, environ []string) error {
+	known := make(map[string]bool)
+	var errs []string
+	var apply func(v reflect.Value, prefix string)
+	apply = func(v reflect.Value, prefix string) {
+		for i := 0; i < v.NumField(); i++ {
+			tag := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			field := v.Field(i)
+			if field.Kind() == reflect.Struct {
+				apply(field, prefix+tag+".")
+				continue
+			}
+			name := envConfigVar(prefix + tag)
+			known[name] = true
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setEnvValue(field, value); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid %s: %v", name, err))
+			}
+		}
+	}
+	apply(reflect.ValueOf(config).Elem(), "")
+
+	var unknown []string
+	for _, entry := range environ {
+		name := strings.SplitN(entry, "=", 2)[0]
+		if strings.HasPrefix(name, envPrefix) && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	if len(unknown) > 0 {
+		errs = append(errs, "unknown environment variable(s): "+strings.Join(unknown, ", "))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error reading the environment: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// setEnvValue sets a config field from the value of its environment variable.
+func setEnvValue(field reflect.Value, value string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setEnvValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		var list stringList
+		list.Set(value)
+		field.Set(reflect.ValueOf([]string(list)))
+	default:
+		decoded := reflect.New(field.Type())
+		if err := yaml.Unmarshal([]byte(value), decoded.Interface()); err != nil {
+			return fmt.Errorf("expected YAML: %v", err)
+		}
+		field.Set(decoded.Elem())
+	}
+	return nil
+}
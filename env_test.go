@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvConfigVar(t *testing.T) {
+	if got := envConfigVar("collapse.threshold"); got != "DOCS_GEN_COLLAPSE_THRESHOLD" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestReadProjectConfig_Env(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	os.WriteFile(path, []byte("output: DOCS.md\nformat: markdown\nsort: name\n"), 0644)
+
+	t.Setenv("DOCS_GEN_TEMPLATES_DIR", "ci/templates")
+	t.Setenv("DOCS_GEN_OUTPUT", "README.md")
+	t.Setenv("DOCS_GEN_EXCLUDE", "internal-*, templates/_*.yml")
+	t.Setenv("DOCS_GEN_SOURCE_LINKS", "true")
+	t.Setenv("DOCS_GEN_RESOLVE_INCLUDES", "false")
+	t.Setenv("DOCS_GEN_COLLAPSE_THRESHOLD", "0")
+	t.Setenv("DOCS_GEN_WEBHOOK_URL", "https://chat.example.com/hook")
+	t.Setenv("DOCS_GEN_TRANSLATIONS", "{inputs: Parameters}")
+	t.Setenv("DOCS_GEN_MIRRORS", "[{remote: public, server: gitlab.com}]")
+
+	config, err := readProjectConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.TemplatesDir != "ci/templates" || config.Output != "README.md" || config.Sort != "name" {
+		t.Errorf("expected env vars over the config file, got %+v", config)
+	}
+	if strings.Join(config.Exclude, ",") != "internal-*,templates/_*.yml" {
+		t.Errorf("unexpected exclude %q", config.Exclude)
+	}
+	if !config.SourceLinks || config.ResolveIncludes == nil || *config.ResolveIncludes {
+		t.Errorf("unexpected booleans: source_links %v, resolve_includes %v", config.SourceLinks, config.ResolveIncludes)
+	}
+	if config.Collapse.Threshold == nil || *config.Collapse.Threshold != 0 || config.Webhook.URL != "https://chat.example.com/hook" {
+		t.Errorf("unexpected nested keys %+v %+v", config.Collapse, config.Webhook)
+	}
+	if config.Translations["inputs"] != "Parameters" || len(config.Mirrors) != 1 || config.Mirrors[0].Server != "gitlab.com" {
+		t.Errorf("unexpected YAML values %v %+v", config.Translations, config.Mirrors)
+	}
+
+	// Without a config file, the environment alone configures the run
+	config, err = readProjectConfig(filepath.Join(dir, "missing.yml"))
+	if err != nil || config.Output != "README.md" {
+		t.Errorf("expected the environment's config, got %+v, %v", config, err)
+	}
+}
+
+func TestReadProjectConfig_EnvErrors(t *testing.T) {
+	t.Setenv("DOCS_GEN_FOOTER", "sometimes")
+	t.Setenv("DOCS_GEN_OUTPT", "README.md")
+	_, err := readProjectConfig(filepath.Join(t.TempDir(), "missing.yml"))
+	if err == nil || !strings.Contains(err.Error(), "invalid DOCS_GEN_FOOTER") || !strings.Contains(err.Error(), "unknown environment variable(s): DOCS_GEN_OUTPT") {
+		t.Errorf("expected both errors, got %v", err)
+	}
+}
+
+func TestRun_EnvConfig(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "ci"), 0755)
+	os.WriteFile(filepath.Join(dir, "ci", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	t.Setenv("DOCS_GEN_TEMPLATES_DIR", "ci")
+	t.Setenv("DOCS_GEN_OUTPUT", "env.md")
+	if err := run([]string{"--project-path", "g/p", "--version", "1.0.0", "--quiet", "--output", "flag.md"}, os.Stdout); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("flag.md"); err != nil {
+		t.Errorf("expected the flag to win over DOCS_GEN_OUTPUT: %v", err)
+	}
+	data, _ := os.ReadFile("flag.md")
+	if !strings.Contains(string(data), "build") {
+		t.Errorf("expected DOCS_GEN_TEMPLATES_DIR to locate the templates, got:\n%s", data)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+)
+
+// codeSpan wraps s in an inline code span, using a fence longer than any
+// backtick run inside it (padded with spaces when s starts or ends with a
+// backtick), so values containing backticks stay in one span.
+func codeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// isCodeSpan reports whether s is a single inline code span.
+func isCodeSpan(s string) bool {
+	n := len(s) - len(strings.TrimLeft(s, "`"))
+	if n == 0 || len(s) < 2*n+1 {
+		return false
+	}
+	fence := s[:n]
+	inner := s[n : len(s)-n]
+	return strings.HasSuffix(s, fence) && !strings.HasSuffix(inner, "`") && !strings.Contains(inner, fence)
+}
+
+// markdownCell makes s safe inside a Markdown table cell. Pipes are escaped
+// everywhere (GitLab and GitHub unescape `\|` even in code spans). Outside
+// code spans, `*` and unmatched backticks are escaped and line breaks become
+// <br> (trailing ones are dropped); inside them, line breaks become spaces.
+func markdownCell(s string) string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '`' {
+			n := 1
+			for i+n < len(s) && s[i+n] == '`' {
+				n++
+			}
+			fence := s[i : i+n]
+			if end := closingFence(s[i+n:], n); end >= 0 {
+				inner := s[i+n : i+n+end]
+				inner = strings.ReplaceAll(inner, "\n", " ")
+				b.WriteString(fence + strings.ReplaceAll(inner, "|", `\|`) + fence)
+				i += n + end + n
+				continue
+			}
+			b.WriteString(strings.Repeat("\\`", n))
+			i += n
+			continue
+		}
+		switch s[i] {
+		case '|':
+			b.WriteString(`\|`)
+		case '*':
+			b.WriteString(`\*`)
+		case '\n':
+			b.WriteString("<br>")
+		default:
+			b.WriteByte(s[i])
+		}
+		i++
+	}
+	return b.String()
+}
+
+// closingFence returns the index in s of the first run of exactly n
+// backticks, or -1 when the code span is never closed.
+func closingFence(s string, n int) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(s) && s[i+run] == '`' {
+			run++
+		}
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// asciidocCell makes s safe inside an AsciiDoc table cell: pipes are escaped
+// and line breaks become hard line breaks.
+func asciidocCell(s string) string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " +\n")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeSpan(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"plain", "`plain`"},
+		{"a`b", "``a`b``"},
+		{"`quoted`", "`` `quoted` ``"},
+		{"a``b`c", "```a``b`c```"},
+	}
+	for _, tt := range tests {
+		if got := codeSpan(tt.input); got != tt.want {
+			t.Errorf("codeSpan(%q): expected %q, got %q", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "Application name", "Application name"},
+		{"pipe", "a | b", `a \| b`},
+		{"asterisk", "*.log", `\*.log`},
+		{"newlines", "line one\nline two\r\nthree", "line one<br>line two<br>three"},
+		{"code span with pipe", "`a|b`", "`a\\|b`"},
+		{"code span keeps asterisks", "`*.log`", "`*.log`"},
+		{"code span newline", "`a\nb`", "`a b`"},
+		{"unmatched backtick", "it`s", "it\\`s"},
+		{"double fence", "``a`b``", "``a`b``"},
+		{"mixed", "Use `x|y` or *z*", "Use `x\\|y` or \\*z\\*"},
+	}
+	for _, tt := range tests {
+		if got := markdownCell(tt.input); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAsciidocCell(t *testing.T) {
+	if got := asciidocCell("`a|b`\nnext"); got != "`a\\|b` +\nnext" {
+		t.Errorf("unexpected AsciiDoc cell: %q", got)
+	}
+}
+
+func TestRender_EscapesTableCells(t *testing.T) {
+	dir := t.TempDir()
+	yamlContent := `spec:
+  inputs:
+    pattern:
+      description: "Files matching *.log | *.txt"
+      default: "a|b"
+    matrix:
+      description: |
+        First line
+        second line
+      default:
+        - "x|y"
+        - z
+    env:
+      default: {KEY: "v|w"}
+    quote:
+      default: "it's a ` + "`" + `tick"
+`
+	path := filepath.Join(dir, "escape.yml")
+	os.WriteFile(path, []byte(yamlContent), 0644)
+	component, err := parseTemplate(path, ParseOptions{SortOrder: "name"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	settings := Settings{Format: "markdown", Template: filepath.Join(dir, "missing.tmpl")}
+	doc, err := renderDocument(settings, TemplateData{ProjectPath: "g/p", Version: "1", Components: []ComponentData{component}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := make(map[string]string)
+	for _, line := range strings.Split(string(doc), "\n") {
+		if strings.HasPrefix(line, "| ") {
+			rows[strings.Fields(line)[1]] = line
+		}
+	}
+
+	for name, want := range map[string]string{
+		"pattern": "| pattern | Files matching \\*.log \\| \\*.txt | string | false | a\\|b |",
+		"matrix":  "| matrix | First line<br>second line | array | false | `[\"x\\|y\",\"z\"]` |",
+		"env":     "| env |  | object | false | `{\"KEY\":\"v\\|w\"}` |",
+		"quote":   "| quote |  | string | false | it's a \\`tick |",
+	} {
+		if rows[name] != want {
+			t.Errorf("expected row %q, got %q", want, rows[name])
+		}
+	}
+	for _, row := range rows {
+		// Every row keeps the 5 columns of the header: unescaped pipes only
+		if n := strings.Count(strings.ReplaceAll(row, `\|`, ""), "|"); n != 6 {
+			t.Errorf("expected 6 cell separators, got %d in %q", n, row)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// loadExamples reads the usage examples of a component from
+// <examplesDir>/<name>/*.yml (and *.yaml), sorted by file name. Each file
+// becomes an example titled after it. A file that is not valid YAML is an
+// error, so broken examples never get published.
+func loadExamples(examplesDir, name string) ([]ExampleData, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(examplesDir, name, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("error finding examples: %w", err)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	var examples []ExampleData
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading example %s: %w", path, err)
+		}
+		if data, err = decodeSource(path, data); err != nil {
+			return nil, err
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing example %s: %w", path, err)
+		}
+		examples = append(examples, ExampleData{
+			Title: exampleTitle(filepath.Base(path)),
+			Code:  strings.TrimRight(string(data), "\n"),
+		})
+	}
+	return examples, nil
+}
+
+// exampleTitle turns an example file name into a title: "with-cache.yml"
+// becomes "With cache".
+func exampleTitle(file string) string {
+	title := strings.TrimSuffix(file, filepath.Ext(file))
+	title = strings.NewReplacer("-", " ", "_", " ").Replace(title)
+	if title == "" {
+		return file
+	}
+	return strings.ToUpper(title[:1]) + title[1:]
+}
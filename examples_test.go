@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadExamples(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "build"), 0755)
+	os.WriteFile(filepath.Join(dir, "build", "with-cache.yml"), []byte("include:\n  - component: x\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "build", "basic_usage.yaml"), []byte("include: x\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "build", "notes.txt"), []byte("ignored"), 0644)
+
+	examples, err := loadExamples(dir, "build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(examples) != 2 {
+		t.Fatalf("expected 2 examples, got %+v", examples)
+	}
+	if examples[0].Title != "Basic usage" || examples[0].Code != "include: x" {
+		t.Errorf("unexpected first example: %+v", examples[0])
+	}
+	if examples[1].Title != "With cache" || examples[1].Code != "include:\n  - component: x" {
+		t.Errorf("unexpected second example: %+v", examples[1])
+	}
+
+	if examples, err := loadExamples(dir, "missing"); err != nil || examples != nil {
+		t.Errorf("expected no examples for a missing directory, got %v, %v", examples, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "build", "broken.yml"), []byte("include: [\n"), 0644)
+	if _, err := loadExamples(dir, "build"); err == nil || !strings.Contains(err.Error(), "broken.yml") {
+		t.Errorf("expected an error naming the invalid example, got %v", err)
+	}
+}
+
+func TestParseTemplate_Examples(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.MkdirAll(filepath.Join(dir, "examples", "build"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "build.md"), []byte("---\nexamples:\n  - title: Inline\n    code: a\n---\nBuilds.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "examples", "build", "from-file.yml"), []byte("b: 1\n"), 0644)
+	path := filepath.Join(dir, "build.yml")
+	os.WriteFile(path, []byte("spec:\n  inputs: {}\n"), 0644)
+
+	component, err := parseTemplate(path, ParseOptions{DocsDir: filepath.Join(dir, "docs"), ExamplesDir: filepath.Join(dir, "examples")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(component.Examples) != 2 || component.Examples[0].Title != "Inline" || component.Examples[1].Title != "From file" {
+		t.Errorf("expected front-matter examples followed by example files, got %+v", component.Examples)
+	}
+}
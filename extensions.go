@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// extensionPrefix marks vendor extension keys on inputs (e.g. `x-owner: platform`).
+const extensionPrefix = "x-"
+
+// ExtensionDecoder converts the raw YAML value of an extension key into the
+// value exposed to templates. Returning an error fails parsing of the template.
+type ExtensionDecoder func(value interface{}) (interface{}, error)
+
+// inputExtensions holds the decoders registered with RegisterInputExtension,
+// keyed by extension name (without the "x-" prefix).
+var inputExtensions = map[string]ExtensionDecoder{}
+
+// RegisterInputExtension registers a decoder for the `x-<name>` key of
+// spec:inputs entries. Decoded values are available to templates as
+// `.Extensions.<name>` (or `index .Extensions "<name>"` for names containing
+// dashes). Extension keys without a registered decoder are exposed as-is.
+// It is meant to be called from init functions and is not safe for concurrent use.
+func RegisterInputExtension(name string, decode ExtensionDecoder) {
+	name = strings.TrimPrefix(name, extensionPrefix)
+	if name == "" || decode == nil {
+		panic("RegisterInputExtension: empty name or nil decoder")
+	}
+	if _, exists := inputExtensions[name]; exists {
+		panic(fmt.Sprintf("RegisterInputExtension: x-%s registered twice", name))
+	}
+	inputExtensions[name] = decode
+}
+
+// decodeExtensions extracts the `x-` keys of a raw input definition.
+func decodeExtensions(raw map[string]interface{}) (map[string]interface{}, error) {
+	var keys []string
+	for key := range raw {
+		if strings.HasPrefix(key, extensionPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+
+	extensions := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, extensionPrefix)
+		value := raw[key]
+		if decode, ok := inputExtensions[name]; ok {
+			decoded, err := decode(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			value = decoded
+		}
+		extensions[name] = value
+	}
+	return extensions, nil
+}
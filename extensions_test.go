@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate_Extensions(t *testing.T) {
+	RegisterInputExtension("x-test-owner", func(value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("expected a string")
+		}
+		return strings.ToUpper(s), nil
+	})
+	defer delete(inputExtensions, "test-owner")
+
+	dir := t.TempDir()
+	yamlContent := `spec:
+  inputs:
+    app_name:
+      description: "Application name"
+      x-test-owner: platform
+      x-docs-url: https://example.com/app_name
+    stage:
+      default: build
+`
+	path := filepath.Join(dir, "deploy.yml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	component, err := parseTemplate(path, ParseOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ext := component.Inputs[0].Extensions
+	if ext["test-owner"] != "PLATFORM" {
+		t.Errorf("expected registered decoder to be applied, got %v", ext["test-owner"])
+	}
+	if ext["docs-url"] != "https://example.com/app_name" {
+		t.Errorf("expected unregistered extension to be kept as-is, got %v", ext["docs-url"])
+	}
+	if component.Inputs[1].Extensions != nil {
+		t.Errorf("expected no extensions for 'stage', got %v", component.Inputs[1].Extensions)
+	}
+
+	// Decoder errors fail parsing
+	os.WriteFile(path, []byte("spec:\n  inputs:\n    app_name:\n      x-test-owner: [not, a, string]\n"), 0644)
+	if _, err := parseTemplate(path, ParseOptions{}); err == nil || !strings.Contains(err.Error(), "x-test-owner") {
+		t.Errorf("expected error mentioning x-test-owner, got %v", err)
+	}
+}
+
+func TestRegisterInputExtension_Duplicate(t *testing.T) {
+	decode := func(value interface{}) (interface{}, error) { return value, nil }
+	RegisterInputExtension("test-dup", decode)
+	defer delete(inputExtensions, "test-dup")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	RegisterInputExtension("x-test-dup", decode)
+}
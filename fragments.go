@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// fragmentTimeout bounds each fragment upload.
+const fragmentTimeout = 30 * time.Second
+
+// FragmentsConfig configures the docs portal `publish --pages-fragment`
+// uploads each component's docs to, as an HTML fragment. Environment
+// variables in the URL and the header are expanded, so a token can stay in a
+// masked CI/CD variable.
+type FragmentsConfig struct {
+	URL    string `yaml:"url"`    // each component is PUT to <url>/<name>.html
+	Header string `yaml:"header"` // "Name: value" sent with every upload, e.g. an Authorization header
+}
+
+// fragment is a component's docs rendered as HTML.
+type fragment struct {
+	Component string
+	HTML      []byte
+}
+
+// fragmentURL returns where the fragment of a component is uploaded; nested
+// component names get nested paths.
+func fragmentURL(base, name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.Join(parts, "/") + ".html"
+}
+
+// splitHeader splits a "Name: value" header setting.
+func splitHeader(header string) (name, value string, ok bool) {
+	name, value, ok = strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	return name, strings.TrimSpace(value), ok && name != "" && !strings.ContainsAny(name, " \t")
+}
+
+// useMarkdown sets overrides to render with the Markdown template, whatever
+// the configured format: the default one (and its partials) unless a
+// template is given.
+func useMarkdown(config ProjectConfig, overrides *Settings) {
+	overrides.Format = "markdown"
+	if config.Format != "" && config.Format != "markdown" && overrides.Template == "" {
+		overrides.Template = defaultTemplatePaths["markdown"]
+	}
+	if config.Format != "" && config.Format != "markdown" && overrides.PartialsDir == "" {
+		overrides.PartialsDir = defaultPartialsDirs["markdown"]
+	}
+}
+
+// renderFragments renders one HTML fragment per component: its section of
+// the README, rendered with the Markdown template, then as HTML. The header
+// and footer files belong to the README, and are left out.
+func renderFragments(settings Settings, data TemplateData) ([]fragment, error) {
+	settings.HeaderFile, settings.FooterFile = "", ""
+	var fragments []fragment
+	for _, c := range data.Components {
+		single := data
+		single.Components = []ComponentData{c}
+		doc, err := renderDocument(settings, single)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering the fragment of %s: %w", c.Name, err)
+		}
+		fragments = append(fragments, fragment{Component: c.Name, HTML: []byte(markdownHTML(string(bytes.TrimLeft(doc, "\n"))))})
+	}
+	return fragments, nil
+}
+
+// putFragments uploads the fragments to the portal, one PUT request each,
+// and stops at the first failure.
+func putFragments(config FragmentsConfig, fragments []fragment) error {
+	base := os.ExpandEnv(config.URL)
+	var headerName, headerValue string
+	if config.Header != "" {
+		headerName, headerValue, _ = splitHeader(os.ExpandEnv(config.Header))
+	}
+	client := &http.Client{Timeout: fragmentTimeout}
+	for _, f := range fragments {
+		req, err := http.NewRequest(http.MethodPut, fragmentURL(base, f.Component), bytes.NewReader(f.HTML))
+		if err != nil {
+			return fmt.Errorf("error publishing the fragment of %s: %w", f.Component, err)
+		}
+		req.Header.Set("Content-Type", "text/html; charset=utf-8")
+		if headerName != "" {
+			req.Header.Set(headerName, headerValue)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error publishing the fragment of %s: %w", f.Component, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("error publishing the fragment of %s: %s", f.Component, resp.Status)
+		}
+	}
+	return nil
+}
+
+// publishFragments implements `publish --pages-fragment`: it renders each
+// component's docs as an HTML fragment and uploads it to the configured
+// portal. Nothing is written to disk. Errors carry their exit code.
+func publishFragments(config ProjectConfig, overrides Settings, projectPath, version, remote string, jobs int, log *logger) error {
+	// Fragments are rendered from Markdown, whatever the README's format
+	useMarkdown(config, &overrides)
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if settings.Fragments.URL == "" {
+		return withExitCode(exitConfig, errors.New("publish --pages-fragment: set fragments.url (or --fragments-url) to the portal's endpoint"))
+	}
+
+	data, err := collectTemplateData(settings, config.Mirrors, projectPath, version, remote, jobs, log)
+	if err != nil {
+		return err
+	}
+	fragments, err := renderFragments(settings, data)
+	if err != nil {
+		return withExitCode(exitTemplate, err)
+	}
+	if err := putFragments(settings.Fragments, fragments); err != nil {
+		return withExitCode(exitFailure, err)
+	}
+	log.Infof("Published %d HTML fragment(s) to %s", len(fragments), settings.Fragments.URL)
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFragmentURL(t *testing.T) {
+	tests := []struct {
+		base, name, want string
+	}{
+		{"https://portal.example.com/api/docs", "build", "https://portal.example.com/api/docs/build.html"},
+		{"https://portal.example.com/api/docs/", "aws/deploy", "https://portal.example.com/api/docs/aws/deploy.html"},
+		{"https://portal.example.com", "a b", "https://portal.example.com/a%20b.html"},
+	}
+	for _, tt := range tests {
+		if got := fragmentURL(tt.base, tt.name); got != tt.want {
+			t.Errorf("fragmentURL(%q, %q) = %q, want %q", tt.base, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitHeader(t *testing.T) {
+	name, value, ok := splitHeader("Authorization: Bearer abc:def")
+	if !ok || name != "Authorization" || value != "Bearer abc:def" {
+		t.Errorf("unexpected split: %q %q %v", name, value, ok)
+	}
+	for _, header := range []string{"Bearer abc", ": abc", "X Token: abc"} {
+		if _, _, ok := splitHeader(header); ok {
+			t.Errorf("expected %q to be invalid", header)
+		}
+	}
+}
+
+func TestRunPublish_PagesFragment(t *testing.T) {
+	var mu sync.Mutex
+	uploads := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploads[r.URL.Path] = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("PORTAL_TOKEN", "secret")
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "header.md"), []byte("# Project intro\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".gitlab-component-docs-gen.yml"), []byte("format: asciidoc\nheader_file: header.md\nfragments:\n  url: "+server.URL+"/docs\n  header: \"Authorization: Bearer ${PORTAL_TOKEN}\"\n"), 0644)
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"publish", "--pages-fragment", "--project-path", "g/p", "--version", "1.0.0"}
+	var out strings.Builder
+	if err := run(args, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(uploads) != 2 || uploads["/docs/build.html"] == "" || uploads["/docs/deploy.html"] == "" {
+		t.Fatalf("expected the fragments of build and deploy, got %v", uploads)
+	}
+	build := uploads["/docs/build.html"]
+	if !strings.Contains(build, "<table>") || !strings.Contains(build, "<td>stage</td>") || strings.Contains(build, "deploy") {
+		t.Errorf("expected the HTML of the build component alone, got:\n%s", build)
+	}
+	if strings.Contains(build, "Project intro") {
+		t.Errorf("expected no header file in fragments, got:\n%s", build)
+	}
+	if !strings.Contains(out.String(), "Published 2 HTML fragment(s) to "+server.URL+"/docs") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+	for _, path := range []string{"README.adoc", "README.adoc.tmpl", "README.md.tmpl"} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("expected nothing written to disk, found %s", path)
+		}
+	}
+
+	// A rejected upload fails the run
+	t.Setenv("PORTAL_TOKEN", "wrong")
+	if got := exitCode(run(append(args, "--quiet"), io.Discard)); got != exitFailure {
+		t.Errorf("expected exit code %d for a rejected upload, got %d", exitFailure, got)
+	}
+}
+
+func TestRunPublish_PagesFragmentErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if got := exitCode(run([]string{"publish", "--pages-fragment", "--merge-request"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for two destinations, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"publish", "--pages-fragment"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without fragments.url, got %d", exitConfig, got)
+	}
+	os.WriteFile(".gitlab-component-docs-gen.yml", []byte("fragments:\n  url: https://portal.example.com\n  header: Bearer abc\n"), 0644)
+	if got := exitCode(run([]string{"publish", "--pages-fragment"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an invalid header, got %d", exitConfig, got)
+	}
+
+	// A header held whole by a variable is checked once expanded
+	t.Setenv("PORTAL_HEADER", "Authorization: Bearer abc")
+	if _, err := resolveSettings(ProjectConfig{Fragments: FragmentsConfig{Header: "${PORTAL_HEADER}"}}, Settings{}); err != nil {
+		t.Errorf("expected the expanded header to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// generateOptions holds the flags of `generate` and its specialized forms
+// that select what the run does rather than a setting. Flags specific to
+// another form keep their zero value.
+type generateOptions struct {
+	projectPath, version, remote, source string
+
+	validate, check, hook, dryRun, showDiff, noPrompt bool
+	watch, normalize, commit, dataDump                bool
+	diagnostics, emitSchema, emitDescriptor           string
+	failOnDeprecated                                  bool
+	fromStdin                                         bool
+	stdinName                                         string
+	unitNames                                         []string
+
+	quiet, verbose, debug, noColor bool
+	logFormat                      string
+	changedOnly                    bool
+	base                           string
+	porcelain, keepGoing, noCache  bool
+	jobs                           int
+}
+
+// newGenerateFlags defines the flags of command, one of generate, check and
+// validate, storing them in opts and overrides.
+func newGenerateFlags(command string, opts *generateOptions, overrides *Settings) *flag.FlagSet {
+	flags := newFlagSet(command)
+	generate := command == "generate"
+
+	if command != "validate" {
+		flags.StringVar(&opts.projectPath, "project-path", "", "GitLab project path (e.g. group/project)")
+		flags.StringVar(&opts.version, "version", "", "Component version (e.g. 1.0.0)")
+		flags.StringVar(&opts.remote, "remote", "", "Git remote used to detect the project path (default \"origin\")")
+	}
+	flags.StringVar(&opts.source, "source", "", "Fetch the component files from a remote project: "+sourceScheme+"group/project[@ref]")
+	flags.BoolVar(&opts.quiet, "quiet", false, "Suppress informational output (errors are still reported)")
+	flags.BoolVar(&opts.verbose, "verbose", false, "Also log which files are read or skipped, and why")
+	flags.BoolVar(&opts.debug, "debug", false, "Log every step of the run (implies --verbose)")
+	flags.StringVar(&opts.logFormat, "log-format", "text", "Log format: "+strings.Join(logFormats, ", "))
+	flags.BoolVar(&opts.noColor, "no-color", false, "Print status lines and diffs without ANSI colors (also with NO_COLOR set)")
+	if generate {
+		flags.BoolVar(&opts.validate, "validate", false, "Validate component templates and report issues without writing any file (same as the validate command)")
+		flags.BoolVar(&opts.check, "check", false, "Fail if the output file is not up to date, without writing it (same as the check command)")
+	}
+	flags.BoolVar(&opts.changedOnly, "changed-only", false, "Only process components changed since --base (for merge request pipelines)")
+	flags.StringVar(&opts.base, "base", "", "Git ref to compare against with --changed-only (default: merge request base, then origin/main)")
+	if generate {
+		flags.BoolVar(&opts.hook, "hook", false, "Pre-commit mode: regenerate the output when staged files affect a component, stage it and fail if it changed")
+		flags.BoolVar(&opts.dryRun, "dry-run", false, "Print the rendered documentation to stdout without writing any file")
+		flags.BoolVar(&opts.showDiff, "diff", false, "With --dry-run, print a diff against the current output instead of the whole document")
+		flags.BoolVar(&opts.dataDump, "template-data-dump", false, "Print the data README templates are executed with as YAML, without writing any file")
+		flags.BoolVar(&opts.fromStdin, "stdin", false, "Read a single component template from stdin and print its documentation to stdout (needs --name)")
+		flags.StringVar(&opts.stdinName, "name", "", "With --stdin, the component's name (e.g. deploy or aws/deploy)")
+		flags.StringVar(&opts.emitSchema, "emit-schema", "", "Also write a JSON Schema of each component's inputs to this directory (<name>.schema.json)")
+		flags.StringVar(&opts.emitDescriptor, "emit", "", "Also write the components' interface descriptor to this file (YAML, or JSON for a .json file)")
+		flags.StringVar(&overrides.Webhook.URL, "webhook", "", "After writing the output, post the components' contract changes to this URL (Slack-compatible)")
+		flags.StringVar(&overrides.Webhook.Base, "webhook-base", "", "Git ref holding the previous contract for --webhook (default: CI_COMMIT_BEFORE_SHA, then HEAD)")
+		flags.BoolVar(&opts.normalize, "normalize", false, "Rewrite the output file (or the files given as arguments) in canonical form, without regenerating it")
+		flags.BoolVar(&opts.commit, "commit", false, "After writing the output, commit it and push it (with DOCS_PUSH_TOKEN or CI_JOB_TOKEN in CI), unless the last commit is the bot's")
+		flags.StringVar(&overrides.Commit.Message, "commit-message", "", "Message of the --commit commit (default \""+defaultCommitMessage+"\")")
+	}
+	if command != "check" {
+		flags.BoolVar(&opts.watch, "watch", false, "Keep running and report diagnostics whenever a template changes (implies --validate)")
+		flags.StringVar(&opts.diagnostics, "diagnostics", "", "Report validation diagnostics per file: "+strings.Join(diagnosticFormats, ", "))
+		flags.BoolVar(&overrides.DescriptionLint.Enabled, "lint-descriptions", false, "With validation, also check input descriptions (missing, short, unpunctuated or duplicated)")
+		flags.BoolVar(&opts.failOnDeprecated, "fail-on-deprecated-usage", false, "With validation, report examples using deprecated inputs or components as errors instead of warnings")
+	}
+	flags.BoolVar(&opts.porcelain, "porcelain", false, "Print a single JSON object describing the result instead of human-readable messages")
+	flags.IntVar(&opts.jobs, "jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	flags.BoolVar(&opts.keepGoing, "keep-going", false, "Document the components that parse when others don't, then exit with the parse errors")
+	if generate {
+		flags.BoolVar(&opts.noPrompt, "no-prompt", false, "Never ask for missing project path/version interactively")
+	}
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Parse every template, ignoring and not updating "+cacheFile)
+	if command != "validate" {
+		flags.StringVar(&overrides.Template, "template", "", "README template path (default depends on --format)")
+		flags.StringVar(&overrides.PartialsDir, "partials-dir", "", "Directory of partials replacing sections of the templates (default depends on --format)")
+	}
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory containing component descriptions (default \"docs\")")
+	flags.StringVar(&overrides.ExamplesDir, "examples-dir", "", "Directory containing usage examples, one subdirectory per component (default \"examples\")")
+	if command != "validate" {
+		flags.StringVar(&overrides.Output, "output", "", "Output file (default depends on --format)")
+		flags.StringVar(&overrides.HeaderFile, "header-file", "", "File whose content is prepended to the generated document")
+		flags.StringVar(&overrides.FooterFile, "footer-file", "", "File whose content is appended to the generated document")
+		flags.BoolVar(&overrides.SourceLinks, "source-links", false, "Link input names to the line declaring them in the template")
+		flags.BoolVar(&overrides.PipelineDiagrams, "pipeline-diagrams", false, "Embed a Mermaid diagram of each component's jobs, stages and needs")
+		flags.BoolVar(&overrides.Backup, "backup", false, "Keep the previous output, when it changes, as <output>.bak")
+		flags.BoolVar(&overrides.Footer, "footer", false, "Append a \"generated by gitlab-component-docs-gen\" line with the tool version (ignored by --check)")
+	}
+	flags.StringVar(&overrides.Format, "format", "", "Output format: "+strings.Join(outputFormats, ", ")+" (default \"markdown\")")
+	if command != "validate" {
+		flags.StringVar(&overrides.Locale, "locale", "", "Language of the default templates' headings and labels: "+strings.Join(localeNames(), ", ")+" (default \"en\")")
+		flags.StringVar(&overrides.Dialect, "markdown-dialect", "", "Markdown dialect of the default template: "+strings.Join(markdownDialects, ", ")+" (default \"gitlab\")")
+	}
+	flags.Var((*stringList)(&opts.unitNames), "unit", "Only process this documentation unit of the config's units (repeatable)")
+	flags.Var((*stringList)(&overrides.Include), "include", "Only document components matching this glob (name or template path; repeatable)")
+	flags.Var((*stringList)(&overrides.Exclude), "exclude", "Skip components matching this glob (name or template path; repeatable)")
+	flags.BoolVar(&overrides.NoIgnore, "no-ignore", false, "Also scan template files ignored by .gitignore or the config's ignore list")
+	flags.BoolVar(&overrides.NoIncludes, "no-resolve-includes", false, "Document templates without merging in the local files they include")
+	flags.StringVar(&overrides.SortOrder, "sort", "", "Input sort order: "+strings.Join(sortOrders, ", ")+" (default \"required\")")
+	flags.StringVar(&overrides.Pages.FrontMatter, "front-matter", "", "Also write one page per component to pages.output_dir (default \"pages\"), with front-matter for this site generator: "+strings.Join(frontMatterFormats, ", "))
+	return flags
+}
+
+// complete applies what command and the flags imply to opts and rejects the
+// flags that cannot be combined. args are the command line's arguments.
+func (opts *generateOptions) complete(command string, args []string) error {
+	if len(args) > 0 && !opts.normalize {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", args[0]))
+	}
+	switch command {
+	case "check":
+		opts.check, opts.noPrompt = true, true
+	case "validate":
+		opts.validate = true
+	}
+
+	if !contains(logFormats, opts.logFormat) {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported log format %q (expected one of: %s)", opts.logFormat, strings.Join(logFormats, ", ")))
+	}
+	if opts.jobs < 1 {
+		return withExitCode(exitConfig, fmt.Errorf("invalid --jobs %d: must be at least 1", opts.jobs))
+	}
+	if opts.showDiff {
+		opts.dryRun = true
+	}
+	if opts.emitSchema != "" && (opts.validate || opts.check || opts.hook || opts.dryRun || opts.watch) {
+		return withExitCode(exitConfig, errors.New("--emit-schema cannot be combined with --validate, --check, --hook, --dry-run or --watch"))
+	}
+	if opts.emitDescriptor != "" && (opts.validate || opts.check || opts.hook || opts.dryRun || opts.watch) {
+		return withExitCode(exitConfig, errors.New("--emit cannot be combined with --validate, --check, --hook, --dry-run or --watch"))
+	}
+	if opts.commit && (opts.validate || opts.check || opts.hook || opts.dryRun || opts.watch || opts.normalize || opts.fromStdin || opts.dataDump) {
+		return withExitCode(exitConfig, errors.New("--commit cannot be combined with --validate, --check, --hook, --dry-run, --watch, --normalize, --stdin or --template-data-dump"))
+	}
+	if opts.fromStdin {
+		if opts.stdinName == "" {
+			return withExitCode(exitConfig, errors.New("--stdin needs --name, the component's name"))
+		}
+		if opts.source != "" || opts.validate || opts.check || opts.hook || opts.showDiff || opts.watch || opts.normalize || opts.changedOnly || opts.emitSchema != "" || opts.emitDescriptor != "" || len(opts.unitNames) > 0 {
+			return withExitCode(exitConfig, errors.New("--stdin cannot be combined with --source, --validate, --check, --hook, --diff, --watch, --normalize, --changed-only, --emit-schema, --emit or --unit"))
+		}
+		// A one-off rendering: printed, never written
+		opts.dryRun = true
+	} else if opts.stdinName != "" {
+		return withExitCode(exitConfig, errors.New("--name only applies to --stdin"))
+	}
+	return nil
+}
+
+// runNormalize canonicalizes existing documents, e.g. ones written by older
+// releases: the given paths, or the output file.
+func runNormalize(settings Settings, opts generateOptions, paths []string, log *logger) error {
+	if opts.validate || opts.check || opts.hook || opts.dryRun || opts.watch || opts.source != "" {
+		return withExitCode(exitConfig, errors.New("--normalize cannot be combined with --validate, --check, --hook, --dry-run, --watch or --source"))
+	}
+	if len(paths) == 0 {
+		paths = []string{settings.Output}
+	}
+	changed, err := normalizeFiles(paths)
+	for _, path := range changed {
+		log.Infof("Normalized %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		log.Infof("Already normalized: %s", strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// prepareSource fetches the components of the --source project into a
+// temporary directory, which the caller removes, and returns the settings
+// pointing at it. The project path and version default to the source's.
+func prepareSource(settings Settings, opts *generateOptions, log *logger) (Settings, string, error) {
+	src, err := parseSource(opts.source)
+	if err != nil {
+		return settings, "", withExitCode(exitConfig, err)
+	}
+	if opts.watch || opts.hook || opts.changedOnly {
+		return settings, "", withExitCode(exitConfig, errors.New("--source cannot be combined with --watch, --hook or --changed-only"))
+	}
+	dir, err := os.MkdirTemp("", "gitlab-component-docs-gen-")
+	if err != nil {
+		return settings, "", withExitCode(exitFailure, err)
+	}
+	settings, project, err := fetchSource(src, settings, resolveRemote(opts.remote), dir, log)
+	if err != nil {
+		return settings, dir, withExitCode(exitParse, err)
+	}
+	// The local repository's path and tags say nothing about the source
+	if opts.projectPath == "" {
+		opts.projectPath = src.Project
+		if project.Path != "" {
+			opts.projectPath = project.Path
+		}
+	}
+	if opts.version == "" {
+		opts.version = src.Ref
+	}
+	if opts.version == "" {
+		opts.version = project.LatestRelease
+	}
+	if opts.version == "" {
+		opts.version = project.DefaultBranch
+	}
+	opts.noPrompt, opts.noCache = true, true
+	return settings, dir, nil
+}
+
+// prepareStdin writes the --stdin template into a temporary directory, which
+// the caller removes, and returns the settings documenting only it.
+func prepareStdin(settings Settings, opts *generateOptions) (Settings, string, error) {
+	dir, err := os.MkdirTemp("", "gitlab-component-docs-gen-")
+	if err != nil {
+		return settings, "", withExitCode(exitFailure, err)
+	}
+	if err := stdinTemplate(stdinInput, opts.stdinName, dir); err != nil {
+		return settings, dir, withExitCode(exitConfig, err)
+	}
+	settings.TemplatesDir = dir
+	settings.Include, settings.Exclude, settings.NoIgnore = nil, nil, true
+	opts.noPrompt, opts.noCache = true, true
+	return settings, dir, nil
+}
+
+// runDiagnostics reports per-file diagnostics for editor integrations, once
+// or, with --watch, whenever a template changes.
+func runDiagnostics(settings Settings, opts generateOptions, stdout io.Writer) error {
+	format := opts.diagnostics
+	if format == "" {
+		format = "text"
+	}
+	if !contains(diagnosticFormats, format) {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported diagnostics format %q (expected one of: %s)", format, strings.Join(diagnosticFormats, ", ")))
+	}
+	if opts.watch {
+		return watchDiagnostics(settings, format, stdout, nil)
+	}
+	results, err := diagnoseAll(settings)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if err := writeDiagnostics(stdout, format, results); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing diagnostics: %w", err))
+	}
+	var issues []Issue
+	for _, r := range results {
+		issues = append(issues, r.Diagnostics...)
+	}
+	if n := countErrors(issues); n > 0 {
+		return withExitCode(exitInvalid, fmt.Errorf("validation failed: %d error(s)", n))
+	}
+	return nil
+}
+
+// filterChanged skips work in merge request pipelines when no component
+// changed since --base: done reports there is nothing left to do. The output
+// covers every component, so it is regenerated in full otherwise; only
+// validation is restricted to the affected templates, which it returns.
+func filterChanged(settings Settings, opts generateOptions, templates []string, log *logger) (selected []string, done bool, err error) {
+	diffBase := resolveDiffBase(opts.base)
+	changed, err := changedFiles(diffBase)
+	if err != nil {
+		return nil, false, withExitCode(exitConfig, err)
+	}
+	log.Debugf("Changed files since %s: %s", diffBase, strings.Join(changed, ", "))
+	affected, all := affectedComponents(settings, templates, changed)
+	if all {
+		log.Verbosef("A shared file changed since %s: all components are affected", diffBase)
+		return templates, false, nil
+	}
+	if len(affected) == 0 {
+		log.Infof("No component changes since %s, nothing to do", diffBase)
+		return nil, true, nil
+	}
+	log.Verbosef("%d component(s) changed since %s", len(affected), diffBase)
+	if !opts.validate {
+		return templates, false, nil
+	}
+	for _, t := range templates {
+		if affected[t] {
+			selected = append(selected, t)
+		}
+	}
+	return selected, false, nil
+}
+
+// hookAffected reports whether the staged changes affect a component, for
+// the pre-commit hook. The output covers every component, so it is
+// regenerated in full when any of them is affected.
+func hookAffected(settings Settings, templates []string, log *logger) (bool, error) {
+	staged, err := stagedFiles()
+	if err != nil {
+		return false, withExitCode(exitConfig, err)
+	}
+	affected, all := affectedComponents(settings, templates, staged)
+	if !all && len(affected) == 0 {
+		log.Infof("No staged component changes, nothing to do")
+		return false, nil
+	}
+	return true, nil
+}
+
+// runValidation reports the components' issues, adding them to report, and
+// fails when one of them is an error.
+func runValidation(settings Settings, opts generateOptions, components []ComponentData, report *RunReport, stdout io.Writer, log *logger) error {
+	for _, c := range components {
+		report.Issues = append(report.Issues, validateComponent(c)...)
+		report.Issues = append(report.Issues, lintDescriptions(c, settings.DescriptionLint)...)
+		report.Issues = append(report.Issues, c.secrets...)
+	}
+	report.Issues = append(report.Issues, deprecatedUsage(components, opts.failOnDeprecated)...)
+	for _, issue := range report.Issues {
+		if !opts.porcelain {
+			fmt.Fprintln(stdout, issue)
+		}
+	}
+	if n := countErrors(report.Issues); n > 0 {
+		return withExitCode(exitInvalid, fmt.Errorf("validation failed: %d error(s)", n))
+	}
+	log.Infof("Validation passed (%d components)", len(components))
+	return nil
+}
+
+// resolveTemplateData resolves the project path, version and mirrors of a
+// regular run, asking for the missing ones on a first run in a terminal.
+func resolveTemplateData(settings Settings, config ProjectConfig, opts *generateOptions, components []ComponentData, stdout io.Writer, log *logger) (TemplateData, error) {
+	mirrors, err := resolveMirrors(config.Mirrors)
+	if err != nil {
+		return TemplateData{}, withExitCode(exitConfig, err)
+	}
+
+	// First run in a terminal without any configuration: ask instead of guessing
+	gitRemote := resolveRemote(opts.remote)
+	if !opts.quiet && !opts.noPrompt && !opts.dryRun && !opts.hook && shouldPrompt(opts.projectPath, opts.version) {
+		answers, err := promptConfig(os.Stdin, stdout, detectGitProjectPath(gitRemote), detectGitVersion())
+		if err != nil {
+			return TemplateData{}, withExitCode(exitConfig, fmt.Errorf("error reading answers: %w", err))
+		}
+		if opts.projectPath == "" {
+			opts.projectPath = answers.ProjectPath
+		}
+		if opts.version == "" {
+			opts.version = answers.Version
+		}
+		if answers.Save {
+			if err := writePromptConfig(configFile, answers); err != nil {
+				return TemplateData{}, withExitCode(exitWrite, err)
+			}
+			log.Infof("Saved answers to %s", configFile)
+		}
+	}
+
+	if _, err := lookupGitLabProject(gitRemote); err != nil {
+		log.Warnf("%v", err)
+	}
+
+	resolvedPath := resolveProjectPath(opts.projectPath, gitRemote)
+	if resolvedPath != projectPathPlaceholder {
+		if resolvedPath, err = normalizeProjectPath(resolvedPath); err != nil {
+			return TemplateData{}, withExitCode(exitConfig, err)
+		}
+		if warning := projectPathMismatch(resolvedPath, detectGitProjectPath(gitRemote), gitRemote); warning != "" {
+			log.Warnf("%s", warning)
+		}
+	}
+
+	data := TemplateData{
+		ProjectPath:   resolvedPath,
+		Version:       resolveVersion(opts.version, gitRemote),
+		DefaultBranch: resolveDefaultBranch(gitRemote),
+		Mirrors:       mirrors,
+		Components:    components,
+	}
+	if settings.SourceLinks {
+		linkSources(data.Components, sourceBaseURL(gitRemote, data.ProjectPath, data.DefaultBranch), settings.Output)
+	}
+	return data, nil
+}
+
+// runPreview prints the rendered document, or with --diff its difference
+// from the current output, instead of writing it. doc is the rendered
+// document when --diff needs it.
+func runPreview(settings Settings, opts generateOptions, data TemplateData, doc []byte, stdout io.Writer) error {
+	if opts.porcelain {
+		return streamDocument(io.Discard, settings, data)
+	}
+	if !opts.showDiff {
+		return streamDocument(stdout, settings, data)
+	}
+	current, err := os.ReadFile(settings.Output)
+	if err != nil && !os.IsNotExist(err) {
+		return withExitCode(exitFailure, fmt.Errorf("error reading %s: %w", settings.Output, err))
+	}
+	diff := unifiedDiff(string(current), string(doc), settings.Output, settings.Output+" (rendered)", colorEnabled(stdout, opts.noColor))
+	if diff == "" {
+		diff = fmt.Sprintf("%s is up to date\n", settings.Output)
+	}
+	if _, err := io.WriteString(stdout, diff); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing diff: %w", err))
+	}
+	return nil
+}
+
+// runCheck fails when the output file, or one of the pages and localized
+// variants written next to it, differs from the rendered document doc.
+func runCheck(settings Settings, data TemplateData, doc []byte, report *RunReport, log *logger) error {
+	current, err := os.ReadFile(settings.Output)
+	if err != nil && !os.IsNotExist(err) {
+		return withExitCode(exitFailure, fmt.Errorf("error reading %s: %w", settings.Output, err))
+	}
+	if !sameDocument(current, doc) {
+		return withExitCode(exitOutdated, fmt.Errorf("%s is out of date; run gitlab-component-docs-gen to regenerate it", settings.Output))
+	}
+	if settings.Pages.FrontMatter != "" {
+		pages, _, err := renderPages(settings, data)
+		if err != nil {
+			return withExitCode(exitTemplate, err)
+		}
+		if outdated := outdatedPages(pages); len(outdated) > 0 {
+			return withExitCode(exitOutdated, fmt.Errorf("%s out of date; run gitlab-component-docs-gen to regenerate them", strings.Join(outdated, ", ")))
+		}
+	}
+	if len(settings.Locales) > 0 {
+		variants, err := renderLocalized(settings, data)
+		if err != nil {
+			return withExitCode(exitTemplate, err)
+		}
+		if outdated := outdatedPages(variants); len(outdated) > 0 {
+			return withExitCode(exitOutdated, fmt.Errorf("%s out of date; run gitlab-component-docs-gen to regenerate them", strings.Join(outdated, ", ")))
+		}
+	}
+	report.Output = settings.Output
+	log.Infof("%s is up to date", settings.Output)
+	return nil
+}
+
+// writeExtraOutputs writes the files generated next to the output: the
+// localized variants, input schemas, descriptor and pages. It returns the
+// variants' paths.
+func writeExtraOutputs(settings Settings, opts generateOptions, data TemplateData, statuses *runStatuses, log *logger) ([]string, error) {
+	var variantPaths []string
+	if len(settings.Locales) > 0 {
+		variants, err := renderLocalized(settings, data)
+		if err != nil {
+			return nil, withExitCode(exitTemplate, err)
+		}
+		written, err := writePages(variants)
+		statuses.addFiles(written)
+		if err != nil {
+			return nil, withExitCode(exitWrite, err)
+		}
+		for _, v := range variants {
+			variantPaths = append(variantPaths, v.Path)
+		}
+	}
+
+	if opts.emitSchema != "" {
+		written, err := writeInputSchemas(opts.emitSchema, data.Components)
+		statuses.addFiles(written)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.emitDescriptor != "" {
+		status, err := writeDescriptor(opts.emitDescriptor, data)
+		if err != nil {
+			return nil, err
+		}
+		statuses.add(status, opts.emitDescriptor)
+	}
+
+	if settings.Pages.FrontMatter != "" {
+		pages, warnings, err := renderPages(settings, data)
+		if err != nil {
+			return nil, withExitCode(exitTemplate, err)
+		}
+		for _, warning := range warnings {
+			log.Warnf("%s", warning)
+		}
+		written, err := writePages(pages)
+		statuses.addFiles(written)
+		if err != nil {
+			return nil, withExitCode(exitWrite, err)
+		}
+	}
+	return variantPaths, nil
+}
+
+// runCommit commits and pushes the files the run wrote: the output, its
+// localized variants and whatever --emit-schema, --emit and pages added.
+func runCommit(settings Settings, opts generateOptions, variantPaths []string, log *logger) error {
+	paths := append([]string{settings.Output}, variantPaths...)
+	if opts.emitSchema != "" {
+		paths = append(paths, opts.emitSchema)
+	}
+	if opts.emitDescriptor != "" {
+		paths = append(paths, opts.emitDescriptor)
+	}
+	if settings.Pages.FrontMatter != "" {
+		paths = append(paths, settings.Pages.OutputDir)
+	}
+	committed, err := commitDocs(paths, settings.Commit, resolveRemote(opts.remote))
+	switch {
+	case err != nil:
+		return withExitCode(exitWrite, err)
+	case committed:
+		log.Infof("Committed and pushed %s", strings.Join(paths, ", "))
+	default:
+		log.Infof("%s unchanged, nothing to commit", strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// runWebhook posts the components' contract changes to the webhook. A
+// failure is only a warning: the documentation is written already.
+func runWebhook(settings Settings, opts generateOptions, data TemplateData, log *logger) {
+	webhook := settings.Webhook
+	webhook.Base = resolveWebhookBase(webhook.Base)
+	sent, err := notifyWebhook(webhook, data, settings, opts.jobs)
+	switch {
+	case err != nil:
+		log.Warnf("%v", err)
+	case sent:
+		log.Infof("Notified the webhook of the contract changes since %s", webhook.Base)
+	default:
+		log.Verbosef("No contract change since %s, webhook not notified", webhook.Base)
+	}
+}
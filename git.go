@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func gitRemoteURL(remote string) string {
+	out, err := exec.Command("git", "remote", "get-url", remote).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func detectGitProjectPath(remote string) string {
+	return parseGitRemoteURL(gitRemoteURL(remote))
+}
+
+// parseGitRemoteHost extracts the host from an SSH or HTTPS git remote URL.
+func parseGitRemoteHost(remote string) string {
+	// SSH: git@gitlab.com:group/project.git
+	if !strings.Contains(remote, "//") && strings.Contains(remote, ":") && strings.Contains(remote, "@") {
+		host := strings.SplitN(remote, ":", 2)[0]
+		return host[strings.Index(host, "@")+1:]
+	}
+
+	// HTTPS: https://user@gitlab.com:8443/group/project.git
+	if strings.Contains(remote, "//") {
+		host := strings.SplitN(remote, "//", 2)[1]
+		if slashIdx := strings.Index(host, "/"); slashIdx >= 0 {
+			host = host[:slashIdx]
+		}
+		if atIdx := strings.LastIndex(host, "@"); atIdx >= 0 {
+			host = host[atIdx+1:]
+		}
+		return host
+	}
+
+	return ""
+}
+
+func parseGitRemoteURL(remote string) string {
+	// SSH: git@gitlab.com:group/project.git
+	if !strings.Contains(remote, "//") && strings.Contains(remote, ":") && strings.Contains(remote, "@") {
+		parts := strings.SplitN(remote, ":", 2)
+		if len(parts) == 2 {
+			path := parts[1]
+			path = strings.TrimSuffix(path, ".git")
+			return path
+		}
+	}
+
+	// HTTPS: https://gitlab.com/group/project.git
+	if strings.Contains(remote, "//") {
+		parts := strings.SplitN(remote, "//", 2)
+		if len(parts) == 2 {
+			// Remove host: gitlab.com/group/project.git -> group/project.git
+			slashIdx := strings.Index(parts[1], "/")
+			if slashIdx >= 0 {
+				path := parts[1][slashIdx+1:]
+				path = strings.TrimSuffix(path, ".git")
+				return path
+			}
+		}
+	}
+
+	return ""
+}
+
+func detectGitVersion() string {
+	out, err := exec.Command("git", "describe", "--tags", "--abbrev=0").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// resolveDiffBase determines the ref changes are compared against:
+// flag --base, then the merge request pipeline variables, then origin/main.
+func resolveDiffBase(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if sha := os.Getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"); sha != "" {
+		return sha
+	}
+	if branch := os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"); branch != "" {
+		return "origin/" + branch
+	}
+	if branch := os.Getenv("CI_DEFAULT_BRANCH"); branch != "" {
+		return "origin/" + branch
+	}
+	return "origin/main"
+}
+
+// changedFiles lists the files under the current directory that differ
+// between the merge base of base and HEAD and the working tree, including
+// untracked files. Paths are slash-separated and relative to the current
+// directory, like the settings' paths they are matched against, so a
+// component repository in a subdirectory of a monorepo works too.
+func changedFiles(base string) ([]string, error) {
+	out, err := exec.Command("git", "merge-base", base, "HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error finding merge base with %s: %w", base, err)
+	}
+	mergeBase := strings.TrimSpace(string(out))
+
+	var files []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", mergeBase},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("error listing changed files: %w", err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				files = append(files, line)
+			}
+		}
+	}
+	return files, nil
+}
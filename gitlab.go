@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitlabAPITimeout bounds each GitLab API request, so an unreachable
+// instance only delays the run instead of hanging it.
+const gitlabAPITimeout = 5 * time.Second
+
+// gitlabProject is what the GitLab API tells about the documented project.
+type gitlabProject struct {
+	Path          string // canonical path_with_namespace
+	DefaultBranch string
+	LatestRelease string // tag of the most recent release
+	Description   string
+}
+
+var (
+	gitlabCacheMu sync.Mutex
+	gitlabCache   = map[string]gitlabLookup{}
+)
+
+type gitlabLookup struct {
+	project gitlabProject
+	err     error
+}
+
+// gitlabAPIConfig returns the API base URL, the auth header and its token.
+// Without a token, ok is false and the API is not used.
+func gitlabAPIConfig(remote string) (baseURL, header, token string, ok bool) {
+	header, token = gitlabToken()
+	if token == "" {
+		return "", "", "", false
+	}
+	baseURL = gitlabBaseURL(remote)
+	if baseURL == "" {
+		return "", "", "", false
+	}
+	return baseURL, header, token, true
+}
+
+// gitlabToken returns the auth header and token to call the API with:
+// GITLAB_TOKEN (a personal, project or group access token) wins over
+// CI_JOB_TOKEN. Both are empty when neither is set.
+func gitlabToken() (header, token string) {
+	switch {
+	case os.Getenv("GITLAB_TOKEN") != "":
+		return "PRIVATE-TOKEN", os.Getenv("GITLAB_TOKEN")
+	case os.Getenv("CI_JOB_TOKEN") != "":
+		return "JOB-TOKEN", os.Getenv("CI_JOB_TOKEN")
+	}
+	return "", ""
+}
+
+// gitlabBaseURL returns CI_API_V4_URL, or the API URL of the git remote's
+// host. It is empty when neither is known.
+func gitlabBaseURL(remote string) string {
+	if baseURL := strings.TrimRight(os.Getenv("CI_API_V4_URL"), "/"); baseURL != "" {
+		return baseURL
+	}
+	if host := parseGitRemoteHost(gitRemoteURL(remote)); host != "" {
+		return "https://" + host + "/api/v4"
+	}
+	return ""
+}
+
+// lookupGitLabProject fetches the project's canonical path, default branch
+// and latest release from the GitLab REST API. The project is identified by
+// CI_PROJECT_ID, or by the path detected from the git remote. It returns an
+// empty result (and no error) when no token is available; results are cached
+// for the whole run.
+func lookupGitLabProject(remote string) (gitlabProject, error) {
+	baseURL, header, token, ok := gitlabAPIConfig(remote)
+	if !ok {
+		return gitlabProject{}, nil
+	}
+	id := os.Getenv("CI_PROJECT_ID")
+	if id == "" {
+		id = detectGitProjectPath(remote)
+	}
+	if id == "" {
+		return gitlabProject{}, nil
+	}
+
+	key := baseURL + "|" + header + "|" + id
+	gitlabCacheMu.Lock()
+	defer gitlabCacheMu.Unlock()
+	if cached, ok := gitlabCache[key]; ok {
+		return cached.project, cached.err
+	}
+
+	project, err := fetchGitLabProject(baseURL, header, token, id)
+	if err != nil {
+		err = fmt.Errorf("GitLab API lookup failed, falling back to git: %w", err)
+	}
+	gitlabCache[key] = gitlabLookup{project, err}
+	return project, err
+}
+
+// fetchGitLabProject queries the project and its most recent release.
+func fetchGitLabProject(baseURL, header, token, id string) (gitlabProject, error) {
+	get := func(path string, v interface{}) error {
+		return gitlabRequest(baseURL, header, token, http.MethodGet, path, nil, v)
+	}
+
+	projectPath := "/projects/" + url.PathEscape(id)
+	var p struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		DefaultBranch     string `json:"default_branch"`
+		Description       string `json:"description"`
+	}
+	if err := get(projectPath, &p); err != nil {
+		return gitlabProject{}, err
+	}
+	var releases []struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := get(projectPath+"/releases?order_by=released_at&sort=desc&per_page=1", &releases); err != nil {
+		return gitlabProject{}, err
+	}
+
+	project := gitlabProject{Path: p.PathWithNamespace, DefaultBranch: p.DefaultBranch, Description: p.Description}
+	if len(releases) > 0 {
+		project.LatestRelease = releases[0].TagName
+	}
+	return project, nil
+}
+
+// gitlabError is a non-2xx response of the GitLab API.
+type gitlabError struct {
+	method, path, status string
+	code                 int
+}
+
+func (e *gitlabError) Error() string { return fmt.Sprintf("%s %s: %s", e.method, e.path, e.status) }
+
+// gitlabRequest calls the GitLab REST API, sending body (if not nil) and
+// decoding the response into out (if not nil) as JSON, or storing it as is
+// when out is a *[]byte. Without a token (empty header), the request is
+// anonymous, which only works for public projects.
+func gitlabRequest(baseURL, header, token, method, path string, body, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	if header != "" {
+		req.Header.Set(header, token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: gitlabAPITimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &gitlabError{method: method, path: path, status: resp.Status, code: resp.StatusCode}
+	}
+	switch v := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*v, err = io.ReadAll(resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// resolveDefaultBranch returns the project's default branch: env var
+// CI_DEFAULT_BRANCH, then the GitLab API. It is empty when neither is
+// available.
+func resolveDefaultBranch(remote string) string {
+	if branch := os.Getenv("CI_DEFAULT_BRANCH"); branch != "" {
+		return branch
+	}
+	project, _ := lookupGitLabProject(remote)
+	return project.DefaultBranch
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// gitlabTestServer serves a project and its releases, counting requests and
+// rejecting those without the expected token.
+func gitlabTestServer(t *testing.T, header, token string, hits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Header.Get(header) != token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/42":
+			w.Write([]byte(`{"path_with_namespace": "group/sub/project", "default_branch": "main"}`))
+		case "/api/v4/projects/42/releases":
+			if r.URL.Query().Get("per_page") != "1" {
+				t.Errorf("expected per_page=1, got %q", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"tag_name": "v2.3.0"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setGitLabEnv points the API client at url and clears the lookup cache.
+func setGitLabEnv(t *testing.T, url string) {
+	t.Helper()
+	t.Setenv("CI_API_V4_URL", url+"/api/v4")
+	t.Setenv("CI_PROJECT_ID", "42")
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv("CI_JOB_TOKEN", "")
+	t.Setenv("CI_DEFAULT_BRANCH", "")
+	gitlabCache = map[string]gitlabLookup{}
+	t.Cleanup(func() { gitlabCache = map[string]gitlabLookup{} })
+}
+
+func TestLookupGitLabProject(t *testing.T) {
+	var hits int32
+	server := gitlabTestServer(t, "PRIVATE-TOKEN", "secret", &hits)
+	setGitLabEnv(t, server.URL)
+	t.Setenv("GITLAB_TOKEN", "secret")
+
+	project, err := lookupGitLabProject("origin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := gitlabProject{Path: "group/sub/project", DefaultBranch: "main", LatestRelease: "v2.3.0"}
+	if project != want {
+		t.Errorf("expected %+v, got %+v", want, project)
+	}
+
+	// A second lookup is served from the cache
+	if _, err := lookupGitLabProject("origin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hits != 2 {
+		t.Errorf("expected 2 requests, got %d", hits)
+	}
+}
+
+func TestLookupGitLabProject_JobToken(t *testing.T) {
+	var hits int32
+	server := gitlabTestServer(t, "JOB-TOKEN", "job", &hits)
+	setGitLabEnv(t, server.URL)
+	t.Setenv("CI_JOB_TOKEN", "job")
+
+	project, err := lookupGitLabProject("origin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if project.Path != "group/sub/project" {
+		t.Errorf("expected path from API, got %q", project.Path)
+	}
+}
+
+func TestLookupGitLabProject_NoToken(t *testing.T) {
+	var hits int32
+	server := gitlabTestServer(t, "PRIVATE-TOKEN", "secret", &hits)
+	setGitLabEnv(t, server.URL)
+
+	project, err := lookupGitLabProject("origin")
+	if err != nil || project != (gitlabProject{}) {
+		t.Errorf("expected empty result without a token, got %+v, %v", project, err)
+	}
+	if hits != 0 {
+		t.Errorf("expected no request without a token, got %d", hits)
+	}
+}
+
+func TestLookupGitLabProject_Error(t *testing.T) {
+	var hits int32
+	server := gitlabTestServer(t, "PRIVATE-TOKEN", "secret", &hits)
+	setGitLabEnv(t, server.URL)
+	t.Setenv("GITLAB_TOKEN", "wrong")
+
+	_, err := lookupGitLabProject("origin")
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+}
+
+func TestResolve_GitLabAPIPrecedence(t *testing.T) {
+	var hits int32
+	server := gitlabTestServer(t, "PRIVATE-TOKEN", "secret", &hits)
+	setGitLabEnv(t, server.URL)
+	t.Setenv("GITLAB_TOKEN", "secret")
+	t.Setenv("PROJECT_PATH", "")
+	t.Setenv("VERSION", "")
+
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if got := resolveProjectPath("", "origin"); got != "group/sub/project" {
+		t.Errorf("expected project path from API, got %q", got)
+	}
+	if got := resolveVersion("", "origin"); got != "v2.3.0" {
+		t.Errorf("expected version from API, got %q", got)
+	}
+	if got := resolveDefaultBranch("origin"); got != "main" {
+		t.Errorf("expected default branch from API, got %q", got)
+	}
+
+	// Flags, env vars and config still win
+	t.Setenv("VERSION", "1.0.0")
+	t.Setenv("CI_DEFAULT_BRANCH", "develop")
+	os.WriteFile(".gitlab-component-docs-gen.yml", []byte("project_path: from/config\n"), 0644)
+	if got := resolveProjectPath("", "origin"); got != "from/config" {
+		t.Errorf("expected project path from config, got %q", got)
+	}
+	if got := resolveVersion("", "origin"); got != "1.0.0" {
+		t.Errorf("expected version from env, got %q", got)
+	}
+	if got := resolveDefaultBranch("origin"); got != "develop" {
+		t.Errorf("expected default branch from env, got %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// groupExtension is the input extension naming the group an input is listed
+// under (`x-group: AWS settings`).
+const groupExtension = "group"
+
+func init() {
+	RegisterInputExtension(groupExtension, decodeGroup)
+}
+
+// decodeGroup accepts a non-empty group name.
+func decodeGroup(value interface{}) (interface{}, error) {
+	name, ok := value.(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return nil, errors.New("expected a group name")
+	}
+	return strings.TrimSpace(name), nil
+}
+
+// GroupsConfig groups inputs from the config file, and orders the groups.
+type GroupsConfig struct {
+	// Order lists group names in the order they are rendered; the other
+	// groups follow in the order their first input appears
+	Order  []string                     `yaml:"order"`
+	Inputs map[string]map[string]string `yaml:"inputs"` // component -> input -> group
+}
+
+// InputGroup is a titled part of a component's inputs. The inputs without
+// a group form a group without a name.
+type InputGroup struct {
+	Name   string
+	Inputs []InputData
+}
+
+// applyGroups assigns the inputs listed in the config to their group, over
+// their x-group. Entries naming unknown components or inputs are returned as
+// warnings, so typos don't go unnoticed.
+func applyGroups(config GroupsConfig, components []ComponentData) []string {
+	byName := make(map[string]*ComponentData, len(components))
+	for i := range components {
+		byName[components[i].Name] = &components[i]
+	}
+
+	var warnings []string
+	for name, inputs := range config.Inputs {
+		c := byName[name]
+		if c == nil {
+			warnings = append(warnings, fmt.Sprintf("groups: unknown component %q", name))
+			continue
+		}
+		for input, group := range inputs {
+			found := false
+			for i := range c.Inputs {
+				if c.Inputs[i].Name == input {
+					c.Inputs[i].Group = strings.TrimSpace(group)
+					found = true
+				}
+			}
+			if !found {
+				warnings = append(warnings, fmt.Sprintf("groups: component %q has no input %q", name, input))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// inputGroups splits a component's inputs by group, keeping their order
+// within each group. The ungrouped inputs come first, then the groups listed
+// in order, then the others in the order their first input appears. A
+// component without inputs has one empty group, so its table is rendered.
+func inputGroups(c ComponentData, order []string) []InputGroup {
+	if len(c.Inputs) == 0 {
+		return []InputGroup{{}}
+	}
+	var names []string
+	byGroup := make(map[string][]InputData)
+	for _, input := range c.Inputs {
+		if _, seen := byGroup[input.Group]; !seen {
+			names = append(names, input.Group)
+		}
+		byGroup[input.Group] = append(byGroup[input.Group], input)
+	}
+	rank := func(name string) int {
+		if name == "" {
+			return -1
+		}
+		if i := indexOf(order, name); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(names, func(i, j int) bool { return rank(names[i]) < rank(names[j]) })
+
+	groups := make([]InputGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, InputGroup{Name: name, Inputs: byGroup[name]})
+	}
+	return groups
+}
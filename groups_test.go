@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeGroup(t *testing.T) {
+	if got, err := decodeGroup(" AWS settings "); err != nil || got != "AWS settings" {
+		t.Errorf("got %v, %v", got, err)
+	}
+	for _, value := range []interface{}{"", true, []interface{}{"a"}} {
+		if _, err := decodeGroup(value); err == nil {
+			t.Errorf("expected %v to be rejected", value)
+		}
+	}
+}
+
+func TestApplyGroups(t *testing.T) {
+	components := []ComponentData{{Name: "deploy", Inputs: []InputData{{Name: "region", Group: "AWS"}, {Name: "stage"}}}}
+	warnings := applyGroups(GroupsConfig{Inputs: map[string]map[string]string{
+		"deploy":  {"region": "Cloud", "stage": "General", "missing": "General"},
+		"unknown": {"stage": "General"},
+	}}, components)
+	if components[0].Inputs[0].Group != "Cloud" || components[0].Inputs[1].Group != "General" {
+		t.Errorf("expected the config to set the groups, got %+v", components[0].Inputs)
+	}
+	want := []string{`groups: component "deploy" has no input "missing"`, `groups: unknown component "unknown"`}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got warnings %q, want %q", warnings, want)
+	}
+}
+
+func TestInputGroups(t *testing.T) {
+	c := ComponentData{Inputs: []InputData{
+		{Name: "a", Group: "Network"},
+		{Name: "b"},
+		{Name: "c", Group: "AWS"},
+		{Name: "d", Group: "Network"},
+		{Name: "e", Group: "Logging"},
+	}}
+	describe := func(groups []InputGroup) string {
+		var parts []string
+		for _, g := range groups {
+			var names []string
+			for _, input := range g.Inputs {
+				names = append(names, input.Name)
+			}
+			parts = append(parts, g.Name+":"+strings.Join(names, ","))
+		}
+		return strings.Join(parts, " ")
+	}
+	if got := describe(inputGroups(c, nil)); got != ":b Network:a,d AWS:c Logging:e" {
+		t.Errorf("expected groups in order of appearance, got %q", got)
+	}
+	if got := describe(inputGroups(c, []string{"Logging", "AWS"})); got != ":b Logging:e AWS:c Network:a,d" {
+		t.Errorf("expected the configured order first, got %q", got)
+	}
+	if got := inputGroups(ComponentData{}, nil); len(got) != 1 || got[0].Name != "" {
+		t.Errorf("expected one empty group without inputs, got %+v", got)
+	}
+}
+
+func TestRun_InputGroups(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs:\n    region:\n      default: eu-west-1\n      x-group: AWS settings\n    stage:\n      default: deploy\n    replicas:\n      default: 2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("groups:\n  order: [Scaling, AWS settings]\n  inputs:\n    deploy:\n      replicas: Scaling\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	doc := string(data)
+	stage, scaling, aws := strings.Index(doc, "| stage |"), strings.Index(doc, "#### Scaling\n\n| Name |"), strings.Index(doc, "#### AWS settings\n\n| Name |")
+	if stage < 0 || scaling < stage || aws < scaling || !strings.Contains(doc[scaling:aws], "| replicas |") || !strings.Contains(doc[aws:], "| region |") {
+		t.Errorf("expected the ungrouped inputs, then Scaling, then AWS settings, got:\n%s", doc)
+	}
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--format", "json"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("components.json"); !strings.Contains(string(data), `"group": "AWS settings"`) || strings.Contains(string(data), `"extensions"`) {
+		t.Errorf("expected the group in the JSON model, not as an extension, got:\n%s", data)
+	}
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--format", "asciidoc"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.Contains(string(data), "|===\n\n==== Scaling\n\n[options=\"header\"]\n|===\n") {
+		t.Errorf("expected titled AsciiDoc tables, got:\n%s", data)
+	}
+}
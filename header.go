@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// headerComment returns the comment block preceding `spec:` at the top of a
+// component template, as prose: the leading `#` (and one space) is stripped
+// from each line, blank lines separate paragraphs, and editor modelines
+// (`# yaml-language-server: ...`) are skipped.
+func headerComment(data []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || (trimmed == "---" && len(lines) == 0):
+			lines = append(lines, "")
+		case strings.HasPrefix(trimmed, "#"):
+			text := strings.TrimPrefix(trimmed, "#")
+			text = strings.TrimPrefix(text, " ")
+			if isModeline(text) {
+				continue
+			}
+			lines = append(lines, text)
+		case strings.HasPrefix(line, "spec:"):
+			return strings.TrimSpace(strings.Join(lines, "\n"))
+		default:
+			// Comments not followed by the spec header document something else
+			return ""
+		}
+	}
+	return ""
+}
+
+// isModeline reports whether a comment is an editor or tool directive rather than prose.
+func isModeline(text string) bool {
+	return strings.HasPrefix(text, "yaml-language-server:") ||
+		strings.HasPrefix(text, "-*-") ||
+		strings.HasPrefix(text, "!") ||
+		strings.HasPrefix(text, "vim:")
+}
+
+// docsMarker starts the part of an input comment meant for the docs.
+const docsMarker = "docs:"
+
+// inputComments returns, per input, the comment written above its key (or
+// after it on the same line) in spec:inputs, used when the input has no
+// `description`. When a line starts with `# docs:`, only the text from that
+// marker on is kept, so notes for maintainers can precede it. The file must
+// be parsed with parser.ParseComments.
+func inputComments(file *ast.File) map[string]string {
+	inputs := mappingValue(mappingValue(specDocument(file), "spec"), "inputs")
+
+	comments := make(map[string]string)
+	for _, input := range mappingValues(inputs) {
+		group := input.GetComment()
+		if group == nil {
+			group = input.Key.GetComment()
+		}
+		if null, ok := input.Value.(*ast.NullNode); group == nil && ok {
+			// The comment after a key without a body (`name: # ...`)
+			group = null.GetComment()
+		}
+		if group == nil {
+			continue
+		}
+		var lines []string
+		marked := false
+		for _, comment := range group.Comments {
+			text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment.String()), "#"))
+			if rest, ok := strings.CutPrefix(text, docsMarker); ok && !marked {
+				lines, marked = nil, true
+				text = strings.TrimSpace(rest)
+			}
+			lines = append(lines, text)
+		}
+		if text := strings.TrimSpace(strings.Join(lines, " ")); text != "" {
+			comments[input.Key.GetToken().Value] = text
+		}
+	}
+	return comments
+}
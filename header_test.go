@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestHeaderComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "no comment",
+			content:  "spec:\n  inputs: {}\n",
+			expected: "",
+		},
+		{
+			name:     "paragraphs",
+			content:  "# Builds the app.\n#\n#   Indented detail.\n\nspec:\n  inputs: {}\n",
+			expected: "Builds the app.\n\n  Indented detail.",
+		},
+		{
+			name:     "modeline skipped",
+			content:  "# yaml-language-server: $schema=x.json\n# Deploys.\nspec:\n  inputs: {}\n",
+			expected: "Deploys.",
+		},
+		{
+			name:     "document marker",
+			content:  "---\n# Tests.\nspec:\n  inputs: {}\n",
+			expected: "Tests.",
+		},
+		{
+			name:     "comment not before spec",
+			content:  "# A job.\nbuild:\n  script: echo\n",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headerComment([]byte(tt.content)); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInputComments(t *testing.T) {
+	file, err := parser.ParseBytes([]byte(`spec:
+  inputs:
+    # Stage the job runs in,
+    # before deploy
+    stage:
+      default: build
+    image: # Image to build with
+      default: alpine
+    plain:
+      default: x
+    # TODO: drop once v2 is out
+    # docs: Registry to push to,
+    # without the scheme
+    registry:
+      default: registry.example.com
+`), parser.ParseComments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	comments := inputComments(file)
+	if comments["stage"] != "Stage the job runs in, before deploy" {
+		t.Errorf("expected joined head comment, got %q", comments["stage"])
+	}
+	if comments["image"] != "Image to build with" {
+		t.Errorf("expected inline comment, got %q", comments["image"])
+	}
+	if comments["registry"] != "Registry to push to, without the scheme" {
+		t.Errorf("expected the comment from the docs marker on, got %q", comments["registry"])
+	}
+	if _, ok := comments["plain"]; ok {
+		t.Errorf("expected no comment for plain, got %q", comments["plain"])
+	}
+}
+
+func TestParseTemplate_Header(t *testing.T) {
+	dir := t.TempDir()
+	yamlContent := `# Builds the application.
+spec:
+  component: [name, version]
+  inputs:
+    # Ignored: the description wins
+    stage:
+      description: "Pipeline stage"
+      default: build
+    # Image to build with
+    image:
+      default: alpine
+`
+	path := filepath.Join(dir, "build.yml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	component, err := parseTemplate(path, ParseOptions{SortOrder: "name"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if component.Comment != "Builds the application." {
+		t.Errorf("expected header comment, got %q", component.Comment)
+	}
+	if strings.Join(component.Context, ",") != "name,version" {
+		t.Errorf("expected context [name version], got %v", component.Context)
+	}
+	if component.Inputs[0].Description != "Image to build with" {
+		t.Errorf("expected comment as description, got %q", component.Inputs[0].Description)
+	}
+	if component.Inputs[1].Description != "Pipeline stage" {
+		t.Errorf("expected explicit description to win, got %q", component.Inputs[1].Description)
+	}
+}
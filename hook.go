@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// preCommitHooksFile is the hook definition file read by the pre-commit
+// framework (https://pre-commit.com).
+const preCommitHooksFile = ".pre-commit-hooks.yaml"
+
+// preCommitHooks is the content written by `hook install`. The files pattern
+// is filled in from the configured directories.
+const preCommitHooks = `# Generated by gitlab-component-docs-gen hook install
+- id: gitlab-component-docs-gen
+  name: Update GitLab CI/CD component docs
+  description: Regenerates the component documentation and stages it when a component changed
+  entry: gitlab-component-docs-gen --hook
+  language: system
+  pass_filenames: false
+  files: '%s'
+`
+
+// stagedFiles lists the files staged for the next commit.
+func stagedFiles() ([]string, error) {
+	out, err := exec.Command("git", "diff", "--cached", "--name-only", "--relative", "--diff-filter=ACMRD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing staged files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// stageFile adds path to the index, so the regenerated output is part of
+// the commit being made.
+func stageFile(path string) error {
+	if out, err := exec.Command("git", "add", "--", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("error staging %s: %v: %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// hookFilesPattern is the regular expression of the files that can affect
+// the documentation: templates, descriptions, examples, the config file and
+// the README template.
+func hookFilesPattern(settings Settings) string {
+	var dirs []string
+	for _, dir := range []string{settings.TemplatesDir, settings.DocsDir, settings.ExamplesDir} {
+		dirs = append(dirs, regexp.QuoteMeta(strings.TrimSuffix(dir, "/"))+"/")
+	}
+	files := []string{regexp.QuoteMeta(configFile)}
+	for _, f := range []string{settings.Template, settings.HeaderFile, settings.FooterFile} {
+		if f != "" {
+			files = append(files, regexp.QuoteMeta(f))
+		}
+	}
+	return fmt.Sprintf("^(%s|(%s)$)", strings.Join(dirs, "|"), strings.Join(files, "|"))
+}
+
+// runHook implements the `hook` subcommand. `hook install` writes a
+// .pre-commit-hooks.yaml defining a hook that runs --hook.
+func runHook(args []string, stdout io.Writer) error {
+	if len(args) == 0 || (args[0] != "install" && !isHelpFlag(args[0])) {
+		return withExitCode(exitConfig, errors.New("usage: gitlab-component-docs-gen hook install"))
+	}
+
+	flags := newFlagSet("hook")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "README template path (default depends on --format)")
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory containing component descriptions (default \"docs\")")
+	flags.StringVar(&overrides.ExamplesDir, "examples-dir", "", "Directory containing usage examples (default \"examples\")")
+	if args[0] == "install" {
+		args = args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+
+	content := fmt.Sprintf(preCommitHooks, hookFilesPattern(settings))
+	created, err := ensureTemplate(preCommitHooksFile, []byte(content))
+	if err != nil {
+		return withExitCode(exitWrite, err)
+	}
+	log := newLogger(stdout, logLevelFor(*quiet, false, false), "text")
+	if created {
+		log.Infof("Created %s", preCommitHooksFile)
+	} else {
+		log.Infof("%s already exists, left untouched", preCommitHooksFile)
+	}
+	return nil
+}
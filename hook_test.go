@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRun_Hook(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return string(out)
+	}
+	git("init", "-q")
+	os.MkdirAll("templates", 0755)
+	os.WriteFile("templates/build.yml", []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile("notes.txt", []byte("hello\n"), 0644)
+	git("add", "notes.txt")
+
+	args := []string{"--hook", "--quiet", "--project-path", "g/p", "--version", "1.0.0"}
+
+	// Nothing relevant staged: nothing to do
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected no README.md without staged component changes")
+	}
+
+	// A staged template: the README is written, staged, and the hook fails
+	git("add", "templates/build.yml")
+	if got := exitCode(run(args, io.Discard)); got != exitOutdated {
+		t.Fatalf("expected exit code %d when the README changed, got %d", exitOutdated, got)
+	}
+	if staged := git("diff", "--cached", "--name-only"); !strings.Contains(staged, "README.md") {
+		t.Errorf("expected README.md to be staged, got %q", staged)
+	}
+
+	// Up to date: the hook passes
+	if err := run(args, io.Discard); err != nil {
+		t.Errorf("expected an up to date README to pass, got %v", err)
+	}
+}
+
+func TestRunHookInstall(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"hook", "install", "--quiet", "--docs-dir", "documentation"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(preCommitHooksFile)
+	if err != nil {
+		t.Fatalf("expected %s: %v", preCommitHooksFile, err)
+	}
+	if !strings.Contains(string(data), "entry: gitlab-component-docs-gen --hook") {
+		t.Errorf("expected the hook entry, got:\n%s", data)
+	}
+
+	// An existing file is left untouched
+	os.WriteFile(preCommitHooksFile, []byte("custom\n"), 0644)
+	if err := run([]string{"hook", "install", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(preCommitHooksFile); string(data) != "custom\n" {
+		t.Errorf("expected the existing file to be kept, got %q", data)
+	}
+
+	if got := exitCode(run([]string{"hook"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without an action, got %d", exitConfig, got)
+	}
+}
+
+func TestHookFilesPattern(t *testing.T) {
+	settings, _ := resolveSettings(ProjectConfig{FooterFile: "FOOTER.md"}, Settings{DocsDir: "documentation"})
+	pattern := regexp.MustCompile(hookFilesPattern(settings))
+	for path, want := range map[string]bool{
+		"templates/build.yml":                true,
+		"documentation/build.md":             true,
+		"examples/build/basic.yml":           true,
+		".gitlab-component-docs-gen.yml":     true,
+		"README.md.tmpl":                     true,
+		"FOOTER.md":                          true,
+		"docs/build.md":                      false,
+		"README.md":                          false,
+		"sub/.gitlab-component-docs-gen.yml": false,
+	} {
+		if got := pattern.MatchString(path); got != want {
+			t.Errorf("%s: expected match %v, got %v", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRules select the template files a scan skips: those matching one of
+// the `ignore` patterns and, with gitignore, those git ignores.
+type ignoreRules struct {
+	patterns  []string
+	gitignore bool
+}
+
+// ignoreRules returns the rules of a run; --no-ignore disables them all.
+func (s Settings) ignoreRules() ignoreRules {
+	if s.NoIgnore {
+		return ignoreRules{}
+	}
+	return ignoreRules{patterns: s.Ignore, gitignore: true}
+}
+
+// filter returns the paths of files under dir that the rules keep. Patterns
+// are globs matched against the file name (e.g. "*.draft.yml"), the path
+// relative to dir and the path as scanned (e.g. "templates/drafts/*.yml").
+func (r ignoreRules) filter(dir string, paths []string) []string {
+	var ignored map[string]bool
+	if r.gitignore {
+		ignored = gitIgnored(paths)
+	}
+	var kept []string
+	for _, p := range paths {
+		if !ignored[p] && !r.matches(dir, p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func (r ignoreRules) matches(dir, p string) bool {
+	candidates := []string{filepath.Base(p), filepath.ToSlash(p)}
+	if rel, err := filepath.Rel(dir, p); err == nil {
+		candidates = append(candidates, filepath.ToSlash(rel))
+	}
+	for _, pattern := range r.patterns {
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gitIgnored returns the paths git ignores (.gitignore files,
+// .git/info/exclude and the global excludes file). Tracked files are never
+// ignored. Outside a git repository, or without git, nothing is.
+func gitIgnored(paths []string) map[string]bool {
+	ignored := make(map[string]bool)
+	if len(paths) == 0 {
+		return ignored
+	}
+	cmd := exec.Command("git", "check-ignore", "-z", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		// Exit code 1 means no path is ignored; anything else, no repository
+		return ignored
+	}
+	for _, p := range bytes.Split(out, []byte{0}) {
+		if len(p) > 0 {
+			ignored[string(p)] = true
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules_Filter(t *testing.T) {
+	rules := ignoreRules{patterns: []string{"*.draft.yml", "wip/*", "templates/old/*.yml"}}
+	paths := []string{
+		filepath.Join("templates", "build.yml"),
+		filepath.Join("templates", "build.draft.yml"),
+		filepath.Join("templates", "wip", "deploy.yml"),
+		filepath.Join("templates", "old", "lint.yml"),
+		filepath.Join("templates", "aws", "wip.yml"),
+	}
+	got := rules.filter("templates", paths)
+	want := []string{filepath.Join("templates", "build.yml"), filepath.Join("templates", "aws", "wip.yml")}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := (ignoreRules{}).filter("templates", paths); len(got) != len(paths) {
+		t.Errorf("expected no rules to keep every path, got %v", got)
+	}
+	if rules := (Settings{Ignore: []string{"*.draft.yml"}, NoIgnore: true}).ignoreRules(); rules.gitignore || rules.patterns != nil {
+		t.Errorf("expected --no-ignore to disable every rule, got %+v", rules)
+	}
+}
+
+func TestRun_Ignore(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	os.MkdirAll(filepath.Join("templates", "generated"), 0755)
+	for _, name := range []string{"build.yml", "deploy.draft.yml", filepath.Join("generated", "lint.yml")} {
+		os.WriteFile(filepath.Join("templates", name), []byte("spec:\n  inputs:\n    stage:\n      default: test\n---\njob:\n  script: make\n"), 0644)
+	}
+	os.WriteFile(".gitignore", []byte("templates/generated/\n"), 0644)
+	os.WriteFile(configFile, []byte("ignore:\n  - \"*.draft.yml\"\n"), 0644)
+	if out, err := exec.Command("git", "init", "-q").CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v\n%s", err, out)
+	}
+
+	args := []string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readme, _ := os.ReadFile("README.md")
+	if !strings.Contains(string(readme), "## build") || strings.Contains(string(readme), "## deploy") || strings.Contains(string(readme), "## generated/lint") {
+		t.Errorf("expected only the build component, got:\n%s", readme)
+	}
+
+	if err := run(append(args, "--no-ignore"), io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readme, _ = os.ReadFile("README.md")
+	if !strings.Contains(string(readme), "## deploy.draft") || !strings.Contains(string(readme), "## generated/lint") {
+		t.Errorf("expected --no-ignore to document every template, got:\n%s", readme)
+	}
+
+	os.WriteFile(configFile, []byte("ignore:\n  - \"[\"\n"), 0644)
+	if got := exitCode(run(args, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an invalid pattern, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxIncludeDepth is GitLab's limit on nested includes.
+const maxIncludeDepth = 100
+
+// includeResolver composes body documents with the local files they
+// `include:`, like GitLab does when it creates the pipeline.
+type includeResolver struct {
+	root  string  // project root local paths are relative to
+	log   *logger // receives the includes that were not found
+	files []string
+}
+
+// resolveLocalIncludes returns the body documents with their local includes
+// merged in, and the files that were read. Included files are resolved
+// recursively; files from outside the repository stay in `include`.
+func resolveLocalIncludes(body []map[string]interface{}, opts ParseOptions) ([]map[string]interface{}, []string, error) {
+	r := &includeResolver{root: opts.RootDir, log: opts.Log}
+	if r.root == "" {
+		r.root = "."
+	}
+	composed := make([]map[string]interface{}, 0, len(body))
+	for _, doc := range body {
+		resolved, err := r.resolve(doc, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		composed = append(composed, resolved)
+	}
+	return composed, r.files, nil
+}
+
+// resolve merges the local includes of doc under its own content: keys the
+// document defines win, and mappings (jobs, variables) are merged key by key.
+// stack holds the files being included, to report cycles.
+func (r *includeResolver) resolve(doc map[string]interface{}, stack []string) (map[string]interface{}, error) {
+	local, external := splitIncludes(doc["include"])
+	if len(local) == 0 {
+		return doc, nil
+	}
+	if len(stack) >= maxIncludeDepth {
+		return nil, fmt.Errorf("includes nested more than %d levels deep: %s", maxIncludeDepth, strings.Join(stack, " -> "))
+	}
+
+	merged := make(map[string]interface{})
+	for _, pattern := range local {
+		files, err := r.expand(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if indexOf(stack, file) >= 0 {
+				return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), file)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading included file %s: %w", file, err)
+			}
+			if data, err = decodeSource(file, data); err != nil {
+				return nil, err
+			}
+			r.files = append(r.files, file)
+			docs, err := decodeBody(data)
+			if err != nil {
+				return nil, yamlError(file, data, err)
+			}
+			next := append(append([]string{}, stack...), file)
+			for _, included := range docs {
+				resolved, err := r.resolve(included, next)
+				if err != nil {
+					return nil, err
+				}
+				_, nested := splitIncludes(resolved["include"])
+				external = append(external, nested...)
+				delete(resolved, "include")
+				merged = deepMerge(merged, resolved)
+			}
+		}
+	}
+
+	own := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		if key != "include" {
+			own[key] = value
+		}
+	}
+	merged = deepMerge(merged, own)
+	if len(external) > 0 {
+		merged["include"] = external
+	}
+	return merged, nil
+}
+
+// expand returns the files a local include refers to, relative to the
+// project root. Paths may be globs (`/configs/*.yml`); an include matching no
+// file is reported and skipped, since a fetched source or a git ref may hold
+// only the templates directory.
+func (r *includeResolver) expand(pattern string) ([]string, error) {
+	full := filepath.Join(r.root, filepath.FromSlash(strings.TrimPrefix(pattern, "/")))
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(full); err != nil {
+			r.log.Warnf("included file %s not found, its jobs are not documented", pattern)
+			return nil, nil
+		}
+		return []string{full}, nil
+	}
+	files, err := filepath.Glob(full)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+	}
+	if len(files) == 0 {
+		r.log.Warnf("no file matches the include %s, its jobs are not documented", pattern)
+	}
+	return files, nil
+}
+
+// splitIncludes separates the local files of an `include` value (a path, a
+// list, or `local:` entries) from the other entries: remote URLs, projects,
+// components and templates.
+func splitIncludes(value interface{}) (local []string, other []interface{}) {
+	var entries []interface{}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		entries = v
+	default:
+		entries = []interface{}{v}
+	}
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			if !strings.HasPrefix(e, "http://") && !strings.HasPrefix(e, "https://") {
+				local = append(local, e)
+				continue
+			}
+		case map[string]interface{}:
+			if path, ok := e["local"].(string); ok {
+				local = append(local, path)
+				continue
+			}
+		}
+		other = append(other, entry)
+	}
+	return local, other
+}
+
+// deepMerge returns base with over merged in: nested mappings are merged,
+// other values of over replace those of base. Neither argument is modified.
+func deepMerge(base, over map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(over))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range over {
+		if overMap, ok := value.(map[string]interface{}); ok {
+			if baseMap, ok := merged[key].(map[string]interface{}); ok {
+				merged[key] = deepMerge(baseMap, overMap)
+				continue
+			}
+		}
+		merged[key] = value
+	}
+	return merged
+}
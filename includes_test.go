@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitIncludes(t *testing.T) {
+	local, other := splitIncludes([]interface{}{
+		"/shared/rules.yml",
+		"https://example.com/ci.yml",
+		map[string]interface{}{"local": "shared/jobs.yml"},
+		map[string]interface{}{"project": "group/ci", "file": "ci.yml"},
+	})
+	if !reflect.DeepEqual(local, []string{"/shared/rules.yml", "shared/jobs.yml"}) {
+		t.Errorf("unexpected local includes %v", local)
+	}
+	if len(other) != 2 {
+		t.Errorf("expected the remote and project includes to be kept, got %v", other)
+	}
+	if local, other := splitIncludes("shared.yml"); len(local) != 1 || other != nil {
+		t.Errorf("expected a single path to be local, got %v %v", local, other)
+	}
+}
+
+func TestDeepMerge(t *testing.T) {
+	base := map[string]interface{}{
+		"variables": map[string]interface{}{"A": "1", "B": "1"},
+		"build":     map[string]interface{}{"stage": "build", "script": []interface{}{"make"}},
+	}
+	over := map[string]interface{}{
+		"variables": map[string]interface{}{"B": "2"},
+		"build":     map[string]interface{}{"script": []interface{}{"make all"}},
+	}
+	want := map[string]interface{}{
+		"variables": map[string]interface{}{"A": "1", "B": "2"},
+		"build":     map[string]interface{}{"stage": "build", "script": []interface{}{"make all"}},
+	}
+	if got := deepMerge(base, over); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if base["variables"].(map[string]interface{})["B"] != "1" {
+		t.Error("expected the base to be left untouched")
+	}
+}
+
+func TestResolveLocalIncludes(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "shared"), 0755)
+	os.WriteFile(filepath.Join(root, "shared", "jobs.yml"), []byte("include:\n  - local: /shared/vars.yml\n  - remote: https://example.com/lint.yml\nlint:\n  stage: test\n  script: lint\npackage:\n  stage: build\n"), 0644)
+	os.WriteFile(filepath.Join(root, "shared", "vars.yml"), []byte("variables:\n  LEVEL: info\n  MODE: fast\n"), 0644)
+
+	body := []map[string]interface{}{{
+		"include":   []interface{}{"/shared/jobs.yml", "/shared/missing.yml"},
+		"variables": map[string]interface{}{"MODE": "safe"},
+		"package":   map[string]interface{}{"stage": "deploy", "script": "make"},
+	}}
+	composed, files, err := resolveLocalIncludes(body, ParseOptions{RootDir: root})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]interface{}{
+		"include":   []interface{}{map[string]interface{}{"remote": "https://example.com/lint.yml"}},
+		"variables": map[string]interface{}{"LEVEL": "info", "MODE": "safe"},
+		"lint":      map[string]interface{}{"stage": "test", "script": "lint"},
+		"package":   map[string]interface{}{"stage": "deploy", "script": "make"},
+	}
+	if len(composed) != 1 || !reflect.DeepEqual(composed[0], want) {
+		t.Errorf("unexpected composition:\n%v\nwant:\n%v", composed, want)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected both included files to be reported, got %v", files)
+	}
+
+	os.WriteFile(filepath.Join(root, "shared", "vars.yml"), []byte("include: /shared/jobs.yml\n"), 0644)
+	if _, _, err := resolveLocalIncludes(body, ParseOptions{RootDir: root}); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("expected an include cycle error, got %v", err)
+	}
+}
+
+func TestRun_Includes(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "ci"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n---\ninclude:\n  - local: ci/common.yml\ncompile:\n  stage: $[[ inputs.stage ]]\n  script: make\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "ci", "common.yml"), []byte("publish:\n  stage: deploy\n  script: upload\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); !strings.Contains(string(data), "| publish | deploy |") {
+		t.Errorf("expected the included job to be documented, got:\n%s", data)
+	}
+
+	// The cache notices a change to the included file
+	os.WriteFile(filepath.Join("ci", "common.yml"), []byte("release:\n  stage: deploy\n  script: upload\n"), 0644)
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); !strings.Contains(string(data), "| release | deploy |") {
+		t.Errorf("expected the changed included job, got:\n%s", data)
+	}
+
+	if err := run(append(args, "--no-resolve-includes"), io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); strings.Contains(string(data), "| release |") {
+		t.Errorf("expected included jobs to be left out, got:\n%s", data)
+	}
+}
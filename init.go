@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sampleComponent is the component template written by `init` when the
+// templates directory holds none yet.
+const sampleComponent = `spec:
+  inputs:
+    stage:
+      description: "Pipeline stage the job runs in"
+      default: test
+    image:
+      description: "Container image used by the job"
+      default: alpine:latest
+    message:
+      description: "Message printed by the job (required: no default)"
+---
+%[1]s:
+  stage: $[[ inputs.stage ]]
+  image: $[[ inputs.image ]]
+  script:
+    - echo "$[[ inputs.message ]]"
+`
+
+// sampleDescription is the docs stub written for the sample component.
+const sampleDescription = `Describe what the %[1]s component does, when to use it and any
+prerequisites. This text is inserted in the generated README between the
+usage example and the inputs table.
+`
+
+// sampleConfig is the config file written by `init`.
+const sampleConfig = `# Settings for gitlab-component-docs-gen; flags and env vars take precedence.
+project_path: %[1]s
+# version: "1.0.0"       # defaults to the latest git tag
+# templates_dir: templates
+# docs_dir: docs
+# examples_dir: examples
+# format: markdown       # markdown, asciidoc, json or pdf
+# sort: required         # required, name or source
+`
+
+// scaffoldFile is a file created by `init` unless it already exists.
+type scaffoldFile struct {
+	path    string
+	content []byte
+}
+
+// runInit implements the `init` subcommand: it scaffolds a component
+// repository (templates/ with a sample component, a docs/ stub, the config
+// file and the README template). Existing files are never overwritten, and
+// the sample component is only added when templates/ holds no component yet.
+// Regular runs never create files besides the output.
+func runInit(args []string, stdout io.Writer) error {
+	flags := newFlagSet("init")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	projectPath := flags.String("project-path", "", "GitLab project path written to the config file (default: detected from git)")
+	component := flags.String("component", "my-component", "Name of the sample component")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "README template path to create (default depends on --format)")
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory for component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory for component descriptions (default \"docs\")")
+	flags.StringVar(&overrides.Format, "format", "", "Output format: "+strings.Join(outputFormats, ", ")+" (default \"markdown\")")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+
+	log := newLogger(stdout, logLevelFor(*quiet, false, false), "text")
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if !pathSegmentPattern.MatchString(*component) {
+		return withExitCode(exitConfig, fmt.Errorf("invalid component name %q", *component))
+	}
+
+	path := *projectPath
+	if path == "" {
+		path = detectGitProjectPath(resolveRemote(""))
+	}
+	if path == "" {
+		path = "my-group/my-project"
+	} else if path, err = normalizeProjectPath(path); err != nil {
+		return withExitCode(exitConfig, err)
+	}
+
+	var files []scaffoldFile
+	existing, err := findTemplates(settings.TemplatesDir, settings.ignoreRules())
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if len(existing) == 0 {
+		files = append(files,
+			scaffoldFile{filepath.Join(settings.TemplatesDir, *component+".yml"), []byte(fmt.Sprintf(sampleComponent, *component))},
+			scaffoldFile{filepath.Join(settings.DocsDir, *component+".md"), []byte(fmt.Sprintf(sampleDescription, *component))},
+		)
+	}
+	files = append(files, scaffoldFile{configFile, []byte(fmt.Sprintf(sampleConfig, path))})
+	if content, ok := defaultTemplates[settings.Format]; ok {
+		files = append(files, scaffoldFile{settings.Template, content})
+	}
+
+	for _, f := range files {
+		if dir := filepath.Dir(f.path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return withExitCode(exitWrite, fmt.Errorf("error creating %s: %w", dir, err))
+			}
+		}
+		created, err := ensureTemplate(f.path, f.content)
+		if err != nil {
+			return withExitCode(exitWrite, err)
+		}
+		if created {
+			log.Infof("Created %s", f.path)
+		} else {
+			log.Infof("%s already exists, leaving it untouched", f.path)
+		}
+	}
+	return nil
+}
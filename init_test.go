@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunInit(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"init", "--project-path", "group/project"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, file := range []string{"templates/my-component.yml", "docs/my-component.md", configFile, "README.md.tmpl"} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s to be created: %v", file, err)
+		}
+		if !strings.Contains(out.String(), "Created "+filepath.FromSlash(file)) {
+			t.Errorf("expected creation message for %s, got %q", file, out.String())
+		}
+	}
+	data, _ := os.ReadFile("README.md.tmpl")
+	if !bytes.Equal(data, defaultTemplate) {
+		t.Error("expected README.md.tmpl to hold the embedded default")
+	}
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		t.Fatalf("expected a valid config file: %v", err)
+	}
+	if config.ProjectPath != "group/project" {
+		t.Errorf("expected project_path 'group/project', got %q", config.ProjectPath)
+	}
+	scaffold, _ := os.ReadFile(configFile)
+	for _, format := range outputFormats {
+		if !strings.Contains(string(scaffold), format) {
+			t.Errorf("expected the config's format comment to list %s, got:\n%s", format, scaffold)
+		}
+	}
+
+	// The scaffold documents itself
+	component, err := parseTemplate("templates/my-component.yml", ParseOptions{DocsDir: "docs"})
+	if err != nil {
+		t.Fatalf("expected sample component to parse: %v", err)
+	}
+	if len(component.Inputs) != 3 || component.Description == "" {
+		t.Errorf("unexpected sample component: %+v", component)
+	}
+	if issues := validateComponent(component); len(issues) != 0 {
+		t.Errorf("expected sample component to validate cleanly, got %v", issues)
+	}
+
+	// Existing files are never overwritten
+	os.WriteFile("README.md.tmpl", []byte("custom"), 0644)
+	out.Reset()
+	if err := run([]string{"init"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md.tmpl"); string(data) != "custom" {
+		t.Errorf("expected existing template to be kept, got %q", data)
+	}
+	if !strings.Contains(out.String(), "README.md.tmpl already exists") {
+		t.Errorf("expected 'already exists' message, got %q", out.String())
+	}
+
+	if err := run([]string{"init", "--format", "asciidoc", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("README.adoc.tmpl"); err != nil {
+		t.Errorf("expected README.adoc.tmpl to be created: %v", err)
+	}
+}
+
+func TestRunInit_ExistingComponents(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"init", "--quiet", "--format", "json"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("templates", "my-component.yml")); !os.IsNotExist(err) {
+		t.Error("expected no sample component next to existing ones")
+	}
+	if _, err := os.Stat(configFile); err != nil {
+		t.Errorf("expected %s to be created: %v", configFile, err)
+	}
+
+	if got := exitCode(run([]string{"init", "--component", "../x"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an invalid component name, got %d", exitConfig, got)
+	}
+}
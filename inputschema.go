@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the emitted input schemas.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// InputSchema is the JSON Schema of a component's `include:inputs` block.
+type InputSchema struct {
+	Schema     string                         `json:"$schema"`
+	Title      string                         `json:"title"`
+	Type       string                         `json:"type"`
+	Properties map[string]InputSchemaProperty `json:"properties"`
+	Required   []string                       `json:"required,omitempty"`
+	Additional bool                           `json:"additionalProperties"`
+}
+
+// InputSchemaProperty describes one input: its type, allowed values and default.
+type InputSchemaProperty struct {
+	Type        string        `json:"type"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	Deprecated  bool          `json:"deprecated,omitempty"`
+	WriteOnly   bool          `json:"writeOnly,omitempty"`
+}
+
+// inputSchemaPath returns the file receiving a component's input schema.
+func inputSchemaPath(dir, name string) string {
+	return filepath.Join(dir, filepath.FromSlash(name)+".schema.json")
+}
+
+// componentInputSchema builds the JSON Schema of a component's inputs. Names,
+// descriptions, types and required flags come from the parsed component; the
+// template is read again for the values the docs only keep formatted:
+// defaults, options (as `enum`) and `regex` (as `pattern`). Deprecated inputs
+// get the `deprecated` annotation, sensitive ones `writeOnly` and no default.
+// GitLab rejects unknown inputs, so additional properties are not allowed.
+func componentInputSchema(c ComponentData) (InputSchema, error) {
+	decls, err := readInputDeclarations(c.path)
+	if err != nil {
+		return InputSchema{}, err
+	}
+
+	schema := InputSchema{
+		Schema:     jsonSchemaDialect,
+		Title:      fmt.Sprintf("Inputs of the %s component", c.Name),
+		Type:       "object",
+		Properties: make(map[string]InputSchemaProperty, len(c.Inputs)),
+	}
+	for _, input := range c.Inputs {
+		decl := decls[input.Name]
+		schema.Properties[input.Name] = InputSchemaProperty{
+			Type:        input.Type,
+			Description: input.Description,
+			Default:     decl.Default,
+			Enum:        decl.Options,
+			Pattern:     strings.TrimSuffix(strings.TrimPrefix(decl.Regex, "/"), "/"),
+			Deprecated:  input.Deprecated,
+		}
+		if input.Sensitive {
+			// Secrets are not published, and clients should not echo them
+			property := schema.Properties[input.Name]
+			property.Default, property.WriteOnly = nil, true
+			schema.Properties[input.Name] = property
+		}
+		if input.Required {
+			schema.Required = append(schema.Required, input.Name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema, nil
+}
+
+// readInputDeclarations decodes spec:inputs of a template.
+func readInputDeclarations(path string) (map[string]Inputs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading YAML file %s: %w", path, err)
+	}
+	if data, err = decodeSource(path, data); err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, yamlError(path, data, err)
+	}
+	resolveMergeKeys(file)
+	var config Config
+	if spec := specDocument(file); spec != nil {
+		if err := yaml.NodeToValue(spec, &config); err != nil {
+			return nil, yamlError(path, data, err)
+		}
+	}
+	return config.Spec.Inputs, nil
+}
+
+// writeInputSchemas writes the input schema of every component to dir, as
+// <name>.schema.json, and returns the files written. Errors carry their exit
+// code.
+func writeInputSchemas(dir string, components []ComponentData) ([]writtenFile, error) {
+	var written []writtenFile
+	for _, c := range components {
+		schema, err := componentInputSchema(c)
+		if err != nil {
+			return written, withExitCode(exitParse, err)
+		}
+		out, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return written, withExitCode(exitFailure, fmt.Errorf("error encoding the %s input schema: %w", c.Name, err))
+		}
+		path := inputSchemaPath(dir, c.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, withExitCode(exitWrite, fmt.Errorf("error creating %s: %w", filepath.Dir(path), err))
+		}
+		out = append(out, '\n')
+		status := writeStatus(path, out)
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return written, withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", path, err))
+		}
+		written = append(written, writtenFile{path, status})
+	}
+	return written, nil
+}
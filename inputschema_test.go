@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComponentInputSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "build.yml")
+	os.WriteFile(path, []byte(`spec:
+  inputs:
+    # Pipeline stage
+    stage:
+      default: build
+    tag:
+      regex: /^v\d+$/
+    level:
+      type: number
+      default: 2
+      options: [1, 2, 3]
+    debug:
+      type: boolean
+      default: false
+`), 0644)
+
+	c, err := parseTemplate(path, ParseOptions{TemplatesDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schema, err := componentInputSchema(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := json.Marshal(schema)
+
+	expected := map[string]string{
+		"stage": `{"type":"string","description":"Pipeline stage","default":"build"}`,
+		"tag":   `{"type":"string","pattern":"^v\\d+$"}`,
+		"level": `{"type":"number","default":2,"enum":[1,2,3]}`,
+		"debug": `{"type":"boolean","default":false}`,
+	}
+	for name, want := range expected {
+		got, _ := json.Marshal(schema.Properties[name])
+		if string(got) != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+	if strings.Join(schema.Required, ",") != "tag" {
+		t.Errorf("expected only tag to be required, got %v", schema.Required)
+	}
+	if !strings.Contains(string(out), `"additionalProperties":false`) {
+		t.Errorf("expected unknown inputs to be rejected, got %s", out)
+	}
+}
+
+func TestRun_EmitSchema(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "aws"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "aws", "deploy.yml"), []byte("spec:\n  inputs:\n    region: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--emit-schema", "schemas"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join("schemas", "aws", "deploy.schema.json"))
+	if err != nil {
+		t.Fatalf("expected the schema to be written: %v", err)
+	}
+	var schema InputSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	if schema.Schema != jsonSchemaDialect || schema.Properties["region"].Type != "string" || len(schema.Required) != 1 {
+		t.Errorf("unexpected schema: %s", data)
+	}
+
+	if got := exitCode(run([]string{"--quiet", "--emit-schema", "schemas", "--dry-run"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d with --dry-run, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Kinds of interface changes, in the order they are reported for an input.
+const (
+	changeComponentAdded   = "component_added"
+	changeComponentRemoved = "component_removed"
+	changeInputAdded       = "input_added"
+	changeInputRemoved     = "input_removed"
+	changeType             = "type_changed"
+	changeRequired         = "required_changed"
+	changeDefault          = "default_changed"
+	changeOptions          = "options_changed"
+)
+
+// failOnLevels are the values of `diff --fail-on`.
+var failOnLevels = []string{"none", "breaking", "feature", "any"}
+
+// InterfaceChange is a change of a component's interface between two
+// versions of the templates. Breaking changes can fail pipelines that
+// include the component: removals, new required inputs, type changes and
+// narrowed options; see changeImpact for the others.
+type InterfaceChange struct {
+	Component string `json:"component"`
+	Input     string `json:"input,omitempty"`
+	Kind      string `json:"kind"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+	Breaking  bool   `json:"breaking"`
+	Impact    string `json:"impact"` // patch, feature or breaking
+}
+
+func (c InterfaceChange) String() string {
+	var text string
+	input := "input " + codeSpan(c.Input)
+	switch c.Kind {
+	case changeComponentAdded:
+		text = "component added"
+	case changeComponentRemoved:
+		text = "component removed"
+	case changeInputAdded:
+		text = input + " added"
+		if c.After == "required" {
+			text += " (required)"
+		}
+	case changeInputRemoved:
+		text = input + " removed"
+	case changeRequired:
+		text = input + " is now " + c.After
+	default:
+		field := strings.TrimSuffix(c.Kind, "_changed")
+		text = fmt.Sprintf("%s %s changed from %s to %s", input, field, orNone(c.Before), orNone(c.After))
+	}
+	if c.Breaking {
+		text += " (breaking)"
+	}
+	return c.Component + ": " + text
+}
+
+// orNone returns value, or "none" when it is empty.
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// interfaceChanges compares the components before and after a change,
+// sorted by component then input name.
+func interfaceChanges(before, after []ComponentData) []InterfaceChange {
+	old := make(map[string]ComponentData, len(before))
+	for _, c := range before {
+		old[c.Name] = c
+	}
+	current := make(map[string]ComponentData, len(after))
+	for _, c := range after {
+		current[c.Name] = c
+	}
+	var changes []InterfaceChange
+	for _, name := range unionKeys(old, current) {
+		prev, wasThere := old[name]
+		c, isThere := current[name]
+		switch {
+		case !wasThere:
+			changes = append(changes, InterfaceChange{Component: name, Kind: changeComponentAdded})
+		case !isThere:
+			changes = append(changes, InterfaceChange{Component: name, Kind: changeComponentRemoved, Breaking: true})
+		default:
+			changes = append(changes, inputInterfaceChanges(prev, c)...)
+		}
+	}
+	for i := range changes {
+		changes[i].Impact = changeImpact(changes[i])
+	}
+	return changes
+}
+
+// inputInterfaceChanges compares the inputs of two versions of a component.
+func inputInterfaceChanges(before, after ComponentData) []InterfaceChange {
+	old := make(map[string]InputData, len(before.Inputs))
+	for _, input := range before.Inputs {
+		old[input.Name] = input
+	}
+	current := make(map[string]InputData, len(after.Inputs))
+	for _, input := range after.Inputs {
+		current[input.Name] = input
+	}
+	var changes []InterfaceChange
+	add := func(input, kind, beforeValue, afterValue string, breaking bool) {
+		changes = append(changes, InterfaceChange{Component: after.Name, Input: input, Kind: kind, Before: beforeValue, After: afterValue, Breaking: breaking})
+	}
+	for _, name := range unionKeys(old, current) {
+		prev, wasThere := old[name]
+		input, isThere := current[name]
+		switch {
+		case !wasThere:
+			add(name, changeInputAdded, "", requiredness(input.Required), input.Required)
+			continue
+		case !isThere:
+			add(name, changeInputRemoved, requiredness(prev.Required), "", true)
+			continue
+		}
+		if prev.Type != input.Type {
+			add(name, changeType, prev.Type, input.Type, true)
+		}
+		if prev.Required != input.Required {
+			add(name, changeRequired, requiredness(prev.Required), requiredness(input.Required), input.Required)
+		}
+		if prev.Default != input.Default {
+			add(name, changeDefault, prev.Default, input.Default, false)
+		}
+		if strings.Join(prev.Options, "\x00") != strings.Join(input.Options, "\x00") {
+			add(name, changeOptions, strings.Join(prev.Options, ", "), strings.Join(input.Options, ", "), optionsNarrowed(prev.Options, input.Options))
+		}
+	}
+	return changes
+}
+
+// requiredness describes whether an input is required.
+func requiredness(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
+
+// optionsNarrowed reports whether a value allowed by the before options is
+// rejected by the after ones (no options allow any value).
+func optionsNarrowed(before, after []string) bool {
+	if len(after) == 0 {
+		return false
+	}
+	if len(before) == 0 {
+		return true
+	}
+	for _, option := range before {
+		if !contains(after, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// componentsAt parses the selected templates as they are at a git ref. The
+// templates are extracted into a temporary directory, so their component
+// names match the working tree's.
+func componentsAt(ref string, settings Settings, jobs int) ([]ComponentData, error) {
+	listing, err := runGit("ls-tree", "-r", "--name-only", ref, "--", settings.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "gitlab-component-docs-gen-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	var templates []string
+	for _, path := range strings.Split(listing, "\n") {
+		if filepath.Ext(path) == ".yml" {
+			templates = append(templates, path)
+		}
+	}
+	// Ignored templates are left out, as they are from the working tree
+	var paths []string
+	for _, path := range settings.ignoreRules().filter(settings.TemplatesDir, templates) {
+		if !componentSelected(settings, componentName(settings.TemplatesDir, path), path) {
+			continue
+		}
+		content, err := exec.Command("git", "show", ref+":./"+path).Output()
+		if err != nil {
+			return nil, fmt.Errorf("git show %s:%s: %w", ref, path, err)
+		}
+		dest := filepath.Join(tmp, path)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(dest, content, 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, dest)
+	}
+	opts := settings.parseOptions()
+	opts.TemplatesDir = filepath.Join(tmp, settings.TemplatesDir)
+	opts.DocsDir, opts.ExamplesDir = filepath.Join(tmp, "docs"), filepath.Join(tmp, "examples")
+	opts.RootDir, opts.Log, opts.Cache = tmp, nil, nil
+	components, err := parseTemplates(paths, opts, jobs)
+	// Sensitive defaults are masked like the working tree's, or their
+	// masking would show up as a change revealing the secret
+	applySensitive(settings.SensitiveInputs, components)
+	return components, err
+}
+
+// workingComponents parses the selected templates of the working tree.
+func workingComponents(settings Settings, jobs int) ([]ComponentData, error) {
+	templates, err := findTemplates(settings.TemplatesDir, settings.ignoreRules())
+	if err != nil {
+		return nil, withExitCode(exitConfig, err)
+	}
+	components, err := parseSelected(settings, templates, jobs, newLogger(io.Discard, levelQuiet, "text"), nil)
+	if err != nil {
+		return nil, withExitCode(exitParse, err)
+	}
+	return components, nil
+}
+
+// InterfaceDiff is the `diff --format json` output.
+type InterfaceDiff struct {
+	Base     string            `json:"base"`
+	Head     string            `json:"head"`
+	Breaking int               `json:"breaking"`
+	Changes  []InterfaceChange `json:"changes"`
+	// Impact is the most disruptive impact of the changes, and the
+	// suggested bump the version part it calls for
+	Impact        string `json:"impact"`
+	SuggestedBump string `json:"suggested_bump"`
+	// BaseVersion is the release at the base ("" when unknown), and
+	// SuggestedVersion the next one
+	BaseVersion      string `json:"base_version,omitempty"`
+	SuggestedVersion string `json:"suggested_version,omitempty"`
+}
+
+// runDiff implements the `diff` subcommand: the interface changes of the
+// components between two git refs, or between a ref and the working tree.
+func runDiff(args []string, stdout io.Writer) error {
+	flags := newFlagSet("diff")
+	format := flags.String("format", "text", "Output format: text or json")
+	failOn := flags.String("fail-on", "none", "Exit with code 9 on changes of this level: "+strings.Join(failOnLevels, ", "))
+	expectVersion := flags.String("expect-version", "", "Exit with code 9 if releasing this version after the base's bumps less than the changes need")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.Var((*stringList)(&overrides.Include), "include", "Only compare components matching this glob (repeatable)")
+	flags.Var((*stringList)(&overrides.Exclude), "exclude", "Skip components matching this glob (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if flags.NArg() > 2 {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", flags.Arg(2)))
+	}
+	if *format != "text" && *format != "json" {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported format %q (expected one of: text, json)", *format))
+	}
+	if !contains(failOnLevels, *failOn) {
+		return withExitCode(exitConfig, fmt.Errorf("unsupported --fail-on %q (expected one of: %s)", *failOn, strings.Join(failOnLevels, ", ")))
+	}
+	if *jobs < 1 {
+		return withExitCode(exitConfig, fmt.Errorf("invalid --jobs %d: must be at least 1", *jobs))
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+
+	diff := InterfaceDiff{Base: resolveDiffBase(flags.Arg(0)), Head: flags.Arg(1)}
+	before, err := componentsAt(diff.Base, settings, *jobs)
+	if err != nil {
+		return withExitCode(exitParse, fmt.Errorf("error reading the components at %s: %w", diff.Base, err))
+	}
+	var after []ComponentData
+	if diff.Head != "" {
+		if after, err = componentsAt(diff.Head, settings, *jobs); err != nil {
+			return withExitCode(exitParse, fmt.Errorf("error reading the components at %s: %w", diff.Head, err))
+		}
+	} else if after, err = workingComponents(settings, *jobs); err != nil {
+		return err
+	}
+	diff.Changes = interfaceChanges(before, after)
+	for _, c := range diff.Changes {
+		if c.Breaking {
+			diff.Breaking++
+		}
+	}
+	diff.BaseVersion = refVersion(diff.Base)
+	diff.Impact = highestImpact(diff.Changes)
+	diff.SuggestedBump = suggestedBump(diff.BaseVersion, diff.Impact)
+	if diff.BaseVersion != "" {
+		diff.SuggestedVersion, _ = nextVersion(diff.BaseVersion, diff.SuggestedBump)
+	}
+
+	if err := writeInterfaceDiff(stdout, *format, diff); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing the diff: %w", err))
+	}
+	if *expectVersion != "" {
+		if diff.BaseVersion == "" {
+			return withExitCode(exitConfig, fmt.Errorf("--expect-version: no release tag found at %s", diff.Base))
+		}
+		if err := checkVersionBump(diff.BaseVersion, *expectVersion, diff.Changes); err != nil {
+			return withExitCode(exitChanged, err)
+		}
+	}
+	switch {
+	case *failOn == "breaking" && diff.Breaking > 0:
+		return withExitCode(exitChanged, fmt.Errorf("%d breaking interface change(s) since %s", diff.Breaking, diff.Base))
+	case *failOn == "feature" && diff.Impact != impactPatch:
+		return withExitCode(exitChanged, fmt.Errorf("interface changes of %s impact since %s", diff.Impact, diff.Base))
+	case *failOn == "any" && len(diff.Changes) > 0:
+		return withExitCode(exitChanged, fmt.Errorf("%d interface change(s) since %s", len(diff.Changes), diff.Base))
+	}
+	return nil
+}
+
+// writeInterfaceDiff prints the changes one per line, or as an InterfaceDiff.
+func writeInterfaceDiff(w io.Writer, format string, diff InterfaceDiff) error {
+	if format == "json" {
+		if diff.Changes == nil {
+			diff.Changes = []InterfaceChange{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	head := diff.Head
+	if head == "" {
+		head = "the working tree"
+	}
+	var b strings.Builder
+	if len(diff.Changes) == 0 {
+		fmt.Fprintf(&b, "No interface change from %s to %s\n", diff.Base, head)
+	} else {
+		for _, c := range diff.Changes {
+			fmt.Fprintln(&b, c)
+		}
+		fmt.Fprintf(&b, "%d change(s) from %s to %s, %d breaking\n", len(diff.Changes), diff.Base, head, diff.Breaking)
+	}
+	if diff.SuggestedVersion != "" {
+		fmt.Fprintf(&b, "Suggested version: %s (%s release after %s)\n", diff.SuggestedVersion, diff.SuggestedBump, diff.BaseVersion)
+	} else {
+		fmt.Fprintf(&b, "Suggested release: %s\n", diff.SuggestedBump)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterfaceChanges(t *testing.T) {
+	before := []ComponentData{
+		{Name: "build", Inputs: []InputData{
+			{Name: "stage", Type: "string", Default: "`build`"},
+			{Name: "image", Type: "string"},
+			{Name: "mode", Type: "string", Options: []string{"`fast`", "`safe`"}},
+			{Name: "level", Type: "string", Required: true},
+		}},
+		{Name: "lint"},
+	}
+	after := []ComponentData{
+		{Name: "build", Inputs: []InputData{
+			{Name: "stage", Type: "string", Default: "`test`"},
+			{Name: "mode", Type: "string", Options: []string{"`fast`"}},
+			{Name: "level", Type: "number", Default: "`1`"},
+			{Name: "token", Type: "string", Required: true},
+			{Name: "cache", Type: "boolean", Default: "`true`"},
+		}},
+		{Name: "deploy"},
+	}
+	var got []string
+	for _, c := range interfaceChanges(before, after) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"build: input `cache` added",
+		"build: input `image` removed (breaking)",
+		"build: input `level` type changed from string to number (breaking)",
+		"build: input `level` is now optional",
+		"build: input `level` default changed from none to `1`",
+		"build: input `mode` options changed from `fast`, `safe` to `fast` (breaking)",
+		"build: input `stage` default changed from `build` to `test`",
+		"build: input `token` added (required) (breaking)",
+		"deploy: component added",
+		"lint: component removed (breaking)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestOptionsNarrowed(t *testing.T) {
+	tests := []struct {
+		before, after []string
+		want          bool
+	}{
+		{nil, []string{"a"}, true},
+		{[]string{"a"}, nil, false},
+		{[]string{"a"}, []string{"a", "b"}, false},
+		{[]string{"a", "b"}, []string{"b"}, true},
+	}
+	for _, tt := range tests {
+		if got := optionsNarrowed(tt.before, tt.after); got != tt.want {
+			t.Errorf("optionsNarrowed(%v, %v) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestRun_Diff(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	git("tag", "v1.0.0")
+
+	var out bytes.Buffer
+	if err := run([]string{"diff", "main"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "No interface change from main to the working tree\nSuggested version: v1.0.1 (patch release after v1.0.0)\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: test\n    token:\n      description: Token\n"), 0644)
+	out.Reset()
+	if err := run([]string{"diff", "--fail-on", "any", "main"}, &out); exitCode(err) != exitChanged {
+		t.Errorf("expected exit code %d with --fail-on any, got %v", exitChanged, err)
+	}
+	want := "build: input `stage` default changed from build to test\nbuild: input `token` added (required) (breaking)\n2 change(s) from main to the working tree, 1 breaking\nSuggested version: v2.0.0 (major release after v1.0.0)\n"
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	git("commit", "-q", "-am", "token")
+	out.Reset()
+	if err := run([]string{"diff", "--format", "json", "--fail-on", "breaking", "v1.0.0", "HEAD"}, &out); exitCode(err) != exitChanged {
+		t.Errorf("expected exit code %d with --fail-on breaking, got %v", exitChanged, err)
+	}
+	var diff InterfaceDiff
+	if err := json.Unmarshal(out.Bytes(), &diff); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if diff.Base != "v1.0.0" || diff.SuggestedVersion != "v2.0.0" || diff.Impact != impactBreaking || diff.Head != "HEAD" || diff.Breaking != 1 || len(diff.Changes) != 2 || diff.Changes[1].Kind != changeInputAdded {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+
+	if got := exitCode(run([]string{"diff", "--expect-version", "v1.1.0", "v1.0.0", "HEAD"}, io.Discard)); got != exitChanged {
+		t.Errorf("expected exit code %d for a minor release of a breaking change, got %d", exitChanged, got)
+	}
+	if err := run([]string{"diff", "--expect-version", "v2.0.0", "v1.0.0", "HEAD"}, io.Discard); err != nil {
+		t.Errorf("expected a major release to be accepted, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: deploy\n    token:\n      description: Token\n"), 0644)
+	if err := run([]string{"diff", "--fail-on", "breaking", "HEAD"}, io.Discard); err != nil {
+		t.Errorf("expected no failure without breaking changes, got %v", err)
+	}
+	if got := exitCode(run([]string{"diff", "--fail-on", "major", "HEAD"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown level, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"diff", "no-such-ref"}, io.Discard)); got != exitParse {
+		t.Errorf("expected exit code %d for an unknown ref, got %d", exitParse, got)
+	}
+}
+
+func TestRun_DiffBase(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "deploy.yml"), []byte("spec:\n  inputs:\n    api_token:\n      default: not-a-secret\n"), 0644)
+	os.WriteFile(filepath.Join("templates", "next.draft.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(".gitlab-component-docs-gen.yml", []byte("ignore: [\"*.draft.yml\"]\n"), 0644)
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	// The base is read like the working tree: sensitive defaults are masked,
+	// ignored templates left out
+	var out bytes.Buffer
+	if err := run([]string{"diff", "main"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "not-a-secret") || !strings.HasPrefix(out.String(), "No interface change") {
+		t.Errorf("expected no change for a sensitive default or an ignored template, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// interpolationPattern matches a GitLab input interpolation block, e.g.
+	// $[[ inputs.app_name ]] or $[[ inputs.app_name | expand_vars | truncate(0,8) ]].
+	interpolationPattern = regexp.MustCompile(`\$\[\[\s*(.*?)\s*\]\]`)
+
+	// ciVariablePattern matches $VAR and ${VAR} CI/CD variable references.
+	ciVariablePattern = regexp.MustCompile(`\$(?:\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Interpolation is a parsed $[[ ... ]] block referencing an input.
+type Interpolation struct {
+	Raw       string   // the whole $[[ ... ]] block
+	Input     string   // referenced input name
+	Functions []string // applied functions, in order (e.g. "expand_vars", "truncate(0,8)")
+}
+
+// parseInterpolations returns the input interpolations found in s, in order
+// of appearance. Blocks that don't reference an input are skipped.
+func parseInterpolations(s string) []Interpolation {
+	var result []Interpolation
+	for _, m := range interpolationPattern.FindAllStringSubmatch(s, -1) {
+		parts := strings.Split(m[1], "|")
+		ref := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(ref, "inputs.") {
+			continue
+		}
+		interp := Interpolation{Raw: m[0], Input: strings.TrimPrefix(ref, "inputs.")}
+		for _, fn := range parts[1:] {
+			if fn = strings.TrimSpace(fn); fn != "" {
+				interp.Functions = append(interp.Functions, fn)
+			}
+		}
+		result = append(result, interp)
+	}
+	return result
+}
+
+// referencedInputs returns the sorted, unique input names interpolated in s.
+func referencedInputs(s string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, interp := range parseInterpolations(s) {
+		if !seen[interp.Input] {
+			seen[interp.Input] = true
+			names = append(names, interp.Input)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isExpression reports whether s references inputs or CI/CD variables, in
+// which case it is rendered verbatim as code instead of as plain text.
+func isExpression(s string) bool {
+	return interpolationPattern.MatchString(s) || ciVariablePattern.MatchString(s)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInterpolations(t *testing.T) {
+	s := `$[[ inputs.app_name ]]-$[[inputs.env|expand_vars | truncate(0,8)]]-$[[ component.name ]]`
+	got := parseInterpolations(s)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 interpolations, got %d: %+v", len(got), got)
+	}
+	if got[0].Input != "app_name" || len(got[0].Functions) != 0 || got[0].Raw != "$[[ inputs.app_name ]]" {
+		t.Errorf("unexpected first interpolation: %+v", got[0])
+	}
+	if got[1].Input != "env" || strings.Join(got[1].Functions, ";") != "expand_vars;truncate(0,8)" {
+		t.Errorf("unexpected second interpolation: %+v", got[1])
+	}
+}
+
+func TestReferencedInputs(t *testing.T) {
+	got := referencedInputs("$[[ inputs.b ]]/$[[ inputs.a ]]/$[[ inputs.b | expand_vars ]]")
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("expected [a b], got %v", got)
+	}
+	if got := referencedInputs("plain"); got != nil {
+		t.Errorf("expected no references, got %v", got)
+	}
+}
+
+func TestIsExpression(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"$[[ inputs.app_name ]]-cache", true},
+		{"$CI_COMMIT_SHA", true},
+		{"${CI_PROJECT_DIR}/build", true},
+		{"plain value", false},
+		{"costs $5", false},
+	}
+	for _, tt := range tests {
+		if got := isExpression(tt.value); got != tt.expected {
+			t.Errorf("isExpression(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
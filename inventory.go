@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dependency types found in component jobs.
+const (
+	dependencyImage   = "image"
+	dependencyInclude = "include"
+	dependencyScript  = "script"
+)
+
+// remoteScriptPattern finds URLs downloaded by curl or wget in job scripts.
+var remoteScriptPattern = regexp.MustCompile(`\b(?:curl|wget)\b[^|;&\n]*?(https?://[^\s'"|;&)]+)`)
+
+// DependencyData is an external artifact a component pulls in: a container
+// image, an include from outside the repository, or a script downloaded at
+// run time.
+type DependencyData struct {
+	Type string `json:"type"` // "image", "include" or "script"
+	Ref  string `json:"ref"`
+	Job  string `json:"job"` // job name, "default" or "include"
+}
+
+// componentDependencies inventories the images, external includes and remote
+// scripts referenced by the body documents, sorted by type, reference and job.
+// Input interpolations are replaced by the inputs' defaults when they have one.
+func componentDependencies(body []map[string]interface{}, defaults map[string]string) []DependencyData {
+	var deps []DependencyData
+	add := func(kind, ref, job string) {
+		ref = strings.TrimSpace(expandInputs(ref, defaults))
+		if ref != "" {
+			deps = append(deps, DependencyData{Type: kind, Ref: ref, Job: job})
+		}
+	}
+
+	for _, doc := range body {
+		for key, value := range doc {
+			if key == "include" {
+				for _, ref := range externalIncludes(value) {
+					add(dependencyInclude, ref, "include")
+				}
+				continue
+			}
+			if globalKeywords[key] && key != "default" {
+				continue
+			}
+			job, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if image := imageName(job["image"]); image != "" {
+				add(dependencyImage, image, key)
+			}
+			if services, ok := job["services"].([]interface{}); ok {
+				for _, service := range services {
+					if image := imageName(service); image != "" {
+						add(dependencyImage, image, key)
+					}
+				}
+			}
+			for _, section := range []string{"before_script", "script", "after_script"} {
+				for _, line := range scriptLines(job[section]) {
+					for _, m := range remoteScriptPattern.FindAllStringSubmatch(line, -1) {
+						add(dependencyScript, m[1], key)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Ref != b.Ref {
+			return a.Ref < b.Ref
+		}
+		return a.Job < b.Job
+	})
+	var unique []DependencyData
+	for i, d := range deps {
+		if i == 0 || d != deps[i-1] {
+			unique = append(unique, d)
+		}
+	}
+	return unique
+}
+
+// imageName returns the image of an `image:` or `services:` entry, which can
+// be a string or a map with a `name` key.
+func imageName(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// externalIncludes lists the includes that come from outside the repository:
+// remote URLs, other projects, components and GitLab templates.
+func externalIncludes(value interface{}) []string {
+	var entries []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		entries = v
+	default:
+		entries = []interface{}{v}
+	}
+
+	var refs []string
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			if strings.HasPrefix(e, "http://") || strings.HasPrefix(e, "https://") {
+				refs = append(refs, e)
+			}
+		case map[string]interface{}:
+			switch {
+			case e["remote"] != nil:
+				refs = append(refs, fmt.Sprintf("%v", e["remote"]))
+			case e["component"] != nil:
+				refs = append(refs, fmt.Sprintf("%v", e["component"]))
+			case e["template"] != nil:
+				refs = append(refs, fmt.Sprintf("template:%v", e["template"]))
+			case e["project"] != nil:
+				ref := fmt.Sprintf("%v", e["project"])
+				files := e["file"]
+				if list, ok := files.([]interface{}); ok && len(list) > 0 {
+					files = list[0]
+				}
+				if files != nil {
+					ref += fmt.Sprintf(":%v", files)
+				}
+				if e["ref"] != nil {
+					ref += fmt.Sprintf("@%v", e["ref"])
+				}
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// scriptLines flattens a script section (a string or nested lists of strings).
+func scriptLines(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Split(v, "\n")
+	case []interface{}:
+		var lines []string
+		for _, item := range v {
+			lines = append(lines, scriptLines(item)...)
+		}
+		return lines
+	}
+	return nil
+}
+
+// expandInputs replaces input interpolations without functions by the
+// input's default, leaving the others untouched.
+func expandInputs(s string, defaults map[string]string) string {
+	return interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		interps := parseInterpolations(match)
+		if len(interps) != 1 || len(interps[0].Functions) > 0 {
+			return match
+		}
+		if value, ok := defaults[interps[0].Input]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// scalarDefaults returns the defaults of inputs with a scalar default, as
+// substituted by GitLab when the component is included without inputs.
+func scalarDefaults(inputs map[string]Inputs) map[string]string {
+	defaults := make(map[string]string)
+	for name, input := range inputs {
+		switch input.Default.(type) {
+		case nil, []interface{}, map[string]interface{}:
+			continue
+		}
+		defaults[name] = fmt.Sprintf("%v", input.Default)
+	}
+	return defaults
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestComponentDependencies(t *testing.T) {
+	body, err := decodeBody([]byte(`spec:
+  inputs: {}
+---
+include:
+  - local: ci/common.yml
+  - remote: https://example.com/ci.yml
+  - project: group/shared
+    file: [/templates/a.yml, /templates/b.yml]
+    ref: v1
+  - component: $CI_SERVER_FQDN/org/lint/lint@2.0
+  - template: Security/SAST.gitlab-ci.yml
+default:
+  image: alpine:3.19
+build:
+  image:
+    name: $[[ inputs.image ]]
+  services:
+    - docker:24-dind
+    - name: postgres:16
+  before_script:
+    - wget -qO- https://get.example.com/install.sh | sh
+  script:
+    - |
+      echo start
+      curl -sSL "https://example.com/tool.tar.gz" -o tool.tar.gz
+    - echo https://not-downloaded.example.com
+test:
+  image: alpine:3.19
+  script: echo $[[ inputs.tag | truncate(0,8) ]]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := componentDependencies(body, map[string]string{"image": "kaniko:v1", "tag": "x"})
+	expected := []DependencyData{
+		{Type: "image", Ref: "alpine:3.19", Job: "default"},
+		{Type: "image", Ref: "alpine:3.19", Job: "test"},
+		{Type: "image", Ref: "docker:24-dind", Job: "build"},
+		{Type: "image", Ref: "kaniko:v1", Job: "build"},
+		{Type: "image", Ref: "postgres:16", Job: "build"},
+		{Type: "include", Ref: "$CI_SERVER_FQDN/org/lint/lint@2.0", Job: "include"},
+		{Type: "include", Ref: "group/shared:/templates/a.yml@v1", Job: "include"},
+		{Type: "include", Ref: "https://example.com/ci.yml", Job: "include"},
+		{Type: "include", Ref: "template:Security/SAST.gitlab-ci.yml", Job: "include"},
+		{Type: "script", Ref: "https://example.com/tool.tar.gz", Job: "build"},
+		{Type: "script", Ref: "https://get.example.com/install.sh", Job: "build"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d dependencies, got %d: %+v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("dependency %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	defaults := map[string]string{"image": "alpine", "tag": "3.19"}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$[[ inputs.image ]]:$[[ inputs.tag ]]", "alpine:3.19"},
+		{"$[[ inputs.missing ]]", "$[[ inputs.missing ]]"},
+		{"$[[ inputs.tag | truncate(0,1) ]]", "$[[ inputs.tag | truncate(0,1) ]]"},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := expandInputs(tt.input, defaults); got != tt.expected {
+			t.Errorf("expandInputs(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
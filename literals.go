@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// inputLiterals holds the source form of an input's default and options when
+// decoding would lose it.
+type inputLiterals struct {
+	Default    string
+	HasDefault bool
+	Options    map[int]string // option index -> literal
+}
+
+// scalarLiterals reads the spec header and returns, per input, the literal
+// form of defaults and options whose decoded value would not round-trip:
+// numbers in another notation (`0755`, `0x1F`, `1_000`, `1.0`, `.inf`),
+// booleans spelled differently (`True`) and values with a custom tag
+// (`!vault secret`). Documenting the source keeps what the author wrote
+// instead of silently coerced values.
+func scalarLiterals(file *ast.File) map[string]inputLiterals {
+	spec := mappingValue(specDocument(file), "spec")
+	inputs := mappingValue(spec, "inputs")
+
+	literals := make(map[string]inputLiterals)
+	for _, input := range mappingValues(inputs) {
+		var lit inputLiterals
+		if s, ok := literalOf(mappingValue(input.Value, "default")); ok {
+			lit.Default, lit.HasDefault = s, true
+		}
+		if seq, ok := unwrapAnchor(mappingValue(input.Value, "options")).(*ast.SequenceNode); ok {
+			for i, option := range seq.Values {
+				if s, ok := literalOf(option); ok {
+					if lit.Options == nil {
+						lit.Options = make(map[int]string)
+					}
+					lit.Options[i] = s
+				}
+			}
+		}
+		if lit.HasDefault || lit.Options != nil {
+			literals[input.Key.GetToken().Value] = lit
+		}
+	}
+	return literals
+}
+
+// mappingValues returns the key/value pairs of a mapping node (nil otherwise).
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := unwrapAnchor(node).(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node ast.Node, key string) ast.Node {
+	for _, mv := range mappingValues(node) {
+		if mv.Key.GetToken().Value == key {
+			return mv.Value
+		}
+	}
+	return nil
+}
+
+// unwrapAnchor returns the value of an anchored node.
+func unwrapAnchor(node ast.Node) ast.Node {
+	if anchor, ok := node.(*ast.AnchorNode); ok {
+		return anchor.Value
+	}
+	return node
+}
+
+// literalOf returns the source form of a scalar whose decoded value would lose
+// information. Plain strings, nulls and collections decode faithfully and are
+// left to the regular formatting.
+func literalOf(node ast.Node) (string, bool) {
+	switch n := unwrapAnchor(node).(type) {
+	case *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode, *ast.InfinityNode, *ast.NanNode:
+		return n.GetToken().Value, true
+	case *ast.TagNode:
+		tag := n.Start.Value
+		if strings.HasPrefix(tag, "!!") {
+			// Standard tags only force a type, which the decoded value reflects
+			return literalOf(n.Value)
+		}
+		value := n.Value.String()
+		if strings.Contains(value, "\n") {
+			return "", false
+		}
+		return codeSpan(tag + " " + value), true
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate_LiteralDefaults(t *testing.T) {
+	dir := t.TempDir()
+	yamlContent := `spec:
+  inputs:
+    on_flag:
+      default: on
+    yes_flag:
+      default: yes
+    bool_title:
+      default: True
+    mode:
+      default: 0755
+    hex:
+      default: 0x1F
+    big:
+      default: 1_000
+    ratio:
+      default: 1.0  # trailing comment
+    limit:
+      default: .inf
+    date:
+      default: 2024-01-02
+    timestamp:
+      default: 2024-01-02T10:00:00Z
+    quoted:
+      default: "0755"
+    forced:
+      default: !!str 012
+    secret:
+      default: !vault secret/path
+    anchored:
+      default: &mode 0644
+    perms:
+      default: 0644
+      options: [0644, 0755, "0600"]
+    plain:
+      default: 42
+`
+	path := filepath.Join(dir, "edge.yml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	component, err := parseTemplate(path, ParseOptions{SortOrder: "source"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"on_flag":    "on",
+		"yes_flag":   "yes",
+		"bool_title": "True",
+		"mode":       "0755",
+		"hex":        "0x1F",
+		"big":        "1_000",
+		"ratio":      "1.0",
+		"limit":      ".inf",
+		"date":       "2024-01-02",
+		"timestamp":  "2024-01-02T10:00:00Z",
+		"quoted":     "0755",
+		"forced":     "012",
+		"secret":     "`!vault secret/path`",
+		"anchored":   "0644",
+		"perms":      "0644",
+		"plain":      "42",
+	}
+	for _, input := range component.Inputs {
+		want, ok := expected[input.Name]
+		if !ok {
+			t.Errorf("unexpected input %q", input.Name)
+			continue
+		}
+		if input.Default != want {
+			t.Errorf("input %q: expected default %q, got %q", input.Name, want, input.Default)
+		}
+		if input.Required {
+			t.Errorf("input %q: expected optional input", input.Name)
+		}
+	}
+
+	perms := component.Inputs[len(component.Inputs)-2]
+	if strings.Join(perms.Options, ",") != "0644,0755,0600" {
+		t.Errorf("expected literal options [0644 0755 0600], got %v", perms.Options)
+	}
+	if issues := validateComponent(component); len(issues) != 0 {
+		t.Errorf("expected literal default to match literal options, got %v", issues)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// locales holds the built-in translations of the default templates' strings,
+// by language. Every locale defines every message of "en"; the inputs table
+// column titles use the column names as ids.
+var locales = map[string]messages{
+	"en": {
+		"inputs":                    "Inputs",
+		"inputs_count":              "%d inputs",
+		"examples":                  "Examples",
+		"jobs":                      "Jobs",
+		"job":                       "Job",
+		"stage":                     "Stage",
+		"requirements":              "Requirements",
+		"stages_note":               "Stages: %s (a pipeline defining its own `stages` must list them)",
+		"variables_to_provide":      "CI/CD variables to provide:",
+		"condition":                 "Condition",
+		"when":                      "When",
+		"required_variables":        "Required CI/CD variables",
+		"variable":                  "Variable",
+		"secret":                    "Secret",
+		"variables":                 "Variables",
+		"defined_in":                "Defined in",
+		"environment_variables":     "Environment variables",
+		"input":                     "Input",
+		"environment_variable":      "Environment variable",
+		"scope":                     "Scope",
+		"storage_impact":            "Storage impact",
+		"artifacts":                 "Artifacts",
+		"expires":                   "Expires",
+		"runner_requirements":       "Runner requirements",
+		"tags":                      "Tags",
+		"image":                     "Image",
+		"services":                  "Services",
+		"dind_note":                 "Jobs running Docker-in-Docker, which need a runner allowing privileged containers: %s",
+		"derived_defaults":          "Defaults derived from other inputs:",
+		"depends_on":                "%s depends on %s",
+		"from_mirror":               "From %s:",
+		"context":                   "Uses the component context",
+		"maintainers":               "Maintainers",
+		"deprecated":                "Deprecated",
+		"sensitive":                 "Sensitive",
+		"deprecated_component":      "This component is deprecated.",
+		"deprecated_component_note": "This component is deprecated: %s",
+		"name":                      "Name",
+		"description":               "Description",
+		"type":                      "Type",
+		"required":                  "Required",
+		"default":                   "Default",
+		"options":                   "Options",
+		"used_in":                   "Used in",
+	},
+	"it": {
+		"inputs":                    "Input",
+		"inputs_count":              "%d input",
+		"examples":                  "Esempi",
+		"jobs":                      "Job",
+		"job":                       "Job",
+		"stage":                     "Stage",
+		"requirements":              "Requisiti",
+		"stages_note":               "Stage: %s (una pipeline che definisce i propri `stages` deve elencarli)",
+		"variables_to_provide":      "Variabili CI/CD da fornire:",
+		"condition":                 "Condizione",
+		"when":                      "Quando",
+		"required_variables":        "Variabili CI/CD richieste",
+		"variable":                  "Variabile",
+		"secret":                    "Segreta",
+		"variables":                 "Variabili",
+		"defined_in":                "Definita in",
+		"environment_variables":     "Variabili d'ambiente",
+		"input":                     "Input",
+		"environment_variable":      "Variabile d'ambiente",
+		"scope":                     "Ambito",
+		"storage_impact":            "Impatto sullo storage",
+		"artifacts":                 "Artefatti",
+		"expires":                   "Scadenza",
+		"runner_requirements":       "Requisiti del runner",
+		"tags":                      "Tag",
+		"image":                     "Immagine",
+		"services":                  "Servizi",
+		"dind_note":                 "Job che usano Docker-in-Docker, che richiedono un runner con container privilegiati: %s",
+		"derived_defaults":          "Valori predefiniti derivati da altri input:",
+		"depends_on":                "%s dipende da %s",
+		"from_mirror":               "Da %s:",
+		"context":                   "Usa il contesto del componente",
+		"maintainers":               "Manutentori",
+		"deprecated":                "Deprecato",
+		"sensitive":                 "Sensibile",
+		"deprecated_component":      "Questo componente è deprecato.",
+		"deprecated_component_note": "Questo componente è deprecato: %s",
+		"name":                      "Nome",
+		"description":               "Descrizione",
+		"type":                      "Tipo",
+		"required":                  "Obbligatorio",
+		"default":                   "Predefinito",
+		"options":                   "Opzioni",
+		"used_in":                   "Usato in",
+	},
+	"de": {
+		"inputs":                    "Eingaben",
+		"inputs_count":              "%d Eingaben",
+		"examples":                  "Beispiele",
+		"jobs":                      "Jobs",
+		"job":                       "Job",
+		"stage":                     "Stage",
+		"requirements":              "Voraussetzungen",
+		"stages_note":               "Stages: %s (eine Pipeline mit eigenen `stages` muss sie auflisten)",
+		"variables_to_provide":      "Bereitzustellende CI/CD-Variablen:",
+		"condition":                 "Bedingung",
+		"when":                      "Wann",
+		"required_variables":        "Erforderliche CI/CD-Variablen",
+		"variable":                  "Variable",
+		"secret":                    "Geheim",
+		"variables":                 "Variablen",
+		"defined_in":                "Definiert in",
+		"environment_variables":     "Umgebungsvariablen",
+		"input":                     "Eingabe",
+		"environment_variable":      "Umgebungsvariable",
+		"scope":                     "Geltungsbereich",
+		"storage_impact":            "Speicherbedarf",
+		"artifacts":                 "Artefakte",
+		"expires":                   "Läuft ab",
+		"runner_requirements":       "Runner-Anforderungen",
+		"tags":                      "Tags",
+		"image":                     "Image",
+		"services":                  "Services",
+		"dind_note":                 "Jobs mit Docker-in-Docker, die einen Runner mit privilegierten Containern brauchen: %s",
+		"derived_defaults":          "Von anderen Eingaben abgeleitete Standardwerte:",
+		"depends_on":                "%s hängt von %s ab",
+		"from_mirror":               "Von %s:",
+		"context":                   "Verwendet den Komponentenkontext",
+		"maintainers":               "Maintainer",
+		"deprecated":                "Veraltet",
+		"sensitive":                 "Vertraulich",
+		"deprecated_component":      "Diese Komponente ist veraltet.",
+		"deprecated_component_note": "Diese Komponente ist veraltet: %s",
+		"name":                      "Name",
+		"description":               "Beschreibung",
+		"type":                      "Typ",
+		"required":                  "Erforderlich",
+		"default":                   "Standardwert",
+		"options":                   "Optionen",
+		"used_in":                   "Verwendet in",
+	},
+	"fr": {
+		"inputs":                    "Entrées",
+		"inputs_count":              "%d entrées",
+		"examples":                  "Exemples",
+		"jobs":                      "Jobs",
+		"job":                       "Job",
+		"stage":                     "Étape",
+		"requirements":              "Prérequis",
+		"stages_note":               "Étapes : %s (un pipeline définissant ses propres `stages` doit les lister)",
+		"variables_to_provide":      "Variables CI/CD à fournir :",
+		"condition":                 "Condition",
+		"when":                      "Quand",
+		"required_variables":        "Variables CI/CD requises",
+		"variable":                  "Variable",
+		"secret":                    "Secrète",
+		"variables":                 "Variables",
+		"defined_in":                "Définie dans",
+		"environment_variables":     "Variables d'environnement",
+		"input":                     "Entrée",
+		"environment_variable":      "Variable d'environnement",
+		"scope":                     "Portée",
+		"storage_impact":            "Impact sur le stockage",
+		"artifacts":                 "Artefacts",
+		"expires":                   "Expiration",
+		"runner_requirements":       "Exigences du runner",
+		"tags":                      "Tags",
+		"image":                     "Image",
+		"services":                  "Services",
+		"dind_note":                 "Jobs utilisant Docker-in-Docker, qui demandent un runner autorisant les conteneurs privilégiés : %s",
+		"derived_defaults":          "Valeurs par défaut dérivées d'autres entrées :",
+		"depends_on":                "%s dépend de %s",
+		"from_mirror":               "Depuis %s :",
+		"context":                   "Utilise le contexte du composant",
+		"maintainers":               "Mainteneurs",
+		"deprecated":                "Obsolète",
+		"sensitive":                 "Sensible",
+		"deprecated_component":      "Ce composant est obsolète.",
+		"deprecated_component_note": "Ce composant est obsolète : %s",
+		"name":                      "Nom",
+		"description":               "Description",
+		"type":                      "Type",
+		"required":                  "Obligatoire",
+		"default":                   "Défaut",
+		"options":                   "Options",
+		"used_in":                   "Utilisée dans",
+	},
+	"es": {
+		"inputs":                    "Entradas",
+		"inputs_count":              "%d entradas",
+		"examples":                  "Ejemplos",
+		"jobs":                      "Jobs",
+		"job":                       "Job",
+		"stage":                     "Etapa",
+		"requirements":              "Requisitos",
+		"stages_note":               "Etapas: %s (un pipeline que define sus propias `stages` debe incluirlas)",
+		"variables_to_provide":      "Variables CI/CD a proporcionar:",
+		"condition":                 "Condición",
+		"when":                      "Cuándo",
+		"required_variables":        "Variables CI/CD requeridas",
+		"variable":                  "Variable",
+		"secret":                    "Secreta",
+		"variables":                 "Variables",
+		"defined_in":                "Definida en",
+		"environment_variables":     "Variables de entorno",
+		"input":                     "Entrada",
+		"environment_variable":      "Variable de entorno",
+		"scope":                     "Ámbito",
+		"storage_impact":            "Impacto en el almacenamiento",
+		"artifacts":                 "Artefactos",
+		"expires":                   "Caduca",
+		"runner_requirements":       "Requisitos del runner",
+		"tags":                      "Etiquetas",
+		"image":                     "Imagen",
+		"services":                  "Servicios",
+		"dind_note":                 "Jobs que usan Docker-in-Docker, que necesitan un runner con contenedores privilegiados: %s",
+		"derived_defaults":          "Valores por defecto derivados de otras entradas:",
+		"depends_on":                "%s depende de %s",
+		"from_mirror":               "Desde %s:",
+		"context":                   "Usa el contexto del componente",
+		"maintainers":               "Responsables",
+		"deprecated":                "Obsoleto",
+		"sensitive":                 "Sensible",
+		"deprecated_component":      "Este componente está obsoleto.",
+		"deprecated_component_note": "Este componente está obsoleto: %s",
+		"name":                      "Nombre",
+		"description":               "Descripción",
+		"type":                      "Tipo",
+		"required":                  "Obligatoria",
+		"default":                   "Predeterminado",
+		"options":                   "Opciones",
+		"used_in":                   "Usada en",
+	},
+}
+
+// messages maps message ids to their text in a locale.
+type messages map[string]string
+
+// text returns the message with the given id, formatted with args if any.
+// Messages missing from m (e.g. of zero Settings) are taken from "en".
+func (m messages) text(id string, args ...interface{}) string {
+	text, ok := m[id]
+	if !ok {
+		text = locales["en"][id]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// localeNames returns the built-in locales, sorted.
+func localeNames() []string {
+	var names []string
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveMessages returns the messages of a locale with the `translations`
+// overrides applied. Overrides must name known message ids.
+func resolveMessages(locale string, overrides map[string]string) (messages, error) {
+	bundle, ok := locales[locale]
+	if !ok {
+		return nil, fmt.Errorf("unsupported locale %q (expected one of: %s)", locale, strings.Join(localeNames(), ", "))
+	}
+	resolved := make(messages, len(bundle))
+	for id, text := range bundle {
+		resolved[id] = text
+	}
+	var unknown []string
+	for id, text := range overrides {
+		if _, ok := bundle[id]; !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+		resolved[id] = text
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown translation id(s): %s", strings.Join(unknown, ", "))
+	}
+	return resolved, nil
+}
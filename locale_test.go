@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocales_Complete(t *testing.T) {
+	for name, bundle := range locales {
+		for id, text := range locales["en"] {
+			translated, ok := bundle[id]
+			if !ok || translated == "" {
+				t.Errorf("%s: missing message %q", name, id)
+			}
+			if strings.Count(translated, "%") != strings.Count(text, "%") {
+				t.Errorf("%s: message %q has different arguments than in en", name, id)
+			}
+		}
+		if len(bundle) != len(locales["en"]) {
+			t.Errorf("%s: %d messages, want %d", name, len(bundle), len(locales["en"]))
+		}
+	}
+}
+
+func TestResolveMessages(t *testing.T) {
+	msgs, err := resolveMessages("it", map[string]string{"inputs": "Parametri"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msgs.text("inputs") != "Parametri" || msgs.text("examples") != "Esempi" {
+		t.Errorf("expected the override and the Italian bundle, got %q and %q", msgs.text("inputs"), msgs.text("examples"))
+	}
+	if got := msgs.text("inputs_count", 12); got != "12 input" {
+		t.Errorf("unexpected formatted message %q", got)
+	}
+	if _, err := resolveMessages("pt", nil); err == nil {
+		t.Error("expected an error for an unknown locale")
+	}
+	if _, err := resolveMessages("en", map[string]string{"input_title": "x"}); err == nil {
+		t.Error("expected an error for an unknown translation id")
+	}
+	if got := messages(nil).text("jobs"); got != "Jobs" {
+		t.Errorf("expected English without messages, got %q", got)
+	}
+}
+
+func TestTableHeader(t *testing.T) {
+	msgs := locales["de"]
+	if got := tableHeader("markdown", msgs, []string{"job", "expires"}); got != "| Job | Läuft ab |\n|-----|----------|" {
+		t.Errorf("unexpected Markdown header %q", got)
+	}
+	if got := tableHeader("asciidoc", msgs, []string{"job", "expires"}); got != "| Job | Läuft ab" {
+		t.Errorf("unexpected AsciiDoc header %q", got)
+	}
+}
+
+func TestRun_Locale(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	for _, name := range []string{"build", "deploy"} {
+		os.WriteFile(filepath.Join(dir, "templates", name+".yml"), []byte("spec:\n  inputs:\n    stage:\n      description: Stage\n      default: test\n---\n"+name+":\n  stage: $[[ inputs.stage ]]\n  script: make\n"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, configFile), []byte("locale: de\ntranslations:\n  jobs: Aufträge\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	for _, want := range []string{
+		"  - [Eingaben](#eingaben)\n",
+		"### Eingaben\n\n| Name | Beschreibung | Typ | Erforderlich | Standardwert | Verwendet in |\n|------|--------------|-----|--------------|--------------|--------------|\n",
+		"### Aufträge\n\n| Job | Stage |\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0", "--locale", "fr", "--format", "asciidoc"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.Contains(string(data), "=== Entrées\n") {
+		t.Errorf("expected French headings, got:\n%s", data)
+	}
+
+	if got := exitCode(run([]string{"--quiet", "--locale", "xx"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown locale, got %d", exitConfig, got)
+	}
+}
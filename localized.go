@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localizedPath returns the variant of a file in another language: the
+// language code goes before the extension, README.md becoming README.it.md.
+func localizedPath(path, lang string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + lang + ext
+}
+
+// variantLocales returns the `locales` other than the README's, each of which
+// gets a README.<lang> variant.
+func variantLocales(locales []string, readme string) []string {
+	var variants []string
+	for _, lang := range locales {
+		if lang != readme && !contains(variants, lang) {
+			variants = append(variants, lang)
+		}
+	}
+	return variants
+}
+
+// firstLocale returns the first of the configured locales, the README's
+// language unless `locale` is set.
+func firstLocale(locales []string) string {
+	if len(locales) == 0 {
+		return ""
+	}
+	return locales[0]
+}
+
+// localizeComponents returns copies of the components whose prose comes
+// from their description in lang (docs/<name>.<lang>.md), where there is one.
+// Its title, maintainers and front-matter keys replace those of the main
+// description; inputs, jobs and examples are shared by every language.
+func localizeComponents(docsDir, format, lang string, components []ComponentData) ([]ComponentData, error) {
+	var exts []string
+	for _, ext := range descriptionExtensions(format) {
+		exts = append(exts, "."+lang+ext)
+	}
+	localized := make([]ComponentData, len(components))
+	for i, c := range components {
+		docs, err := loadComponentDocs(docsDir, c.Name, exts...)
+		if err != nil {
+			return nil, err
+		}
+		if docs.path != "" {
+			c.Description = docs.Description
+			if docs.Title != "" {
+				c.Title = docs.Title
+			}
+			if len(docs.Maintainers) > 0 {
+				c.Maintainers = docs.Maintainers
+			}
+			if len(docs.Meta) > 0 {
+				meta := make(map[string]interface{}, len(c.Meta)+len(docs.Meta))
+				for key, value := range c.Meta {
+					meta[key] = value
+				}
+				for key, value := range docs.Meta {
+					meta[key] = value
+				}
+				c.Meta = meta
+			}
+		}
+		localized[i] = c
+	}
+	return localized, nil
+}
+
+// renderLocalized renders the README variant of each of settings.Locales,
+// with the strings of its language and the localized descriptions. The
+// `translations` overrides are the README's, and don't apply. Header
+// and footer files have variants too (header.it.md), falling back to the
+// main ones.
+func renderLocalized(settings Settings, data TemplateData) ([]page, error) {
+	var pages []page
+	for _, lang := range settings.Locales {
+		variant := settings
+		variant.Locale = lang
+		messages, err := resolveMessages(lang, nil)
+		if err != nil {
+			return nil, err
+		}
+		variant.Messages = messages
+		variant.HeaderFile = localizedFile(settings.HeaderFile, lang)
+		variant.FooterFile = localizedFile(settings.FooterFile, lang)
+
+		localized := data
+		if localized.Components, err = localizeComponents(settings.DocsDir, settings.Format, lang, data.Components); err != nil {
+			return nil, err
+		}
+		doc, err := renderDocument(variant, localized)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering the %s variant: %w", lang, err)
+		}
+		pages = append(pages, page{Path: localizedPath(settings.Output, lang), Content: doc})
+	}
+	return pages, nil
+}
+
+// localizedFile returns the variant of path in lang when it exists, else
+// path itself.
+func localizedFile(path, lang string) string {
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(localizedPath(path, lang)); err == nil {
+		return localizedPath(path, lang)
+	}
+	return path
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalizedPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"README.md":        "README.it.md",
+		"docs/README.adoc": "docs/README.it.adoc",
+		"components.json":  "components.it.json",
+	} {
+		if got := localizedPath(path, "it"); got != want {
+			t.Errorf("localizedPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestLocalizeComponents(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "build.it.md"), []byte("---\ntitle: Compilazione\nteam: piattaforma\n---\nCompila il progetto.\n"), 0644)
+	components := []ComponentData{
+		{Name: "build", Description: "Builds the project.", Title: "Build", Maintainers: []string{"@alice"}, Meta: map[string]interface{}{"team": "platform", "tier": 1}},
+		{Name: "test", Description: "Runs the tests."},
+	}
+	localized, err := localizeComponents(dir, "markdown", "it", components)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	build := localized[0]
+	if build.Description != "Compila il progetto." || build.Title != "Compilazione" || build.Maintainers[0] != "@alice" {
+		t.Errorf("expected the Italian prose with the shared maintainers, got %+v", build)
+	}
+	if build.Meta["team"] != "piattaforma" || build.Meta["tier"] != 1 {
+		t.Errorf("expected the front-matter keys merged, got %v", build.Meta)
+	}
+	if localized[1].Description != "Runs the tests." {
+		t.Errorf("expected the main description without a translation, got %q", localized[1].Description)
+	}
+	if components[0].Description != "Builds the project." || components[0].Meta["team"] != "platform" {
+		t.Errorf("expected the components left untouched, got %+v", components[0])
+	}
+}
+
+func TestResolveSettings_Locales(t *testing.T) {
+	settings, err := resolveSettings(ProjectConfig{Locales: []string{"it", "en", "it"}}, Settings{})
+	if err != nil || settings.Locale != "it" || strings.Join(settings.Locales, ",") != "en" {
+		t.Errorf("expected an Italian README with an English variant, got %q %v, %v", settings.Locale, settings.Locales, err)
+	}
+	settings, _ = resolveSettings(ProjectConfig{Locale: "en", Locales: []string{"it", "en"}}, Settings{})
+	if settings.Locale != "en" || strings.Join(settings.Locales, ",") != "it" {
+		t.Errorf("expected locale to choose the README's language, got %q %v", settings.Locale, settings.Locales)
+	}
+	if _, err := resolveSettings(ProjectConfig{Locales: []string{"en", "xx"}}, Settings{}); err == nil {
+		t.Error("expected an unknown locale to be rejected")
+	}
+}
+
+func TestRun_Locales(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n---\nbuild:\n  stage: $[[ inputs.stage ]]\n  script: make\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "build.md"), []byte("Builds the project.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "build.it.md"), []byte("Compila il progetto.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("locales: [en, it]\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readme, _ := os.ReadFile("README.md")
+	variant, err := os.ReadFile("README.it.md")
+	if err != nil {
+		t.Fatalf("expected README.it.md to be written: %v", err)
+	}
+	if !strings.Contains(string(readme), "Builds the project.") || strings.Contains(string(readme), "Compila") {
+		t.Errorf("expected the English description in README.md, got:\n%s", readme)
+	}
+	if !strings.Contains(string(variant), "Compila il progetto.") || !strings.Contains(string(variant), "| stage |") {
+		t.Errorf("expected the Italian description and the shared inputs in README.it.md, got:\n%s", variant)
+	}
+	if string(readme) == strings.Replace(string(variant), "Compila il progetto.", "Builds the project.", 1) {
+		t.Error("expected the Italian strings of the default template in README.it.md")
+	}
+
+	if err := run(append(args, "--check"), io.Discard); err != nil {
+		t.Errorf("expected the variants to be up to date, got %v", err)
+	}
+	os.WriteFile(filepath.Join("docs", "build.it.md"), []byte("Compila tutto.\n"), 0644)
+	if code := exitCode(run(append(args, "--check"), io.Discard)); code != exitOutdated {
+		t.Errorf("expected exit code %d for an outdated variant, got %d", exitOutdated, code)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// logLevel is the minimum importance of the messages a logger prints.
+type logLevel int
+
+const (
+	levelDebug   logLevel = iota // every step, for troubleshooting
+	levelVerbose                 // files read and skipped, and why
+	levelInfo                    // outcome of the run (the default)
+	levelQuiet                   // nothing but errors (printed by main)
+)
+
+// levelNames names the levels in JSON logs; warnings use "warning".
+var levelNames = map[logLevel]string{
+	levelDebug:   "debug",
+	levelVerbose: "verbose",
+	levelInfo:    "info",
+}
+
+// logFormats lists the supported values of --log-format.
+var logFormats = []string{"text", "json"}
+
+// logger prints leveled messages as plain lines or as JSON objects (one per
+// line). It is safe for concurrent use; a nil logger discards everything.
+type logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level logLevel
+	json  bool
+	color bool // colorize prefixes and statuses of plain lines
+	now   func() time.Time
+}
+
+// newLogger returns a logger printing messages of at least level to w.
+func newLogger(w io.Writer, level logLevel, format string) *logger {
+	return &logger{w: w, level: level, json: format == "json", now: time.Now}
+}
+
+// logLevelFor maps the verbosity flags to a level; --quiet wins.
+func logLevelFor(quiet, verbose, debug bool) logLevel {
+	switch {
+	case quiet:
+		return levelQuiet
+	case debug:
+		return levelDebug
+	case verbose:
+		return levelVerbose
+	}
+	return levelInfo
+}
+
+// prefixStyles are the colors of the prefixes of plain lines.
+var prefixStyles = map[string]string{
+	"debug: ":   ansiMuted,
+	"warning: ": ansiYellow,
+}
+
+func (l *logger) print(level logLevel, name, prefix string, status fileStatus, format string, a ...interface{}) {
+	if l == nil || level < l.level {
+		return
+	}
+	msg := fmt.Sprintf(format, a...)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.json {
+		line, _ := json.Marshal(struct {
+			Time   string     `json:"time"`
+			Level  string     `json:"level"`
+			Status fileStatus `json:"status,omitempty"`
+			Msg    string     `json:"msg"`
+		}{l.now().UTC().Format(time.RFC3339), name, status, msg})
+		fmt.Fprintf(l.w, "%s\n", line)
+		return
+	}
+	if status != "" {
+		prefix = paint(l.color, statusStyles[status], fmt.Sprintf("%-9s", status)) + " "
+	} else {
+		prefix = paint(l.color, prefixStyles[prefix], prefix)
+	}
+	fmt.Fprintf(l.w, "%s%s\n", prefix, msg)
+}
+
+// Debugf logs a step of the run, shown with --debug.
+func (l *logger) Debugf(format string, a ...interface{}) {
+	l.print(levelDebug, levelNames[levelDebug], "debug: ", "", format, a...)
+}
+
+// Verbosef logs what was read or skipped, shown with --verbose.
+func (l *logger) Verbosef(format string, a ...interface{}) {
+	l.print(levelVerbose, levelNames[levelVerbose], "", "", format, a...)
+}
+
+// Infof logs the outcome of the run, hidden by --quiet.
+func (l *logger) Infof(format string, a ...interface{}) {
+	l.print(levelInfo, levelNames[levelInfo], "", "", format, a...)
+}
+
+// Warnf logs a problem that does not fail the run, hidden by --quiet.
+func (l *logger) Warnf(format string, a ...interface{}) {
+	l.print(levelInfo, "warning", "warning: ", "", format, a...)
+}
+
+// Status logs what the run did to a file, hidden by --quiet: the status
+// comes first, padded and colored in terminals, or in a field of its own in
+// JSON logs.
+func (l *logger) Status(status fileStatus, path string) {
+	l.print(levelInfo, levelNames[levelInfo], "", status, "%s", path)
+}
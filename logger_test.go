@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	tests := []struct {
+		level logLevel
+		want  string
+	}{
+		{levelDebug, "debug: d\nv\ni\nwarning: w\n"},
+		{levelVerbose, "v\ni\nwarning: w\n"},
+		{levelInfo, "i\nwarning: w\n"},
+		{levelQuiet, ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		log := newLogger(&out, tt.level, "text")
+		log.Debugf("d")
+		log.Verbosef("v")
+		log.Infof("i")
+		log.Warnf("w")
+		if out.String() != tt.want {
+			t.Errorf("level %d: expected %q, got %q", tt.level, tt.want, out.String())
+		}
+	}
+
+	// A nil logger discards everything
+	var log *logger
+	log.Infof("ignored")
+}
+
+func TestLogger_JSON(t *testing.T) {
+	var out bytes.Buffer
+	log := newLogger(&out, levelDebug, "json")
+	log.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	log.Warnf("careful: %d", 1)
+	if got := out.String(); got != `{"time":"2024-05-01T12:00:00Z","level":"warning","msg":"careful: 1"}`+"\n" {
+		t.Errorf("unexpected JSON log line: %q", got)
+	}
+}
+
+func TestLogLevelFor(t *testing.T) {
+	if got := logLevelFor(true, true, true); got != levelQuiet {
+		t.Errorf("expected --quiet to win, got %d", got)
+	}
+	if got := logLevelFor(false, true, true); got != levelDebug {
+		t.Errorf("expected --debug to win over --verbose, got %d", got)
+	}
+	if got := logLevelFor(false, false, false); got != levelInfo {
+		t.Errorf("expected info by default, got %d", got)
+	}
+}
+
+func TestRun_Verbose(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "internal.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "build.md"), []byte("Builds.\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"--verbose", "--project-path", "g/p", "--version", "1.0.0", "--exclude", "internal"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"Found 3 template(s) in templates",
+		"Skipping " + filepath.Join("templates", "internal.yml") + ": filtered out by include/exclude",
+		"build: description from " + filepath.Join("docs", "build.md"),
+		"deploy: no description (looked for ",
+		"created   README.md",
+		"Documentation generated: 1 created",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in verbose output, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "debug: ") {
+		t.Errorf("expected no debug messages with --verbose, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := run([]string{"--debug", "--log-format", "json", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	levels := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var entry struct{ Level, Msg string }
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		levels[entry.Level] = true
+	}
+	if !levels["debug"] || !levels["verbose"] || !levels["info"] {
+		t.Errorf("expected debug, verbose and info entries, got %v", levels)
+	}
+
+	if got := exitCode(run([]string{"--log-format", "xml"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown log format, got %d", exitConfig, got)
+	}
+}
+
+func TestLogger_Status(t *testing.T) {
+	var out bytes.Buffer
+	log := newLogger(&out, levelInfo, "text")
+	log.Status(statusCreated, "README.md")
+	log.color = true
+	log.Status(statusUnchanged, "docs/build.md")
+	log.Warnf("careful")
+	want := "created   README.md\n" + ansiMuted + "unchanged" + ansiReset + " docs/build.md\n" + ansiYellow + "warning: " + ansiReset + "careful\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+	log = newLogger(&out, levelInfo, "json")
+	log.color = true
+	log.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	log.Status(statusUpdated, "README.md")
+	if got := out.String(); got != `{"time":"2024-05-01T12:00:00Z","level":"info","status":"updated","msg":"README.md"}`+"\n" {
+		t.Errorf("unexpected JSON status line: %q", got)
+	}
+
+	out.Reset()
+	newLogger(&out, levelQuiet, "text").Status(statusCreated, "README.md")
+	if out.Len() != 0 {
+		t.Errorf("expected no status line with --quiet, got %q", out.String())
+	}
+}
@@ -4,184 +4,195 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
 )
 
 //go:embed README.md.tmpl
 var defaultTemplate []byte
 
+//go:embed README.adoc.tmpl
+var defaultAsciiDocTemplate []byte
+
+// defaultTemplates maps each template-based format to its embedded default.
+var defaultTemplates = map[string][]byte{
+	"markdown": defaultTemplate,
+	"asciidoc": defaultAsciiDocTemplate,
+	"pdf":      defaultTemplate,
+}
+
 // Struct representing YAML inputs
 type Inputs struct {
-	Description string      `yaml:"description"`
-	Default     interface{} `yaml:"default"`
+	Description string        `yaml:"description"`
+	Type        string        `yaml:"type"`
+	Default     interface{}   `yaml:"default"`
+	Options     []interface{} `yaml:"options"`
+	Regex       string        `yaml:"regex"`
 }
 
 type Spec struct {
 	Inputs map[string]Inputs `yaml:"inputs"`
+	// Component lists the context fields exposed as $[[ component.<field> ]]
+	Component []string `yaml:"component"`
 }
 
 type Config struct {
 	Spec Spec `yaml:"spec"`
 }
 
-// Struct representing template data
-type InputData struct {
-	Name        string
-	Description string
-	Required    bool
-	Default     string
-}
-
-type ComponentData struct {
-	Name        string
-	Description string
-	Inputs      []InputData
-}
-
-type TemplateData struct {
-	ProjectPath string
-	Version     string
-	Components  []ComponentData
+// orderedConfig decodes spec:inputs preserving declaration order
+type orderedConfig struct {
+	Spec struct {
+		Inputs yaml.MapSlice `yaml:"inputs"`
+	} `yaml:"spec"`
 }
 
-// ProjectConfig represents the optional config file .gitlab-component-docs-gen.yml
-type ProjectConfig struct {
-	ProjectPath string `yaml:"project_path"`
-	Version     string `yaml:"version"`
-}
-
-// resolveProjectPath determines the project path using priority:
-// 1. CLI flag --project-path
-// 2. Env var PROJECT_PATH
-// 3. Config file .gitlab-component-docs-gen.yml
-// 4. Git remote auto-detect
-// 5. Fallback placeholder
-func resolveProjectPath(flagValue string) string {
-	// 1. CLI flag
-	if flagValue != "" {
-		return flagValue
-	}
-
-	// 2. Env var
-	if envPath := os.Getenv("PROJECT_PATH"); envPath != "" {
-		return envPath
-	}
-
-	// 3. Config file
-	if configPath := readConfigProjectPath(); configPath != "" {
-		return configPath
-	}
+// Struct representing template data
+type InputData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Type is the declared type, or the one inferred from the default
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	Default  string `json:"default"`
+	// DefaultYAML is an array or map default as a YAML block
+	DefaultYAML string `json:"default_yaml,omitempty"`
+	// Options lists the allowed values, formatted like defaults
+	Options []string `json:"options,omitempty"`
+	// References lists the other inputs interpolated in the default
+	References []string `json:"references,omitempty"`
+	// UsedIn lists the jobs (or global keywords) interpolating the input
+	UsedIn []string `json:"used_in,omitempty"`
+	// Functions lists the function chains applied where the input is
+	// interpolated (e.g. "expand_vars | truncate(0,8)")
+	Functions []string `json:"functions,omitempty"`
 
-	// 4. Git remote
-	if gitPath := detectGitProjectPath(); gitPath != "" {
-		return gitPath
-	}
+	// Extensions holds the input's `x-` keys, without the prefix
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	// Deprecated is set by `x-deprecated` or the config's deprecations
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	DeprecationNote string `json:"deprecation_note,omitempty"`
+	// Group is set by `x-group` or the config's groups
+	Group string `json:"group,omitempty"`
+	// Sensitive is set by `x-sensitive` or a name matching `sensitive_inputs`;
+	// the default of a sensitive input is masked
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Shorthand is set when the input is declared without a body
+	// (`name:`), which makes it a required string input
+	Shorthand bool `json:"shorthand,omitempty"`
+	// Line and Column locate the input's key in the template (1-based)
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// SourceURL links to the declaration, when source links are enabled
+	SourceURL string `json:"source_url,omitempty"`
 
-	// 5. Fallback
-	return "<your-project-path>"
+	position    int // declaration order in spec:inputs
+	defaultNote int // footnote number of a default rendered below the table
 }
 
-func readConfigProjectPath() string {
-	data, err := os.ReadFile(".gitlab-component-docs-gen.yml")
-	if err != nil {
-		return ""
-	}
-	var config ProjectConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return ""
-	}
-	return config.ProjectPath
-}
+type ComponentData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Title, Maintainers, Examples and Meta come from the description's front-matter
+	Title       string                 `json:"title,omitempty"`
+	Maintainers []string               `json:"maintainers,omitempty"`
+	Examples    []ExampleData          `json:"examples,omitempty"`
+	Meta        map[string]interface{} `json:"meta,omitempty"`
+	// Comment is the comment block preceding `spec:` in the template
+	Comment string `json:"comment,omitempty"`
+	// Context lists the spec:component fields (e.g. name, version) the jobs use
+	Context []string    `json:"context,omitempty"`
+	Inputs  []InputData `json:"inputs"`
+	// Jobs lists the jobs defined after the spec header
+	Jobs    []JobData    `json:"jobs,omitempty"`
+	EnvVars []EnvVarData `json:"env_vars,omitempty"`
+	// Variables lists the CI/CD variables defined in `variables:` blocks
+	Variables []VariableData `json:"variables,omitempty"`
+	// Requirements lists the stages, CI/CD variables and rules the jobs depend on
+	Requirements *RequirementsData `json:"requirements,omitempty"`
+	// RequiredVariables lists the CI/CD variables declared in the config or
+	// in docs/<name>.env.example
+	RequiredVariables []RequiredVariableData `json:"required_variables,omitempty"`
+	// Artifacts lists the jobs uploading artifacts, for the storage impact note
+	Artifacts []ArtifactData `json:"artifacts,omitempty"`
+	// Dependencies lists the images, external includes and remote scripts used
+	Dependencies []DependencyData `json:"dependencies,omitempty"`
+	// Undeclared lists inputs interpolated in the jobs but missing from spec:inputs
+	Undeclared []string `json:"undeclared,omitempty"`
+	// HasBody is true when the template defines jobs after the spec header
+	HasBody bool `json:"-"`
+	// Deprecated is set by the config's deprecations
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	DeprecationNote string `json:"deprecation_note,omitempty"`
 
-func detectGitProjectPath() string {
-	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
-	if err != nil {
-		return ""
-	}
-	return parseGitRemoteURL(strings.TrimSpace(string(out)))
+	path     string         // template file
+	lines    map[string]int // input -> line of its declaration (or first use, if undeclared)
+	includes []string       // local files the template includes, when resolved
+	secrets  []Issue        // secret scan findings, made before sensitive defaults are masked
 }
 
-func parseGitRemoteURL(remote string) string {
-	// SSH: git@gitlab.com:group/project.git
-	if strings.Contains(remote, ":") && strings.Contains(remote, "@") {
-		parts := strings.SplitN(remote, ":", 2)
-		if len(parts) == 2 {
-			path := parts[1]
-			path = strings.TrimSuffix(path, ".git")
-			return path
+// HasReferences reports whether any input default depends on other inputs.
+func (c ComponentData) HasReferences() bool {
+	for _, input := range c.Inputs {
+		if len(input.References) > 0 {
+			return true
 		}
 	}
+	return false
+}
 
-	// HTTPS: https://gitlab.com/group/project.git
-	if strings.Contains(remote, "//") {
-		parts := strings.SplitN(remote, "//", 2)
-		if len(parts) == 2 {
-			// Remove host: gitlab.com/group/project.git -> group/project.git
-			slashIdx := strings.Index(parts[1], "/")
-			if slashIdx >= 0 {
-				path := parts[1][slashIdx+1:]
-				path = strings.TrimSuffix(path, ".git")
-				return path
-			}
+// HasOptions reports whether any input restricts its values with `options`.
+func (c ComponentData) HasOptions() bool {
+	for _, input := range c.Inputs {
+		if len(input.Options) > 0 {
+			return true
 		}
 	}
-
-	return ""
+	return false
 }
 
-// resolveVersion determines the version using priority:
-// 1. CLI flag --version
-// 2. Env var VERSION
-// 3. Config file .gitlab-component-docs-gen.yml
-// 4. Git tag auto-detect
-// 5. Fallback placeholder
-func resolveVersion(flagValue string) string {
-	if flagValue != "" {
-		return flagValue
-	}
-
-	if envVersion := os.Getenv("VERSION"); envVersion != "" {
-		return envVersion
-	}
-
-	if configVersion := readConfigVersion(); configVersion != "" {
-		return configVersion
-	}
-
-	if gitVersion := detectGitVersion(); gitVersion != "" {
-		return gitVersion
-	}
-
-	return "<version>"
+type MirrorData struct {
+	Name        string `json:"name"`
+	Server      string `json:"server"`
+	ProjectPath string `json:"project_path"`
 }
 
-func readConfigVersion() string {
-	data, err := os.ReadFile(".gitlab-component-docs-gen.yml")
-	if err != nil {
-		return ""
-	}
-	var config ProjectConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return ""
-	}
-	return config.Version
+type TemplateData struct {
+	// DataVersion is the version of this data model (templateDataVersion)
+	DataVersion   int             `json:"data_version"`
+	ProjectPath   string          `json:"project_path"`
+	Version       string          `json:"version"`
+	DefaultBranch string          `json:"default_branch,omitempty"`
+	Mirrors       []MirrorData    `json:"mirrors,omitempty"`
+	Components    []ComponentData `json:"components"`
 }
 
-func detectGitVersion() string {
-	out, err := exec.Command("git", "describe", "--tags", "--abbrev=0").Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(out))
+// ParseOptions controls how component templates are parsed. The zero value
+// uses the defaults: templates under templates/, Markdown descriptions from
+// docs/, examples from examples/ and required-first sorting.
+type ParseOptions struct {
+	TemplatesDir string // component names are derived from paths relative to it
+	DocsDir      string
+	ExamplesDir  string
+	SortOrder    string
+	Format       string
+	// ResolveIncludes merges the local files templates `include:` into
+	// their jobs, read relative to RootDir ("" is the working directory)
+	ResolveIncludes bool
+	RootDir         string
+	Log             *logger     // receives what was read and skipped; nil discards
+	Cache           *parseCache // reuses unchanged components; nil always parses
 }
 
 // formatDefault converts a default value to its string representation for documentation.
@@ -191,6 +202,15 @@ func formatDefault(val interface{}) string {
 	}
 	switch v := val.(type) {
 	case string:
+		// An empty default makes the input optional: show it, so it does not
+		// read like a required input's blank cell
+		if v == "" {
+			return codeSpan(`""`)
+		}
+		// Keep interpolations and variable references verbatim, as code
+		if isExpression(v) {
+			return codeSpan(v)
+		}
 		return v
 	case bool:
 		return fmt.Sprintf("%v", v)
@@ -199,59 +219,265 @@ func formatDefault(val interface{}) string {
 		if err != nil {
 			return fmt.Sprintf("%v", v)
 		}
-		return "`" + string(jsonBytes) + "`"
+		return codeSpan(string(jsonBytes))
 	default:
 		return fmt.Sprintf("%v", v)
 	}
 }
 
-// loadComponentDescription reads an optional docs/<name>.md file for a component
-func loadComponentDescription(name string) string {
-	data, err := os.ReadFile(filepath.Join("docs", name+".md"))
-	if err != nil {
-		return ""
+// inferType returns the GitLab input type matching a default's YAML type.
+// Inputs without a default are strings, as in GitLab.
+func inferType(val interface{}) string {
+	switch val.(type) {
+	case bool:
+		return "boolean"
+	case int, int64, uint64, float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
 	}
-	return strings.TrimSpace(string(data))
+	return "string"
+}
+
+// loadComponentDescription reads the description of a component from
+// <docsDir>/<name>.md (or <docsDir>/<name>/index.md), without front-matter.
+// When extensions are given, the first existing file wins.
+func loadComponentDescription(docsDir, name string, exts ...string) string {
+	docs, _ := loadComponentDocs(docsDir, name, exts...)
+	return docs.Description
+}
+
+// descriptionExtensions lists the description files looked up for a format:
+// AsciiDoc output prefers docs/<name>.adoc and falls back to Markdown.
+func descriptionExtensions(format string) []string {
+	if format == "asciidoc" {
+		return []string{".adoc", ".md"}
+	}
+	return []string{".md"}
+}
+
+// sortInputs orders inputs in place according to the given sort order:
+// "required" (required first, then by name), "name" or "source" (declaration order).
+// Every order falls back to the name so the result never depends on map iteration.
+func sortInputs(inputs []InputData, order string) {
+	sort.SliceStable(inputs, func(i, j int) bool {
+		switch order {
+		case "name":
+			return inputs[i].Name < inputs[j].Name
+		case "source":
+			if inputs[i].position != inputs[j].position {
+				return inputs[i].position < inputs[j].position
+			}
+			return inputs[i].Name < inputs[j].Name
+		default:
+			// Sort required first, then alphabetically by name
+			if inputs[i].Required != inputs[j].Required {
+				return inputs[i].Required
+			}
+			return inputs[i].Name < inputs[j].Name
+		}
+	})
 }
 
-func parseTemplate(path string) (ComponentData, error) {
+// sortComponents orders components by name (byte-wise, independent of locale
+// and of the order files were found in).
+func sortComponents(components []ComponentData) {
+	sort.SliceStable(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+}
+
+func parseTemplate(path string, opts ParseOptions) (ComponentData, error) {
+	if opts.TemplatesDir == "" {
+		opts.TemplatesDir = "templates"
+	}
+	if opts.DocsDir == "" {
+		opts.DocsDir = "docs"
+	}
+	if opts.ExamplesDir == "" {
+		opts.ExamplesDir = "examples"
+	}
+
+	opts.Log.Debugf("Parsing %s", path)
 	yamlFile, err := os.ReadFile(path)
 	if err != nil {
 		return ComponentData{}, fmt.Errorf("error reading YAML file %s: %w", path, err)
 	}
+	if yamlFile, err = decodeSource(path, yamlFile); err != nil {
+		return ComponentData{}, err
+	}
+
+	// Decode from the syntax tree, with anchors and merge keys resolved
+	file, err := parser.ParseBytes(yamlFile, parser.ParseComments)
+	if err != nil {
+		return ComponentData{}, yamlError(path, yamlFile, err)
+	}
+	resolveMergeKeys(file)
 
 	var config Config
-	err = yaml.Unmarshal(yamlFile, &config)
+	var ordered orderedConfig
+	if spec := specDocument(file); spec != nil {
+		if err := yaml.NodeToValue(spec, &config); err != nil {
+			return ComponentData{}, yamlError(path, yamlFile, err)
+		}
+		if err := yaml.NodeToValue(spec, &ordered); err != nil {
+			return ComponentData{}, yamlError(path, yamlFile, err)
+		}
+	}
+	body, err := bodyDocuments(file)
 	if err != nil {
-		return ComponentData{}, fmt.Errorf("error parsing YAML file %s: %w", path, err)
+		return ComponentData{}, yamlError(path, yamlFile, err)
+	}
+	// Interpolations belong to the file they are in: inputs are cross-checked
+	// against the template's own jobs, the rest is documented composed
+	own := body
+	var includes []string
+	if opts.ResolveIncludes {
+		if body, includes, err = resolveLocalIncludes(body, opts); err != nil {
+			return ComponentData{}, fmt.Errorf("error resolving includes of %s: %w", path, err)
+		}
+	}
+
+	literals := scalarLiterals(file)
+	blocks := defaultBlocks(file)
+	comments := inputComments(file)
+	locations := inputLocations(file)
+
+	positions := make(map[string]int)
+	shorthand := make(map[string]bool)
+	extensions := make(map[string]map[string]interface{})
+	for i, item := range ordered.Spec.Inputs {
+		key := fmt.Sprintf("%v", item.Key)
+		positions[key] = i
+		if raw, ok := item.Value.(map[string]interface{}); item.Value == nil || (ok && len(raw) == 0) {
+			shorthand[key] = true
+		}
+		if raw, ok := item.Value.(map[string]interface{}); ok {
+			ext, err := decodeExtensions(raw)
+			if err != nil {
+				if at, ok := locations[key]; ok {
+					return ComponentData{}, locatedError(path, yamlFile, at.Line, at.Column, fmt.Sprintf("input %q: %v", key, err))
+				}
+				return ComponentData{}, fmt.Errorf("error parsing input %q in %s: %w", key, path, err)
+			}
+			extensions[key] = ext
+		}
 	}
 
 	var inputs []InputData
 	for name, input := range config.Spec.Inputs {
+		lit := literals[name]
+		var options []string
+		for i, option := range input.Options {
+			if s, ok := lit.Options[i]; ok {
+				options = append(options, s)
+				continue
+			}
+			options = append(options, formatDefault(option))
+		}
+		defaultValue := formatDefault(input.Default)
+		if lit.HasDefault {
+			defaultValue = lit.Default
+		}
+		var references []string
+		if s, ok := input.Default.(string); ok {
+			references = referencedInputs(s)
+		}
+		description := input.Description
+		if description == "" {
+			description = comments[name]
+		}
+		inputType := input.Type
+		if inputType == "" {
+			inputType = inferType(input.Default)
+		}
+		// x-deprecated and x-group are modeled, not exposed as raw extensions
+		deprecation, _ := extensions[name][deprecationExtension].(Deprecation)
+		group, _ := extensions[name][groupExtension].(string)
+		delete(extensions[name], deprecationExtension)
+		delete(extensions[name], groupExtension)
+		if len(extensions[name]) == 0 {
+			delete(extensions, name)
+		}
 		inputs = append(inputs, InputData{
 			Name:        name,
-			Description: input.Description,
-			Required:    input.Default == nil,
-			Default:     formatDefault(input.Default),
+			Description: description,
+			Type:        inputType,
+			Required:    input.Default == nil, // no `default` key, or `default: null`
+			Default:     defaultValue,
+			DefaultYAML: blocks[name],
+			Options:     options,
+			References:  references,
+			Extensions:  extensions[name],
+			Shorthand:   shorthand[name],
+			Line:        locations[name].Line,
+			Column:      locations[name].Column,
+			position:    positions[name],
+
+			Deprecated:      deprecation.Deprecated,
+			DeprecationNote: deprecation.Note,
+			Group:           group,
 		})
 	}
 
-	sort.Slice(inputs, func(i, j int) bool {
-		// Sort required first, then alphabetically by name
-		if inputs[i].Required != inputs[j].Required {
-			return inputs[i].Required
+	sortInputs(inputs, opts.SortOrder)
+
+	// Cross-check interpolations in the jobs against the declared inputs
+	usages, functions := inputUsages(own), inputFunctions(own)
+	for i := range inputs {
+		inputs[i].UsedIn = usages[inputs[i].Name]
+		inputs[i].Functions = functions[inputs[i].Name]
+	}
+	var undeclared []string
+	for input := range usages {
+		if _, ok := config.Spec.Inputs[input]; !ok {
+			undeclared = append(undeclared, input)
 		}
-		return inputs[i].Name < inputs[j].Name
-	})
+	}
+	sort.Strings(undeclared)
 
-	// Derive component name from filename (without extension)
-	base := filepath.Base(path)
-	name := base[:len(base)-len(filepath.Ext(base))]
+	name := componentName(opts.TemplatesDir, path)
+	docs, err := loadComponentDocs(opts.DocsDir, name, descriptionExtensions(opts.Format)...)
+	if err != nil {
+		return ComponentData{}, err
+	}
+	if docs.path != "" {
+		opts.Log.Verbosef("%s: description from %s", name, docs.path)
+	} else {
+		opts.Log.Verbosef("%s: no description (looked for %s)", name, strings.Join(descriptionPaths(opts.DocsDir, name, descriptionExtensions(opts.Format)...), ", "))
+	}
+	examples, err := loadExamples(opts.ExamplesDir, name)
+	if err != nil {
+		return ComponentData{}, err
+	}
+	if len(examples) > 0 {
+		opts.Log.Verbosef("%s: %d example(s) from %s", name, len(examples), filepath.Join(opts.ExamplesDir, name))
+	}
 
+	jobs := componentJobs(body)
 	return ComponentData{
-		Name:        name,
-		Description: loadComponentDescription(name),
-		Inputs:      inputs,
+		Name:         name,
+		Description:  docs.Description,
+		Title:        docs.Title,
+		Maintainers:  docs.Maintainers,
+		Examples:     append(docs.Examples, examples...),
+		Meta:         docs.Meta,
+		Comment:      headerComment(yamlFile),
+		Context:      config.Spec.Component,
+		Inputs:       inputs,
+		Jobs:         jobs,
+		EnvVars:      envVarMappings(own),
+		Variables:    componentVariables(body),
+		Requirements: componentRequirements(body, jobs),
+		Artifacts:    artifactUsage(body),
+		Dependencies: componentDependencies(body, scalarDefaults(config.Spec.Inputs)),
+		Undeclared:   undeclared,
+		HasBody:      len(body) > 0,
+		path:         path,
+		lines:        inputLines(file, yamlFile, undeclared),
+		includes:     includes,
 	}, nil
 }
 
@@ -271,73 +497,618 @@ func ensureTemplate(path string, defaultContent []byte) (bool, error) {
 	return false, nil
 }
 
-func main() {
-	projectPath := flag.String("project-path", "", "GitLab project path (e.g. group/project)")
-	version := flag.String("version", "", "Component version (e.g. 1.0.0)")
-	flag.Parse()
+// Exit codes returned by the tool, so CI jobs can tell failure causes apart.
+const (
+	exitOK       = 0
+	exitFailure  = 1 // unexpected or unclassified error
+	exitConfig   = 2 // invalid flags or configuration
+	exitParse    = 3 // a component template could not be read or parsed
+	exitTemplate = 4 // the README template could not be parsed or executed
+	exitWrite    = 5 // the output (or default template) could not be written
+	exitInvalid  = 6 // --validate found errors in the component templates
+	exitOutdated = 7 // --check found the output out of date
+	exitNoInput  = 8 // no component templates were found
+	exitChanged  = 9 // diff found changes rejected by --fail-on
+)
+
+// exitError attaches an exit code to an error returned by run.
+type exitError struct {
+	code     int
+	err      error
+	reported bool // already reported to the user (e.g. in the --porcelain JSON)
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err so that main exits with the given code.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitCode maps an error returned by run to a process exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return exitFailure
+}
+
+// markReported wraps err so that main exits with its code without printing it again.
+func markReported(err error) error {
+	return &exitError{code: exitCode(err), err: err, reported: true}
+}
+
+// isReported reports whether err was already shown to the user.
+func isReported(err error) bool {
+	var ee *exitError
+	return errors.As(err, &ee) && ee.reported
+}
+
+// run dispatches the command-line arguments to a subcommand. Without one
+// (or when the first argument is a flag), the documentation is generated.
+// Informational messages go to stdout (unless --quiet), errors are returned.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runGenerate("generate", args, stdout)
+	}
+	if args[0] == "help" {
+		if len(args) > 1 {
+			return run([]string{args[1], "--help"}, stdout)
+		}
+		return runGenerate("generate", []string{"--help"}, stdout)
+	}
+	for _, cmd := range commandList() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout)
+		}
+	}
+	return withExitCode(exitConfig, fmt.Errorf("unknown command %q (run gitlab-component-docs-gen help for the list)", args[0]))
+}
+
+// runGenerate implements `generate` and its specialized forms `check` (fail
+// if the output is outdated) and `validate` (report template issues). Each
+// form only accepts the flags that apply to it. With --porcelain, stdout
+// receives a single JSON RunReport instead of messages.
+func runGenerate(command string, args []string, stdout io.Writer) error {
+	return runGenerateShared(command, args, stdout, nil)
+}
+
+// runGenerateShared is runGenerate with the parse cache of an enclosing run,
+// which loads and saves it: the units of a monorepo share one, so they don't
+// drop each other's entries. A nil cache means the run has its own.
+func runGenerateShared(command string, args []string, stdout io.Writer, shared *parseCache) (err error) {
+	var opts generateOptions
+	var overrides Settings
+	flags := newGenerateFlags(command, &opts, &overrides)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if err := opts.complete(command, flags.Args()); err != nil {
+		return err
+	}
 
-	// If README.md.tmpl doesn't exist, create it from the embedded default
-	created, err := ensureTemplate("README.md.tmpl", defaultTemplate)
+	// Monorepos: one run per documentation unit, unless the command line
+	// picks the templates, template or output itself
+	config, err := readProjectConfig(configFile)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return withExitCode(exitConfig, err)
 	}
-	if created {
-		fmt.Println("Created default README.md.tmpl")
+	applyBoolFlags(&config, overrides, flags)
+	if len(config.Units) > 0 && opts.source == "" && !opts.fromStdin && overrides.TemplatesDir == "" && overrides.Output == "" && overrides.Template == "" {
+		if overrides.Include != nil || overrides.Exclude != nil {
+			return withExitCode(exitConfig, errors.New("--include and --exclude cannot be combined with units; select units with --unit"))
+		}
+		if (opts.porcelain || opts.watch || opts.commit) && len(opts.unitNames) != 1 {
+			return withExitCode(exitConfig, errors.New("--porcelain, --watch and --commit handle a single unit; select it with --unit"))
+		}
+		var cache *parseCache
+		if !opts.noCache {
+			cache = loadParseCache(cacheFile)
+		}
+		err = runUnits(command, args, config, opts.unitNames, cache, stdout)
+		if !opts.dryRun && !opts.dataDump {
+			if saveErr := cache.save(); saveErr != nil {
+				newLogger(stdout, logLevelFor(opts.quiet, opts.verbose, opts.debug), opts.logFormat).Warnf("%v", saveErr)
+			}
+		}
+		return err
+	}
+	if len(opts.unitNames) > 0 && len(config.Units) == 0 {
+		return withExitCode(exitConfig, fmt.Errorf("--unit: %s defines no units", configFile))
+	}
+
+	// The preview owns stdout; informational messages would corrupt it
+	if opts.dryRun || opts.dataDump {
+		opts.quiet = true
 	}
 
-	// Find all templates in the templates/ directory
-	templates, err := filepath.Glob("templates/*.yml")
+	var report RunReport
+	if opts.porcelain {
+		opts.quiet = true
+		defer func() {
+			report.finish(err)
+			if werr := writeReport(stdout, report); werr != nil && err == nil {
+				err = withExitCode(exitWrite, werr)
+			}
+			if err != nil {
+				err = markReported(err)
+			}
+		}()
+	}
+
+	log := newLogger(stdout, logLevelFor(opts.quiet, opts.verbose, opts.debug), opts.logFormat)
+	log.color = colorEnabled(stdout, opts.noColor)
+	statuses := newRunStatuses(log)
+
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	// Bot pipelines: the push of --commit starts a pipeline of its own
+	if opts.commit {
+		bot, err := lastCommitByBot(settings.Commit.Author)
+		if err != nil {
+			return withExitCode(exitConfig, err)
+		}
+		if bot {
+			log.Infof("The last commit is the documentation bot's, skipping the run to avoid a commit loop")
+			return nil
+		}
+	}
+	if opts.normalize {
+		return runNormalize(settings, opts, flags.Args(), log)
+	}
+	// Remote source or stdin: document components from a temporary copy
+	var tempDir string
+	switch {
+	case opts.source != "":
+		settings, tempDir, err = prepareSource(settings, &opts, log)
+	case opts.fromStdin:
+		settings, tempDir, err = prepareStdin(settings, &opts)
+	}
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
+	if err != nil {
+		return err
+	}
+	log.Debugf("Settings: templates %s, docs %s, examples %s, template %s, output %s, format %s, sort %s",
+		settings.TemplatesDir, settings.DocsDir, settings.ExamplesDir, settings.Template, settings.Output, settings.Format, settings.SortOrder)
+	report.Format = settings.Format
+
+	// Editor integration: per-file diagnostics, once or continuously
+	if opts.watch || opts.diagnostics != "" {
+		return runDiagnostics(settings, opts, stdout)
+	}
+
+	// Find all templates in the templates directory
+	templates, err := findTemplates(settings.TemplatesDir, settings.ignoreRules())
 	if err != nil {
-		fmt.Printf("Error finding template files: %s\n", err)
-		return
+		return withExitCode(exitConfig, err)
 	}
 
 	if len(templates) == 0 {
-		fmt.Println("No template files found in templates/")
-		return
+		return withExitCode(exitNoInput, diagnoseNoTemplates(settings))
+	}
+	log.Verbosef("Found %d template(s) in %s", len(templates), settings.TemplatesDir)
+
+	if opts.changedOnly {
+		var done bool
+		if templates, done, err = filterChanged(settings, opts, templates, log); err != nil || done {
+			return err
+		}
+	}
+	if opts.hook {
+		if affected, err := hookAffected(settings, templates, log); err != nil || !affected {
+			return err
+		}
+	}
+
+	// Parse all templates, skipping filtered-out components
+	cache := shared
+	if cache == nil && !opts.noCache {
+		cache = loadParseCache(cacheFile)
+	}
+	components, err := parseSelected(settings, templates, opts.jobs, log, cache)
+	var parseErrs ParseErrors
+	if err != nil {
+		if !opts.keepGoing || !errors.As(err, &parseErrs) {
+			return withExitCode(exitParse, err)
+		}
+		log.Warnf("%d template(s) failed to parse, documenting the other components", len(parseErrs))
+		for _, e := range parseErrs {
+			statuses.add(statusSkipped, e.Path)
+		}
+		// The run still fails once the valid components are documented
+		defer func() {
+			if err == nil {
+				err = withExitCode(exitParse, parseErrs)
+			}
+		}()
+	}
+	if !opts.dryRun && !opts.dataDump && shared == nil {
+		if err := cache.save(); err != nil {
+			log.Warnf("%v", err)
+		}
+	}
+	for _, c := range components {
+		report.Components = append(report.Components, c.Name)
+	}
+
+	if opts.validate {
+		return runValidation(settings, opts, components, &report, stdout, log)
+	}
+
+	templateData, err := resolveTemplateData(settings, config, &opts, components, stdout, log)
+	if err != nil {
+		return err
+	}
+	report.ProjectPath = templateData.ProjectPath
+	report.Version = templateData.Version
+
+	if opts.dataDump {
+		if err := writeTemplateData(stdout, templateData); err != nil {
+			return withExitCode(exitWrite, err)
+		}
+		return nil
+	}
+
+	// The document is only rendered in memory when it is compared to the
+	// current one; otherwise it streams from the template to its destination
+	var doc []byte
+	rendered := opts.check || opts.hook || settings.Backup || (opts.dryRun && opts.showDiff && !opts.porcelain)
+	if rendered {
+		if doc, err = renderDocument(settings, templateData); err != nil {
+			return withExitCode(exitTemplate, err)
+		}
 	}
 
-	sort.Strings(templates)
+	if opts.dryRun {
+		return runPreview(settings, opts, templateData, doc, stdout)
+	}
+	if opts.check {
+		return runCheck(settings, templateData, doc, &report, log)
+	}
+	if opts.hook {
+		if current, err := os.ReadFile(settings.Output); err == nil && sameDocument(current, doc) {
+			log.Infof("%s is up to date", settings.Output)
+			return nil
+		}
+	}
 
-	// Parse all templates
-	var components []ComponentData
-	for _, t := range templates {
-		component, err := parseTemplate(t)
+	// Write the documentation file
+	if rendered {
+		status := writeStatus(settings.Output, doc)
+		if err := writeOutput(settings.Output, doc, settings.Backup); err != nil {
+			return withExitCode(exitWrite, err)
+		}
+		statuses.add(status, settings.Output)
+	} else {
+		status, err := streamOutput(settings.Output, settings, templateData)
 		if err != nil {
-			fmt.Printf("%s\n", err)
-			return
+			return err
+		}
+		statuses.add(status, settings.Output)
+	}
+	report.Output = settings.Output
+
+	variantPaths, err := writeExtraOutputs(settings, opts, templateData, statuses, log)
+	if err != nil {
+		return err
+	}
+
+	// Pre-commit hook: the regenerated output is staged for the next attempt
+	if opts.hook {
+		if err := stageFile(settings.Output); err != nil {
+			return withExitCode(exitWrite, err)
 		}
-		components = append(components, component)
+		return withExitCode(exitOutdated, fmt.Errorf("%s was out of date: regenerated and staged it, review it and commit again", settings.Output))
+	}
+	if opts.commit {
+		if err := runCommit(settings, opts, variantPaths, log); err != nil {
+			return err
+		}
+	}
+	if settings.Webhook.URL != "" {
+		runWebhook(settings, opts, templateData, log)
 	}
 
-	templateData := TemplateData{
-		ProjectPath: resolveProjectPath(*projectPath),
-		Version:     resolveVersion(*version),
-		Components:  components,
+	if parseErrs != nil {
+		log.Infof("Documentation generated (%s), without the templates that failed to parse", statuses.summary())
+		return nil
 	}
+	log.Infof("Documentation generated: %s", statuses.summary())
+	return nil
+}
 
-	// Read the template file
-	tmpl, err := template.ParseFiles("README.md.tmpl")
+// collectTemplateData parses the selected components and resolves the
+// project path, version and mirrors like a regular run, without prompting.
+// Errors carry their exit code. It is used by the subcommands that render
+// or inspect the documentation.
+func collectTemplateData(settings Settings, mirrorConfigs []MirrorConfig, projectPath, version, remote string, jobs int, log *logger) (TemplateData, error) {
+	templates, err := findTemplates(settings.TemplatesDir, settings.ignoreRules())
 	if err != nil {
-		fmt.Printf("Error reading template file: %s\n", err)
-		return
+		return TemplateData{}, withExitCode(exitConfig, err)
+	}
+	if len(templates) == 0 {
+		return TemplateData{}, withExitCode(exitNoInput, diagnoseNoTemplates(settings))
 	}
 
-	// Execute the template with data
-	var doc bytes.Buffer
-	err = tmpl.Execute(&doc, templateData)
+	components, err := parseSelected(settings, templates, jobs, log, nil)
+	if err != nil {
+		return TemplateData{}, withExitCode(exitParse, err)
+	}
+	data, err := resolveProject(mirrorConfigs, projectPath, version, remote)
 	if err != nil {
-		fmt.Printf("Error executing template: %s\n", err)
-		return
+		return TemplateData{}, err
 	}
+	data.Components = components
+	if settings.SourceLinks {
+		linkSources(data.Components, sourceBaseURL(remote, data.ProjectPath, data.DefaultBranch), settings.Output)
+	}
+	return data, nil
+}
 
-	// Write the documentation file
-	err = os.WriteFile("README.md", doc.Bytes(), 0644)
+// resolveProject returns the template data without components: the project
+// path, version, default branch and mirrors, resolved like a regular run.
+func resolveProject(mirrorConfigs []MirrorConfig, projectPath, version, remote string) (TemplateData, error) {
+	mirrors, err := resolveMirrors(mirrorConfigs)
 	if err != nil {
-		fmt.Printf("Error writing Markdown file: %s\n", err)
-		return
+		return TemplateData{}, withExitCode(exitConfig, err)
+	}
+	resolvedPath := resolveProjectPath(projectPath, remote)
+	if resolvedPath != projectPathPlaceholder {
+		if resolvedPath, err = normalizeProjectPath(resolvedPath); err != nil {
+			return TemplateData{}, withExitCode(exitConfig, err)
+		}
 	}
+	return TemplateData{
+		ProjectPath:   resolvedPath,
+		Version:       resolveVersion(version, remote),
+		DefaultBranch: resolveDefaultBranch(remote),
+		Mirrors:       mirrors,
+	}, nil
+}
+
+// renderDocument produces the output document in the configured format.
+func renderDocument(settings Settings, data TemplateData) ([]byte, error) {
+	var out bytes.Buffer
+	if err := renderDocumentTo(&out, settings, data); err != nil {
+		return nil, err
+	}
+	if out.Len() == 0 {
+		return nil, nil
+	}
+	return out.Bytes(), nil
+}
+
+// renderDocumentTo writes the output document in the configured format to w
+// as the template produces it: the template's output goes through the table
+// of contents and normalization line by line on its way, instead of being
+// rendered in full and copied at each step. PDF and JSON are encoded whole.
+// On an error, w may have received part of the document.
+func renderDocumentTo(w io.Writer, settings Settings, data TemplateData) error {
+	data.DataVersion = templateDataVersion
+	if settings.Format == "pdf" {
+		settings.Format = "markdown"
+		doc, err := renderDocument(settings, data)
+		if err != nil {
+			return err
+		}
+		pdf, err := renderPDF(doc, pdfTitle(data))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(pdf)
+		return err
+	}
+	if settings.Format == "json" {
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+		_, err = w.Write(append(out, '\n'))
+		return err
+	}
+
+	// Read the template file, falling back to the embedded default when it
+	// doesn't exist (it is only written to disk by the init subcommand)
+	content, err := os.ReadFile(settings.Template)
+	if os.IsNotExist(err) && defaultTemplates[settings.Format] != nil {
+		content, err = defaultTemplates[settings.Format], nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading template file: %w", err)
+	}
+	if content, err = decodeSource(settings.Template, content); err != nil {
+		return err
+	}
+	// `override` renders a component with its own template, if any; those
+	// templates get the same functions, but can't nest overrides
+	funcs, overrideFuncs := templateFuncs(settings), templateFuncs(settings)
+	overrideFuncs["override"] = func(interface{}) string { return "" }
+	funcs["override"] = func(c ComponentData) (string, error) {
+		return renderOverride(settings, data, c, overrideFuncs)
+	}
+	tmpl, err := newTemplate(filepath.Base(settings.Template), string(content), funcs, settings.Format, settings.PartialsDir)
+	if err != nil {
+		return fmt.Errorf("error parsing template file: %w", err)
+	}
+	applyFieldAliases(tmpl, funcs, reflect.TypeOf(data))
+
+	// Execute the template with data, between the header and footer files
+	normalized := newNormalizer(w)
+	err = wrapDocument(normalized, settings.HeaderFile, settings.FooterFile, func(w io.Writer) error {
+		toc := newTOCWriter(w, settings.Messages.text("inputs"))
+		if err := tmpl.Execute(toc, data); err != nil {
+			return fmt.Errorf("error executing template: %w", err)
+		}
+		return toc.Close()
+	})
+	if err != nil {
+		return err
+	}
+	if settings.Footer {
+		io.WriteString(normalized, generatedFooter())
+	}
+	return normalized.Close()
+}
 
-	fmt.Println("Documentation generated successfully!")
+// streamDocument is renderDocumentTo for run's commands: errors carry their
+// exit code, exitWrite when w fails and exitTemplate otherwise.
+func streamDocument(w io.Writer, settings Settings, data TemplateData) error {
+	dest := &recordingWriter{w: w}
+	if err := renderDocumentTo(dest, settings, data); err != nil {
+		if dest.err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing the document: %w", dest.err))
+		}
+		return withExitCode(exitTemplate, err)
+	}
+	return nil
+}
+
+// recordingWriter remembers the first error of the writer it wraps, telling
+// output errors apart from template errors once they come back wrapped.
+type recordingWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (r *recordingWriter) Write(p []byte) (int, error) {
+	n, err := r.w.Write(p)
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// wrapDocument writes the content of headerFile, then what body writes, then
+// the content of footerFile (each file optional) to w, separated by blank
+// lines. Both files are read before anything is written, so a missing one
+// leaves w untouched.
+func wrapDocument(w io.Writer, headerFile, footerFile string, body func(w io.Writer) error) error {
+	read := func(path string) ([]byte, error) {
+		if path == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		if data, err = decodeSource(path, data); err != nil {
+			return nil, err
+		}
+		return bytes.Trim(data, "\n"), nil
+	}
+	header, err := read(headerFile)
+	if err != nil {
+		return err
+	}
+	footer, err := read(footerFile)
+	if err != nil {
+		return err
+	}
+
+	if len(header) > 0 {
+		if _, err := w.Write(append(header, "\n\n"...)); err != nil {
+			return err
+		}
+	}
+	if err := body(w); err != nil {
+		return err
+	}
+	if len(footer) > 0 {
+		if _, err := w.Write(append(append([]byte("\n\n"), footer...), '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// codeList formats values as a comma-separated list of inline code spans.
+// Values already formatted as a code span (e.g. expressions) are kept as is.
+func codeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		if isCodeSpan(v) {
+			quoted[i] = v
+			continue
+		}
+		quoted[i] = codeSpan(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// templateFuncs returns the helper functions available to README templates.
+func templateFuncs(settings Settings) template.FuncMap {
+	toc, cell := tocFunc, markdownCell
+	if settings.Format == "asciidoc" {
+		// AsciiDoc processors build the TOC themselves (requires `:toc: macro`)
+		toc = func() string { return "toc::[]" }
+		cell = asciidocCell
+	}
+	funcs := template.FuncMap{
+		"toc":      toc,
+		"codeList": codeList,
+		"cell":     cell,
+		"columns": func(c ComponentData) []string {
+			return componentColumns(settings.Columns, c)
+		},
+		"inputGroups": func(c ComponentData) []InputGroup {
+			return inputGroups(c, settings.Groups.Order)
+		},
+		"columnTitle": func(column string) string { return settings.Messages.text(column) },
+		"columnRule":  func(column string) string { return columnRule(settings.Messages.text(column)) },
+		"inputCell": func(input InputData, column string) string {
+			return inputCell(input, column, cell, templateDialect(settings), settings.Messages)
+		},
+		"defaultNotes": func(c ComponentData) string {
+			return defaultNotes(templateDialect(settings), c, settings.Groups.Order)
+		},
+		"t": func(id string, args ...interface{}) (string, error) {
+			if _, ok := locales["en"][id]; !ok {
+				return "", fmt.Errorf("unknown message %q", id)
+			}
+			return settings.Messages.text(id, args...), nil
+		},
+		"tableHeader": func(ids ...string) string {
+			return tableHeader(settings.Format, settings.Messages, ids)
+		},
+		"pipelineDiagram": func(c ComponentData) string {
+			if !settings.PipelineDiagrams {
+				return ""
+			}
+			return diagramBlock(settings.Format, pipelineDiagram(c.Jobs))
+		},
+		"deprecationNotice": func(c ComponentData) string {
+			if c.DeprecationNote == "" {
+				return settings.Messages.text("deprecated_component")
+			}
+			return settings.Messages.text("deprecated_component_note", c.DeprecationNote)
+		},
+	}
+	for name, fn := range dialectFuncs(settings) {
+		funcs[name] = fn
+	}
+	for name, fn := range sprigFuncs() {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !isReported(err) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(exitCode(err))
+	}
 }
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markdownHTML renders the Markdown the tool generates as HTML, for the
+// fragments published to docs portals and the live preview: headings (with
+// GitLab's anchors), paragraphs, fenced code, pipe tables, nested and task
+// lists, blockquotes and alerts, the HTML blocks of collapsible sections, and
+// the usual inline markup. It approximates GitLab's rendering; it is not a
+// complete CommonMark implementation.
+func markdownHTML(doc string) string {
+	r := &markdownRenderer{slugger: newAnchorSlugger()}
+	r.blocks(strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n"))
+	return r.out.String()
+}
+
+var (
+	fencePattern      = regexp.MustCompile("^(`{3,}|~{3,})\\s*([^`\\s]*)")
+	rulePattern       = regexp.MustCompile(`^([-*_])(\s*[-*_]){2,}$`)
+	listItemPattern   = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])(?: +(.*))?$`)
+	tableRulePattern  = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	alertPattern      = regexp.MustCompile(`^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\]$`)
+	htmlBlockPattern  = regexp.MustCompile(`^</?(details|summary|div|p|table|br|hr|img|picture|!--)\b`)
+	inlineTagPattern  = regexp.MustCompile(`</?(?:br|details|summary|kbd|sub|sup|b|i|em|strong|span|a|img)\b[^>]*>`)
+	imagePattern      = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+&#34;[^)]*&#34;)?\)`)
+	linkPattern       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+&#34;[^)]*&#34;)?\)`)
+	strongPattern     = regexp.MustCompile(`\*\*([^*]+?)\*\*`)
+	emphasisPattern   = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	underscorePattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^_\s](?:[^_]*[^_\s])?)_($|[^\p{L}\p{N}_])`)
+	strikePattern     = regexp.MustCompile(`~~([^~]+?)~~`)
+	placeholder       = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+type markdownRenderer struct {
+	slugger *anchorSlugger
+	out     strings.Builder
+}
+
+// blocks renders a sequence of block-level lines.
+func (r *markdownRenderer) blocks(lines []string) {
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+		case fencePattern.MatchString(trimmed):
+			i = r.fence(lines, i)
+		case headingPattern.MatchString(trimmed):
+			m := headingPattern.FindStringSubmatch(trimmed)
+			fmt.Fprintf(&r.out, "<h%d id=\"%s\">%s</h%d>\n", len(m[1]), html.EscapeString(r.slugger.slug(m[2])), inlineHTML(m[2]), len(m[1]))
+			i++
+		case rulePattern.MatchString(trimmed):
+			r.out.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			i = r.blockquote(lines, i)
+		case isTableStart(lines, i):
+			i = r.table(lines, i)
+		case listItemPattern.MatchString(lines[i]):
+			i = r.list(lines, i)
+		case htmlBlockPattern.MatchString(trimmed):
+			r.out.WriteString(trimmed + "\n")
+			i++
+		default:
+			i = r.paragraph(lines, i)
+		}
+	}
+}
+
+func (r *markdownRenderer) fence(lines []string, i int) int {
+	m := fencePattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+	var code []string
+	j := i + 1
+	for ; j < len(lines); j++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[j]), m[1]) {
+			break
+		}
+		code = append(code, lines[j])
+	}
+	class := ""
+	if m[2] != "" {
+		class = ` class="language-` + html.EscapeString(m[2]) + `"`
+	}
+	fmt.Fprintf(&r.out, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(code, "\n")))
+	return j + 1
+}
+
+func (r *markdownRenderer) blockquote(lines []string, i int) int {
+	var quoted []string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		quoted = append(quoted, strings.TrimPrefix(trimmed, " "))
+	}
+	if len(quoted) > 0 {
+		if m := alertPattern.FindStringSubmatch(strings.TrimSpace(quoted[0])); m != nil {
+			kind := strings.ToLower(m[1])
+			fmt.Fprintf(&r.out, "<div class=\"alert alert-%s\">\n<p class=\"alert-title\">%s</p>\n", kind, strings.ToUpper(kind[:1])+kind[1:])
+			r.blocks(quoted[1:])
+			r.out.WriteString("</div>\n")
+			return i
+		}
+	}
+	r.out.WriteString("<blockquote>\n")
+	r.blocks(quoted)
+	r.out.WriteString("</blockquote>\n")
+	return i
+}
+
+// isTableStart reports whether a pipe table (a header row followed by its
+// separator row) starts at line i.
+func isTableStart(lines []string, i int) bool {
+	return strings.HasPrefix(strings.TrimSpace(lines[i]), "|") && i+1 < len(lines) && tableRulePattern.MatchString(strings.TrimSpace(lines[i+1]))
+}
+
+func (r *markdownRenderer) table(lines []string, i int) int {
+	r.out.WriteString("<table>\n<thead>\n<tr>")
+	for _, cell := range tableCells(lines[i]) {
+		fmt.Fprintf(&r.out, "<th>%s</th>", inlineHTML(cell))
+	}
+	r.out.WriteString("</tr>\n</thead>\n<tbody>\n")
+	i += 2
+	for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+		r.out.WriteString("<tr>")
+		for _, cell := range tableCells(lines[i]) {
+			fmt.Fprintf(&r.out, "<td>%s</td>", inlineHTML(cell))
+		}
+		r.out.WriteString("</tr>\n")
+	}
+	r.out.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// tableCells splits a table row on its unescaped pipes. Escaped pipes, also
+// inside code spans, stand for a literal pipe.
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = strings.TrimSuffix(row, "|")
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// list renders the list starting at line i, with the items indented deeper
+// as nested blocks, and returns the line after it.
+func (r *markdownRenderer) list(lines []string, i int) int {
+	first := listItemPattern.FindStringSubmatch(lines[i])
+	indent := len(first[1])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	r.out.WriteString("<" + tag + ">\n")
+	for i < len(lines) {
+		// A blank line between items keeps the list going
+		if strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) && listItemPattern.MatchString(lines[i+1]) {
+			i++
+		}
+		m := listItemPattern.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != indent || (m[2][0] >= '0' && m[2][0] <= '9') != ordered {
+			break
+		}
+		width := indent + len(m[2]) + 1
+		var nested []string
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) > indent {
+					nested = append(nested, "")
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) <= indent {
+				break
+			}
+			nested = append(nested, line[min(leadingSpaces(line), width):])
+		}
+		text := m[3]
+		r.out.WriteString("<li>")
+		if strings.HasPrefix(text, "[ ] ") || strings.HasPrefix(text, "[x] ") || strings.HasPrefix(text, "[X] ") {
+			checked := ""
+			if text[1] != ' ' {
+				checked = " checked"
+			}
+			r.out.WriteString(`<input type="checkbox" disabled` + checked + `> `)
+			text = text[4:]
+		}
+		r.out.WriteString(inlineHTML(text))
+		if len(nested) > 0 {
+			r.out.WriteString("\n")
+			r.blocks(nested)
+		}
+		r.out.WriteString("</li>\n")
+	}
+	r.out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// paragraph renders the lines up to the next blank line or block as a
+// paragraph. Lines ending with two spaces or a backslash break the line.
+func (r *markdownRenderer) paragraph(lines []string, i int) int {
+	var text []string
+	for start := i; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || (i > start && (fencePattern.MatchString(trimmed) || headingPattern.MatchString(trimmed) ||
+			strings.HasPrefix(trimmed, ">") || isTableStart(lines, i) || listItemPattern.MatchString(lines[i]) || htmlBlockPattern.MatchString(trimmed))) {
+			break
+		}
+		line := inlineHTML(strings.TrimSpace(strings.TrimSuffix(lines[i], `\`)))
+		if strings.HasSuffix(lines[i], "  ") || strings.HasSuffix(lines[i], `\`) {
+			line += "<br>"
+		}
+		text = append(text, line)
+	}
+	r.out.WriteString("<p>" + strings.Join(text, "\n") + "</p>\n")
+	return i
+}
+
+// inlineHTML renders the inline markup of text: code spans, backslash
+// escapes, images, links, strong, emphasis and strikethrough. The HTML tags
+// GitLab allows in table cells and summaries (e.g. <br>) are kept; any other
+// markup is escaped.
+func inlineHTML(text string) string {
+	var held []string
+	hold := func(s string) string {
+		held = append(held, s)
+		return fmt.Sprintf("\x00%d\x00", len(held)-1)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!|~<>\"", text[i+1]) >= 0:
+			b.WriteString(hold(html.EscapeString(text[i+1 : i+2])))
+			i += 2
+		case c == '`':
+			n := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			fence := text[i : i+n]
+			end := -1
+			for j := i + n; j < len(text); {
+				k := strings.Index(text[j:], fence)
+				if k < 0 {
+					break
+				}
+				k += j
+				run := len(text[k:]) - len(strings.TrimLeft(text[k:], "`"))
+				if run == n {
+					end = k
+					break
+				}
+				j = k + run
+			}
+			if end < 0 {
+				b.WriteString(fence)
+				i += n
+				continue
+			}
+			code := text[i+n : end]
+			if len(code) > 2 && strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") {
+				code = code[1 : len(code)-1]
+			}
+			b.WriteString(hold("<code>" + html.EscapeString(code) + "</code>"))
+			i = end + n
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	out := inlineTagPattern.ReplaceAllStringFunc(b.String(), hold)
+	out = html.EscapeString(out)
+	out = imagePattern.ReplaceAllString(out, `<img src="$2" alt="$1">`)
+	out = linkPattern.ReplaceAllString(out, `<a href="$2">$1</a>`)
+	out = strongPattern.ReplaceAllString(out, "<strong>$1</strong>")
+	out = emphasisPattern.ReplaceAllString(out, "<em>$1</em>")
+	out = underscorePattern.ReplaceAllString(out, "$1<em>$2</em>$3")
+	out = strikePattern.ReplaceAllString(out, "<del>$1</del>")
+	return placeholder.ReplaceAllStringFunc(out, func(m string) string {
+		n, _ := strconv.Atoi(m[1 : len(m)-1])
+		return held[n]
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineHTML(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain & <simple>", "plain &amp; &lt;simple&gt;"},
+		{"`a | b` and `` x`y ``", "<code>a | b</code> and <code>x`y</code>"},
+		{"**bold**, *em*, _em_ and ~~gone~~", "<strong>bold</strong>, <em>em</em>, <em>em</em> and <del>gone</del>"},
+		{"snake_case_name", "snake_case_name"},
+		{`\*not em\*`, "*not em*"},
+		{"[docs](https://example.com/a_b) ![logo](logo.png)", `<a href="https://example.com/a_b">docs</a> <img src="logo.png" alt="logo">`},
+		{"one<br>two", "one<br>two"},
+		{"`**code**`", "<code>**code**</code>"},
+	}
+	for _, tt := range tests {
+		if got := inlineHTML(tt.text); got != tt.want {
+			t.Errorf("inlineHTML(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTableCells(t *testing.T) {
+	got := tableCells("| `a \\| b` | two |  |")
+	want := []string{"`a | b`", "two", ""}
+	if strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownHTML(t *testing.T) {
+	doc := strings.Join([]string{
+		"## Deploy `aws`",
+		"",
+		"Deploys the",
+		"application.",
+		"",
+		"| Name | Default |",
+		"|------|---------|",
+		"| `level` | `info` |",
+		"",
+		"- one",
+		"  - nested",
+		"- [x] done",
+		"",
+		"1. first",
+		"",
+		"> [!WARNING]",
+		"> Deprecated.",
+		"",
+		"> quoted",
+		"",
+		"<details>",
+		"<summary>More</summary>",
+		"",
+		"```yaml",
+		"a: <b>",
+		"```",
+		"</details>",
+		"",
+		"---",
+		"## Deploy `aws`",
+	}, "\n")
+	want := strings.Join([]string{
+		`<h2 id="deploy-aws">Deploy <code>aws</code></h2>`,
+		"<p>Deploys the\napplication.</p>",
+		"<table>\n<thead>\n<tr><th>Name</th><th>Default</th></tr>\n</thead>\n<tbody>\n<tr><td><code>level</code></td><td><code>info</code></td></tr>\n</tbody>\n</table>",
+		"<ul>\n<li>one\n<ul>\n<li>nested</li>\n</ul>\n</li>\n<li><input type=\"checkbox\" disabled checked> done</li>\n</ul>",
+		"<ol>\n<li>first</li>\n</ol>",
+		"<div class=\"alert alert-warning\">\n<p class=\"alert-title\">Warning</p>\n<p>Deprecated.</p>\n</div>",
+		"<blockquote>\n<p>quoted</p>\n</blockquote>",
+		"<details>\n<summary>More</summary>",
+		`<pre><code class="language-yaml">a: &lt;b&gt;</code></pre>`,
+		"</details>\n<hr>",
+		`<h2 id="deploy-aws-1">Deploy <code>aws</code></h2>`,
+	}, "\n") + "\n"
+	if got := markdownHTML(doc); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// resolveMergeKeys rewrites each document of file so that aliases are
+// replaced by the node they refer to and merge keys (`<<: *defaults`) by the
+// merged entries. Keys written explicitly always win over merged ones,
+// wherever `<<` appears in the mapping; with a list of merged mappings
+// (`<<: [*a, *b]`), the first one defining a key wins, as in YAML 1.1.
+// Decoding the rewritten tree never trips over the "duplicate key" the
+// decoder otherwise reports for `<<: *defaults` followed by an override.
+func resolveMergeKeys(file *ast.File) {
+	for _, doc := range file.Docs {
+		anchors := make(map[string]ast.Node)
+		doc.Body = resolveNode(doc.Body, anchors)
+	}
+}
+
+// resolveNode resolves aliases and merge keys below node, recording anchors
+// in document order, and returns the node to use in its place.
+func resolveNode(node ast.Node, anchors map[string]ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		n.Value = resolveNode(n.Value, anchors)
+		anchors[n.Name.GetToken().Value] = n.Value
+		return n
+	case *ast.AliasNode:
+		if target, ok := anchors[n.Value.GetToken().Value]; ok {
+			return target
+		}
+		return n
+	case *ast.TagNode:
+		n.Value = resolveNode(n.Value, anchors)
+		return n
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			n.Values[i] = resolveNode(v, anchors)
+		}
+		return n
+	case *ast.MappingNode:
+		return mergeMapping(n.Start, n.IsFlowStyle, n.Values, anchors, n)
+	case *ast.MappingValueNode:
+		return mergeMapping(n.Start, false, []*ast.MappingValueNode{n}, anchors, n)
+	}
+	return node
+}
+
+// mergeMapping resolves the values of a mapping and expands its merge keys,
+// returning a new mapping node when it had any.
+func mergeMapping(start *token.Token, flow bool, values []*ast.MappingValueNode, anchors map[string]ast.Node, original ast.Node) ast.Node {
+	var explicit []*ast.MappingValueNode
+	hasMerge := false
+	for _, mv := range values {
+		mv.Value = resolveNode(mv.Value, anchors)
+		if mv.Key.IsMergeKey() {
+			hasMerge = true
+		} else {
+			explicit = append(explicit, mv)
+		}
+	}
+	if !hasMerge {
+		return original
+	}
+
+	// Merged entries take the place of their merge key, keeping source order
+	seen := make(map[string]bool)
+	for _, mv := range explicit {
+		seen[mv.Key.GetToken().Value] = true
+	}
+	var merged []*ast.MappingValueNode
+	for _, mv := range values {
+		if !mv.Key.IsMergeKey() {
+			merged = append(merged, mv)
+			continue
+		}
+		sources := []ast.Node{mv.Value}
+		if seq, ok := unwrapAnchor(mv.Value).(*ast.SequenceNode); ok {
+			sources = seq.Values
+		}
+		for _, source := range sources {
+			for _, entry := range mappingValues(source) {
+				if key := entry.Key.GetToken().Value; !seen[key] {
+					seen[key] = true
+					merged = append(merged, entry)
+				}
+			}
+		}
+	}
+	return ast.Mapping(start, flow, merged...)
+}
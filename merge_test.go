@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTemplate_AnchorsAndMergeKeys(t *testing.T) {
+	dir := t.TempDir()
+	yamlContent := `spec:
+  inputs:
+    stage:
+      description: Pipeline stage
+      default: &stage build
+    image:
+      <<: &common
+        description: Shared description
+        default: alpine
+    tag:
+      <<: *common
+      default: latest
+    override_first:
+      default: first
+      <<: *common
+    alias:
+      default: *stage
+    tags:
+      default: &tags [a, b]
+    more_tags:
+      default: *tags
+    env:
+      default: &env {A: "1", B: "2"}
+    env_override:
+      default:
+        <<: *env
+        B: "3"
+    chained:
+      <<: [*common, {description: ignored, options: [x, alpine]}]
+---
+.base: &base
+  image: $[[ inputs.image ]]
+  script: echo $[[ inputs.stage ]]
+job:
+  <<: *base
+  variables:
+    TAG: $[[ inputs.tag ]]
+`
+	path := filepath.Join(dir, "anchors.yml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	component, err := parseTemplate(path, ParseOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inputs := make(map[string]InputData)
+	for _, input := range component.Inputs {
+		inputs[input.Name] = input
+	}
+
+	expected := []struct {
+		name        string
+		description string
+		def         string
+	}{
+		{"stage", "Pipeline stage", "build"},
+		{"image", "Shared description", "alpine"},
+		{"tag", "Shared description", "latest"},
+		{"override_first", "Shared description", "first"},
+		{"alias", "", "build"},
+		{"tags", "", "`[\"a\",\"b\"]`"},
+		{"more_tags", "", "`[\"a\",\"b\"]`"},
+		{"env_override", "", "`{\"A\":\"1\",\"B\":\"3\"}`"},
+		{"chained", "Shared description", "alpine"},
+	}
+	for _, exp := range expected {
+		input, ok := inputs[exp.name]
+		if !ok {
+			t.Errorf("missing input %s", exp.name)
+			continue
+		}
+		if input.Description != exp.description || input.Default != exp.def {
+			t.Errorf("input %s: expected %q / %q, got %q / %q", exp.name, exp.description, exp.def, input.Description, input.Default)
+		}
+	}
+	if got := inputs["chained"].Options; len(got) != 2 || got[1] != "alpine" {
+		t.Errorf("expected options merged from the second mapping, got %v", got)
+	}
+
+	// Jobs merging a hidden job use its keys
+	if used := inputs["stage"].UsedIn; len(used) != 2 || used[0] != ".base" || used[1] != "job" {
+		t.Errorf("expected stage to be used in .base and job, got %v", used)
+	}
+	if len(component.EnvVars) != 1 || component.EnvVars[0].Scope != "job" {
+		t.Errorf("expected the job's own variables next to merged keys, got %+v", component.EnvVars)
+	}
+	if issues := validateComponent(component); countErrors(issues) > 0 {
+		t.Errorf("expected no validation errors, got %v", issues)
+	}
+}
+
+func TestDecodeBody_MergeKeys(t *testing.T) {
+	body, err := decodeBody([]byte(`spec:
+  inputs: {}
+---
+.defaults: &defaults
+  image: alpine
+  tags: [docker]
+build:
+  image: golang
+  <<: *defaults
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	build, _ := body[0]["build"].(map[string]interface{})
+	if build["image"] != "golang" {
+		t.Errorf("expected the explicit image to win over the merged one, got %v", build["image"])
+	}
+	if tags, _ := build["tags"].([]interface{}); len(tags) != 1 || tags[0] != "docker" {
+		t.Errorf("expected tags merged from .defaults, got %v", build["tags"])
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultStageOrder is the order of GitLab's default stages. Other stages are
+// ordered by the including pipeline's `stages`, unknown here: they are drawn
+// after deploy, by name.
+var defaultStageOrder = []string{".pre", "build", "test", "deploy", ".post"}
+
+// stageRank returns the position of a stage in defaultStageOrder, other
+// stages ranking between deploy and .post.
+func stageRank(stage string) int {
+	if i := indexOf(defaultStageOrder, stage); i >= 0 {
+		if stage == ".post" {
+			return i + 1
+		}
+		return i
+	}
+	return indexOf(defaultStageOrder, "deploy") + 1
+}
+
+// pipelineDiagram returns a Mermaid graph of jobs: one box per stage, in
+// order, holding its jobs, and an arrow from each needed job to the job
+// needing it. Needed jobs the component does not define (the including
+// pipeline's) are drawn as rounded nodes outside the stages. It returns ""
+// without jobs.
+func pipelineDiagram(jobs []JobData) string {
+	if len(jobs) == 0 {
+		return ""
+	}
+	ids := make(map[string]string, len(jobs))
+	byStage := make(map[string][]JobData)
+	for i, job := range jobs {
+		ids[job.Name] = fmt.Sprintf("j%d", i)
+		byStage[job.Stage] = append(byStage[job.Stage], job)
+	}
+	var stages []string
+	for stage := range byStage {
+		stages = append(stages, stage)
+	}
+	sort.Slice(stages, func(i, j int) bool {
+		if ri, rj := stageRank(stages[i]), stageRank(stages[j]); ri != rj {
+			return ri < rj
+		}
+		return stages[i] < stages[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, stage := range stages {
+		fmt.Fprintf(&b, "  subgraph s%d [%s]\n", i, mermaidLabel(stage))
+		for _, job := range byStage[stage] {
+			fmt.Fprintf(&b, "    %s[%s]\n", ids[job.Name], mermaidLabel(job.Name))
+		}
+		b.WriteString("  end\n")
+	}
+	// Invisible links keep the stages in pipeline order
+	for i := 1; i < len(stages); i++ {
+		fmt.Fprintf(&b, "  s%d ~~~ s%d\n", i-1, i)
+	}
+	external := 0
+	for _, job := range jobs {
+		for _, need := range job.Needs {
+			id, ok := ids[need]
+			if !ok {
+				id = fmt.Sprintf("x%d", external)
+				ids[need] = id
+				external++
+				fmt.Fprintf(&b, "  %s([%s])\n", id, mermaidLabel(need))
+			}
+			fmt.Fprintf(&b, "  %s --> %s\n", id, ids[job.Name])
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// mermaidLabel quotes text as a Mermaid node label. Quotes are written as
+// entity codes, the only escape Mermaid understands in labels.
+func mermaidLabel(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, "#quot;") + `"`
+}
+
+// diagramBlock wraps Mermaid code in the block GitLab renders as a diagram
+// in the output format. It returns "" for empty code.
+func diagramBlock(format, code string) string {
+	if code == "" {
+		return ""
+	}
+	if format == "asciidoc" {
+		return "[mermaid]\n----\n" + code + "\n----"
+	}
+	return "```mermaid\n" + code + "\n```"
+}
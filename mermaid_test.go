@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPipelineDiagram(t *testing.T) {
+	jobs := []JobData{
+		{Name: "compile", Stage: "build"},
+		{Name: "package", Stage: "package", Needs: []string{"compile"}},
+		{Name: "release", Stage: "deploy", Needs: []string{"package", "approve"}},
+		{Name: `say "hi"`, Stage: ".pre"},
+	}
+	want := `graph LR
+  subgraph s0 [".pre"]
+    j3["say #quot;hi#quot;"]
+  end
+  subgraph s1 ["build"]
+    j0["compile"]
+  end
+  subgraph s2 ["deploy"]
+    j2["release"]
+  end
+  subgraph s3 ["package"]
+    j1["package"]
+  end
+  s0 ~~~ s1
+  s1 ~~~ s2
+  s2 ~~~ s3
+  j0 --> j1
+  j1 --> j2
+  x0(["approve"])
+  x0 --> j2`
+	if got := pipelineDiagram(jobs); got != want {
+		t.Errorf("unexpected diagram:\n%s\nwant:\n%s", got, want)
+	}
+	if got := pipelineDiagram(nil); got != "" {
+		t.Errorf("expected no diagram without jobs, got %q", got)
+	}
+}
+
+func TestDiagramBlock(t *testing.T) {
+	if got := diagramBlock("markdown", "graph LR"); got != "```mermaid\ngraph LR\n```" {
+		t.Errorf("unexpected Markdown block %q", got)
+	}
+	if got := diagramBlock("asciidoc", "graph LR"); got != "[mermaid]\n----\ngraph LR\n----" {
+		t.Errorf("unexpected AsciiDoc block %q", got)
+	}
+	if got := diagramBlock("markdown", ""); got != "" {
+		t.Errorf("expected no block without code, got %q", got)
+	}
+}
+
+func TestRun_PipelineDiagrams(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n---\ncompile:\n  stage: build\n  script: make\nunit:\n  needs: [compile]\n  script: make test\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); strings.Contains(string(data), "mermaid") {
+		t.Errorf("expected no diagram by default, got:\n%s", data)
+	}
+
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0", "--pipeline-diagrams"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "| unit | test |\n\n```mermaid\ngraph LR\n  subgraph s0 [\"build\"]\n    j0[\"compile\"]\n  end\n  subgraph s1 [\"test\"]\n    j1[\"unit\"]\n  end\n  s0 ~~~ s1\n  j0 --> j1\n```\n"
+	if data, _ := os.ReadFile("README.md"); !strings.Contains(string(data), want) {
+		t.Errorf("expected %q in:\n%s", want, data)
+	}
+
+	os.WriteFile(configFile, []byte("pipeline_diagrams: true\nformat: asciidoc\n"), 0644)
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.Contains(string(data), "|===\n\n[mermaid]\n----\ngraph LR\n") {
+		t.Errorf("expected an AsciiDoc diagram after the jobs table, got:\n%s", data)
+	}
+}
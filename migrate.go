@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// migrationFile is the patch written by `migrate` by default.
+const migrationFile = "migration.patch"
+
+// pseudoInput is a global variable used as a component's input, with the
+// spec:inputs declaration replacing it.
+type pseudoInput struct {
+	Variable    string
+	Input       string
+	Default     string
+	Description string
+	Options     []string
+}
+
+// templateMigration is the proposed migration of one template.
+type templateMigration struct {
+	Path     string
+	Original string
+	Migrated string
+	Inputs   []pseudoInput
+	Skipped  string   // why the template is left as is, if it is
+	Kept     []string // variables left as variables, with the reason
+}
+
+// migrateTemplate proposes the spec:inputs migration of a variable-driven
+// template: global variables referenced by the jobs become inputs, their
+// definitions are removed and $VAR / ${VAR} references become
+// $[[ inputs.var ]] interpolations. Templates that already declare a spec
+// header, or whose global variables are not used as inputs, are skipped.
+// Variables also set by a job are kept, since the job's value wins over the
+// pipeline's in that job. Comments and layout are kept.
+func migrateTemplate(path string, data []byte) (templateMigration, error) {
+	m := templateMigration{Path: path, Original: string(data)}
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return m, yamlError(path, data, err)
+	}
+
+	var docs []*ast.DocumentNode
+	for _, d := range file.Docs {
+		if !isEmptyDocument(d) {
+			docs = append(docs, d)
+		}
+	}
+	switch {
+	case len(docs) > 0 && mappingValue(docs[0].Body, "spec") != nil:
+		m.Skipped = "already has a spec header"
+		return m, nil
+	case len(docs) != 1:
+		m.Skipped = "not a single YAML document"
+		return m, nil
+	}
+	root := docs[0].Body
+	variablesNode := mappingValue(root, "variables")
+	if variablesNode == nil {
+		m.Skipped = "no global variables"
+		return m, nil
+	}
+	if node, ok := variablesNode.(*ast.MappingNode); ok && node.IsFlowStyle {
+		m.Skipped = "flow-style variables block"
+		return m, nil
+	}
+	// Merged entries live elsewhere in the file: lines can't be edited safely
+	if mappingValue(variablesNode, "<<") != nil {
+		m.Skipped = "variables block with merge keys"
+		return m, nil
+	}
+
+	var body map[string]interface{}
+	if err := yaml.NodeToValue(root, &body); err != nil {
+		return m, yamlError(path, data, err)
+	}
+	values, _ := body["variables"].(map[string]interface{})
+	setByJobs := make(map[string][]string)
+	for job, def := range jobDefinitions([]map[string]interface{}{body}) {
+		if vars, ok := def["variables"].(map[string]interface{}); ok {
+			for name := range vars {
+				setByJobs[name] = append(setByJobs[name], job)
+			}
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(m.Original, "\n"), "\n")
+	entries := mappingValues(variablesNode)
+	blockStart, blockEnd := lineRange(lines, mappingValues(root), "variables")
+	spans := make([][2]int, len(entries)) // 1-based, inclusive
+	for i, entry := range entries {
+		spans[i][0] = withLeadingComments(lines, entry.Key.GetToken().Position.Line, blockStart+1)
+		if i > 0 {
+			spans[i-1][1] = spans[i][0] - 1
+		}
+	}
+	if len(entries) > 0 {
+		spans[len(entries)-1][1] = blockEnd
+	}
+
+	removed := make(map[int]bool)
+	for i, entry := range entries {
+		name := entry.Key.GetToken().Value
+		if jobs := setByJobs[name]; len(jobs) > 0 {
+			sort.Strings(jobs)
+			m.Kept = append(m.Kept, fmt.Sprintf("%s (also set by %s)", name, strings.Join(jobs, ", ")))
+			continue
+		}
+		if !referencesVariable(lines, name, spans[i]) {
+			continue
+		}
+		input := pseudoInput{Variable: name, Input: strings.ToLower(name)}
+		value := values[name]
+		if def, ok := value.(map[string]interface{}); ok {
+			value = def["value"]
+			if description, ok := def["description"]; ok && description != nil {
+				input.Description = strings.TrimSpace(fmt.Sprintf("%v", description))
+			}
+			if options, ok := def["options"].([]interface{}); ok {
+				for _, option := range options {
+					input.Options = append(input.Options, fmt.Sprintf("%v", option))
+				}
+			}
+		}
+		if value != nil {
+			input.Default = fmt.Sprintf("%v", value)
+		}
+		m.Inputs = append(m.Inputs, input)
+		for line := spans[i][0]; line <= spans[i][1]; line++ {
+			removed[line] = true
+		}
+	}
+	if len(m.Inputs) == 0 {
+		m.Skipped = "no global variable is used as an input"
+		return m, nil
+	}
+	// An emptied block goes away with its key
+	if len(m.Inputs) == len(entries) {
+		for line := withLeadingComments(lines, blockStart, 1); line <= blockEnd; line++ {
+			removed[line] = true
+		}
+	}
+
+	header, err := specHeader(m.Inputs)
+	if err != nil {
+		return m, fmt.Errorf("error encoding the spec header of %s: %w", path, err)
+	}
+	var out strings.Builder
+	out.WriteString(header)
+	out.WriteString("---\n")
+	for i, line := range lines {
+		if !removed[i+1] {
+			out.WriteString(rewriteVariableReferences(line, m.Inputs) + "\n")
+		}
+	}
+	m.Migrated = out.String()
+	return m, nil
+}
+
+// lineRange returns the first and last line (1-based) of a top-level key's
+// entry: from its key to the line before the next key, without trailing
+// blank lines and comments (which belong to the next key).
+func lineRange(lines []string, entries []*ast.MappingValueNode, key string) (int, int) {
+	for i, entry := range entries {
+		if entry.Key.GetToken().Value != key {
+			continue
+		}
+		start, end := entry.Key.GetToken().Position.Line, len(lines)
+		if i+1 < len(entries) {
+			end = entries[i+1].Key.GetToken().Position.Line - 1
+		}
+		for end > start {
+			if trimmed := strings.TrimSpace(lines[end-1]); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				break
+			}
+			end--
+		}
+		return start, end
+	}
+	return 0, 0
+}
+
+// withLeadingComments returns the first line of the comment block directly
+// above line (1-based), not going above min.
+func withLeadingComments(lines []string, line, min int) int {
+	for line > min && strings.HasPrefix(strings.TrimSpace(lines[line-2]), "#") {
+		line--
+	}
+	return line
+}
+
+// referencesVariable reports whether a line outside span references the variable.
+func referencesVariable(lines []string, name string, span [2]int) bool {
+	for i, line := range lines {
+		if i+1 >= span[0] && i+1 <= span[1] {
+			continue
+		}
+		if rewriteVariableReferences(line, []pseudoInput{{Variable: name}}) != line {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteVariableReferences replaces the $VAR and ${VAR} references to
+// pseudo-inputs with input interpolations. Escaped references ($$VAR) are
+// left alone.
+func rewriteVariableReferences(line string, inputs []pseudoInput) string {
+	names := make(map[string]string, len(inputs))
+	for _, input := range inputs {
+		names[input.Variable] = input.Input
+	}
+	var out strings.Builder
+	last := 0
+	for _, loc := range ciVariablePattern.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		name := strings.Trim(line[start+1:end], "{}")
+		input, ok := names[name]
+		if !ok || (start > 0 && line[start-1] == '$') {
+			continue
+		}
+		out.WriteString(line[last:start])
+		out.WriteString("$[[ inputs." + input + " ]]")
+		last = end
+	}
+	out.WriteString(line[last:])
+	return out.String()
+}
+
+// specHeader returns the spec:inputs block declaring the pseudo-inputs, in
+// the order of the variables block. Defaults are strings, as variables are.
+func specHeader(inputs []pseudoInput) (string, error) {
+	var decls yaml.MapSlice
+	for _, input := range inputs {
+		var decl yaml.MapSlice
+		if input.Description != "" {
+			decl = append(decl, yaml.MapItem{Key: "description", Value: input.Description})
+		}
+		decl = append(decl, yaml.MapItem{Key: "default", Value: input.Default})
+		if len(input.Options) > 0 {
+			decl = append(decl, yaml.MapItem{Key: "options", Value: input.Options})
+		}
+		decls = append(decls, yaml.MapItem{Key: input.Input, Value: decl})
+	}
+	spec := yaml.MapSlice{{Key: "spec", Value: yaml.MapSlice{{Key: "inputs", Value: decls}}}}
+	out, err := yaml.MarshalWithOptions(spec, yaml.Indent(2), yaml.IndentSequence(true))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// migrationPatch renders the migrated templates as a unified diff that
+// `git apply` accepts, in template order.
+func migrationPatch(migrations []templateMigration) string {
+	var patch strings.Builder
+	for _, m := range migrations {
+		name := filepath.ToSlash(m.Path)
+		patch.WriteString(unifiedDiff(m.Original, m.Migrated, "a/"+name, "b/"+name, false))
+	}
+	return patch.String()
+}
+
+// runMigrate implements `migrate`: propose the spec:inputs migration of
+// variable-driven templates as a patch, without touching the templates.
+func runMigrate(args []string, stdout io.Writer) error {
+	flags := newFlagSet("migrate")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	verbose := flags.Bool("verbose", false, "Also log the templates left as they are, and why")
+	output := flags.String("output", migrationFile, "Patch file to write (\"-\" for stdout)")
+	var overrides Settings
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.Var((*stringList)(&overrides.Include), "include", "Only migrate components matching this glob (repeatable)")
+	flags.Var((*stringList)(&overrides.Exclude), "exclude", "Skip components matching this glob (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	// The patch owns stdout; informational messages would corrupt it
+	if *output == "-" {
+		*quiet = true
+	}
+	log := newLogger(stdout, logLevelFor(*quiet, *verbose, false), "text")
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	templates, err := findTemplates(settings.TemplatesDir, settings.ignoreRules())
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+
+	var migrations []templateMigration
+	for _, t := range templates {
+		if !componentSelected(settings, componentName(settings.TemplatesDir, t), t) {
+			continue
+		}
+		data, err := os.ReadFile(t)
+		if err != nil {
+			return withExitCode(exitParse, fmt.Errorf("error reading YAML file %s: %w", t, err))
+		}
+		m, err := migrateTemplate(t, data)
+		if err != nil {
+			return withExitCode(exitParse, err)
+		}
+		for _, kept := range m.Kept {
+			log.Warnf("%s: variable %s is kept", t, kept)
+		}
+		if m.Skipped != "" {
+			log.Verbosef("%s: skipped, %s", t, m.Skipped)
+			continue
+		}
+		var names []string
+		for _, input := range m.Inputs {
+			names = append(names, input.Input)
+		}
+		log.Infof("%s: %s", t, strings.Join(names, ", "))
+		migrations = append(migrations, m)
+	}
+	if len(migrations) == 0 {
+		log.Infof("No variable-driven template to migrate")
+		return nil
+	}
+
+	patch := migrationPatch(migrations)
+	if *output == "-" {
+		if _, err := io.WriteString(stdout, patch); err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing the patch: %w", err))
+		}
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(patch), 0644); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", *output, err))
+	}
+	log.Infof("Wrote %s (%d template(s)); review it, then apply it with `git apply %s`", *output, len(migrations), *output)
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyTemplate = `# Lints the sources.
+variables:
+  # Paths to lint
+  LINT_PATHS: src
+  LINT_LEVEL:
+    value: warning
+    description: Minimum level reported
+    options: [info, warning, error]
+  GIT_DEPTH: "10"
+  DEBUG: "false"
+
+lint:
+  variables:
+    DEBUG: "true"
+  script:
+    - lint --level ${LINT_LEVEL} $LINT_PATHS $$LINT_PATHS
+    - echo $DEBUG
+`
+
+func TestMigrateTemplate(t *testing.T) {
+	m, err := migrateTemplate("templates/lint.yml", []byte(legacyTemplate))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `spec:
+  inputs:
+    lint_paths:
+      default: src
+    lint_level:
+      description: Minimum level reported
+      default: warning
+      options:
+        - info
+        - warning
+        - error
+---
+# Lints the sources.
+variables:
+  GIT_DEPTH: "10"
+  DEBUG: "false"
+
+lint:
+  variables:
+    DEBUG: "true"
+  script:
+    - lint --level $[[ inputs.lint_level ]] $[[ inputs.lint_paths ]] $$LINT_PATHS
+    - echo $DEBUG
+`
+	if m.Skipped != "" || m.Migrated != want {
+		t.Errorf("unexpected migration (skipped: %q):\n%s", m.Skipped, m.Migrated)
+	}
+	if len(m.Kept) != 1 || m.Kept[0] != "DEBUG (also set by lint)" {
+		t.Errorf("expected DEBUG to be kept, got %v", m.Kept)
+	}
+}
+
+func TestMigrateTemplate_EmptiedBlock(t *testing.T) {
+	m, err := migrateTemplate("t.yml", []byte("stages: [build]\n# Settings\nvariables:\n  TARGET: \"\"\n\n# The job\nbuild:\n  script: make $TARGET\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "spec:\n  inputs:\n    target:\n      default: \"\"\n---\nstages: [build]\n\n# The job\nbuild:\n  script: make $[[ inputs.target ]]\n"
+	if m.Migrated != want {
+		t.Errorf("expected the variables block to be removed, got:\n%s", m.Migrated)
+	}
+}
+
+func TestMigrateTemplate_Skipped(t *testing.T) {
+	for content, reason := range map[string]string{
+		"spec:\n  inputs: {}\n---\njob:\n  script: echo\n":                "already has a spec header",
+		"job:\n  script: echo\n":                                          "no global variables",
+		"variables: {A: 1}\njob:\n  script: echo $A\n":                    "flow-style variables block",
+		"variables:\n  UNUSED: 1\njob:\n  script: echo\n":                 "no global variable is used as an input",
+		".v: &v\n  A: 1\nvariables:\n  <<: *v\njob:\n  script: echo $A\n": "variables block with merge keys",
+	} {
+		m, err := migrateTemplate("t.yml", []byte(content))
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", content, err)
+		}
+		if m.Skipped != reason {
+			t.Errorf("expected %q to be skipped with %q, got %q", content, reason, m.Skipped)
+		}
+	}
+	if _, err := migrateTemplate("t.yml", []byte("a: [")); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
+
+func TestRun_Migrate(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "lint.yml"), []byte(legacyTemplate), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n---\nbuild:\n  script: echo\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"migrate", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	patch, _ := os.ReadFile(migrationFile)
+	if !strings.HasPrefix(string(patch), "--- a/templates/lint.yml\n+++ b/templates/lint.yml\n@@ -1,11 +1,17 @@\n+spec:\n") {
+		t.Errorf("unexpected patch:\n%s", patch)
+	}
+	if data, _ := os.ReadFile(filepath.Join("templates", "lint.yml")); string(data) != legacyTemplate {
+		t.Error("expected the templates to be left untouched")
+	}
+
+	var out strings.Builder
+	if err := run([]string{"migrate", "--output", "-", "--exclude", "lint"}, &out); err != nil || out.Len() > 0 {
+		t.Errorf("expected nothing to migrate, got %v: %q", err, out.String())
+	}
+	out.Reset()
+	if err := run([]string{"migrate", "--output", "-"}, &out); err != nil || out.String() != string(patch) {
+		t.Errorf("expected the patch on stdout, got %v:\n%s", err, out.String())
+	}
+}
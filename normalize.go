@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// normalizeDocument puts a document in canonical form, so the output only
+// depends on its content and not on the platform or the template's layout:
+// no byte order mark, LF line endings, no trailing whitespace (a Markdown
+// hard break keeps exactly two spaces), no leading blank lines, at most one
+// blank line in a row outside code blocks, and a single trailing newline.
+func normalizeDocument(doc []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(doc))
+	n := newNormalizer(&out)
+	n.Write(doc)
+	n.Close()
+	if out.Len() == 0 {
+		return nil
+	}
+	return out.Bytes()
+}
+
+// normalizer is the streaming form of normalizeDocument: it normalizes what
+// is written to it line by line, so a rendered document reaches its
+// destination without being held in memory first. Blank lines are held back
+// until a line of content follows, as trailing ones are dropped; Close
+// writes the last line.
+type normalizer struct {
+	w      io.Writer
+	line   []byte // the line being written, until its end
+	cr     bool   // the last byte was a CR, so a LF right after it is part of the same line ending
+	first  bool   // the next line is the first one, where a byte order mark is dropped
+	wrote  bool   // a line was written
+	blanks int    // blank lines held back
+	fence  string // the marker of the open code block, if any
+	err    error
+}
+
+var newline = []byte("\n")
+
+func newNormalizer(w io.Writer) *normalizer {
+	return &normalizer{w: w, first: true}
+}
+
+func (n *normalizer) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '\n' && n.cr:
+			n.cr = false
+		case c == '\n' || c == '\r':
+			n.cr = c == '\r'
+			n.endLine()
+		default:
+			n.cr = false
+			n.line = append(n.line, c)
+		}
+	}
+	return len(p), n.err
+}
+
+// Close writes the last line, if it has no line ending. It does not close the
+// underlying writer.
+func (n *normalizer) Close() error {
+	if len(n.line) > 0 {
+		n.endLine()
+	}
+	return n.err
+}
+
+// endLine normalizes the current line and writes it, or holds it back if it
+// is blank.
+func (n *normalizer) endLine() {
+	line := n.line
+	n.line = n.line[:0]
+	if n.first {
+		line = bytes.TrimPrefix(line, []byte("\ufeff"))
+		n.first = false
+	}
+	trimmed := bytes.TrimRight(line, " \t")
+	hardBreak := n.fence == "" && len(trimmed) > 0 && bytes.HasSuffix(line, []byte("  ")) && len(bytes.TrimRight(line, " ")) == len(trimmed)
+	if hardBreak {
+		trimmed = line[:len(trimmed)+2]
+	}
+	marker := ""
+	if len(trimmed) > 0 && (trimmed[0] == '`' || trimmed[0] == '~' || trimmed[0] == '-') {
+		marker = codeFence(string(trimmed))
+	}
+	if marker != "" && (n.fence == "" || marker == n.fence) {
+		if n.fence == "" {
+			n.fence = marker
+		} else {
+			n.fence = ""
+		}
+	} else if len(trimmed) == 0 {
+		// Outside code blocks, leading blank lines and runs of blank lines
+		// collapse
+		if n.fence != "" || (n.wrote && n.blanks == 0) {
+			n.blanks++
+		}
+		return
+	}
+	for ; n.blanks > 0; n.blanks-- {
+		n.write(newline)
+	}
+	n.write(trimmed)
+	n.write(newline)
+	n.wrote = true
+}
+
+func (n *normalizer) write(p []byte) {
+	if n.err == nil {
+		_, n.err = n.w.Write(p)
+	}
+}
+
+// codeFence returns the marker opening or closing a code block on this line:
+// a Markdown fence (``` or ~~~, with an optional info string) or an AsciiDoc
+// listing delimiter (----). It returns "" for other lines.
+func codeFence(line string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, marker) {
+			return marker
+		}
+	}
+	if line == "----" {
+		return line
+	}
+	return ""
+}
+
+// normalizeFiles rewrites files in canonical form (see normalizeDocument) and
+// returns the ones that changed. Errors carry their exit code.
+func normalizeFiles(paths []string) ([]string, error) {
+	var changed []string
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return changed, withExitCode(exitFailure, fmt.Errorf("error reading %s: %w", path, err))
+		}
+		normalized := normalizeDocument(content)
+		if bytes.Equal(content, normalized) {
+			continue
+		}
+		if err := os.WriteFile(path, normalized, 0644); err != nil {
+			return changed, withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", path, err))
+		}
+		changed = append(changed, path)
+	}
+	return changed, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "\n\n", ""},
+		{"line endings", "\ufeff# Title\r\n\r\nBody\rEnd", "# Title\n\nBody\nEnd\n"},
+		{"trailing whitespace", "a \t\nhard break   \nb\t \n", "a\nhard break  \nb\n"},
+		{"blank lines", "\n\n# A\n\n\n \nB\n\n\n", "# A\n\nB\n"},
+		{"markdown code block", "```yaml\na:\n\n\n  b: 1  \n```\n\n\nc\n", "```yaml\na:\n\n\n  b: 1\n```\n\nc\n"},
+		{"nested fence", "~~~\n```\n\n\n~~~\n", "~~~\n```\n\n\n~~~\n"},
+		{"asciidoc listing", "----\na\n\n\nb\n----\n", "----\na\n\n\nb\n----\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(normalizeDocument([]byte(tt.input))); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			// Streamed a byte at a time, a CRLF or a byte order mark split
+			// across writes normalizes the same
+			var out bytes.Buffer
+			n := newNormalizer(&out)
+			for i := 0; i < len(tt.input); i++ {
+				n.Write([]byte{tt.input[i]})
+			}
+			if err := n.Close(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("expected %q when streamed, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func BenchmarkNormalizeDocument(b *testing.B) {
+	var doc bytes.Buffer
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&doc, "## component-%03d  \r\n\n\n| Name | Default |\n|------|---------|\n| `stage` | `test` |  \n\n```yaml\ninclude:\n\n\n  - component: x\n```\n", i)
+	}
+	b.SetBytes(int64(doc.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		normalizeDocument(doc.Bytes())
+	}
+}
+
+func TestRun_Normalize(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated, _ := os.ReadFile("README.md")
+
+	// A CRLF checkout of up-to-date docs is not outdated
+	crlf := []byte{}
+	for _, b := range generated {
+		if b == '\n' {
+			crlf = append(crlf, '\r')
+		}
+		crlf = append(crlf, b)
+	}
+	os.WriteFile("README.md", crlf, 0644)
+	if err := run(append([]string{"check"}, args[1:]...), io.Discard); err != nil {
+		t.Errorf("expected CRLF docs to be up to date, got %v", err)
+	}
+
+	os.WriteFile("OTHER.md", []byte("# Other  \r\n\r\n\r\n"), 0644)
+	if err := run([]string{"--quiet", "--normalize", "README.md", "OTHER.md"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); string(data) != string(generated) {
+		t.Errorf("expected the normalized README to match the generated one, got %q", data)
+	}
+	if data, _ := os.ReadFile("OTHER.md"); string(data) != "# Other  \n" {
+		t.Errorf("expected OTHER.md to be normalized, got %q", data)
+	}
+
+	if got := exitCode(run([]string{"--quiet", "--normalize", "missing.md"}, io.Discard)); got != exitFailure {
+		t.Errorf("expected exit code %d for a missing file, got %d", exitFailure, got)
+	}
+	if got := exitCode(run([]string{"--quiet", "--normalize", "--check"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d with --check, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"--quiet", "stray"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for a stray argument, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory and a rename, so readers (and a crash midway) see either the
+// previous content or the new one, never a truncated file. An existing
+// file's permissions are kept.
+func writeFileAtomic(path string, data []byte) error {
+	return writeFileAtomicFunc(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomicFunc is writeFileAtomic for content written by write as it
+// is produced. When write fails, path is left as it was and its error is
+// returned as is.
+func writeFileAtomicFunc(path string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// bufferPool holds the buffers of rendering, reused across the documents of
+// a run (the README, pages, localized variants and component templates).
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool. Its content must not be used
+// afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	bufferPool.Put(buf)
+}
+
+// writeOutput writes the generated document to path atomically. With backup,
+// a previous document that differs is first kept as <path>.bak, so a run that
+// changes nothing leaves the last real backup alone.
+func writeOutput(path string, doc []byte, backup bool) error {
+	if backup {
+		previous, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+		if err == nil && !bytes.Equal(previous, doc) {
+			if err := writeFileAtomic(path+".bak", previous); err != nil {
+				return fmt.Errorf("error writing %s.bak: %w", path, err)
+			}
+		}
+	}
+	if err := writeFileAtomic(path, doc); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// streamOutput writes the generated document to path atomically, streaming
+// it from the template to the file, and returns the write's status (told by
+// hashing what streams by). Errors carry their exit code.
+func streamOutput(path string, settings Settings, data TemplateData) (fileStatus, error) {
+	status := statusCreated
+	var previous [sha256.Size]byte
+	if current, err := os.ReadFile(path); err == nil {
+		status, previous = statusUpdated, sha256.Sum256(current)
+	}
+	hash := sha256.New()
+	err := writeFileAtomicFunc(path, func(w io.Writer) error {
+		buffered := bufio.NewWriterSize(io.MultiWriter(w, hash), 64*1024)
+		if err := streamDocument(buffered, settings, data); err != nil {
+			return err
+		}
+		if err := buffered.Flush(); err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", path, err))
+		}
+		return nil
+	})
+	var ee *exitError
+	if err != nil && !errors.As(err, &ee) {
+		return "", withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", path, err))
+	}
+	if err != nil {
+		return "", err
+	}
+	if status == statusUpdated && bytes.Equal(hash.Sum(nil), previous[:]) {
+		status = statusUnchanged
+	}
+	return status, nil
+}
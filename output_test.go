@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "README.md")
+	os.WriteFile(path, []byte("old"), 0600)
+
+	if err := writeFileAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("expected the new content, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the permissions to be kept, got %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary file left behind, got %v", entries)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "README.md"), []byte("new")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestWriteOutput_Backup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+
+	if err := writeOutput(path, []byte("first"), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("expected no backup without a previous output")
+	}
+
+	writeOutput(path, []byte("second"), true)
+	if data, _ := os.ReadFile(path + ".bak"); string(data) != "first" {
+		t.Errorf("expected the previous output as backup, got %q", data)
+	}
+
+	// An unchanged output keeps the last real backup
+	writeOutput(path, []byte("second"), true)
+	if data, _ := os.ReadFile(path + ".bak"); string(data) != "first" {
+		t.Errorf("expected the backup to be kept, got %q", data)
+	}
+
+	writeOutput(path, []byte("third"), false)
+	if data, _ := os.ReadFile(path + ".bak"); string(data) != "first" {
+		t.Errorf("expected no backup without the option, got %q", data)
+	}
+}
+
+func TestRun_FailedTemplateKeepsOutput(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n---\ncompile:\n  script: make\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	os.WriteFile("README.md", []byte("previous"), 0644)
+	os.WriteFile("README.md.tmpl", []byte("partial {{ range .Components }}{{ index .Inputs 3 }}{{ end }}"), 0644)
+	for _, args := range [][]string{{"--backup"}, nil} {
+		if got := exitCode(run(append([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}, args...), io.Discard)); got != exitTemplate {
+			t.Fatalf("expected exit code %d with %v, got %d", exitTemplate, args, got)
+		}
+		if data, _ := os.ReadFile("README.md"); string(data) != "previous" {
+			t.Errorf("expected the output to be left alone with %v, got %q", args, data)
+		}
+	}
+	if matches, _ := filepath.Glob(".README.md.*.tmp"); len(matches) > 0 {
+		t.Errorf("expected the partly streamed document to be removed, got %v", matches)
+	}
+
+	os.WriteFile("README.md.tmpl", []byte("{{ range .Components }}{{ .Name }}{{ end }}\n"), 0644)
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0", "--backup"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md.bak"); string(data) != "previous" {
+		t.Errorf("expected the previous output as backup, got %q", data)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestStreamDocument_ExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	settings := Settings{Format: "markdown", Template: filepath.Join(dir, "README.md.tmpl")}
+	data := TemplateData{Components: []ComponentData{{Name: "build"}}}
+
+	var out bytes.Buffer
+	if err := streamDocument(&out, settings, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "## build\n") {
+		t.Errorf("expected the default template's document, got %q", out.String())
+	}
+	if code := exitCode(streamDocument(failingWriter{}, settings, data)); code != exitWrite {
+		t.Errorf("expected exit code %d for a failing writer, got %d", exitWrite, code)
+	}
+	os.WriteFile(settings.Template, []byte("{{ .Missing }}"), 0644)
+	if code := exitCode(streamDocument(&out, settings, data)); code != exitTemplate {
+		t.Errorf("expected exit code %d for a failing template, got %d", exitTemplate, code)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// overrideExtensions maps each format to the extension of per-component templates.
+var overrideExtensions = map[string]string{
+	"markdown": ".md.tmpl",
+	"asciidoc": ".adoc.tmpl",
+	"pdf":      ".md.tmpl",
+}
+
+// ComponentTemplateData is the data of a per-component template: the
+// component, plus the project fields the README template reaches through `$`,
+// so a section copied from the README template renders unchanged.
+type ComponentTemplateData struct {
+	ComponentData
+	DataVersion   int
+	ProjectPath   string
+	Version       string
+	DefaultBranch string
+	Mirrors       []MirrorData
+}
+
+// overridePaths returns the candidate per-component templates of a component,
+// in lookup order: templates/<name>.md.tmpl, then docs/<name>.md.tmpl (with
+// .adoc.tmpl for AsciiDoc output).
+func overridePaths(settings Settings, name string) []string {
+	ext, ok := overrideExtensions[settings.Format]
+	if !ok {
+		return nil
+	}
+	return []string{
+		filepath.Join(settings.TemplatesDir, name+ext),
+		filepath.Join(settings.DocsDir, name+ext),
+	}
+}
+
+// renderOverride renders a component with its per-component template, or
+// returns "" when it has none. The section is surrounded by newlines, like
+// the ones of the README template, so it merges into the document in place.
+func renderOverride(settings Settings, data TemplateData, c ComponentData, funcs template.FuncMap) (string, error) {
+	for _, path := range overridePaths(settings, c.Name) {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading component template %s: %w", path, err)
+		}
+		if content, err = decodeSource(path, content); err != nil {
+			return "", err
+		}
+		tmpl, err := newTemplate(filepath.Base(path), string(content), funcs, settings.Format, settings.PartialsDir)
+		if err != nil {
+			return "", fmt.Errorf("error parsing component template %s: %w", path, err)
+		}
+		applyFieldAliases(tmpl, funcs, reflect.TypeOf(ComponentTemplateData{}))
+		out := getBuffer()
+		defer putBuffer(out)
+		err = tmpl.Execute(out, ComponentTemplateData{
+			ComponentData: c,
+			DataVersion:   data.DataVersion,
+			ProjectPath:   data.ProjectPath,
+			Version:       data.Version,
+			DefaultBranch: data.DefaultBranch,
+			Mirrors:       data.Mirrors,
+		})
+		if err != nil {
+			return "", fmt.Errorf("error executing component template %s: %w", path, err)
+		}
+		return "\n" + strings.Trim(out.String(), "\n") + "\n", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_ComponentTemplates(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	for _, name := range []string{"build", "deploy", "lint"} {
+		os.WriteFile(filepath.Join(dir, "templates", name+".yml"), []byte("spec:\n  inputs:\n    stage:\n      default: "+name+"\n"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "templates", "build.md.tmpl"), []byte("## {{ .Name }} (custom)\n\nUse `{{ $.ProjectPath }}/{{ .Name }}@{{ .Version }}` with {{ len .Inputs }} input.{{ override . }}\n\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "lint.md.tmpl"), []byte("## Linting\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "lint.adoc.tmpl"), []byte("== Linting\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	doc := string(data)
+	expected := "## build (custom)\n\nUse `g/p/build@1.0.0` with 1 input.\n\n## deploy\n"
+	if !strings.Contains(doc, expected) {
+		t.Errorf("expected the build section from its template, followed by deploy, got:\n%s", doc)
+	}
+	if !strings.HasSuffix(doc, "## Linting\n") {
+		t.Errorf("expected the lint section from docs/lint.md.tmpl at the end, got:\n%s", doc)
+	}
+	if strings.Count(doc, "### Inputs") != 1 {
+		t.Errorf("expected only deploy to use the default section, got:\n%s", doc)
+	}
+
+	if err := run(append(args, "--format", "asciidoc", "--output", "README.adoc"), io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.HasSuffix(string(data), "== Linting\n") || !strings.Contains(string(data), "== build\n") {
+		t.Errorf("expected only the AsciiDoc lint template to apply, got:\n%s", data)
+	}
+
+	os.WriteFile(filepath.Join("templates", "build.md.tmpl"), []byte("{{ .Missing }}"), 0644)
+	if got := exitCode(run(args, io.Discard)); got != exitTemplate {
+		t.Errorf("expected exit code %d for a broken component template, got %d", exitTemplate, got)
+	}
+}
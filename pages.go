@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// PagesConfig configures the per-component pages written for a static site
+// generator (`pages`, `--front-matter`).
+type PagesConfig struct {
+	FrontMatter string         `yaml:"front_matter"` // hugo, docusaurus or jekyll; empty writes no pages
+	OutputDir   string         `yaml:"output_dir"`   // default defaultPagesDir
+	Weights     map[string]int `yaml:"weights"`      // component -> position in the site's navigation
+}
+
+// frontMatterFormats are the site generators pages have front-matter for.
+var frontMatterFormats = []string{"hugo", "docusaurus", "jekyll"}
+
+// defaultPagesDir is where pages are written when `pages.output_dir` is unset.
+const defaultPagesDir = "pages"
+
+// weightKeys name the ordering key of each site generator: Jekyll has none,
+// `nav_order` is the one of the Just the Docs theme.
+var weightKeys = map[string]string{
+	"hugo":       "weight",
+	"docusaurus": "sidebar_position",
+	"jekyll":     "nav_order",
+}
+
+// page is a per-component output file.
+type page struct {
+	Path    string
+	Content []byte
+}
+
+// pagePath returns the page of a component in dir; nested component names
+// get nested pages.
+func pagePath(dir, name string) string {
+	return filepath.Join(dir, filepath.FromSlash(name)+".md")
+}
+
+// pageFrontMatter returns the front-matter block of a component's page: its
+// title (the description's title, else its name), slug and, when configured,
+// weight.
+func pageFrontMatter(pages PagesConfig, c ComponentData) ([]byte, error) {
+	title := c.Title
+	if title == "" {
+		title = c.Name
+	}
+	front := yaml.MapSlice{
+		{Key: "title", Value: title},
+		{Key: "slug", Value: strings.ReplaceAll(c.Name, "/", "-")},
+	}
+	if weight, ok := pages.Weights[c.Name]; ok {
+		front = append(front, yaml.MapItem{Key: weightKeys[pages.FrontMatter], Value: weight})
+	}
+	out, err := yaml.Marshal(front)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the front-matter of %s: %w", c.Name, err)
+	}
+	return append(append([]byte("---\n"), out...), "---\n\n"...), nil
+}
+
+// renderPages renders one page per component with the README template,
+// prefixed with front-matter. The header and footer files belong to the
+// README, and are left out. Weights naming unknown components are returned
+// as warnings.
+func renderPages(settings Settings, data TemplateData) ([]page, []string, error) {
+	settings.HeaderFile, settings.FooterFile = "", ""
+	known := make(map[string]bool, len(data.Components))
+	var pages []page
+	for _, c := range data.Components {
+		known[c.Name] = true
+		single := data
+		single.Components = []ComponentData{c}
+		doc, err := renderDocument(settings, single)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error rendering the page of %s: %w", c.Name, err)
+		}
+		front, err := pageFrontMatter(settings.Pages, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		pages = append(pages, page{
+			Path:    pagePath(settings.Pages.OutputDir, c.Name),
+			Content: append(front, bytes.TrimLeft(doc, "\n")...),
+		})
+	}
+
+	var warnings []string
+	for name := range settings.Pages.Weights {
+		if !known[name] {
+			warnings = append(warnings, fmt.Sprintf("pages: weight of unknown component %q", name))
+		}
+	}
+	sort.Strings(warnings)
+	return pages, warnings, nil
+}
+
+// writePages writes pages, creating their directories.
+func writePages(pages []page) ([]writtenFile, error) {
+	var written []writtenFile
+	for _, p := range pages {
+		if err := os.MkdirAll(filepath.Dir(p.Path), 0755); err != nil {
+			return written, fmt.Errorf("error creating %s: %w", filepath.Dir(p.Path), err)
+		}
+		status := writeStatus(p.Path, p.Content)
+		if err := writeFileAtomic(p.Path, p.Content); err != nil {
+			return written, fmt.Errorf("error writing %s: %w", p.Path, err)
+		}
+		written = append(written, writtenFile{p.Path, status})
+	}
+	return written, nil
+}
+
+// outdatedPages returns the paths of the pages that differ from the files
+// on disk, or are missing.
+func outdatedPages(pages []page) []string {
+	var outdated []string
+	for _, p := range pages {
+		if current, err := os.ReadFile(p.Path); err != nil || !sameDocument(current, p.Content) {
+			outdated = append(outdated, p.Path)
+		}
+	}
+	return outdated
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageFrontMatter(t *testing.T) {
+	c := ComponentData{Name: "aws/deploy", Title: "Deploy: AWS"}
+	tests := []struct {
+		pages PagesConfig
+		want  string
+	}{
+		{PagesConfig{FrontMatter: "hugo", Weights: map[string]int{"aws/deploy": 3}}, "---\ntitle: \"Deploy: AWS\"\nslug: aws-deploy\nweight: 3\n---\n\n"},
+		{PagesConfig{FrontMatter: "docusaurus", Weights: map[string]int{"aws/deploy": 3}}, "---\ntitle: \"Deploy: AWS\"\nslug: aws-deploy\nsidebar_position: 3\n---\n\n"},
+		{PagesConfig{FrontMatter: "jekyll", Weights: map[string]int{"aws/deploy": 3}}, "---\ntitle: \"Deploy: AWS\"\nslug: aws-deploy\nnav_order: 3\n---\n\n"},
+		{PagesConfig{FrontMatter: "hugo"}, "---\ntitle: \"Deploy: AWS\"\nslug: aws-deploy\n---\n\n"},
+	}
+	for _, tt := range tests {
+		got, err := pageFrontMatter(tt.pages, c)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.pages.FrontMatter, tt.want, got, err)
+		}
+	}
+	if got, _ := pageFrontMatter(PagesConfig{FrontMatter: "hugo"}, ComponentData{Name: "build"}); !strings.HasPrefix(string(got), "---\ntitle: build\n") {
+		t.Errorf("expected the name as title without one, got %q", got)
+	}
+}
+
+func TestResolveSettings_Pages(t *testing.T) {
+	settings, err := resolveSettings(ProjectConfig{Pages: PagesConfig{FrontMatter: "hugo"}}, Settings{})
+	if err != nil || settings.Pages.OutputDir != defaultPagesDir {
+		t.Errorf("expected the default pages directory, got %+v, %v", settings.Pages, err)
+	}
+	if settings, _ := resolveSettings(ProjectConfig{Pages: PagesConfig{FrontMatter: "hugo"}}, Settings{Pages: PagesConfig{FrontMatter: "jekyll"}}); settings.Pages.FrontMatter != "jekyll" {
+		t.Errorf("expected the flag to win, got %q", settings.Pages.FrontMatter)
+	}
+	if _, err := resolveSettings(ProjectConfig{}, Settings{Pages: PagesConfig{FrontMatter: "gatsby"}}); err == nil {
+		t.Error("expected an unknown front-matter to be rejected")
+	}
+	if _, err := resolveSettings(ProjectConfig{Format: "asciidoc"}, Settings{Pages: PagesConfig{FrontMatter: "hugo"}}); err == nil {
+		t.Error("expected pages to need the markdown format")
+	}
+}
+
+func TestRun_FrontMatterPages(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "aws"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "aws", "deploy.yml"), []byte("spec:\n  inputs:\n    region:\n      default: eu-west-1\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "header.md"), []byte("# Components\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("header_file: header.md\npages:\n  output_dir: site/components\n  weights:\n    build: 2\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--front-matter", "hugo"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join("site", "components", "build.md"))
+	if err != nil {
+		t.Fatalf("expected the page to be written: %v", err)
+	}
+	page := string(data)
+	if !strings.HasPrefix(page, "---\ntitle: build\nslug: build\nweight: 2\n---\n\n## build\n") {
+		t.Errorf("expected front-matter and the component's section, got:\n%s", page)
+	}
+	if strings.Contains(page, "# Components") || strings.Contains(page, "region") {
+		t.Errorf("expected the page to hold only its component, without the header, got:\n%s", page)
+	}
+	if _, err := os.Stat(filepath.Join("site", "components", "aws", "deploy.md")); err != nil {
+		t.Errorf("expected a nested page for aws/deploy: %v", err)
+	}
+
+	check := append([]string{"check"}, args...)
+	if err := run(check, io.Discard); err != nil {
+		t.Errorf("expected up-to-date pages to pass --check, got %v", err)
+	}
+	os.WriteFile(filepath.Join("site", "components", "build.md"), []byte("stale\n"), 0644)
+	if code := exitCode(run(check, io.Discard)); code != exitOutdated {
+		t.Errorf("expected exit code %d for an outdated page, got %d", exitOutdated, code)
+	}
+}
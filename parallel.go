@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TemplateError is a template that failed to parse.
+type TemplateError struct {
+	Path string
+	Line int // 1-based line of the problem, 0 when unknown
+	Err  error
+}
+
+func (e TemplateError) Error() string { return e.Err.Error() }
+
+func (e TemplateError) Unwrap() error { return e.Err }
+
+// ParseErrors lists the templates that failed to parse, in path order.
+type ParseErrors []TemplateError
+
+func (e ParseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	lines := []string{fmt.Sprintf("%d templates failed to parse:", len(e))}
+	for _, err := range e {
+		lines = append(lines, "  "+strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newTemplateError wraps the parse error of a template, with the line of the
+// problem when known.
+func newTemplateError(path string, err error) TemplateError {
+	e := TemplateError{Path: path, Err: err}
+	var located *YAMLError
+	if errors.As(err, &located) {
+		e.Line = located.Line
+	}
+	return e
+}
+
+// parseTemplates parses the given component templates with up to jobs
+// concurrent workers. Results keep the order of paths. Every failing
+// template is reported, in path order, as ParseErrors, along with the
+// components that did parse, so the outcome never depends on scheduling.
+func parseTemplates(paths []string, opts ParseOptions, jobs int) ([]ComponentData, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > len(paths) {
+		jobs = len(paths)
+	}
+
+	components := make([]ComponentData, len(paths))
+	errs := make([]error, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				components[i], errs[i] = opts.Cache.parse(paths[i], opts)
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var parsed []ComponentData
+	var failed ParseErrors
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, newTemplateError(paths[i], err))
+			continue
+		}
+		parsed = append(parsed, components[i])
+	}
+	if failed != nil {
+		return parsed, failed
+	}
+	return parsed, nil
+}
+
+// parseSelected parses the templates passing the include/exclude filters and
+// returns the components sorted by name. Skipped templates are logged.
+// Unchanged templates are taken from cache, when not nil. When templates
+// fail to parse, the error is ParseErrors and the components are those that
+// parsed, so --keep-going can still document them.
+func parseSelected(settings Settings, templates []string, jobs int, log *logger, cache *parseCache) ([]ComponentData, error) {
+	var selected []string
+	for _, t := range templates {
+		if componentSelected(settings, componentName(settings.TemplatesDir, t), t) {
+			selected = append(selected, t)
+		} else {
+			log.Verbosef("Skipping %s: filtered out by include/exclude", t)
+		}
+	}
+	opts := settings.parseOptions()
+	opts.Log = log
+	opts.Cache = cache
+	components, parseErr := parseTemplates(selected, opts, jobs)
+	sortComponents(components)
+	for _, warning := range applyDeprecations(settings.Deprecations, components) {
+		log.Warnf("%s", warning)
+	}
+	for _, warning := range applyGroups(settings.Groups, components) {
+		log.Warnf("%s", warning)
+	}
+	for i := range components {
+		components[i].secrets = scanSecrets(components[i], settings.SecretScan)
+	}
+	for _, warning := range applySensitive(settings.SensitiveInputs, components) {
+		log.Warnf("%s", warning)
+	}
+	applyDefaultNotes(settings.ComplexDefaults, settings.Groups.Order, components)
+	warnings, err := applyRequiredVariables(settings.RequiredVariables, settings.DocsDir, components)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		log.Warnf("%s", warning)
+	}
+	return components, parseErr
+}
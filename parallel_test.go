@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeComponents creates n component templates in dir and returns their paths.
+func writeComponents(t testing.TB, dir string, n int) []string {
+	t.Helper()
+	var paths []string
+	for i := 0; i < n; i++ {
+		content := fmt.Sprintf(`spec:
+  inputs:
+    stage:
+      default: test
+    name_%d:
+      description: "Input %d"
+---
+job-%d:
+  stage: $[[ inputs.stage ]]
+  script:
+    - echo $[[ inputs.name_%d ]]
+`, i, i, i, i)
+		path := filepath.Join(dir, fmt.Sprintf("component-%03d.yml", i))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestParseTemplates_Deterministic(t *testing.T) {
+	paths := writeComponents(t, t.TempDir(), 50)
+
+	sequential, err := parseTemplates(paths, ParseOptions{}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, jobs := range []int{2, 8, 100} {
+		parallel, err := parseTemplates(paths, ParseOptions{}, jobs)
+		if err != nil {
+			t.Fatalf("unexpected error with %d jobs: %v", jobs, err)
+		}
+		if !reflect.DeepEqual(sequential, parallel) {
+			t.Errorf("expected the same result with %d jobs as sequentially", jobs)
+		}
+	}
+	if sequential[0].Name != "component-000" || sequential[49].Name != "component-049" {
+		t.Errorf("expected results in path order, got %s ... %s", sequential[0].Name, sequential[49].Name)
+	}
+}
+
+func TestParseTemplates_AllErrors(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeComponents(t, dir, 10)
+	for _, i := range []int{3, 7} {
+		os.WriteFile(paths[i], []byte("spec:\n  inputs: [\n"), 0644)
+	}
+
+	for _, jobs := range []int{1, 4} {
+		components, err := parseTemplates(paths, ParseOptions{}, jobs)
+		var failed ParseErrors
+		if !errors.As(err, &failed) {
+			t.Fatalf("expected ParseErrors with %d jobs, got %v", jobs, err)
+		}
+		if len(failed) != 2 || failed[0].Path != paths[3] || failed[1].Path != paths[7] {
+			t.Errorf("expected every failing template in path order with %d jobs, got %v", jobs, err)
+		}
+		if failed[0].Line != 2 || !strings.HasPrefix(err.Error(), "2 templates failed to parse:\n  "+paths[3]+":2:11: ") {
+			t.Errorf("expected file and line context, got %v", err)
+		}
+		if len(components) != 8 {
+			t.Errorf("expected the other components to be parsed, got %d", len(components))
+		}
+	}
+
+	_, err := parseTemplates(paths[:4], ParseOptions{}, 1)
+	if err == nil || !strings.HasPrefix(err.Error(), paths[3]+":2:11: ") {
+		t.Errorf("expected a single error to be reported as is, got %v", err)
+	}
+}
+
+func TestRun_KeepGoing(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "broken.yml"), []byte("spec: [\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}
+	if got := exitCode(run(args, io.Discard)); got != exitParse {
+		t.Errorf("expected exit code %d, got %d", exitParse, got)
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected nothing to be written without --keep-going")
+	}
+
+	if got := exitCode(run(append(args, "--keep-going"), io.Discard)); got != exitParse {
+		t.Errorf("expected exit code %d with --keep-going, got %d", exitParse, got)
+	}
+	data, _ := os.ReadFile("README.md")
+	if !strings.Contains(string(data), "## build") || strings.Contains(string(data), "broken") {
+		t.Errorf("expected the valid component to be documented, got:\n%s", data)
+	}
+}
+
+func TestRun_InvalidJobs(t *testing.T) {
+	if got := exitCode(run([]string{"--jobs", "0"}, nil)); got != exitConfig {
+		t.Errorf("expected exit code %d for --jobs 0, got %d", exitConfig, got)
+	}
+}
+
+func BenchmarkParseTemplates(b *testing.B) {
+	paths := writeComponents(b, b.TempDir(), 300)
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := parseTemplates(paths, ParseOptions{}, jobs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// builtinPartials holds the sections of the default templates, one
+// `{{ template "name" . }}` partial per file.
+//
+//go:embed templates-md/*.tmpl templates-adoc/*.tmpl
+var builtinPartials embed.FS
+
+// defaultPartialsDirs maps each template-based format to the directory of
+// its built-in partials, which is also where a project's partials are read
+// from when `partials_dir` is unset.
+var defaultPartialsDirs = map[string]string{
+	"markdown": "templates-md",
+	"asciidoc": "templates-adoc",
+	"pdf":      "templates-md", // rendered as Markdown, then converted
+}
+
+// partialName returns the name a partial file defines: its file name up to
+// the first dot, inputs_table.tmpl defining "inputs_table".
+func partialName(file string) string {
+	name, _, _ := strings.Cut(filepath.Base(file), ".")
+	return name
+}
+
+// newTemplate parses a README or per-component template together with the
+// partials it can call: the built-in ones of format, then the ones defined
+// by the template itself, then the *.tmpl files of partialsDir, each
+// replacing the earlier partials of the same name.
+func newTemplate(name, content string, funcs template.FuncMap, format, partialsDir string) (*template.Template, error) {
+	tmpl := template.New(name).Funcs(funcs)
+	if dir, ok := defaultPartialsDirs[format]; ok {
+		files, _ := fs.Glob(builtinPartials, path.Join(dir, "*.tmpl"))
+		for _, file := range files {
+			data, _ := builtinPartials.ReadFile(file)
+			if err := addPartial(tmpl, file, data, funcs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if _, err := tmpl.Parse(content); err != nil {
+		return nil, err
+	}
+	if partialsDir == "" {
+		return tmpl, nil
+	}
+	files, _ := filepath.Glob(filepath.Join(partialsDir, "*.tmpl"))
+	sort.Strings(files)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading partial %s: %w", file, err)
+		}
+		if data, err = decodeSource(file, data); err != nil {
+			return nil, err
+		}
+		if err := addPartial(tmpl, file, data, funcs); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// addPartial parses a partial file into tmpl, under the name of the file,
+// along with the templates it defines. The final newline editors add is
+// dropped, so it doesn't end up in the document.
+func addPartial(tmpl *template.Template, file string, content []byte, funcs template.FuncMap) error {
+	parsed, err := template.New(file).Funcs(funcs).Parse(strings.TrimSuffix(string(content), "\n"))
+	if err != nil {
+		return err
+	}
+	for _, t := range parsed.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		name := t.Name()
+		if name == file {
+			name = partialName(file)
+		}
+		if _, err := tmpl.AddParseTree(name, t.Tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPartialName(t *testing.T) {
+	for file, want := range map[string]string{
+		"templates-md/inputs_table.tmpl":    "inputs_table",
+		"templates-md/inputs_table.md.tmpl": "inputs_table",
+		"examples.tmpl":                     "examples",
+	} {
+		if got := partialName(file); got != want {
+			t.Errorf("partialName(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestNewTemplate_Partials(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "examples.tmpl"), []byte("[{{ .Name }} examples]\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "helpers.tmpl"), []byte(`{{ define "shout" }}{{ .Name }}!{{ end }}`), 0644)
+
+	funcs := templateFuncs(Settings{Format: "markdown"})
+	tmpl, err := newTemplate("README.md.tmpl", `{{ template "examples" . }} {{ template "shout" . }} {{ template "mine" . }}{{ define "mine" }}<{{ .Name }}>{{ end }}`, funcs, "markdown", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, ComponentData{Name: "build"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "[build examples] build! <build>"; out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+	if tmpl.Lookup("inputs_table") == nil {
+		t.Error("expected the built-in partials to stay available")
+	}
+
+	os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte("{{ .Name "), 0644)
+	if _, err := newTemplate("README.md.tmpl", "", funcs, "markdown", dir); err == nil || !strings.Contains(err.Error(), "broken.tmpl") {
+		t.Errorf("expected a parse error naming the partial, got %v", err)
+	}
+}
+
+func TestRun_Partials(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "templates-md"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n      description: Stage of the job.\n---\nbuild:\n  stage: $[[ inputs.stage ]]\n  script: make\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates-md", "inputs_table.tmpl"), []byte("{{ range .Inputs }}\n- `{{ .Name }}` ({{ .Type }}): {{ .Description }}\n{{ end }}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	readme := string(data)
+	if !strings.Contains(readme, "### Inputs\n\n- `stage` (string): Stage of the job.\n\n### Jobs") {
+		t.Errorf("expected the inputs as a list, in the built-in inputs section, got:\n%s", readme)
+	}
+	if strings.Contains(readme, "| Name |") {
+		t.Errorf("expected no inputs table, got:\n%s", readme)
+	}
+
+	os.WriteFile(filepath.Join("templates-md", "inputs_table.tmpl"), []byte("{{ range .Inputs }}{{ .Nmae }}{{ end }}{{ template \"missing\" . }}\n"), 0644)
+	var out bytes.Buffer
+	if code := exitCode(run([]string{"template", "lint"}, &out)); code != exitTemplate {
+		t.Errorf("expected exit code %d, got %d", exitTemplate, code)
+	}
+	for _, want := range []string{"inputs_table.tmpl:1:22: error: unknown field .Nmae in InputData", `error: no template or partial named "missing"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
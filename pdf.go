@@ -0,0 +1,871 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The PDF output is rendered from the Markdown document, through the HTML of
+// the live preview (markdownHTML), with the standard PDF fonts: nothing is
+// embedded, and the same document always gives the same bytes, so --check
+// works. Each component (a level-2 heading) starts a new page.
+
+// Page geometry, in points: A4 with even margins.
+const (
+	pdfPageWidth    = 595.28
+	pdfPageHeight   = 841.89
+	pdfMargin       = 50.0
+	pdfContentWidth = pdfPageWidth - 2*pdfMargin
+	pdfFooterY      = 28.0
+	pdfFontSize     = 10.0 // of body text
+)
+
+// pdfFont is one of the standard fonts of the document.
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+	fontItalic
+	fontCode
+)
+
+// pdfFontNames are the base fonts, in pdfFont order (resources /F1 to /F4).
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Courier"}
+
+// Widths of the printable ASCII characters (32-126) of Helvetica and
+// Helvetica-Bold, in thousandths of the font size, from their metrics.
+// Helvetica-Oblique has Helvetica's; Courier is monospaced (600).
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// textWidth returns the width of WinAnsi-encoded text, in points.
+func textWidth(text string, font pdfFont, size float64) float64 {
+	if font == fontCode {
+		return float64(len(text)) * 600 * size / 1000
+	}
+	widths := &helveticaWidths
+	if font == fontBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c >= 32 && c <= 126:
+			total += widths[c-32]
+		case c == 0x95: // bullet
+			total += 350
+		case c == 0x97: // em dash
+			total += 1000
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// winAnsiRunes maps the characters of WinAnsiEncoding outside Latin-1.
+var winAnsiRunes = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfFallbacks replaces the symbols the tool writes that the standard fonts
+// lack.
+var pdfFallbacks = map[rune]string{'⚠': "!", '→': "->", '←': "<-", '✓': "v", '✔': "v", '✗': "x", '✘': "x"}
+
+// winAnsi encodes text for the standard fonts. Variation selectors are
+// dropped, and other characters the fonts lack become '?'.
+func winAnsi(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b.WriteByte(byte(r))
+		case winAnsiRunes[r] != 0:
+			b.WriteByte(winAnsiRunes[r])
+		case pdfFallbacks[r] != "":
+			b.WriteString(pdfFallbacks[r])
+		case r >= 0xfe00 && r <= 0xfe0f, r == 0x200d:
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfString quotes WinAnsi-encoded text as a PDF literal string.
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfTextString encodes text as UTF-16, for the document information and
+// bookmarks.
+func pdfTextString(text string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// num formats a coordinate.
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// htmlNode is an element or, without a tag, a text node of the HTML tree.
+type htmlNode struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	children []*htmlNode
+}
+
+// parseHTML parses the HTML fragment produced by markdownHTML.
+func parseHTML(fragment string) (*htmlNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<body>" + fragment + "</body>"))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	root := &htmlNode{tag: "root"}
+	stack := []*htmlNode{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the HTML of the document: %w", err)
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &htmlNode{tag: strings.ToLower(t.Name.Local), attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			// Close up to the matching element; stray end tags are ignored
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == strings.ToLower(t.Name.Local) {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &htmlNode{text: string(t)})
+		}
+	}
+}
+
+// textContent returns the text of a node and its descendants.
+func (n *htmlNode) textContent() string {
+	if n.tag == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(child.textContent())
+	}
+	return b.String()
+}
+
+// pdfSpan is a run of WinAnsi-encoded text in one style.
+type pdfSpan struct {
+	text   string
+	font   pdfFont
+	link   bool
+	strike bool
+	brk    bool // a forced line break
+}
+
+// pdfBar is a vertical rule left of quoted blocks and alerts.
+type pdfBar struct {
+	x     float64
+	color string // PDF stroke color operator
+}
+
+// alertColors are the bar colors of GitHub-style alerts.
+var alertColors = map[string]string{
+	"alert-note":      "0.12 0.44 0.85 RG",
+	"alert-tip":       "0.11 0.54 0.27 RG",
+	"alert-important": "0.51 0.31 0.85 RG",
+	"alert-warning":   "0.75 0.52 0.05 RG",
+	"alert-caution":   "0.82 0.18 0.18 RG",
+}
+
+// bookmark is an entry of the document outline: a component's heading.
+type bookmark struct {
+	title string
+	page  int
+	y     float64
+}
+
+// pdfLayout lays out the HTML tree on pages, top to bottom.
+type pdfLayout struct {
+	pages     []*bytes.Buffer
+	page      *bytes.Buffer
+	y         float64 // top of the free space on the page
+	top       float64 // where the content of the page starts
+	bars      []pdfBar
+	marker    string // list marker drawn on the next line
+	markerX   float64
+	bookmarks []bookmark
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = new(bytes.Buffer)
+	l.pages = append(l.pages, l.page)
+	l.y = pdfPageHeight - pdfMargin
+	l.top = l.y
+}
+
+// atTop reports whether nothing was drawn on the page yet.
+func (l *pdfLayout) atTop() bool {
+	return l.y >= l.top
+}
+
+// advance reserves a slot of height h, starting a new page when it doesn't
+// fit, draws the quote bars along it and returns the top of the slot.
+func (l *pdfLayout) advance(h float64) float64 {
+	if l.y-h < pdfMargin && !l.atTop() {
+		l.newPage()
+	}
+	top := l.y
+	for _, bar := range l.bars {
+		fmt.Fprintf(l.page, "%s 2 w %s %s m %s %s l S\n", bar.color, num(bar.x), num(top), num(bar.x), num(top-h))
+	}
+	l.y -= h
+	return top
+}
+
+// space leaves vertical space, within the page.
+func (l *pdfLayout) space(h float64) {
+	if !l.atTop() {
+		l.y = max(l.y-h, pdfMargin)
+	}
+}
+
+func (l *pdfLayout) text(x, y float64, font pdfFont, size float64, text, color string) {
+	fmt.Fprintf(l.page, "BT %s /F%d %s Tf 1 0 0 1 %s %s Tm %s Tj ET\n", color, font+1, num(size), num(x), num(y), pdfString(text))
+}
+
+func (l *pdfLayout) line(x1, y1, x2, y2, width float64, color string) {
+	fmt.Fprintf(l.page, "%s %s w %s %s m %s %s l S\n", color, num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+func (l *pdfLayout) fill(x, y, w, h float64, color string) {
+	fmt.Fprintf(l.page, "%s %s %s %s %s re f\n", color, num(x), num(y), num(w), num(h))
+}
+
+// Colors of the document.
+const (
+	colorText   = "0.2 0.2 0.22 rg"
+	colorLink   = "0.12 0.38 0.8 rg"
+	colorMuted  = "0.45 0.45 0.47 rg"
+	colorRule   = "0.8 0.8 0.82 RG"
+	colorCodeBg = "0.95 0.95 0.96 rg"
+	colorHeadBg = "0.93 0.93 0.95 rg"
+)
+
+// drawLine draws a line of spans with its baseline at y, and the pending
+// list marker.
+func (l *pdfLayout) drawLine(spans []pdfSpan, x, y, size float64) {
+	if l.marker != "" {
+		l.text(l.markerX, y, fontRegular, size, l.marker, colorText)
+		l.marker = ""
+	}
+	for _, s := range spans {
+		w := textWidth(s.text, s.font, size)
+		color := colorText
+		if s.link {
+			color = colorLink
+		}
+		if s.font == fontCode && strings.TrimSpace(s.text) != "" {
+			l.fill(x-1, y-size*0.25, w+2, size*1.1, colorCodeBg)
+		}
+		l.text(x, y, s.font, size, s.text, color)
+		if s.strike {
+			l.line(x, y+size*0.3, x+w, y+size*0.3, 0.6, "0.2 0.2 0.22 RG")
+		}
+		x += w
+	}
+}
+
+// wrap breaks spans into lines of at most width points. Words longer than a
+// line are broken anywhere.
+func wrap(spans []pdfSpan, width, size float64) [][]pdfSpan {
+	// A word is made of pieces without whitespace between them, possibly in
+	// several styles, e.g. a link followed by a comma
+	type word struct {
+		pieces []pdfSpan
+		space  bool // preceded by whitespace
+		brk    bool
+	}
+	var words []word
+	space := false
+	for _, s := range spans {
+		if s.brk {
+			words = append(words, word{brk: true})
+			space = false
+			continue
+		}
+		text := strings.Join(strings.Fields(s.text), " ")
+		if text == "" {
+			space = space || s.text != ""
+			continue
+		}
+		leading := strings.TrimLeft(s.text, " \t\n") != s.text
+		trailing := strings.TrimRight(s.text, " \t\n") != s.text
+		for i, part := range strings.Split(text, " ") {
+			piece := s
+			piece.text = part
+			if i == 0 && !leading && !space && len(words) > 0 && !words[len(words)-1].brk {
+				last := &words[len(words)-1]
+				last.pieces = append(last.pieces, piece)
+				continue
+			}
+			words = append(words, word{pieces: []pdfSpan{piece}, space: true})
+		}
+		space = trailing
+	}
+
+	var lines [][]pdfSpan
+	var current []pdfSpan
+	used := 0.0
+	flush := func() {
+		// One run per style: fewer, longer text operations
+		var merged []pdfSpan
+		for _, s := range current {
+			if n := len(merged); n > 0 && merged[n-1].font == s.font && merged[n-1].link == s.link && merged[n-1].strike == s.strike {
+				merged[n-1].text += s.text
+				continue
+			}
+			merged = append(merged, s)
+		}
+		lines = append(lines, merged)
+		current, used = nil, 0
+	}
+	for _, w := range words {
+		if w.brk {
+			flush()
+			continue
+		}
+		wordWidth := 0.0
+		for _, p := range w.pieces {
+			wordWidth += textWidth(p.text, p.font, size)
+		}
+		spaceWidth := 0.0
+		if len(current) > 0 && w.space {
+			spaceWidth = textWidth(" ", w.pieces[0].font, size)
+		}
+		if len(current) > 0 && used+spaceWidth+wordWidth > width {
+			flush()
+			spaceWidth = 0
+		}
+		if spaceWidth > 0 {
+			// Spaces take the style of the text around them, or the regular one
+			space := pdfSpan{text: " "}
+			if last := current[len(current)-1]; last.font == w.pieces[0].font && last.link == w.pieces[0].link {
+				space = last
+				space.text = " "
+			}
+			current = append(current, space)
+			used += spaceWidth
+		}
+		for _, p := range w.pieces {
+			// Break what doesn't fit on an empty line anywhere
+			for pw := textWidth(p.text, p.font, size); used+pw > width && len(p.text) > 1; pw = textWidth(p.text, p.font, size) {
+				if used > 0 && pw <= width {
+					flush()
+					continue
+				}
+				n := 0
+				for n < len(p.text) && used+textWidth(p.text[:n+1], p.font, size) <= width {
+					n++
+				}
+				if n == 0 && used > 0 {
+					flush()
+					continue
+				}
+				head := p
+				head.text = p.text[:max(n, 1)]
+				current = append(current, head)
+				flush()
+				p.text = p.text[max(n, 1):]
+			}
+			current = append(current, p)
+			used += textWidth(p.text, p.font, size)
+		}
+	}
+	if len(current) > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// spansOf returns the inline content of nodes as spans.
+func spansOf(nodes []*htmlNode, base pdfSpan) []pdfSpan {
+	var spans []pdfSpan
+	for _, n := range nodes {
+		style := base
+		switch n.tag {
+		case "":
+			style.text = winAnsi(n.text)
+			spans = append(spans, style)
+			continue
+		case "br":
+			spans = append(spans, pdfSpan{brk: true})
+			continue
+		case "img":
+			style.text, style.font = winAnsi("["+n.attrs["alt"]+"]"), fontItalic
+			spans = append(spans, style)
+			continue
+		case "input":
+			style.text, style.font = "[ ] ", fontCode
+			if _, checked := n.attrs["checked"]; checked {
+				style.text = "[x] "
+			}
+			spans = append(spans, style)
+			continue
+		case "strong", "b", "th":
+			if style.font != fontCode {
+				style.font = fontBold
+			}
+		case "em", "i":
+			if style.font == fontRegular {
+				style.font = fontItalic
+			}
+		case "code", "kbd":
+			style.font = fontCode
+		case "a":
+			style.link = true
+		case "del", "s":
+			style.strike = true
+		}
+		spans = append(spans, spansOf(n.children, style)...)
+	}
+	return spans
+}
+
+// blockTags are the elements laid out as blocks; the others are inline.
+var blockTags = []string{"p", "h1", "h2", "h3", "h4", "h5", "h6", "pre", "ul", "ol", "li", "table", "blockquote", "div", "details", "summary", "hr", "body"}
+
+// blocks lays out the children of n, indented by indent points. Inline
+// content between blocks forms a paragraph.
+func (l *pdfLayout) blocks(n *htmlNode, indent float64) {
+	var inline []*htmlNode
+	flush := func() {
+		text := (&htmlNode{tag: "span", children: inline}).textContent()
+		if strings.TrimSpace(text) != "" || hasTag(inline, "img", "input") {
+			l.paragraph(spansOf(inline, pdfSpan{}), indent, pdfFontSize, 4)
+		}
+		inline = nil
+	}
+	for _, child := range n.children {
+		if !contains(blockTags, child.tag) {
+			inline = append(inline, child)
+			continue
+		}
+		flush()
+		switch child.tag {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			l.heading(int(child.tag[1]-'0'), child, indent)
+		case "p":
+			base := pdfSpan{}
+			if child.attrs["class"] == "alert-title" {
+				base.font = fontBold
+			}
+			l.paragraph(spansOf(child.children, base), indent, pdfFontSize, 6)
+		case "summary":
+			l.paragraph(spansOf(child.children, pdfSpan{font: fontBold}), indent, pdfFontSize, 6)
+		case "pre":
+			l.code(child.textContent(), indent)
+		case "ul", "ol":
+			l.list(child, indent)
+		case "table":
+			l.table(child, indent)
+		case "blockquote", "div":
+			color, ok := alertColors[strings.TrimPrefix(child.attrs["class"], "alert ")]
+			if !ok {
+				color = colorRule
+			}
+			l.bars = append(l.bars, pdfBar{x: pdfMargin + indent + 1, color: color})
+			l.blocks(child, indent+12)
+			l.bars = l.bars[:len(l.bars)-1]
+			l.space(4)
+		case "hr":
+			top := l.advance(12)
+			l.line(pdfMargin+indent, top-6, pdfMargin+pdfContentWidth, top-6, 0.8, colorRule)
+		default:
+			l.blocks(child, indent)
+		}
+	}
+	flush()
+}
+
+func hasTag(nodes []*htmlNode, tags ...string) bool {
+	for _, n := range nodes {
+		if contains(tags, n.tag) || hasTag(n.children, tags...) {
+			return true
+		}
+	}
+	return false
+}
+
+// paragraph lays out wrapped text, followed by after points of space.
+func (l *pdfLayout) paragraph(spans []pdfSpan, indent, size, after float64) {
+	lineHeight := size * 1.45
+	for _, line := range wrap(spans, pdfContentWidth-indent, size) {
+		top := l.advance(lineHeight)
+		l.drawLine(line, pdfMargin+indent, top-size*1.05, size)
+	}
+	l.space(after)
+}
+
+// headingSizes are the font sizes of headings, by level.
+var headingSizes = map[int]float64{1: 20, 2: 16, 3: 13, 4: 11.5, 5: 10.5, 6: 10}
+
+// heading lays out a heading. Level-2 headings, those of the components,
+// start a new page and are bookmarked.
+func (l *pdfLayout) heading(level int, n *htmlNode, indent float64) {
+	size := headingSizes[level]
+	if level == 2 && !l.atTop() {
+		l.newPage()
+	} else {
+		l.space(size * 0.6)
+	}
+	// Keep the heading with the start of what follows
+	if l.y-size*1.4-3*pdfFontSize*1.45 < pdfMargin && !l.atTop() {
+		l.newPage()
+	}
+	if level <= 2 {
+		title := strings.Join(strings.Fields(n.textContent()), " ")
+		l.bookmarks = append(l.bookmarks, bookmark{title: title, page: len(l.pages) - 1, y: l.y})
+	}
+	lineHeight := size * 1.3
+	for _, line := range wrap(spansOf(n.children, pdfSpan{font: fontBold}), pdfContentWidth-indent, size) {
+		top := l.advance(lineHeight)
+		l.drawLine(line, pdfMargin+indent, top-size, size)
+	}
+	if level <= 2 {
+		top := l.advance(4)
+		l.line(pdfMargin+indent, top-2, pdfMargin+pdfContentWidth, top-2, 0.6, colorRule)
+	}
+	l.space(size * 0.4)
+}
+
+// code lays out a code block on a shaded background, breaking long lines.
+func (l *pdfLayout) code(text string, indent float64) {
+	const size, lineHeight, pad = 8.5, 11.0, 4.0
+	width := pdfContentWidth - indent
+	perLine := max(int((width-2*pad)/(size*0.6)), 1)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(winAnsi(strings.ReplaceAll(text, "\t", "    ")), "\n"), "\n") {
+		for len(line) > perLine {
+			lines = append(lines, line[:perLine])
+			line = line[perLine:]
+		}
+		lines = append(lines, line)
+	}
+	top := l.advance(pad)
+	l.fill(pdfMargin+indent, top-pad, width, pad, colorCodeBg)
+	for _, line := range lines {
+		top := l.advance(lineHeight)
+		l.fill(pdfMargin+indent, top-lineHeight, width, lineHeight, colorCodeBg)
+		if l.marker != "" {
+			l.text(l.markerX, top-size, fontRegular, pdfFontSize, l.marker, colorText)
+			l.marker = ""
+		}
+		l.text(pdfMargin+indent+pad, top-size, fontCode, size, line, colorText)
+	}
+	top = l.advance(pad)
+	l.fill(pdfMargin+indent, top-pad, width, pad, colorCodeBg)
+	l.space(8)
+}
+
+// list lays out the items of a list, with bullets or numbers.
+func (l *pdfLayout) list(n *htmlNode, indent float64) {
+	i := 0
+	for _, item := range n.children {
+		if item.tag != "li" {
+			continue
+		}
+		i++
+		l.marker, l.markerX = winAnsi("•"), pdfMargin+indent+4
+		if n.tag == "ol" {
+			l.marker = strconv.Itoa(i) + "."
+		}
+		l.blocks(item, indent+16)
+		l.marker = ""
+	}
+	l.space(4)
+}
+
+// tableCell is the inline content of a cell.
+type tableCell struct {
+	spans  []pdfSpan
+	header bool
+}
+
+// table lays out a table with columns sized to their content, repeating the
+// header row on every page it spans.
+func (l *pdfLayout) table(n *htmlNode, indent float64) {
+	const size, pad = 8.5, 4.0
+	lineHeight := size * 1.35
+	var rows [][]tableCell
+	var collect func(n *htmlNode)
+	collect = func(n *htmlNode) {
+		for _, child := range n.children {
+			switch child.tag {
+			case "thead", "tbody", "tfoot":
+				collect(child)
+			case "tr":
+				var row []tableCell
+				for _, cell := range child.children {
+					if cell.tag == "th" || cell.tag == "td" {
+						base := pdfSpan{}
+						if cell.tag == "th" {
+							base.font = fontBold
+						}
+						row = append(row, tableCell{spans: spansOf(cell.children, base), header: cell.tag == "th"})
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return
+	}
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	// Natural widths (the widest cell on one line) and minimum widths (the
+	// widest word, capped), then the space left shared in proportion
+	available := pdfContentWidth - indent
+	natural, minimum := make([]float64, columns), make([]float64, columns)
+	for _, row := range rows {
+		for c, cell := range row {
+			var line float64
+			for _, s := range cell.spans {
+				line += textWidth(s.text, s.font, size)
+				for _, word := range strings.Fields(s.text) {
+					minimum[c] = max(minimum[c], min(textWidth(word, s.font, size), available/4)+2*pad)
+				}
+			}
+			natural[c] = max(natural[c], line+2*pad)
+		}
+	}
+	widths := make([]float64, columns)
+	sumNatural, sumMinimum := 0.0, 0.0
+	for c := range widths {
+		natural[c] = max(natural[c], minimum[c], 2*pad+size)
+		sumNatural += natural[c]
+		sumMinimum += minimum[c]
+	}
+	for c := range widths {
+		switch {
+		case sumNatural <= available:
+			widths[c] = natural[c]
+		case sumMinimum >= available:
+			widths[c] = minimum[c] * available / sumMinimum
+		default:
+			widths[c] = minimum[c] + (natural[c]-minimum[c])*(available-sumMinimum)/(sumNatural-sumMinimum)
+		}
+	}
+
+	var drawRow func(i int)
+	drawRow = func(i int) {
+		wrapped := make([][][]pdfSpan, len(rows[i]))
+		lines := 1
+		for c, cell := range rows[i] {
+			wrapped[c] = wrap(cell.spans, widths[c]-2*pad, size)
+			lines = max(lines, len(wrapped[c]))
+		}
+		h := float64(lines)*lineHeight + 2*pad
+		if l.y-h < pdfMargin && !l.atTop() {
+			l.newPage()
+			// Repeat the header on the new page
+			if i > 0 && rows[0][0].header {
+				drawRow(0)
+			}
+		}
+		top := l.advance(h)
+		x := pdfMargin + indent
+		for c, w := range widths {
+			if c < len(rows[i]) && rows[i][c].header {
+				l.fill(x, top-h, w, h, colorHeadBg)
+			}
+			fmt.Fprintf(l.page, "%s 0.5 w %s %s %s %s re S\n", colorRule, num(x), num(top-h), num(w), num(h))
+			if c < len(rows[i]) {
+				for j, line := range wrapped[c] {
+					l.drawLine(line, x+pad, top-pad-size-float64(j)*lineHeight, size)
+				}
+			}
+			x += w
+		}
+	}
+	for i := range rows {
+		drawRow(i)
+	}
+	l.space(8)
+}
+
+// pdfTitle is the title of the PDF document: the project and its version.
+func pdfTitle(data TemplateData) string {
+	title := "CI/CD components"
+	if data.ProjectPath != "" && data.ProjectPath != projectPathPlaceholder {
+		title += " of " + data.ProjectPath
+	}
+	if data.Version != "" {
+		title += " " + data.Version
+	}
+	return title
+}
+
+// renderPDF renders a Markdown document as a PDF document titled title.
+func renderPDF(markdown []byte, title string) ([]byte, error) {
+	root, err := parseHTML(markdownHTML(string(markdown)))
+	if err != nil {
+		return nil, err
+	}
+	l := &pdfLayout{}
+	l.newPage()
+	if title != "" {
+		l.paragraph([]pdfSpan{{text: winAnsi(title), font: fontBold}}, 0, 22, 4)
+		top := l.advance(6)
+		l.line(pdfMargin, top-3, pdfMargin+pdfContentWidth, top-3, 1.2, "0.2 0.2 0.22 RG")
+		l.space(10)
+		l.top = l.y
+	}
+	l.blocks(root, 0)
+
+	footer := winAnsi(title)
+	for i, page := range l.pages {
+		number := fmt.Sprintf("%d / %d", i+1, len(l.pages))
+		l.page = page
+		l.text(pdfMargin, pdfFooterY, fontRegular, 8, footer, colorMuted)
+		l.text(pdfPageWidth-pdfMargin-textWidth(number, fontRegular, 8), pdfFooterY, fontRegular, 8, number, colorMuted)
+	}
+	return writePDF(l.pages, l.bookmarks, title)
+}
+
+// writePDF assembles the PDF file: catalog, page tree, fonts, document
+// information, pages with compressed content streams, and the outline.
+func writePDF(pages []*bytes.Buffer, bookmarks []bookmark, title string) ([]byte, error) {
+	const (
+		catalogObj = 1
+		pagesObj   = 2
+		fontsObj   = 3 // one per font
+	)
+	infoObj := fontsObj + len(pdfFontNames)
+	pageObj := func(i int) int { return infoObj + 1 + 2*i }
+	outlinesObj := pageObj(len(pages))
+
+	var objects []string
+	add := func(content string) { objects = append(objects, content) }
+
+	catalog := fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R", pagesObj)
+	if len(bookmarks) > 0 {
+		catalog += fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", outlinesObj)
+	}
+	add(catalog + " >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj(i))
+	}
+	add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	var fonts []string
+	for i, name := range pdfFontNames {
+		add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, fontsObj+i))
+	}
+	add(fmt.Sprintf("<< /Title %s /Producer %s >>", pdfTextString(title), pdfTextString("gitlab-component-docs-gen")))
+	for i, page := range pages {
+		add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pagesObj, num(pdfPageWidth), num(pdfPageHeight), strings.Join(fonts, " "), pageObj(i)+1))
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		if _, err := w.Write(page.Bytes()); err != nil {
+			return nil, fmt.Errorf("error compressing page %d: %w", i+1, err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("error compressing page %d: %w", i+1, err)
+		}
+		add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+	if len(bookmarks) > 0 {
+		first, last := outlinesObj+1, outlinesObj+len(bookmarks)
+		add(fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", first, last, len(bookmarks)))
+		for i, b := range bookmarks {
+			item := fmt.Sprintf("<< /Title %s /Parent %d 0 R /Dest [%d 0 R /XYZ 0 %s 0]", pdfTextString(b.title), outlinesObj, pageObj(b.page), num(b.y))
+			if i > 0 {
+				item += fmt.Sprintf(" /Prev %d 0 R", first+i-1)
+			}
+			if i < len(bookmarks)-1 {
+				item += fmt.Sprintf(" /Next %d 0 R", first+i+1)
+			}
+			add(item + " >>")
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalogObj, infoObj, xref)
+	return out.Bytes(), nil
+}
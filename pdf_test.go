@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWinAnsi(t *testing.T) {
+	if got := winAnsi("Café – “x” • ⚠️ → 日"); got != "Caf\xe9 \x96 \x93x\x94 \x95 ! -> ?" {
+		t.Errorf("got %q", got)
+	}
+	if got := pdfString(`a(b)\` + "\xe9"); got != `(a\(b\)\\\351)` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := textWidth("Hi", fontRegular, 10); got != 9.44 {
+		t.Errorf("expected 9.44, got %v", got)
+	}
+	if got := textWidth("Hi", fontBold, 10); got != 10 {
+		t.Errorf("expected 10, got %v", got)
+	}
+	if got := textWidth("Hi", fontCode, 10); got != 12 {
+		t.Errorf("expected 12, got %v", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	text := func(lines [][]pdfSpan) []string {
+		var out []string
+		for _, line := range lines {
+			var b strings.Builder
+			for _, s := range line {
+				b.WriteString(s.text)
+			}
+			out = append(out, b.String())
+		}
+		return out
+	}
+	spans := []pdfSpan{{text: "Use the "}, {text: "deploy", font: fontCode}, {text: ", then wait"}}
+	if got := text(wrap(spans, 1000, 10)); strings.Join(got, "|") != "Use the deploy, then wait" {
+		t.Errorf("got %q", got)
+	}
+	if got := text(wrap(spans, 70, 10)); strings.Join(got, "|") != "Use the|deploy, then|wait" {
+		t.Errorf("expected the comma to stay with its word, got %q", got)
+	}
+	if got := text(wrap([]pdfSpan{{text: strings.Repeat("a", 30)}}, 50, 10)); len(got) != 4 || got[0] != "aaaaaaaa" {
+		t.Errorf("expected a long word to be broken, got %q", got)
+	}
+	if got := text(wrap([]pdfSpan{{text: "a"}, {brk: true}, {text: "b"}}, 100, 10)); strings.Join(got, "|") != "a|b" {
+		t.Errorf("expected a forced break, got %q", got)
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	root, err := parseHTML(markdownHTML("# Title\n\n- [x] done & dusted\n\n| a |\n|---|\n| `b` |\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body := root.children[0]
+	var tags []string
+	for _, child := range body.children {
+		if child.tag != "" {
+			tags = append(tags, child.tag)
+		}
+	}
+	if strings.Join(tags, ",") != "h1,ul,table" {
+		t.Errorf("expected h1, ul and table, got %v", tags)
+	}
+	if got := body.children[2].textContent(); !strings.Contains(got, "done & dusted") {
+		t.Errorf("expected the entities decoded, got %q", got)
+	}
+	if !hasTag(body.children[2].children, "input") || !hasTag(body.children[4].children, "code") {
+		t.Error("expected the checkbox and the code span to be kept")
+	}
+}
+
+// pdfPages checks the cross-reference table of a PDF file and returns the
+// decompressed content of its pages.
+func pdfPages(t *testing.T, data []byte) []string {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatalf("no startxref in:\n%s", data)
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(data[offset:], []byte(strconv.Itoa(i+1)+" 0 obj\n")) {
+			t.Fatalf("xref entry %d does not point at its object", i+1)
+		}
+	}
+	var pages []string
+	for _, m := range regexp.MustCompile(`<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(data[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatalf("invalid stream: %v", err)
+		}
+		content, _ := io.ReadAll(r)
+		pages = append(pages, string(content))
+	}
+	return pages
+}
+
+func TestRenderPDF(t *testing.T) {
+	doc := "- [build](#build)\n- [deploy](#deploy)\n\n## build\n\nBuilds (fast).\n\n| Name | Default |\n|------|---------|\n| stage | `build` |\n\n## deploy\n\n> [!CAUTION]\n> Deprecated.\n\n```yaml\ninclude: x\n```\n"
+	out, err := renderPDF([]byte(doc), "CI/CD components of g/p 1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("expected a PDF header, got %q", out[:20])
+	}
+	pages := pdfPages(t, out)
+	if len(pages) != 3 {
+		t.Fatalf("expected the title page and one page per component, got %d pages", len(pages))
+	}
+	for i, want := range []string{"(CI/CD components of g/p 1.0.0)", "(Builds \\(fast\\).)", "(include: x)"} {
+		if !strings.Contains(pages[i], want) {
+			t.Errorf("expected page %d to contain %s, got:\n%s", i+1, want, pages[i])
+		}
+	}
+	if !strings.Contains(pages[2], "(3 / 3)") || !strings.Contains(pages[2], alertColors["alert-caution"]) {
+		t.Errorf("expected the page number and the caution bar, got:\n%s", pages[2])
+	}
+	if !bytes.Contains(out, []byte("/Outlines")) || !bytes.Contains(out, []byte(pdfTextString("deploy"))) {
+		t.Error("expected a bookmark per component")
+	}
+	again, _ := renderPDF([]byte(doc), "CI/CD components of g/p 1.0.0")
+	if !bytes.Equal(out, again) {
+		t.Error("expected the same document to give the same bytes")
+	}
+}
+
+func TestRun_FormatPDF(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs:\n    region:\n      default: eu-west-1\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--format", "pdf"}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile("components.pdf")
+	if err != nil {
+		t.Fatalf("expected components.pdf to be written: %v", err)
+	}
+	pages := pdfPages(t, data)
+	if len(pages) != 3 || !strings.Contains(pages[1], "(build)") || !strings.Contains(pages[2], "(eu-west-1)") {
+		t.Errorf("expected a page per component, got %d pages", len(pages))
+	}
+	if err := run(append([]string{"check"}, args...), io.Discard); err != nil {
+		t.Errorf("expected the PDF to be up to date, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// previewServer renders the documentation on every request, so the page
+// shows the templates as they are on disk. The project path, version,
+// mirrors and default branch are resolved once, when it starts.
+type previewServer struct {
+	index    *componentIndex
+	settings Settings
+	project  TemplateData // without components
+	linkBase string       // base URL of source links, when enabled
+}
+
+// data returns the template data of the current templates.
+func (p *previewServer) data() (TemplateData, error) {
+	components, err := p.index.components()
+	if err != nil {
+		return TemplateData{}, err
+	}
+	data := p.project
+	data.DataVersion = templateDataVersion
+	data.Components = components
+	if p.settings.SourceLinks {
+		linkSources(data.Components, p.linkBase, p.settings.Output)
+	}
+	return data, nil
+}
+
+// render returns the current document.
+func (p *previewServer) render() ([]byte, error) {
+	data, err := p.data()
+	if err != nil {
+		return nil, err
+	}
+	return renderDocument(p.settings, data)
+}
+
+// revision identifies what the page currently shows, rendering errors
+// included, so the page reloads when it changes.
+func revision(doc []byte, err error) string {
+	h := sha256.New()
+	h.Write(doc)
+	if err != nil {
+		fmt.Fprintf(h, "\x00%v", err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// handler serves the preview page at /, the JSON model at /api/components
+// and the page's revision, which the page polls, at /api/revision.
+func (p *previewServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		doc, err := p.render()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		fmt.Fprint(w, previewPage(p.settings, doc, err, revision(doc, err)))
+	})
+	mux.HandleFunc("/api/components", func(w http.ResponseWriter, r *http.Request) {
+		data, err := p.data()
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(data)
+	})
+	mux.HandleFunc("/api/revision", func(w http.ResponseWriter, r *http.Request) {
+		doc, err := p.render()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"revision": revision(doc, err)})
+	})
+	return mux
+}
+
+// previewPage is the HTML page showing doc, or the error that prevented
+// rendering it. Markdown is converted to HTML; other formats are shown as
+// text.
+func previewPage(settings Settings, doc []byte, err error, rev string) string {
+	var body string
+	switch {
+	case err != nil:
+		body = `<div class="alert alert-caution"><p class="alert-title">Rendering failed</p><pre>` + html.EscapeString(err.Error()) + `</pre></div>`
+	case settings.Format == "markdown":
+		body = markdownHTML(string(doc))
+	default:
+		body = "<pre>" + html.EscapeString(string(doc)) + "</pre>"
+	}
+	return strings.NewReplacer("{{title}}", html.EscapeString(settings.Output), "{{body}}", body, "{{revision}}", rev).Replace(previewTemplate)
+}
+
+const previewTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{title}} (preview)</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; line-height: 1.5; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #333238; }
+h1, h2, h3 { border-bottom: 1px solid #dcdcde; padding-bottom: .3rem; }
+code { background: #ececef; border-radius: 3px; padding: .1rem .3rem; font-size: 90%; }
+pre { background: #fbfafd; border: 1px solid #dcdcde; border-radius: 4px; padding: .8rem; overflow-x: auto; }
+pre code { background: none; padding: 0; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #dcdcde; padding: .4rem .7rem; text-align: left; vertical-align: top; }
+th { background: #fbfafd; }
+blockquote { border-left: 4px solid #dcdcde; margin: 0; padding: 0 1rem; color: #626168; }
+.alert { border-left: 4px solid #1f75cb; padding: .1rem 1rem; margin: 1rem 0; }
+.alert-title { font-weight: bold; }
+.alert-warning { border-color: #c17d10; }
+.alert-caution { border-color: #dd2b0e; }
+.alert-tip { border-color: #108548; }
+.alert-important { border-color: #7b58cf; }
+</style>
+</head>
+<body>
+{{body}}
+<script>
+const revision = "{{revision}}";
+setInterval(async () => {
+  try {
+    const response = await fetch("/api/revision");
+    if ((await response.json()).revision !== revision) location.reload();
+  } catch (e) {}
+}, 1000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewServer(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	template := filepath.Join(dir, "templates", "build.yml")
+	os.WriteFile(template, []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	settings, _ := resolveSettings(ProjectConfig{TemplatesDir: filepath.Join(dir, "templates")}, Settings{})
+	preview := &previewServer{
+		index:    newComponentIndex(settings, 2),
+		settings: settings,
+		project:  TemplateData{ProjectPath: "group/project", Version: "1.0.0"},
+	}
+	server := httptest.NewServer(preview.handler())
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	revisionOf := func() string {
+		t.Helper()
+		_, body := get("/api/revision")
+		var r map[string]string
+		json.Unmarshal([]byte(body), &r)
+		return r["revision"]
+	}
+
+	status, page := get("/")
+	if status != http.StatusOK || !strings.Contains(page, `<h2 id="build">build</h2>`) || !strings.Contains(page, "group/project/build@1.0.0") {
+		t.Errorf("unexpected page (%d):\n%s", status, page)
+	}
+	rev := revisionOf()
+	if rev == "" || !strings.Contains(page, `const revision = "`+rev+`"`) {
+		t.Errorf("expected the page to embed revision %q", rev)
+	}
+
+	status, body := get("/api/components")
+	var data TemplateData
+	if err := json.Unmarshal([]byte(body), &data); err != nil || status != http.StatusOK {
+		t.Fatalf("unexpected components (%d): %s", status, body)
+	}
+	if data.DataVersion != templateDataVersion || len(data.Components) != 1 || data.Components[0].Inputs[0].Default != "build" {
+		t.Errorf("unexpected template data %+v", data)
+	}
+
+	os.WriteFile(template, []byte("spec:\n  inputs:\n    stage:\n      default: test\n"), 0644)
+	if revisionOf() == rev {
+		t.Error("expected the revision to change after a template change")
+	}
+
+	os.WriteFile(template, []byte("spec: [\n"), 0644)
+	if status, page := get("/"); status != http.StatusInternalServerError || !strings.Contains(page, "Rendering failed") {
+		t.Errorf("expected an error page, got (%d):\n%s", status, page)
+	}
+	if status, body := get("/api/components"); status != http.StatusInternalServerError || !strings.Contains(body, `"error"`) {
+		t.Errorf("expected an error, got (%d) %s", status, body)
+	}
+	if status, _ := get("/missing"); status != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", status)
+	}
+}
+
+func TestPreviewPage(t *testing.T) {
+	page := previewPage(Settings{Format: "html", Output: "README.html"}, []byte("<b>{{revision}}</b>"), nil, "abc")
+	if !strings.Contains(page, "<pre>&lt;b&gt;{{revision}}&lt;/b&gt;</pre>") || !strings.Contains(page, "<title>README.html (preview)</title>") {
+		t.Errorf("unexpected page:\n%s", page)
+	}
+}
+
+func TestRun_ServeProtocol(t *testing.T) {
+	if got := exitCode(run([]string{"serve", "--json-rpc", "--http", ":0"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d with both protocols, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// projectPathPlaceholder is used when no project path could be resolved.
+const projectPathPlaceholder = "<your-project-path>"
+
+// pathSegmentPattern follows GitLab's rules for group and project paths:
+// letters, digits, '_', '-' and '.', starting and ending with a letter,
+// digit or underscore (see GitLab's Gitlab::PathRegex).
+var pathSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_])?$`)
+
+// normalizeProjectPath cleans up a project path given by the user (surrounding
+// slashes, a `.git` suffix, or a full project URL) and checks it against
+// GitLab's namespace rules, so that broken include paths never get published.
+func normalizeProjectPath(projectPath string) (string, error) {
+	p := strings.TrimSpace(projectPath)
+	if strings.Contains(p, "//") || strings.Contains(p, "@") {
+		if fromURL := parseGitRemoteURL(p); fromURL != "" {
+			p = fromURL
+		}
+	}
+	p = strings.Trim(p, "/")
+	p = strings.TrimSuffix(p, ".git")
+	p = strings.TrimRight(p, "/")
+
+	segments := strings.Split(p, "/")
+	if len(segments) < 2 {
+		return "", fmt.Errorf("invalid project path %q: expected <group>/<project>", projectPath)
+	}
+	for _, segment := range segments {
+		if !pathSegmentPattern.MatchString(segment) {
+			return "", fmt.Errorf("invalid project path %q: %q may only contain letters, digits, '_', '-' and '.', and must start and end with a letter, digit or '_'", projectPath, segment)
+		}
+		if strings.HasSuffix(segment, ".atom") {
+			return "", fmt.Errorf("invalid project path %q: %q cannot end in .atom", projectPath, segment)
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// projectPathMismatch returns a warning when the resolved project path differs
+// from the one detected from the git remote (GitLab paths are case-insensitive).
+func projectPathMismatch(projectPath, detected, remote string) string {
+	if detected == "" || projectPath == projectPathPlaceholder {
+		return ""
+	}
+	if normalized, err := normalizeProjectPath(detected); err == nil {
+		detected = normalized
+	}
+	if strings.EqualFold(projectPath, detected) {
+		return ""
+	}
+	return fmt.Sprintf("project path %q differs from %q detected from git remote %q", projectPath, detected, remote)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeProjectPath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "group/project", expected: "group/project"},
+		{input: "group/sub.group/my_project-2", expected: "group/sub.group/my_project-2"},
+		{input: " group/project/ ", expected: "group/project"},
+		{input: "/group/project.git", expected: "group/project"},
+		{input: "https://gitlab.com/group/project.git", expected: "group/project"},
+		{input: "git@gitlab.com:group/project.git", expected: "group/project"},
+		{input: "project", wantErr: true},
+		{input: "group//project", wantErr: true},
+		{input: "group/-project", wantErr: true},
+		{input: "group/project.", wantErr: true},
+		{input: "group/my project", wantErr: true},
+		{input: "group/feed.atom", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := normalizeProjectPath(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProjectPathMismatch(t *testing.T) {
+	if got := projectPathMismatch("group/project", "", "origin"); got != "" {
+		t.Errorf("expected no warning without a detected path, got %q", got)
+	}
+	if got := projectPathMismatch("Group/Project", "group/project", "origin"); got != "" {
+		t.Errorf("expected case-insensitive match, got %q", got)
+	}
+	if got := projectPathMismatch(projectPathPlaceholder, "group/project", "origin"); got != "" {
+		t.Errorf("expected no warning for the placeholder, got %q", got)
+	}
+	got := projectPathMismatch("group/other", "group/project", "origin")
+	if !strings.Contains(got, `"group/other"`) || !strings.Contains(got, `"group/project"`) {
+		t.Errorf("expected warning naming both paths, got %q", got)
+	}
+}
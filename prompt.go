@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// isTerminal reports whether f is attached to a character device (a TTY).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// shouldPrompt reports whether the interactive setup should run: only in a
+// terminal, outside CI, when neither flags, env vars nor a config file provide
+// the project path and version.
+func shouldPrompt(projectPathFlag, versionFlag string) bool {
+	if os.Getenv("CI") != "" || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return false
+	}
+	if _, err := os.Stat(configFile); err == nil {
+		return false
+	}
+	hasProjectPath := projectPathFlag != "" || os.Getenv("PROJECT_PATH") != "" || os.Getenv(envConfigVar("project_path")) != ""
+	hasVersion := versionFlag != "" || os.Getenv("VERSION") != "" || os.Getenv(envConfigVar("version")) != ""
+	return !hasProjectPath || !hasVersion
+}
+
+// promptAnswers holds the values entered during the interactive setup.
+type promptAnswers struct {
+	ProjectPath string
+	Version     string
+	Save        bool
+}
+
+// promptConfig asks for the project path and version, offering the detected
+// values as defaults, and whether to save them to the config file.
+func promptConfig(in io.Reader, out io.Writer, defaultProjectPath, defaultVersion string) (promptAnswers, error) {
+	reader := bufio.NewReader(in)
+	ask := func(question, def string) (string, error) {
+		if def != "" {
+			fmt.Fprintf(out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(out, "%s: ", question)
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if answer := strings.TrimSpace(line); answer != "" {
+			return answer, nil
+		}
+		return def, nil
+	}
+
+	fmt.Fprintf(out, "No %s found, let's set up the basics (press Enter to accept the default).\n", configFile)
+
+	var answers promptAnswers
+	var err error
+	if answers.ProjectPath, err = ask("GitLab project path (e.g. group/project)", defaultProjectPath); err != nil {
+		return answers, err
+	}
+	if answers.Version, err = ask("Component version", defaultVersion); err != nil {
+		return answers, err
+	}
+	save, err := ask("Save these answers to "+configFile+"? (y/n)", "y")
+	if err != nil {
+		return answers, err
+	}
+	answers.Save = strings.HasPrefix(strings.ToLower(save), "y")
+	return answers, nil
+}
+
+// writePromptConfig saves the answers as a new config file.
+func writePromptConfig(path string, answers promptAnswers) error {
+	var config yaml.MapSlice
+	if answers.ProjectPath != "" {
+		config = append(config, yaml.MapItem{Key: "project_path", Value: answers.ProjectPath})
+	}
+	if answers.Version != "" {
+		config = append(config, yaml.MapItem{Key: "version", Value: answers.Version})
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
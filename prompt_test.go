@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptConfig_Defaults(t *testing.T) {
+	var out bytes.Buffer
+	answers, err := promptConfig(strings.NewReader("\n\n\n"), &out, "group/project", "1.2.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answers.ProjectPath != "group/project" || answers.Version != "1.2.0" || !answers.Save {
+		t.Errorf("expected detected defaults to be accepted, got %+v", answers)
+	}
+	if !strings.Contains(out.String(), "[group/project]") {
+		t.Errorf("expected prompt to show the detected default, got %q", out.String())
+	}
+}
+
+func TestPromptConfig_Answers(t *testing.T) {
+	answers, err := promptConfig(strings.NewReader("my-group/my-project\n2.0\nn\n"), &bytes.Buffer{}, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answers.ProjectPath != "my-group/my-project" || answers.Version != "2.0" || answers.Save {
+		t.Errorf("unexpected answers: %+v", answers)
+	}
+}
+
+func TestPromptConfig_EOF(t *testing.T) {
+	// Closed stdin keeps the defaults instead of failing
+	answers, err := promptConfig(strings.NewReader(""), &bytes.Buffer{}, "group/project", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answers.ProjectPath != "group/project" || answers.Version != "" {
+		t.Errorf("unexpected answers: %+v", answers)
+	}
+}
+
+func TestWritePromptConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitlab-component-docs-gen.yml")
+	if err := writePromptConfig(path, promptAnswers{ProjectPath: "group/project", Version: "2.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, err := readProjectConfig(path)
+	if err != nil {
+		t.Fatalf("written config should be valid: %v", err)
+	}
+	if config.ProjectPath != "group/project" || config.Version != "2.0" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// publishMarker identifies the merge request note owned by the tool, so a
+// later pipeline updates it instead of adding another one.
+const publishMarker = "<!-- gitlab-component-docs-gen -->"
+
+// maxNoteDiff bounds the diff embedded in a note; longer diffs are cut.
+const maxNoteDiff = 50000
+
+// mrNote is a merge request note as returned by the GitLab API.
+type mrNote struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+// driftNote renders the note body for a diff of the output file. An empty
+// diff means the documentation is up to date.
+func driftNote(output, diff string) string {
+	var b strings.Builder
+	b.WriteString(publishMarker + "\n")
+	if diff == "" {
+		fmt.Fprintf(&b, ":white_check_mark: `%s` is up to date with the component templates.\n", output)
+		return b.String()
+	}
+
+	truncated := false
+	if len(diff) > maxNoteDiff {
+		diff = diff[:strings.LastIndex(diff[:maxNoteDiff], "\n")+1]
+		truncated = true
+	}
+	// The fence must be longer than any backtick run in the diff
+	fence := "```"
+	for strings.Contains(diff, fence) {
+		fence += "`"
+	}
+
+	fmt.Fprintf(&b, ":warning: `%s` is out of date with the component templates. Run `gitlab-component-docs-gen` and commit the result.\n\n", output)
+	b.WriteString("<details><summary>Documentation diff</summary>\n\n")
+	b.WriteString(fence + "diff\n" + diff + fence + "\n")
+	if truncated {
+		b.WriteString("\n_The diff was truncated._\n")
+	}
+	b.WriteString("\n</details>\n")
+	return b.String()
+}
+
+// publishNote posts body as the tool's note on a merge request, updating the
+// existing one if any. With skipIfMissing, nothing is posted when there is no
+// note yet. It returns whether a note was created or updated.
+func publishNote(baseURL, header, token, project, iid, body string, skipIfMissing bool) (bool, error) {
+	notesPath := "/projects/" + url.PathEscape(project) + "/merge_requests/" + url.PathEscape(iid) + "/notes"
+	var existing *mrNote
+	for page := 1; existing == nil; page++ {
+		var notes []mrNote
+		if err := gitlabRequest(baseURL, header, token, http.MethodGet, fmt.Sprintf("%s?sort=asc&per_page=100&page=%d", notesPath, page), nil, &notes); err != nil {
+			return false, err
+		}
+		for i := range notes {
+			if strings.HasPrefix(notes[i].Body, publishMarker) {
+				existing = &notes[i]
+				break
+			}
+		}
+		if len(notes) < 100 {
+			break
+		}
+	}
+
+	payload := map[string]string{"body": body}
+	switch {
+	case existing != nil && existing.Body == body:
+		return false, nil
+	case existing != nil:
+		return true, gitlabRequest(baseURL, header, token, http.MethodPut, fmt.Sprintf("%s/%d", notesPath, existing.ID), payload, nil)
+	case skipIfMissing:
+		return false, nil
+	default:
+		return true, gitlabRequest(baseURL, header, token, http.MethodPost, notesPath, payload, nil)
+	}
+}
+
+// runPublish implements the `publish` subcommand. With --merge-request, run
+// in a merge request pipeline, it renders the documentation and posts its
+// diff against the committed output as a merge request note. The note is
+// updated on later pipelines; when the docs are up to date, an existing note
+// says so and no new note is added. With --pages-fragment, it uploads each
+// component's docs to a docs portal instead (see publishFragments).
+func runPublish(args []string, stdout io.Writer) error {
+	flags := newFlagSet("publish")
+	mergeRequest := flags.Bool("merge-request", false, "Post the documentation diff as a note on the pipeline's merge request")
+	pagesFragment := flags.Bool("pages-fragment", false, "Upload each component's docs as an HTML fragment to the docs portal (fragments.url)")
+	projectPath := flags.String("project-path", "", "GitLab project path (e.g. group/project)")
+	version := flags.String("version", "", "Component version (e.g. 1.0.0)")
+	remote := flags.String("remote", "", "Git remote used to detect the project path (default \"origin\")")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "README template path (default depends on --format)")
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory containing component descriptions (default \"docs\")")
+	flags.StringVar(&overrides.ExamplesDir, "examples-dir", "", "Directory containing usage examples, one subdirectory per component (default \"examples\")")
+	flags.StringVar(&overrides.Output, "output", "", "Output file compared against (default depends on --format)")
+	flags.StringVar(&overrides.Format, "format", "", "Output format: "+strings.Join(outputFormats, ", ")+" (default \"markdown\")")
+	flags.Var((*stringList)(&overrides.Include), "include", "Only document components matching this glob (repeatable)")
+	flags.Var((*stringList)(&overrides.Exclude), "exclude", "Skip components matching this glob (repeatable)")
+	flags.StringVar(&overrides.Fragments.URL, "fragments-url", "", "Endpoint --pages-fragment uploads to: each component is PUT to <url>/<name>.html")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if *mergeRequest == *pagesFragment {
+		return withExitCode(exitConfig, errors.New("publish: choose where to publish (--merge-request or --pages-fragment)"))
+	}
+
+	log := newLogger(stdout, logLevelFor(*quiet, false, false), "text")
+
+	if *pagesFragment {
+		config, err := readProjectConfig(configFile)
+		if err != nil {
+			return withExitCode(exitConfig, err)
+		}
+		return publishFragments(config, overrides, *projectPath, *version, resolveRemote(*remote), *jobs, log)
+	}
+
+	iid := os.Getenv("CI_MERGE_REQUEST_IID")
+	if iid == "" {
+		return withExitCode(exitConfig, errors.New("publish --merge-request: CI_MERGE_REQUEST_IID is not set; run it in a merge request pipeline"))
+	}
+	gitRemote := resolveRemote(*remote)
+	baseURL, header, token, ok := gitlabAPIConfig(gitRemote)
+	if !ok {
+		return withExitCode(exitConfig, errors.New("publish --merge-request: set GITLAB_TOKEN (with api scope) or CI_JOB_TOKEN to access the GitLab API"))
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	data, err := collectTemplateData(settings, config.Mirrors, *projectPath, *version, gitRemote, *jobs, log)
+	if err != nil {
+		return err
+	}
+	doc, err := renderDocument(settings, data)
+	if err != nil {
+		return withExitCode(exitTemplate, err)
+	}
+	current, err := os.ReadFile(settings.Output)
+	if err != nil && !os.IsNotExist(err) {
+		return withExitCode(exitFailure, fmt.Errorf("error reading %s: %w", settings.Output, err))
+	}
+	// The footer only changes with the tool version: it is not drift
+	diff := unifiedDiff(string(stripFooter(current)), string(stripFooter(doc)), settings.Output, settings.Output+" (rendered)", false)
+
+	project := os.Getenv("CI_PROJECT_ID")
+	if project == "" {
+		if data.ProjectPath == projectPathPlaceholder {
+			return withExitCode(exitConfig, errors.New("publish --merge-request: cannot determine the project; set CI_PROJECT_ID or --project-path"))
+		}
+		project = data.ProjectPath
+	}
+	posted, err := publishNote(baseURL, header, token, project, iid, driftNote(settings.Output, diff), diff == "")
+	if err != nil {
+		return withExitCode(exitFailure, fmt.Errorf("error publishing merge request note: %w", err))
+	}
+	switch {
+	case posted:
+		log.Infof("Published documentation status on merge request !%s", iid)
+	case diff == "":
+		log.Infof("%s is up to date, nothing to publish", settings.Output)
+	default:
+		log.Infof("Merge request !%s already shows the current documentation diff", iid)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// notesServer fakes the merge request notes API of project 42, MR !7.
+type notesServer struct {
+	mu    sync.Mutex
+	notes []mrNote
+	posts int
+	puts  int
+}
+
+func (s *notesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	const notesPath = "/api/v4/projects/42/merge_requests/7/notes"
+	var payload struct {
+		Body string `json:"body"`
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == notesPath:
+		if r.URL.Query().Get("page") != "1" {
+			w.Write([]byte("[]"))
+			return
+		}
+		json.NewEncoder(w).Encode(s.notes)
+	case r.Method == http.MethodPost && r.URL.Path == notesPath:
+		json.NewDecoder(r.Body).Decode(&payload)
+		s.posts++
+		s.notes = append(s.notes, mrNote{ID: 100 + len(s.notes), Body: payload.Body})
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, notesPath+"/"):
+		json.NewDecoder(r.Body).Decode(&payload)
+		s.puts++
+		for i := range s.notes {
+			if fmt.Sprintf("%s/%d", notesPath, s.notes[i].ID) == r.URL.Path {
+				s.notes[i].Body = payload.Body
+			}
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRunPublish_MergeRequest(t *testing.T) {
+	fake := &notesServer{notes: []mrNote{{ID: 1, Body: "LGTM"}}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	setGitLabEnv(t, server.URL)
+	t.Setenv("GITLAB_TOKEN", "secret")
+	t.Setenv("CI_MERGE_REQUEST_IID", "7")
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"publish", "--merge-request", "--quiet", "--project-path", "g/p", "--version", "1.0.0"}
+
+	// Missing README: the whole document is drift
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.posts != 1 || len(fake.notes) != 2 {
+		t.Fatalf("expected one new note, got %d posts, notes %+v", fake.posts, fake.notes)
+	}
+	note := fake.notes[1].Body
+	if !strings.HasPrefix(note, publishMarker) || !strings.Contains(note, "diff\n--- README.md") || !strings.Contains(note, "+| stage |") {
+		t.Errorf("unexpected note body:\n%s", note)
+	}
+
+	// Same drift again: the note is left alone
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.posts != 1 || fake.puts != 0 {
+		t.Errorf("expected no new request for an unchanged diff, got %d posts, %d puts", fake.posts, fake.puts)
+	}
+
+	// Docs regenerated: the existing note is updated, not duplicated
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.posts != 1 || fake.puts != 1 || !strings.Contains(fake.notes[1].Body, "is up to date") {
+		t.Errorf("expected the note to be updated to up to date, got %d posts, %d puts, %+v", fake.posts, fake.puts, fake.notes)
+	}
+}
+
+func TestRunPublish_UpToDateWithoutNote(t *testing.T) {
+	fake := &notesServer{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	setGitLabEnv(t, server.URL)
+	t.Setenv("GITLAB_TOKEN", "secret")
+	t.Setenv("CI_MERGE_REQUEST_IID", "7")
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := run([]string{"publish", "--merge-request", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.posts != 0 || !strings.Contains(out.String(), "nothing to publish") {
+		t.Errorf("expected nothing to be published, got %d posts, output %q", fake.posts, out.String())
+	}
+}
+
+func TestRunPublish_Errors(t *testing.T) {
+	setGitLabEnv(t, "http://127.0.0.1:0")
+	t.Setenv("CI_MERGE_REQUEST_IID", "")
+
+	if got := exitCode(run([]string{"publish"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without a target, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"publish", "--merge-request"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d outside a merge request pipeline, got %d", exitConfig, got)
+	}
+	t.Setenv("CI_MERGE_REQUEST_IID", "7")
+	if got := exitCode(run([]string{"publish", "--merge-request"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without a token, got %d", exitConfig, got)
+	}
+}
+
+func TestDriftNote(t *testing.T) {
+	note := driftNote("README.md", "--- a\n+++ b\n+```yaml\n")
+	if !strings.Contains(note, "````diff\n") {
+		t.Errorf("expected a fence longer than the diff's backticks, got:\n%s", note)
+	}
+
+	long := strings.Repeat("+line\n", maxNoteDiff)
+	note = driftNote("README.md", long)
+	if len(note) > maxNoteDiff+1000 || !strings.Contains(note, "truncated") {
+		t.Errorf("expected a truncated diff, got %d bytes", len(note))
+	}
+}
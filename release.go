@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// releaseBumps are the version parts `release` can bump.
+var releaseBumps = []string{"major", "minor", "patch"}
+
+// releaseVersionPattern matches the versions `release` accepts: semantic
+// versions, optionally prefixed with "v", with an optional pre-release.
+var releaseVersionPattern = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)(-[0-9A-Za-z.-]+)?$`)
+
+// configVersionLine matches the top-level `version` key of the config file.
+var configVersionLine = regexp.MustCompile(`(?m)^version:.*$`)
+
+// nextVersion returns the version to release: target itself when it is a
+// version, or the current version with the named part bumped. Bumping the
+// patch of a pre-release releases it (1.2.0-rc.1 -> 1.2.0), as in semver.
+func nextVersion(current, target string) (string, error) {
+	if !contains(releaseBumps, target) {
+		if !releaseVersionPattern.MatchString(target) {
+			return "", fmt.Errorf("invalid version %q (expected X.Y.Z or one of: %s)", target, strings.Join(releaseBumps, ", "))
+		}
+		return target, nil
+	}
+	m := releaseVersionPattern.FindStringSubmatch(current)
+	if m == nil {
+		return "", fmt.Errorf("cannot bump the %s version of %q: not a X.Y.Z version (pass the version to release instead)", target, current)
+	}
+	major, _ := strconv.Atoi(m[2])
+	minor, _ := strconv.Atoi(m[3])
+	patch, _ := strconv.Atoi(m[4])
+	switch target {
+	case "major":
+		major, minor, patch = major+1, 0, 0
+	case "minor":
+		minor, patch = minor+1, 0
+	case "patch":
+		if m[5] == "" {
+			patch++
+		}
+	}
+	return fmt.Sprintf("%s%d.%d.%d", m[1], major, minor, patch), nil
+}
+
+// setConfigVersion writes version into the config file, replacing its
+// top-level `version` key (or adding one) so comments and layout are kept.
+// The file is created when missing.
+func setConfigVersion(path, version string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	line := fmt.Sprintf("version: %q", version)
+	content := string(data)
+	if configVersionLine.MatchString(content) {
+		content = configVersionLine.ReplaceAllLiteralString(content, line)
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += line + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// runGit runs a git command, returning its trimmed output.
+func runGit(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// createGitLabRelease creates the release of an existing tag.
+func createGitLabRelease(baseURL, header, token, project, tag, notes string) error {
+	payload := map[string]string{"tag_name": tag, "name": tag, "description": notes}
+	return gitlabRequest(baseURL, header, token, http.MethodPost, "/projects/"+url.PathEscape(project)+"/releases", payload, nil)
+}
+
+// checkReleaseChanges compares the components at the current version's tag
+// with the working tree, and fails when version bumps less than they need.
+func checkReleaseChanges(current, version string, settings Settings) error {
+	ref := versionRef(current)
+	if ref == "" {
+		return withExitCode(exitConfig, fmt.Errorf("release --check-semver: no tag of the current version %q to compare against", current))
+	}
+	before, err := componentsAt(ref, settings, runtime.NumCPU())
+	if err != nil {
+		return withExitCode(exitParse, fmt.Errorf("error reading the components at %s: %w", ref, err))
+	}
+	after, err := workingComponents(settings, runtime.NumCPU())
+	if err != nil {
+		return err
+	}
+	if err := checkVersionBump(current, version, interfaceChanges(before, after)); err != nil {
+		return withExitCode(exitChanged, err)
+	}
+	return nil
+}
+
+// runRelease implements `release`: write the new version into the config
+// file, regenerate the documentation (so include snippets show it), commit
+// both, and create an annotated tag. With --push the commit and tag are
+// pushed, and --gitlab-release (which implies --push) also creates the
+// GitLab release of the tag.
+func runRelease(args []string, stdout io.Writer) error {
+	flags := newFlagSet("release")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	dryRun := flags.Bool("dry-run", false, "Print what would be done, without changing anything")
+	message := flags.String("message", "Release %s", "Commit and tag message; %s is replaced by the version")
+	remote := flags.String("remote", "", "Git remote to push to and to detect the project from (default \"origin\")")
+	push := flags.Bool("push", false, "Push the release commit and the tag")
+	gitlabRelease := flags.Bool("gitlab-release", false, "Also create a GitLab release of the tag through the API (implies --push)")
+	notes := flags.String("notes", "", "Description of the GitLab release")
+	checkSemver := flags.Bool("check-semver", false, "Refuse to release a version bumping less than the interface changes since the current version need (see the diff command)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if flags.NArg() != 1 {
+		return withExitCode(exitConfig, fmt.Errorf("release: expected the version to release, or one of: %s", strings.Join(releaseBumps, ", ")))
+	}
+	if *gitlabRelease {
+		*push = true
+	}
+	log := newLogger(stdout, logLevelFor(*quiet, false, false), "text")
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	current := config.Version
+	if current == "" {
+		current = detectGitVersion()
+	}
+	version, err := nextVersion(current, flags.Arg(0))
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, Settings{})
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	outputs := []string{settings.Output}
+	if len(config.Units) > 0 {
+		outputs = nil
+		for _, u := range config.Units {
+			outputs = append(outputs, u.Output)
+		}
+	}
+	gitRemote := resolveRemote(*remote)
+
+	// Check everything that can be checked before changing anything
+	if _, err := runGit("rev-parse", "--git-dir"); err != nil {
+		return withExitCode(exitConfig, fmt.Errorf("release must run in a git repository: %w", err))
+	}
+	if out, _ := runGit("tag", "--list", version); out != "" {
+		return withExitCode(exitConfig, fmt.Errorf("tag %s already exists", version))
+	}
+	var baseURL, header, token, project string
+	if *gitlabRelease {
+		var ok bool
+		if baseURL, header, token, ok = gitlabAPIConfig(gitRemote); !ok {
+			return withExitCode(exitConfig, errors.New("release --gitlab-release: set GITLAB_TOKEN (with api scope) to access the GitLab API"))
+		}
+		if project = os.Getenv("CI_PROJECT_ID"); project == "" {
+			project = config.ProjectPath
+		}
+		if project == "" {
+			project = detectGitProjectPath(gitRemote)
+		}
+		if project == "" {
+			return withExitCode(exitConfig, errors.New("release --gitlab-release: cannot determine the project; set project_path or CI_PROJECT_ID"))
+		}
+	}
+	if *checkSemver {
+		if err := checkReleaseChanges(current, version, settings); err != nil {
+			return err
+		}
+	}
+	msg := strings.ReplaceAll(*message, "%s", version)
+
+	if *dryRun {
+		log.Infof("Would set version %s in %s (was %q)", version, configFile, current)
+		log.Infof("Would regenerate %s and commit it with %s: %q", strings.Join(outputs, ", "), configFile, msg)
+		log.Infof("Would create the annotated tag %s", version)
+		if *push {
+			log.Infof("Would push the commit and the tag to %s", gitRemote)
+		}
+		if *gitlabRelease {
+			log.Infof("Would create the GitLab release %s of %s", version, project)
+		}
+		return nil
+	}
+
+	if err := setConfigVersion(configFile, version); err != nil {
+		return withExitCode(exitWrite, err)
+	}
+	// The version is given explicitly: VERSION or DOCS_GEN_VERSION in the
+	// environment would otherwise win over the config file's
+	generateArgs := []string{"--no-prompt", "--version", version}
+	if *quiet {
+		generateArgs = append(generateArgs, "--quiet")
+	}
+	if err := runGenerate("generate", generateArgs, stdout); err != nil {
+		return err
+	}
+
+	// Only the config file and the outputs are committed, whatever else is staged
+	paths := append([]string{configFile}, outputs...)
+	if _, err := runGit(append([]string{"add", "--"}, paths...)...); err != nil {
+		return withExitCode(exitFailure, err)
+	}
+	if _, err := runGit(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err != nil {
+		if _, err := runGit(append([]string{"commit", "-m", msg, "--"}, paths...)...); err != nil {
+			return withExitCode(exitFailure, err)
+		}
+		log.Infof("Committed %s and %s", configFile, strings.Join(outputs, ", "))
+	} else {
+		log.Infof("Nothing to commit: %s already documents %s", strings.Join(outputs, ", "), version)
+	}
+	if _, err := runGit("tag", "-a", version, "-m", msg); err != nil {
+		return withExitCode(exitFailure, err)
+	}
+	log.Infof("Tagged %s", version)
+
+	if *push {
+		if _, err := runGit("push", gitRemote, "HEAD", "refs/tags/"+version); err != nil {
+			return withExitCode(exitFailure, err)
+		}
+		log.Infof("Pushed the release commit and %s to %s", version, gitRemote)
+	}
+	if *gitlabRelease {
+		if err := createGitLabRelease(baseURL, header, token, project, version, *notes); err != nil {
+			return withExitCode(exitFailure, fmt.Errorf("error creating the GitLab release %s: %w", version, err))
+		}
+		log.Infof("Created the GitLab release %s", version)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		current, target, want string
+	}{
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "major", "2.0.0"},
+		{"v1.2.3", "minor", "v1.3.0"},
+		{"1.3.0-rc.1", "patch", "1.3.0"},
+		{"", "2.0.0-beta.1", "2.0.0-beta.1"},
+	}
+	for _, tt := range tests {
+		if got, err := nextVersion(tt.current, tt.target); err != nil || got != tt.want {
+			t.Errorf("nextVersion(%q, %q) = %q, %v; want %q", tt.current, tt.target, got, err, tt.want)
+		}
+	}
+	if _, err := nextVersion("", "patch"); err == nil {
+		t.Error("expected an error when bumping without a current version")
+	}
+	if _, err := nextVersion("1.0.0", "latest"); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+}
+
+func TestSetConfigVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFile)
+	if err := setConfigVersion(path, "1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	os.WriteFile(path, []byte("# Docs settings\nproject_path: group/project\nversion: 1.0.0 # bumped by release\nsort: name\n"), 0644)
+	if err := setConfigVersion(path, "1.1.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Docs settings\nproject_path: group/project\nversion: \"1.1.0\"\nsort: name\n" {
+		t.Errorf("unexpected config:\n%s", data)
+	}
+}
+
+func TestRun_Release(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	t.Setenv("CI_DEFAULT_BRANCH", "main")
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(configFile, []byte("project_path: group/project\nversion: 1.2.3\n"), 0644)
+	os.WriteFile(".gitignore", []byte(cacheFile+"\n"), 0644)
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	if err := run([]string{"release", "--dry-run", "minor"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected --dry-run not to write anything")
+	}
+
+	// Neither the environment's version nor unrelated staged files end up in the release
+	t.Setenv("VERSION", "9.9.9")
+	os.WriteFile("notes.txt", []byte("draft\n"), 0644)
+	git("add", "notes.txt")
+	if err := run([]string{"release", "--quiet", "minor"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.md"); !strings.Contains(string(data), "group/project/build@1.3.0") {
+		t.Errorf("expected the new version in the include snippets, got:\n%s", data)
+	}
+	if got := git("log", "-1", "--format=%s"); got != "Release 1.3.0" {
+		t.Errorf("unexpected commit %q", got)
+	}
+	if got := git("status", "--porcelain"); got != "A  notes.txt" {
+		t.Errorf("expected notes.txt to stay staged, got:\n%s", got)
+	}
+	if got := git("for-each-ref", "--format=%(objecttype) %(refname:short)", "refs/tags"); got != "tag 1.3.0" {
+		t.Errorf("expected an annotated tag, got %q", got)
+	}
+
+	if got := exitCode(run([]string{"release", "--quiet", "1.3.0"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an existing tag, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"release", "--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without a version, got %d", exitConfig, got)
+	}
+}
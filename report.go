@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// RunReport is the single JSON object printed by --porcelain. Fields are only
+// ever added, never renamed or removed, so scripts can rely on them.
+type RunReport struct {
+	Status          string   `json:"status"` // "ok" or "error"
+	ExitCode        int      `json:"exit_code"`
+	Error           string   `json:"error,omitempty"`
+	Format          string   `json:"format,omitempty"`
+	Output          string   `json:"output,omitempty"`
+	TemplateCreated bool     `json:"template_created"` // always false: kept for compatibility, templates are only created by init
+	ProjectPath     string   `json:"project_path,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	Components      []string `json:"components"`
+	Issues          []Issue  `json:"issues,omitempty"`
+}
+
+// finish records the outcome of the run in the report.
+func (r *RunReport) finish(err error) {
+	r.ExitCode = exitCode(err)
+	r.Status = "ok"
+	if err != nil {
+		r.Status = "error"
+		r.Error = err.Error()
+	}
+	if r.Components == nil {
+		r.Components = []string{}
+	}
+}
+
+// writeReport prints the report as a single line of JSON.
+func writeReport(w io.Writer, report RunReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("error encoding report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
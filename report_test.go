@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Porcelain(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"--porcelain", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("expected exactly one line of output, got %q", out.String())
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if report.Status != "ok" || report.ExitCode != exitOK || report.Output != "README.md" || report.TemplateCreated {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if strings.Join(report.Components, ",") != "build,deploy" {
+		t.Errorf("expected components [build deploy], got %v", report.Components)
+	}
+	if report.ProjectPath != "g/p" || report.Version != "1.0.0" {
+		t.Errorf("expected resolved project path and version, got %q / %q", report.ProjectPath, report.Version)
+	}
+}
+
+func TestRun_PorcelainError(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "bad.yml"), []byte("not: [valid: yaml: {{{}"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	err := run([]string{"--porcelain"}, &out)
+	if exitCode(err) != exitParse {
+		t.Fatalf("expected exit code %d, got %d (%v)", exitParse, exitCode(err), err)
+	}
+	if !isReported(err) {
+		t.Error("expected error to be marked as reported")
+	}
+
+	var report RunReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if report.Status != "error" || report.ExitCode != exitParse || !strings.Contains(report.Error, "bad.yml") {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.Components == nil {
+		t.Error("expected components to be an empty list, not null")
+	}
+}
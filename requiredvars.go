@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableNamePattern matches valid CI/CD variable names.
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RequiredVariableConfig declares a CI/CD variable a component needs from
+// the including project (e.g. a deploy token), which spec:inputs cannot
+// express.
+type RequiredVariableConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Secret      bool   `yaml:"secret"` // should be a masked (and protected) variable
+}
+
+// RequiredVariableData is a required CI/CD variable of a component.
+type RequiredVariableData struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`
+}
+
+// validateRequiredVariables checks the `required_variables` setting: valid
+// names, each declared at most once per component.
+func validateRequiredVariables(config map[string][]RequiredVariableConfig) error {
+	for component, variables := range config {
+		seen := make(map[string]bool, len(variables))
+		for _, v := range variables {
+			if !variableNamePattern.MatchString(v.Name) {
+				return fmt.Errorf("required_variables: component %q: invalid variable name %q", component, v.Name)
+			}
+			if seen[v.Name] {
+				return fmt.Errorf("required_variables: component %q: duplicate variable %q", component, v.Name)
+			}
+			seen[v.Name] = true
+		}
+	}
+	return nil
+}
+
+// parseEnvExample reads the variables of a .env.example file: `NAME=value`
+// lines (optionally prefixed with `export`), described by the comment lines
+// right above them. Example values are ignored; a `# secret` comment line
+// marks the variable as secret.
+func parseEnvExample(data []byte) ([]RequiredVariableData, error) {
+	var variables []RequiredVariableData
+	var comment []string
+	secret := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			comment, secret = nil, false
+		case strings.HasPrefix(line, "#"):
+			text := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if strings.EqualFold(text, "secret") {
+				secret = true
+				continue
+			}
+			comment = append(comment, text)
+		default:
+			name, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+			name = strings.TrimSpace(name)
+			if !ok || !variableNamePattern.MatchString(name) {
+				return nil, fmt.Errorf("line %d: expected NAME=value", n)
+			}
+			variables = append(variables, RequiredVariableData{Name: name, Description: strings.Join(comment, " "), Secret: secret})
+			comment, secret = nil, false
+		}
+	}
+	return variables, scanner.Err()
+}
+
+// requiredVariablesFile returns the .env.example file of a component.
+func requiredVariablesFile(docsDir, name string) string {
+	return filepath.Join(docsDir, filepath.FromSlash(name)+".env.example")
+}
+
+// applyRequiredVariables sets the required CI/CD variables of the components
+// from their docs/<name>.env.example file and the config, whose entries win
+// for the same name. Declared variables are no longer listed among the
+// detected requirements. Config entries naming unknown components are
+// returned as warnings.
+func applyRequiredVariables(config map[string][]RequiredVariableConfig, docsDir string, components []ComponentData) ([]string, error) {
+	byName := make(map[string]*ComponentData, len(components))
+	for i := range components {
+		byName[components[i].Name] = &components[i]
+	}
+
+	var warnings []string
+	for name := range config {
+		if byName[name] == nil {
+			warnings = append(warnings, fmt.Sprintf("required_variables: unknown component %q", name))
+		}
+	}
+	sort.Strings(warnings)
+
+	for i := range components {
+		c := &components[i]
+		path := requiredVariablesFile(docsDir, c.Name)
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		if data, err = decodeSource(path, data); err != nil {
+			return nil, err
+		}
+		variables, err := parseEnvExample(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		for _, v := range config[c.Name] {
+			declared := RequiredVariableData{Name: v.Name, Description: strings.TrimSpace(v.Description), Secret: v.Secret}
+			replaced := false
+			for j := range variables {
+				if variables[j].Name == v.Name {
+					if declared.Description == "" {
+						declared.Description = variables[j].Description
+					}
+					variables[j], replaced = declared, true
+				}
+			}
+			if !replaced {
+				variables = append(variables, declared)
+			}
+		}
+		if len(variables) == 0 {
+			continue
+		}
+		sort.SliceStable(variables, func(a, b int) bool { return variables[a].Name < variables[b].Name })
+		c.RequiredVariables = variables
+
+		if c.Requirements != nil && len(c.Requirements.Variables) > 0 {
+			requirements := *c.Requirements
+			requirements.Variables = nil
+			for _, name := range c.Requirements.Variables {
+				if !declaresVariable(variables, name) {
+					requirements.Variables = append(requirements.Variables, name)
+				}
+			}
+			c.Requirements = &requirements
+			if len(requirements.Stages) == 0 && len(requirements.Variables) == 0 && len(requirements.Rules) == 0 {
+				c.Requirements = nil
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// declaresVariable reports whether variables includes name.
+func declaresVariable(variables []RequiredVariableData, name string) bool {
+	for _, v := range variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvExample(t *testing.T) {
+	variables, err := parseEnvExample([]byte("# Registry to push to\nREGISTRY=registry.example.com\n\n# Deploy token\n# with write_registry scope\n# secret\nexport DEPLOY_TOKEN=\nDEBUG=false\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []RequiredVariableData{
+		{Name: "REGISTRY", Description: "Registry to push to"},
+		{Name: "DEPLOY_TOKEN", Description: "Deploy token with write_registry scope", Secret: true},
+		{Name: "DEBUG"},
+	}
+	if !reflect.DeepEqual(variables, want) {
+		t.Errorf("got %+v, want %+v", variables, want)
+	}
+	if _, err := parseEnvExample([]byte("not a variable\n")); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func TestValidateRequiredVariables(t *testing.T) {
+	if err := validateRequiredVariables(map[string][]RequiredVariableConfig{"deploy": {{Name: "TOKEN"}, {Name: "REGISTRY"}}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, variables := range [][]RequiredVariableConfig{{{Name: ""}}, {{Name: "MY-TOKEN"}}, {{Name: "TOKEN"}, {Name: "TOKEN"}}} {
+		if err := validateRequiredVariables(map[string][]RequiredVariableConfig{"deploy": variables}); err == nil {
+			t.Errorf("expected an error for %+v", variables)
+		}
+	}
+}
+
+func TestApplyRequiredVariables(t *testing.T) {
+	docsDir := t.TempDir()
+	os.WriteFile(filepath.Join(docsDir, "deploy.env.example"), []byte("# Token pushing the image\nTOKEN=\n# Target registry\nREGISTRY=\n"), 0644)
+	components := []ComponentData{
+		{Name: "deploy", Requirements: &RequirementsData{Variables: []string{"REGISTRY", "KUBECONFIG"}}},
+		{Name: "build", Requirements: &RequirementsData{Variables: []string{"TOKEN"}}},
+	}
+	config := map[string][]RequiredVariableConfig{
+		"deploy":  {{Name: "TOKEN", Secret: true}, {Name: "KUBECONFIG", Description: "Cluster access"}},
+		"missing": {{Name: "TOKEN"}},
+	}
+	warnings, err := applyRequiredVariables(config, docsDir, components)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"missing"`) {
+		t.Errorf("expected a warning for the unknown component, got %v", warnings)
+	}
+	want := []RequiredVariableData{
+		{Name: "KUBECONFIG", Description: "Cluster access"},
+		{Name: "REGISTRY", Description: "Target registry"},
+		{Name: "TOKEN", Description: "Token pushing the image", Secret: true},
+	}
+	if !reflect.DeepEqual(components[0].RequiredVariables, want) {
+		t.Errorf("got %+v, want %+v", components[0].RequiredVariables, want)
+	}
+	if components[0].Requirements != nil {
+		t.Errorf("expected the declared variables to leave the requirements, got %+v", components[0].Requirements)
+	}
+	if components[1].RequiredVariables != nil || len(components[1].Requirements.Variables) != 1 {
+		t.Errorf("expected build to be left alone, got %+v", components[1])
+	}
+}
+
+func TestRun_RequiredVariables(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n---\ndeploy:\n  script: deploy --token $DEPLOY_TOKEN\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("required_variables:\n  deploy:\n    - name: DEPLOY_TOKEN\n      description: Token with the write_registry scope\n      secret: true\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	if !strings.Contains(string(data), "### Required CI/CD variables\n\n| Variable | Secret | Description |\n|----------|--------|-------------|\n| `DEPLOY_TOKEN` | true | Token with the write_registry scope |\n") {
+		t.Errorf("expected a required CI/CD variables section, got:\n%s", data)
+	}
+	if strings.Contains(string(data), "CI/CD variables to provide") {
+		t.Errorf("expected the declared variable to leave the requirements, got:\n%s", data)
+	}
+
+	os.WriteFile(configFile, []byte("required_variables:\n  deploy:\n    - name: deploy-token\n"), 0644)
+	if got := exitCode(run([]string{"--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an invalid name, got %d", exitConfig, got)
+	}
+}
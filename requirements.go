@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// predefinedVariablePrefixes and predefinedVariables name the variables GitLab,
+// the runner or the shell already provide, which pipelines don't have to set.
+var (
+	predefinedVariablePrefixes = []string{"CI_", "GITLAB_", "RUNNER_", "FF_", "CHAT_", "TRIGGER_"}
+	predefinedVariables        = map[string]bool{
+		"CI": true, "HOME": true, "HOSTNAME": true, "IFS": true, "OLDPWD": true,
+		"PATH": true, "PWD": true, "SHELL": true, "USER": true,
+	}
+)
+
+// shellAssignment matches variables a script sets itself (`NAME=`, `export
+// NAME=`, `for NAME in`), whose references are not requirements.
+var shellAssignment = regexp.MustCompile(`(?:^|[\s;&|(])(?:(?:export|local|readonly)\s+)?([A-Za-z_][A-Za-z0-9_]*)=|\bfor\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b`)
+
+// RequirementsData describes what a pipeline including the component must
+// provide: stages for its jobs, CI/CD variables, the rules deciding when the
+// jobs run and the runners they need.
+type RequirementsData struct {
+	Stages    []string     `json:"stages,omitempty"`
+	Variables []string     `json:"variables,omitempty"`
+	Rules     []RuleData   `json:"rules,omitempty"`
+	Runners   []RunnerData `json:"runners,omitempty"`
+}
+
+// RuleData is an entry of a job's (or the workflow's) `rules`.
+type RuleData struct {
+	Job     string   `json:"job"` // "workflow" for workflow:rules
+	If      string   `json:"if,omitempty"`
+	Changes []string `json:"changes,omitempty"`
+	Exists  []string `json:"exists,omitempty"`
+	When    string   `json:"when,omitempty"`
+}
+
+// Condition returns the rule's clauses for display, or "otherwise" for a rule
+// without any, which matches when the previous ones did not.
+func (r RuleData) Condition() string {
+	var clauses []string
+	if r.If != "" {
+		clauses = append(clauses, codeSpan(r.If))
+	}
+	if len(r.Changes) > 0 {
+		clauses = append(clauses, "changes to "+codeList(r.Changes))
+	}
+	if len(r.Exists) > 0 {
+		clauses = append(clauses, "presence of "+codeList(r.Exists))
+	}
+	if len(clauses) == 0 {
+		return "otherwise"
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// Outcome returns the rule's `when`, defaulting to GitLab's "on_success".
+func (r RuleData) Outcome() string {
+	if r.When == "" {
+		return "on_success"
+	}
+	return r.When
+}
+
+// componentRequirements collects the requirements of the body documents, or
+// returns nil when there are none. Stages come from the jobs; variables are
+// the ones referenced (`$NAME`, `${NAME}`) anywhere in the body but neither
+// predefined, set in a `variables:` block nor assigned by a script; rules are
+// listed per job (inherited through `extends`), after workflow:rules; runners
+// are the jobs' tags, images and services.
+func componentRequirements(body []map[string]interface{}, jobs []JobData) *RequirementsData {
+	var req RequirementsData
+
+	stages := make(map[string]bool)
+	for _, job := range jobs {
+		if !stages[job.Stage] {
+			stages[job.Stage] = true
+			req.Stages = append(req.Stages, job.Stage)
+		}
+	}
+	sort.Strings(req.Stages)
+
+	defined := make(map[string]bool)
+	referenced := make(map[string]bool)
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			for _, name := range variableReferences(v) {
+				referenced[name] = true
+			}
+			for _, m := range shellAssignment.FindAllStringSubmatch(v, -1) {
+				defined[m[1]+m[2]] = true
+			}
+		case map[string]interface{}:
+			if vars, ok := v["variables"].(map[string]interface{}); ok {
+				for name := range vars {
+					defined[name] = true
+				}
+			}
+			for _, item := range v {
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	for _, doc := range body {
+		walk(doc)
+	}
+	for name := range referenced {
+		if !defined[name] && !isPredefinedVariable(name) {
+			req.Variables = append(req.Variables, name)
+		}
+	}
+	sort.Strings(req.Variables)
+
+	for _, doc := range body {
+		if workflow, ok := doc["workflow"].(map[string]interface{}); ok {
+			req.Rules = append(req.Rules, parseRules("workflow", workflow["rules"])...)
+		}
+	}
+	defs := jobDefinitions(body)
+	for _, job := range jobs {
+		req.Rules = append(req.Rules, parseRules(job.Name, inheritedKey(defs, job.Name, "rules"))...)
+	}
+
+	req.Runners = runnerRequirements(body, jobs)
+
+	if len(req.Stages) == 0 && len(req.Variables) == 0 && len(req.Rules) == 0 && len(req.Runners) == 0 {
+		return nil
+	}
+	return &req
+}
+
+// parseRules converts a `rules` list into RuleData, in declaration order.
+func parseRules(job string, rules interface{}) []RuleData {
+	list, ok := rules.([]interface{})
+	if !ok {
+		return nil
+	}
+	var parsed []RuleData
+	for _, item := range list {
+		rule, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		data := RuleData{Job: job}
+		if cond, ok := rule["if"]; ok && cond != nil {
+			data.If = fmt.Sprintf("%v", cond)
+		}
+		data.Changes = ruleList(rule["changes"])
+		data.Exists = ruleList(rule["exists"])
+		if when, ok := rule["when"]; ok && when != nil {
+			data.When = fmt.Sprintf("%v", when)
+		}
+		parsed = append(parsed, data)
+	}
+	return parsed
+}
+
+// ruleList returns the paths of a `changes`/`exists` clause, given either
+// as a list or as a map with a `paths` list.
+func ruleList(value interface{}) []string {
+	if m, ok := value.(map[string]interface{}); ok {
+		value = m["paths"]
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		list = append(list, fmt.Sprintf("%v", item))
+	}
+	return list
+}
+
+// variableReferences returns the variables referenced in s as `$NAME` or
+// `${NAME}`. Escaped dollars (`$$NAME`) and input interpolations are skipped.
+func variableReferences(s string) []string {
+	var names []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '$' {
+			i++
+			continue
+		}
+		start, braced := i+1, false
+		if start < len(s) && s[start] == '{' {
+			start, braced = start+1, true
+		}
+		end := start
+		for end < len(s) && (s[end] == '_' || isLetter(s[end]) || (end > start && s[end] >= '0' && s[end] <= '9')) {
+			end++
+		}
+		if end == start || (braced && (end >= len(s) || s[end] != '}')) {
+			continue
+		}
+		names = append(names, s[start:end])
+		i = end - 1
+	}
+	return names
+}
+
+// isLetter reports whether b is an ASCII letter.
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// isPredefinedVariable reports whether GitLab, the runner or the shell
+// provides the variable.
+func isPredefinedVariable(name string) bool {
+	if predefinedVariables[name] {
+		return true
+	}
+	for _, prefix := range predefinedVariablePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComponentRequirements(t *testing.T) {
+	body, err := decodeBody([]byte(`spec:
+  inputs: {}
+---
+workflow:
+  rules:
+    - if: $CI_PIPELINE_SOURCE == "push"
+variables:
+  REGION: eu-west-1
+.base:
+  rules:
+    - if: $CI_COMMIT_TAG
+      when: manual
+    - exists: [Dockerfile]
+    - when: never
+deploy:
+  extends: .base
+  stage: deploy
+  variables:
+    PROFILE: prod
+  script:
+    - export TARGET=prod
+    - for f in *.yml; do echo $f; done
+    - aws s3 sync . s3://$BUCKET --region $REGION --profile ${PROFILE} $TARGET $$ESCAPED
+    - test -n "$AWS_ACCESS_KEY_ID"
+lint:
+  script: echo $HOME $CI_PROJECT_DIR
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := componentRequirements(body, componentJobs(body))
+	if req == nil {
+		t.Fatal("expected requirements, got nil")
+	}
+	if got := strings.Join(req.Stages, ","); got != "deploy,test" {
+		t.Errorf("expected stages deploy,test, got %s", got)
+	}
+	if got := strings.Join(req.Variables, ","); got != "AWS_ACCESS_KEY_ID,BUCKET" {
+		t.Errorf("expected variables AWS_ACCESS_KEY_ID,BUCKET, got %s", got)
+	}
+
+	var rules []string
+	for _, r := range req.Rules {
+		rules = append(rules, r.Job+": "+r.Condition()+" => "+r.Outcome())
+	}
+	expected := []string{
+		"workflow: `$CI_PIPELINE_SOURCE == \"push\"` => on_success",
+		"deploy: `$CI_COMMIT_TAG` => manual",
+		"deploy: presence of `Dockerfile` => on_success",
+		"deploy: otherwise => never",
+	}
+	if strings.Join(rules, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected rules:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(rules, "\n"))
+	}
+
+	if req := componentRequirements(nil, nil); req != nil {
+		t.Errorf("expected no requirements without jobs, got %+v", req)
+	}
+}
+
+func TestVariableReferences(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"echo $FOO ${BAR}", "FOO,BAR"},
+		{"echo $$ESCAPED $[[ inputs.stage ]]", ""},
+		{"${UNTERMINATED", ""},
+		{"$1 $? $_HIDDEN2", "_HIDDEN2"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(variableReferences(tt.input), ","); got != tt.expected {
+			t.Errorf("variableReferences(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RunnerData is what a job needs from the runner picking it up: the tags
+// selecting the runner, the image it runs in and the service containers next
+// to it.
+type RunnerData struct {
+	Job      string   `json:"job"`
+	Tags     []string `json:"tags,omitempty"`
+	Image    string   `json:"image,omitempty"`
+	Services []string `json:"services,omitempty"`
+	// DockerInDocker is set when a service runs the Docker daemon, which
+	// needs a runner allowing privileged containers
+	DockerInDocker bool `json:"docker_in_docker,omitempty"`
+}
+
+// runnerKeywords are the job keywords a runner has to satisfy.
+var runnerKeywords = []string{"tags", "image", "services"}
+
+// runnerRequirements returns the tags, image and services of the jobs that
+// set any, in job order. Values come from the job, its `extends` parents, or
+// else `default:` unless the job's `inherit:default` turns it off. Input
+// interpolations are kept, as they name the input choosing the value.
+func runnerRequirements(body []map[string]interface{}, jobs []JobData) []RunnerData {
+	defaults := make(map[string]interface{})
+	for _, doc := range body {
+		if d, ok := doc["default"].(map[string]interface{}); ok {
+			for _, key := range runnerKeywords {
+				if value, ok := d[key]; ok && value != nil {
+					defaults[key] = value
+				}
+			}
+		}
+	}
+	defs := jobDefinitions(body)
+
+	var runners []RunnerData
+	for _, job := range jobs {
+		inherits := inheritedDefaults(inheritedKey(defs, job.Name, "inherit"))
+		value := func(key string) interface{} {
+			if v := inheritedKey(defs, job.Name, key); v != nil {
+				return v
+			}
+			if inherits(key) {
+				return defaults[key]
+			}
+			return nil
+		}
+		runner := RunnerData{Job: job.Name, Image: imageName(value("image"))}
+		if tags, ok := value("tags").([]interface{}); ok {
+			for _, tag := range tags {
+				runner.Tags = append(runner.Tags, fmt.Sprintf("%v", tag))
+			}
+		}
+		if services, ok := value("services").([]interface{}); ok {
+			for _, service := range services {
+				if image := imageName(service); image != "" {
+					runner.Services = append(runner.Services, image)
+					runner.DockerInDocker = runner.DockerInDocker || isDockerDaemon(image)
+				}
+			}
+		}
+		if len(runner.Tags) > 0 || runner.Image != "" || len(runner.Services) > 0 {
+			runners = append(runners, runner)
+		}
+	}
+	return runners
+}
+
+// inheritedDefaults returns whether a job with the given `inherit:` value
+// inherits a `default:` keyword: `default: false` inherits none, a list only
+// the keywords it names.
+func inheritedDefaults(inherit interface{}) func(key string) bool {
+	m, _ := inherit.(map[string]interface{})
+	switch d := m["default"].(type) {
+	case bool:
+		return func(string) bool { return d }
+	case []interface{}:
+		return func(key string) bool {
+			for _, item := range d {
+				if fmt.Sprintf("%v", item) == key {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return func(string) bool { return true }
+}
+
+// isDockerDaemon reports whether a service image runs the Docker daemon
+// (docker:dind, docker:27-dind, ...).
+func isDockerDaemon(image string) bool {
+	return strings.Contains(path.Base(image), "dind")
+}
+
+// DockerInDockerJobs returns the jobs whose services run Docker-in-Docker.
+func (r RequirementsData) DockerInDockerJobs() []string {
+	var jobs []string
+	for _, runner := range r.Runners {
+		if runner.DockerInDocker {
+			jobs = append(jobs, runner.Job)
+		}
+	}
+	return jobs
+}
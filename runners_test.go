@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const runnersTemplate = `spec:
+  inputs:
+    runner_tag:
+      default: saas-linux-medium-amd64
+---
+default:
+  image: docker:27
+  tags: ["$[[ inputs.runner_tag ]]"]
+.docker:
+  services:
+    - name: docker:27-dind
+      alias: docker
+build:
+  extends: .docker
+  script: docker build .
+lint:
+  inherit:
+    default: [image]
+  image: {name: "alpine:3"}
+  script: lint
+notes:
+  inherit:
+    default: false
+  script: echo
+`
+
+func TestRunnerRequirements(t *testing.T) {
+	body, err := decodeBody([]byte(runnersTemplate))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runners := runnerRequirements(body, componentJobs(body))
+	if len(runners) != 2 {
+		t.Fatalf("expected the build and lint jobs, got %+v", runners)
+	}
+	build, lint := runners[0], runners[1]
+	if build.Job != "build" || build.Image != "docker:27" || strings.Join(build.Tags, ",") != "$[[ inputs.runner_tag ]]" || strings.Join(build.Services, ",") != "docker:27-dind" || !build.DockerInDocker {
+		t.Errorf("expected the defaults and the inherited dind service, got %+v", build)
+	}
+	if lint.Job != "lint" || lint.Image != "alpine:3" || len(lint.Tags) != 0 || lint.DockerInDocker {
+		t.Errorf("expected only the job's image, got %+v", lint)
+	}
+	if jobs := (RequirementsData{Runners: runners}).DockerInDockerJobs(); strings.Join(jobs, ",") != "build" {
+		t.Errorf("expected build to need Docker-in-Docker, got %v", jobs)
+	}
+}
+
+func TestRun_RunnerRequirements(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "docker.yml"), []byte(runnersTemplate), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	want := "#### Runner requirements\n\n" +
+		"| Job | Tags | Image | Services |\n" +
+		"|-----|------|-------|----------|\n" +
+		"| build | `$[[ inputs.runner_tag ]]` | `docker:27` | `docker:27-dind` |\n" +
+		"| lint |  | `alpine:3` |  |\n\n" +
+		"Jobs running Docker-in-Docker, which need a runner allowing privileged containers: `build`\n"
+	if !strings.Contains(string(data), want) {
+		t.Errorf("expected %q in:\n%s", want, data)
+	}
+}
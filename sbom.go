@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// cycloneDXBOM is the subset of a CycloneDX 1.5 JSON document the inventory uses.
+type cycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string              `json:"bom-ref"`
+	Type       string              `json:"type"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// buildSBOM turns the catalog into a CycloneDX inventory: the catalog is the
+// root, each component depends on the images, external includes and remote
+// scripts its jobs use. The output is reproducible (no timestamp or serial).
+func buildSBOM(data TemplateData) cycloneDXBOM {
+	bom := cycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: cycloneDXMetadata{Component: cycloneDXComponent{
+			BOMRef:  "catalog",
+			Type:    "application",
+			Name:    data.ProjectPath,
+			Version: data.Version,
+		}},
+		Components:   []cycloneDXComponent{},
+		Dependencies: []cycloneDXDependency{},
+	}
+
+	root := cycloneDXDependency{Ref: "catalog", DependsOn: []string{}}
+	external := make(map[string]cycloneDXComponent)
+	for _, c := range data.Components {
+		ref := "component:" + c.Name
+		root.DependsOn = append(root.DependsOn, ref)
+		bom.Components = append(bom.Components, cycloneDXComponent{
+			BOMRef:  ref,
+			Type:    "application",
+			Name:    c.Name,
+			Version: data.Version,
+		})
+
+		dep := cycloneDXDependency{Ref: ref, DependsOn: []string{}}
+		for _, d := range c.Dependencies {
+			depRef := d.Type + ":" + d.Ref
+			if !contains(dep.DependsOn, depRef) {
+				dep.DependsOn = append(dep.DependsOn, depRef)
+			}
+			if _, ok := external[depRef]; !ok {
+				external[depRef] = dependencyComponent(depRef, d)
+			}
+		}
+		bom.Dependencies = append(bom.Dependencies, dep)
+	}
+	bom.Dependencies = append([]cycloneDXDependency{root}, bom.Dependencies...)
+
+	var refs []string
+	for ref := range external {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		bom.Components = append(bom.Components, external[ref])
+	}
+	return bom
+}
+
+// dependencyComponent describes an image, include or script as a CycloneDX component.
+func dependencyComponent(ref string, d DependencyData) cycloneDXComponent {
+	component := cycloneDXComponent{
+		BOMRef:     ref,
+		Name:       d.Ref,
+		Properties: []cycloneDXProperty{{Name: "gitlab-component-docs-gen:type", Value: d.Type}},
+	}
+	switch d.Type {
+	case dependencyImage:
+		component.Type = "container"
+		component.Name, component.Version = splitImageRef(d.Ref)
+		if !isExpression(d.Ref) {
+			component.PURL = imagePURL(component.Name, component.Version)
+		}
+	default:
+		component.Type = "file"
+	}
+	if isExpression(d.Ref) {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "gitlab-component-docs-gen:unresolved", Value: "true"})
+	}
+	return component
+}
+
+// splitImageRef splits an image reference into name and tag (or digest).
+func splitImageRef(ref string) (name, version string) {
+	if at := strings.Index(ref, "@"); at >= 0 {
+		return ref[:at], ref[at+1:]
+	}
+	if colon := strings.LastIndex(ref, ":"); colon > strings.LastIndex(ref, "/") {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, "latest"
+}
+
+// imagePURL returns the package URL of a container image.
+func imagePURL(name, version string) string {
+	version = strings.ReplaceAll(version, ":", "%3A")
+	purl := "pkg:docker/"
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		purl += parts[1] + "@" + version + "?repository_url=" + parts[0]
+		return purl
+	}
+	return purl + name + "@" + version
+}
+
+// runSBOM implements the `sbom` subcommand: it writes a CycloneDX JSON
+// inventory of what the catalog's components pull in.
+func runSBOM(args []string, stdout io.Writer) error {
+	flags := newFlagSet("sbom")
+	projectPath := flags.String("project-path", "", "GitLab project path (e.g. group/project)")
+	version := flags.String("version", "", "Component version (e.g. 1.0.0)")
+	remote := flags.String("remote", "", "Git remote used to detect the project path (default \"origin\")")
+	output := flags.String("output", "-", "Output file (\"-\" for stdout)")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.Var((*stringList)(&overrides.Include), "include", "Only inventory components matching this glob (repeatable)")
+	flags.Var((*stringList)(&overrides.Exclude), "exclude", "Skip components matching this glob (repeatable)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	overrides.Format = "json"
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	data, err := collectTemplateData(settings, nil, *projectPath, *version, resolveRemote(*remote), *jobs, nil)
+	if err != nil {
+		return err
+	}
+	bom := buildSBOM(data)
+
+	out, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return withExitCode(exitFailure, fmt.Errorf("error encoding SBOM: %w", err))
+	}
+	out = append(out, '\n')
+	if *output == "-" {
+		if _, err := stdout.Write(out); err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing SBOM: %w", err))
+		}
+		return nil
+	}
+	if err := os.WriteFile(*output, out, 0644); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", *output, err))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImagePURL(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"alpine", "pkg:docker/alpine@latest"},
+		{"alpine:3.19", "pkg:docker/alpine@3.19"},
+		{"library/postgres@sha256:abc", "pkg:docker/library/postgres@sha256%3Aabc"},
+		{"registry.example.com/tools/kaniko:v1", "pkg:docker/tools/kaniko@v1?repository_url=registry.example.com"},
+		{"localhost:5000/app:1", "pkg:docker/app@1?repository_url=localhost:5000"},
+	}
+	for _, tt := range tests {
+		if got := imagePURL(splitImageRef(tt.ref)); got != tt.expected {
+			t.Errorf("imagePURL(%q) = %q, want %q", tt.ref, got, tt.expected)
+		}
+	}
+}
+
+func TestRunSBOM(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte(`spec:
+  inputs:
+    image:
+      default: alpine:3.19
+---
+build:
+  image: $[[ inputs.image ]]
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte(`spec:
+  inputs:
+    image: {}
+---
+deploy:
+  image: $[[ inputs.image ]]
+`), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"sbom", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bom cycloneDXBOM
+	if err := json.Unmarshal(out.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.Metadata.Component.Name != "g/p" {
+		t.Errorf("unexpected BOM header: %+v", bom)
+	}
+
+	byRef := make(map[string]cycloneDXComponent)
+	for _, c := range bom.Components {
+		byRef[c.BOMRef] = c
+	}
+	if c := byRef["image:alpine:3.19"]; c.Type != "container" || c.PURL != "pkg:docker/alpine@3.19" {
+		t.Errorf("expected resolved alpine image, got %+v", c)
+	}
+	unresolved := byRef["image:$[[ inputs.image ]]"]
+	if unresolved.PURL != "" || len(unresolved.Properties) != 2 {
+		t.Errorf("expected unresolved image without purl, got %+v", unresolved)
+	}
+	if len(bom.Dependencies) != 3 || bom.Dependencies[0].Ref != "catalog" || len(bom.Dependencies[0].DependsOn) != 2 {
+		t.Errorf("unexpected dependency graph: %+v", bom.Dependencies)
+	}
+
+	// Reproducible, and written to --output when given
+	if err := run([]string{"sbom", "--project-path", "g/p", "--version", "1.0.0", "--output", "sbom.json"}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("sbom.json"); !bytes.Equal(data, out.Bytes()) {
+		t.Error("expected --output to hold the same, reproducible document")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// schemaFiles holds the JSON Schemas of the machine-readable outputs. They are
+// a public contract: fields are only ever added, never renamed or removed.
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// schemaNames lists the exported schemas, each describing one output:
+// components (--format json), report (--porcelain), diagnostics (one line
+// of --diagnostics json) and descriptor (--emit).
+var schemaNames = []string{"components", "report", "diagnostics", "descriptor"}
+
+// schemaFile returns the file name of a schema.
+func schemaFile(name string) string {
+	return name + ".schema.json"
+}
+
+// loadSchema returns the embedded schema with the given name.
+func loadSchema(name string) ([]byte, error) {
+	if !contains(schemaNames, name) {
+		return nil, fmt.Errorf("unknown schema %q (expected one of: %s)", name, strings.Join(schemaNames, ", "))
+	}
+	return schemaFiles.ReadFile("schemas/" + schemaFile(name))
+}
+
+// runSchema implements the `schema` subcommand. `schema export NAME` prints
+// one schema; `schema export --output-dir DIR [NAME...]` writes the given
+// schemas (all by default) to DIR.
+func runSchema(args []string, stdout io.Writer) error {
+	if len(args) == 0 || (args[0] != "export" && !isHelpFlag(args[0])) {
+		return withExitCode(exitConfig, fmt.Errorf("usage: gitlab-component-docs-gen schema export [--output-dir DIR] [%s]", strings.Join(schemaNames, "|")))
+	}
+
+	flags := newFlagSet("schema")
+	outputDir := flags.String("output-dir", "", "Write the schemas to this directory instead of stdout")
+	if args[0] == "export" {
+		args = args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	names := flags.Args()
+	for _, name := range names {
+		if !contains(schemaNames, name) {
+			return withExitCode(exitConfig, fmt.Errorf("unknown schema %q (expected one of: %s)", name, strings.Join(schemaNames, ", ")))
+		}
+	}
+
+	if *outputDir == "" {
+		if len(names) != 1 {
+			return withExitCode(exitConfig, fmt.Errorf("schema export: name one schema (%s), or use --output-dir to write several", strings.Join(schemaNames, ", ")))
+		}
+		data, err := loadSchema(names[0])
+		if err != nil {
+			return withExitCode(exitFailure, err)
+		}
+		if _, err := stdout.Write(data); err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing schema: %w", err))
+		}
+		return nil
+	}
+
+	if len(names) == 0 {
+		names = schemaNames
+	}
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return withExitCode(exitWrite, fmt.Errorf("error creating %s: %w", *outputDir, err))
+	}
+	for _, name := range names {
+		data, err := loadSchema(name)
+		if err != nil {
+			return withExitCode(exitFailure, err)
+		}
+		path := filepath.Join(*outputDir, schemaFile(name))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return withExitCode(exitWrite, fmt.Errorf("error writing %s: %w", path, err))
+		}
+	}
+	return nil
+}
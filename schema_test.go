@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// validateJSON checks value against the subset of JSON Schema used by the
+// embedded schemas (type, enum, minimum, required, properties, items and
+// local $ref), returning one message per violation.
+func validateJSON(schema, root map[string]interface{}, value interface{}, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := strings.TrimPrefix(ref, "#/$defs/")
+		schema = root["$defs"].(map[string]interface{})[def].(map[string]interface{})
+	}
+
+	var problems []string
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if e == value {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", at, value, enum))
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected object, got %T", at, value))
+		}
+		for _, r := range asSlice(schema["required"]) {
+			if _, ok := obj[r.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required %q", at, r))
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		if props == nil {
+			return problems
+		}
+		for key, v := range obj {
+			prop, ok := props[key].(map[string]interface{})
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: undocumented property %q", at, key))
+				continue
+			}
+			problems = append(problems, validateJSON(prop, root, v, at+"."+key)...)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected array, got %T", at, value))
+		}
+		for i, item := range items {
+			problems = append(problems, validateJSON(schema["items"].(map[string]interface{}), root, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected string, got %T", at, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected boolean, got %T", at, value))
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return append(problems, fmt.Sprintf("%s: expected integer, got %v", at, value))
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			problems = append(problems, fmt.Sprintf("%s: %v is below the minimum %v", at, n, min))
+		}
+	}
+	return problems
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// mustSchema decodes an embedded schema.
+func mustSchema(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+	data, err := loadSchema(name)
+	if err != nil {
+		t.Fatalf("loading schema %s: %v", name, err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema %s is not valid JSON: %v", name, err)
+	}
+	return schema
+}
+
+// assertValid validates a JSON document against the named schema.
+func assertValid(t *testing.T, name string, doc []byte) {
+	t.Helper()
+	schema := mustSchema(t, name)
+	var value interface{}
+	if err := json.Unmarshal(doc, &value); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, doc)
+	}
+	if problems := validateJSON(schema, schema, value, "$"); len(problems) > 0 {
+		t.Errorf("output does not match the %s schema:\n%s\n%s", name, strings.Join(problems, "\n"), doc)
+	}
+}
+
+// schemaFields lists the JSON fields of a struct type missing from the
+// schema, recursing into nested structs and slices of structs.
+func schemaFields(t reflect.Type, schema, root map[string]interface{}, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = root["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	var missing []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+		prop, ok := props[name].(map[string]interface{})
+		if !ok {
+			missing = append(missing, at+"."+name)
+			continue
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+			if items, ok := prop["items"].(map[string]interface{}); ok {
+				prop = items
+			}
+		}
+		if ft.Kind() == reflect.Struct {
+			missing = append(missing, schemaFields(ft, prop, root, at+"."+name)...)
+		}
+	}
+	return missing
+}
+
+func TestSchemas_CoverOutputTypes(t *testing.T) {
+	for name, typ := range map[string]reflect.Type{
+		"components":  reflect.TypeOf(TemplateData{}),
+		"report":      reflect.TypeOf(RunReport{}),
+		"diagnostics": reflect.TypeOf(FileDiagnostics{}),
+		"descriptor":  reflect.TypeOf(InterfaceDescriptor{}),
+	} {
+		schema := mustSchema(t, name)
+		if missing := schemaFields(typ, schema, schema, "$"); len(missing) > 0 {
+			sort.Strings(missing)
+			t.Errorf("schema %s does not document: %s", name, strings.Join(missing, ", "))
+		}
+	}
+}
+
+func TestSchemas_ValidateOutputs(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.MkdirAll(filepath.Join(dir, "examples", "build"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte(`# Builds the project.
+spec:
+  component: [name, version]
+  inputs:
+    stage:
+      default: build
+      x-since: "1.2"
+    level:
+      default: info
+      options: [debug, info]
+    target:
+      description: Build target
+---
+build:
+  image: golang:1.22
+  stage: $[[ inputs.stage ]]
+  variables:
+    TARGET: $[[ inputs.target ]]
+  script: echo $[[ inputs.level ]] $[[ inputs.nope ]]
+  artifacts:
+    paths: [dist/]
+    expire_in: 1 week
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "build.md"), []byte("---\ntitle: Build\nmaintainers: [\"@dev\"]\nteam: ci\n---\nBuilds.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "examples", "build", "basic.yml"), []byte("include: []\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "broken.yml"), []byte("spec:\n  inputs:\n    a: [\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	run([]string{"--diagnostics", "json"}, &out)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		assertValid(t, "diagnostics", []byte(line))
+	}
+
+	out.Reset()
+	run([]string{"--porcelain", "--validate"}, &out)
+	assertValid(t, "report", out.Bytes())
+
+	os.Remove(filepath.Join("templates", "broken.yml"))
+	out.Reset()
+	if err := run([]string{"--porcelain", "--project-path", "g/p", "--version", "1.0.0", "--format", "json"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertValid(t, "report", out.Bytes())
+
+	data, err := os.ReadFile("components.json")
+	if err != nil {
+		t.Fatalf("expected components.json: %v", err)
+	}
+	assertValid(t, "components", data)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--emit", "descriptor.json"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err = os.ReadFile("descriptor.json")
+	if err != nil {
+		t.Fatalf("expected descriptor.json: %v", err)
+	}
+	assertValid(t, "descriptor", data)
+}
+
+func TestRunSchemaExport(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"schema", "export", "report"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := loadSchema("report")
+	if out.String() != string(want) {
+		t.Errorf("expected the report schema on stdout, got %q", out.String())
+	}
+
+	dir := filepath.Join(t.TempDir(), "schemas")
+	if err := run([]string{"schema", "export", "--output-dir", dir}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range schemaNames {
+		if _, err := os.Stat(filepath.Join(dir, name+".schema.json")); err != nil {
+			t.Errorf("expected %s schema to be written: %v", name, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"schema"},
+		{"schema", "export"},
+		{"schema", "export", "manifest"},
+		{"schema", "export", "report", "components"},
+	} {
+		if got := exitCode(run(args, &out)); got != exitConfig {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitConfig, got)
+		}
+	}
+}
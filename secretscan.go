@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SecretScanConfig configures the secret scan of validation (`secret_scan`).
+type SecretScanConfig struct {
+	Enabled  *bool  `yaml:"enabled"`  // unset means true
+	Severity string `yaml:"severity"` // error (default) or warning
+	// Allow lists `component/input` patterns whose values are not scanned,
+	// e.g. `*/example_*`; `component/` matches the component's description
+	Allow []string `yaml:"allow"`
+}
+
+// SecretScan is the resolved secret scan of a run.
+type SecretScan struct {
+	Enabled  bool
+	Severity string
+	Allow    []string
+}
+
+// resolveSecretScan applies the defaults of the secret scan and checks its
+// settings.
+func resolveSecretScan(config SecretScanConfig) (SecretScan, error) {
+	scan := SecretScan{Enabled: true, Severity: config.Severity, Allow: config.Allow}
+	if config.Enabled != nil {
+		scan.Enabled = *config.Enabled
+	}
+	if scan.Severity == "" {
+		scan.Severity = severityError
+	}
+	if scan.Severity != severityWarning && scan.Severity != severityError {
+		return scan, fmt.Errorf("invalid secret_scan.severity %q (expected %s or %s)", scan.Severity, severityWarning, severityError)
+	}
+	for _, pattern := range scan.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return scan, fmt.Errorf("invalid secret_scan.allow pattern %q: %w", pattern, err)
+		}
+	}
+	return scan, nil
+}
+
+// secretPrefixes are the prefixes of well-known credentials, with the kind
+// reported. They only count when followed by a long enough token.
+var secretPrefixes = []struct{ prefix, kind string }{
+	{"glpat-", "GitLab personal access token"},
+	{"gldt-", "GitLab deploy token"},
+	{"glrt-", "GitLab runner token"},
+	{"glptt-", "GitLab pipeline trigger token"},
+	{"glcbt-", "GitLab CI/CD job token"},
+	{"gloas-", "GitLab OAuth application secret"},
+	{"GR1348941", "GitLab runner registration token"},
+	{"ghp_", "GitHub personal access token"},
+	{"github_pat_", "GitHub personal access token"},
+	{"xoxb-", "Slack bot token"},
+	{"xoxp-", "Slack user token"},
+	{"sk_live_", "Stripe secret key"},
+	{"AKIA", "AWS access key ID"},
+	{"AIza", "Google API key"},
+}
+
+// minPrefixedSecret is the length of the token after a known prefix for it
+// to look real: shorter ones are examples such as `glpat-xxxx`.
+const minPrefixedSecret = 16
+
+// High-entropy strings: at least minEntropyLength characters of a token
+// alphabet, mixing upper case, lower case and digits (so hex digests and
+// kebab-case names don't count), with at least minEntropy bits per
+// character.
+const (
+	minEntropyLength = 24
+	minEntropy       = 4.0
+)
+
+// secretToken matches the candidate tokens of a value: runs of characters
+// credentials are made of.
+var secretToken = regexp.MustCompile(`[A-Za-z0-9_+=-]+`)
+
+// privateKeyPattern matches the header of a PEM private key.
+var privateKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+
+// detectSecret returns what a value looks like, when it looks like a real
+// credential, or "" otherwise.
+func detectSecret(value string) string {
+	if privateKeyPattern.MatchString(value) {
+		return "private key"
+	}
+	for _, token := range secretToken.FindAllString(value, -1) {
+		for _, p := range secretPrefixes {
+			rest := strings.TrimPrefix(token, p.prefix)
+			if rest != token && len(rest) >= minPrefixedSecret && !repeatedChar(rest) {
+				return p.kind
+			}
+		}
+		if len(token) >= minEntropyLength && mixedCase(token) && shannonEntropy(token) >= minEntropy {
+			return "high-entropy string"
+		}
+	}
+	return ""
+}
+
+// repeatedChar reports whether s is one character repeated, like the
+// `xxxxxxxx` of placeholders.
+func repeatedChar(s string) bool {
+	return strings.Trim(s, s[:1]) == ""
+}
+
+// mixedCase reports whether s has upper case letters, lower case letters
+// and digits.
+func mixedCase(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return upper && lower && digit
+}
+
+// shannonEntropy returns the entropy of s, in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len(s))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// scanSecrets reports the input defaults and descriptions, and the
+// component's description, that look like real credentials. It runs before
+// sensitive defaults are masked, and reports nothing when the scan is
+// disabled. The values are never included in the messages.
+func scanSecrets(c ComponentData, scan SecretScan) []Issue {
+	if !scan.Enabled {
+		return nil
+	}
+	allowed := func(input string) bool {
+		for _, pattern := range scan.Allow {
+			if ok, _ := path.Match(pattern, c.Name+"/"+input); ok {
+				return true
+			}
+		}
+		return false
+	}
+	var issues []Issue
+	report := func(input, field, kind string) {
+		issues = append(issues, Issue{
+			Severity:  scan.Severity,
+			Component: c.Name,
+			Input:     input,
+			Message:   fmt.Sprintf("%s looks like a %s: remove it from the template, or allow it in secret_scan.allow", field, kind),
+			File:      c.path,
+			Line:      c.lines[input],
+		})
+	}
+
+	if kind := detectSecret(c.Description); kind != "" && !allowed("") {
+		report("", "description", kind)
+	}
+	for _, input := range c.Inputs {
+		if allowed(input.Name) {
+			continue
+		}
+		if kind := detectSecret(input.Default); kind != "" {
+			report(input.Name, "default", kind)
+		}
+		if kind := detectSecret(input.Description); kind != "" {
+			report(input.Name, "description", kind)
+		}
+	}
+	return issues
+}
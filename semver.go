@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Impacts of interface changes on the components' users, from the least to
+// the most disruptive. They map to the patch, minor and major versions.
+const (
+	impactPatch    = "patch"
+	impactFeature  = "feature"
+	impactBreaking = "breaking"
+)
+
+// impactBumps maps impacts to the version part they bump.
+var impactBumps = map[string]string{impactPatch: "patch", impactFeature: "minor", impactBreaking: "major"}
+
+// changeImpact classifies an interface change: breaking changes can fail
+// including pipelines, features let them do more, and the rest (changed
+// defaults) keep every pipeline valid.
+func changeImpact(c InterfaceChange) string {
+	switch {
+	case c.Breaking:
+		return impactBreaking
+	case c.Kind == changeDefault:
+		return impactPatch
+	}
+	return impactFeature
+}
+
+// highestImpact returns the most disruptive impact of changes, patch when
+// there are none.
+func highestImpact(changes []InterfaceChange) string {
+	impact := impactPatch
+	for _, c := range changes {
+		if c.Impact == impactBreaking {
+			return impactBreaking
+		}
+		if c.Impact == impactFeature {
+			impact = impactFeature
+		}
+	}
+	return impact
+}
+
+// suggestedBump returns the version part to bump after changes of the given
+// impact. Before 1.0.0 anything may change, so breaking changes bump the
+// minor version and features the patch, as Cargo and npm do.
+func suggestedBump(current, impact string) string {
+	bump := impactBumps[impact]
+	if m := releaseVersionPattern.FindStringSubmatch(current); m != nil && m[2] == "0" {
+		switch bump {
+		case "major":
+			return "minor"
+		case "minor":
+			return "patch"
+		}
+	}
+	return bump
+}
+
+// versionBump returns the version part bumped from current to next:
+// "major", "minor" or "patch", or "" when next is not greater. Releasing a
+// pre-release counts as bumping the part it pre-released.
+func versionBump(current, next string) string {
+	c, n := releaseVersionPattern.FindStringSubmatch(current), releaseVersionPattern.FindStringSubmatch(next)
+	if c == nil || n == nil {
+		return ""
+	}
+	for i, part := range releaseBumps {
+		cv, _ := strconv.Atoi(c[i+2])
+		nv, _ := strconv.Atoi(n[i+2])
+		switch {
+		case nv > cv:
+			return part
+		case nv < cv:
+			return ""
+		}
+	}
+	if c[5] != "" && n[5] == "" {
+		return "patch"
+	}
+	return ""
+}
+
+// checkVersionBump returns an error when releasing next after current bumps
+// a smaller version part than changes require. Bumping more is allowed.
+func checkVersionBump(current, next string, changes []InterfaceChange) error {
+	impact := highestImpact(changes)
+	want := suggestedBump(current, impact)
+	got := versionBump(current, next)
+	if got == "" {
+		return fmt.Errorf("version %s does not follow %s", next, current)
+	}
+	rank := func(bump string) int { return len(releaseBumps) - indexOf(releaseBumps, bump) }
+	if rank(got) >= rank(want) {
+		return nil
+	}
+	var reasons []string
+	for _, c := range changes {
+		if c.Impact == impact {
+			reasons = append(reasons, c.String())
+		}
+	}
+	return fmt.Errorf("%s is a %s release, but the changes since %s need a %s release:\n  %s", next, got, current, want, strings.Join(reasons, "\n  "))
+}
+
+// indexOf returns the index of value in values, or -1.
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// refVersion returns the release version of a git ref: the ref itself when it
+// is a version tag, else the latest version tag reachable from it ("" when
+// none).
+func refVersion(ref string) string {
+	if releaseVersionPattern.MatchString(ref) {
+		return ref
+	}
+	tag, err := runGit("describe", "--tags", "--abbrev=0", ref)
+	if err != nil || !releaseVersionPattern.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+// versionRef returns the tag of a released version, which may or may not
+// carry the "v" prefix the version is written with ("" when none exists).
+func versionRef(version string) string {
+	for _, tag := range []string{version, "v" + strings.TrimPrefix(version, "v"), strings.TrimPrefix(version, "v")} {
+		if _, err := runGit("rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err == nil {
+			return tag
+		}
+	}
+	return ""
+}
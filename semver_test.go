@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuggestedBump(t *testing.T) {
+	tests := []struct {
+		current, impact, want string
+	}{
+		{"1.2.3", impactBreaking, "major"},
+		{"v1.2.3", impactFeature, "minor"},
+		{"1.2.3", impactPatch, "patch"},
+		{"0.4.1", impactBreaking, "minor"},
+		{"0.4.1", impactFeature, "patch"},
+		{"", impactFeature, "minor"},
+	}
+	for _, tt := range tests {
+		if got := suggestedBump(tt.current, tt.impact); got != tt.want {
+			t.Errorf("suggestedBump(%q, %q) = %q, want %q", tt.current, tt.impact, got, tt.want)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		current, next, want string
+	}{
+		{"1.2.3", "2.0.0", "major"},
+		{"v1.2.3", "v1.3.0", "minor"},
+		{"1.2.3", "1.2.4", "patch"},
+		{"1.3.0-rc.1", "1.3.0", "patch"},
+		{"1.2.3", "1.2.3", ""},
+		{"1.2.3", "1.1.9", ""},
+		{"1.2.3", "next", ""},
+	}
+	for _, tt := range tests {
+		if got := versionBump(tt.current, tt.next); got != tt.want {
+			t.Errorf("versionBump(%q, %q) = %q, want %q", tt.current, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersionBump(t *testing.T) {
+	changes := interfaceChanges(
+		[]ComponentData{{Name: "build", Inputs: []InputData{{Name: "stage", Type: "string"}}}},
+		[]ComponentData{{Name: "build", Inputs: []InputData{{Name: "stage", Type: "string"}, {Name: "cache", Type: "boolean"}}}},
+	)
+	if got := highestImpact(changes); got != impactFeature {
+		t.Fatalf("expected a feature, got %q", got)
+	}
+	if err := checkVersionBump("1.2.3", "1.3.0", changes); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkVersionBump("1.2.3", "2.0.0", changes); err != nil {
+		t.Errorf("expected a larger bump to be accepted, got %v", err)
+	}
+	want := "1.2.4 is a patch release, but the changes since 1.2.3 need a minor release:\n  build: input `cache` added"
+	if err := checkVersionBump("1.2.3", "1.2.4", changes); err == nil || err.Error() != want {
+		t.Errorf("unexpected error:\n%v\nwant:\n%s", err, want)
+	}
+	if err := checkVersionBump("1.2.3", "1.2.3", nil); err == nil {
+		t.Error("expected an error for a version that does not follow the current one")
+	}
+}
+
+func TestRun_ReleaseCheckSemver(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	os.WriteFile(configFile, []byte("project_path: group/project\nversion: 1.2.3\n"), 0644)
+	os.WriteFile(".gitignore", []byte(cacheFile+"\n"), 0644)
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	git("tag", "v1.2.3")
+
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n    image:\n      description: Image\n"), 0644)
+	if got := exitCode(run([]string{"release", "--quiet", "--check-semver", "minor"}, io.Discard)); got != exitChanged {
+		t.Errorf("expected exit code %d for a minor release of a breaking change, got %d", exitChanged, got)
+	}
+	if data, _ := os.ReadFile(configFile); !strings.Contains(string(data), "version: 1.2.3") {
+		t.Errorf("expected the refused release not to change the config, got:\n%s", data)
+	}
+	if err := run([]string{"release", "--quiet", "--check-semver", "--dry-run", "major"}, io.Discard); err != nil {
+		t.Errorf("unexpected error for a major release: %v", err)
+	}
+}
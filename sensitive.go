@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// sensitiveExtension marks an input as sensitive (`x-sensitive: true`), or
+// not (`x-sensitive: false`), whatever its name.
+const sensitiveExtension = "sensitive"
+
+func init() {
+	RegisterInputExtension(sensitiveExtension, decodeSensitive)
+}
+
+// decodeSensitive accepts a boolean.
+func decodeSensitive(value interface{}) (interface{}, error) {
+	if b, ok := value.(bool); ok {
+		return b, nil
+	}
+	return nil, errors.New("expected true or false")
+}
+
+// defaultSensitivePatterns are the names of the inputs considered sensitive
+// when `sensitive_inputs` is unset.
+var defaultSensitivePatterns = []string{"*_token", "*_password", "*_secret", "token", "password", "secret"}
+
+// maskedDefault replaces the default of sensitive inputs.
+const maskedDefault = "***"
+
+// sensitiveInput reports whether an input is sensitive: by its x-sensitive
+// key, or else by a name matching one of the patterns (case-insensitively).
+func sensitiveInput(input InputData, patterns []string) bool {
+	if explicit, ok := input.Extensions[sensitiveExtension].(bool); ok {
+		return explicit
+	}
+	name := strings.ToLower(input.Name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applySensitive marks the sensitive inputs and masks their defaults, so a
+// secret left in a template is not published with the docs. Defaults that
+// are variable references or interpolations hold no secret and are kept.
+// The masked defaults are returned as warnings: the secret is still in the
+// template.
+func applySensitive(patterns []string, components []ComponentData) []string {
+	var warnings []string
+	for i := range components {
+		c := &components[i]
+		// The inputs may be shared with the parse cache: modify a copy
+		c.Inputs = append([]InputData(nil), c.Inputs...)
+		for j := range c.Inputs {
+			input := &c.Inputs[j]
+			input.Sensitive = sensitiveInput(*input, patterns)
+			if _, ok := input.Extensions[sensitiveExtension]; ok {
+				// Modeled as Sensitive, not exposed as a raw extension
+				extensions := make(map[string]interface{}, len(input.Extensions))
+				for key, value := range input.Extensions {
+					if key != sensitiveExtension {
+						extensions[key] = value
+					}
+				}
+				if len(extensions) == 0 {
+					extensions = nil
+				}
+				input.Extensions = extensions
+			}
+			if !input.Sensitive || input.Required || input.Default == codeSpan(`""`) || isExpression(strings.Trim(input.Default, "`")) {
+				continue
+			}
+			input.Default, input.DefaultYAML = maskedDefault, ""
+			warnings = append(warnings, fmt.Sprintf("component %q: input %q is sensitive but has a default; it is masked in the docs, but the template still holds it", c.Name, input.Name))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSensitive(t *testing.T) {
+	if got, err := decodeSensitive(true); err != nil || got != true {
+		t.Errorf("got %v, %v", got, err)
+	}
+	for _, value := range []interface{}{"yes", 1, nil} {
+		if _, err := decodeSensitive(value); err == nil {
+			t.Errorf("expected %v to be rejected", value)
+		}
+	}
+}
+
+func TestSensitiveInput(t *testing.T) {
+	tests := []struct {
+		input InputData
+		want  bool
+	}{
+		{InputData{Name: "api_token"}, true},
+		{InputData{Name: "DB_PASSWORD"}, true},
+		{InputData{Name: "secret"}, true},
+		{InputData{Name: "token_ttl"}, false},
+		{InputData{Name: "stage"}, false},
+		{InputData{Name: "stage", Extensions: map[string]interface{}{"sensitive": true}}, true},
+		{InputData{Name: "api_token", Extensions: map[string]interface{}{"sensitive": false}}, false},
+	}
+	for _, tt := range tests {
+		if got := sensitiveInput(tt.input, defaultSensitivePatterns); got != tt.want {
+			t.Errorf("%s %v: expected %v, got %v", tt.input.Name, tt.input.Extensions, tt.want, got)
+		}
+	}
+	if sensitiveInput(InputData{Name: "api_token"}, []string{}) {
+		t.Error("expected no input to match an empty pattern list")
+	}
+}
+
+func TestApplySensitive(t *testing.T) {
+	inputs := []InputData{
+		{Name: "api_token", Default: "s3cr3t"},
+		{Name: "ci_password", Default: codeSpan("$CI_PASSWORD")},
+		{Name: "deploy_secret", Default: codeSpan(`""`)},
+		{Name: "db_password", Required: true},
+		{Name: "key", Default: "abc", Extensions: map[string]interface{}{"sensitive": true, "owner": "ops"}},
+		{Name: "stage", Default: "test"},
+	}
+	components := []ComponentData{{Name: "deploy", Inputs: inputs}}
+	warnings := applySensitive(defaultSensitivePatterns, components)
+
+	got := components[0].Inputs
+	for i, want := range []string{maskedDefault, codeSpan("$CI_PASSWORD"), codeSpan(`""`), "", maskedDefault, "test"} {
+		if got[i].Default != want {
+			t.Errorf("%s: expected default %q, got %q", got[i].Name, want, got[i].Default)
+		}
+	}
+	if !got[2].Sensitive || !got[4].Sensitive || got[5].Sensitive {
+		t.Errorf("unexpected sensitive inputs: %+v", got)
+	}
+	if len(got[4].Extensions) != 1 || got[4].Extensions["owner"] != "ops" {
+		t.Errorf("expected x-sensitive to be removed from the extensions, got %v", got[4].Extensions)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], `"api_token"`) || !strings.Contains(warnings[1], `"key"`) {
+		t.Errorf("expected a warning per masked default, got %q", warnings)
+	}
+	if inputs[0].Default != "s3cr3t" || inputs[4].Extensions["sensitive"] != true {
+		t.Errorf("expected the original inputs, shared with the parse cache, to be left alone, got %+v", inputs)
+	}
+}
+
+func TestRun_SensitiveInputs(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs:\n    registry_token:\n      default: glpat-s3cr3t\n    signing_key:\n      default: k3y\n    stage:\n      default: deploy\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("sensitive_inputs: ['*_token', '*_key']\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--emit-schema", "schemas"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	doc := string(data)
+	if strings.Contains(doc, "s3cr3t") || strings.Contains(doc, "k3y") {
+		t.Errorf("expected the secrets to be kept out of the docs, got:\n%s", doc)
+	}
+	if !strings.Contains(doc, `\*\*\* ⚠️ **Sensitive** |`) || !strings.Contains(doc, "| deploy |") {
+		t.Errorf("expected masked defaults with a badge, got:\n%s", doc)
+	}
+
+	data, err := os.ReadFile(filepath.Join("schemas", "deploy.schema.json"))
+	if err != nil {
+		t.Fatalf("expected the schema to be written: %v", err)
+	}
+	var schema InputSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	if p := schema.Properties["registry_token"]; p.Default != nil || !p.WriteOnly {
+		t.Errorf("expected a write-only property without default, got %+v", p)
+	}
+
+	os.WriteFile(configFile, []byte("sensitive_inputs: ['[']\n"), 0644)
+	if code := exitCode(run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard)); code != exitConfig {
+		t.Errorf("expected exit code %d for an invalid pattern, got %d", exitConfig, code)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// describeParams are the parameters of the describe methods. Component is a
+// component name or an `include:component` reference to it.
+type describeParams struct {
+	Component string `json:"component"`
+	Input     string `json:"input"`
+}
+
+// ComponentDescription answers describeComponent, InputDescription
+// describeInput: the data, and Markdown ready to show on hover.
+type ComponentDescription struct {
+	Component ComponentData `json:"component"`
+	Markdown  string        `json:"markdown"`
+}
+
+type InputDescription struct {
+	Component string    `json:"component"`
+	Input     InputData `json:"input"`
+	Markdown  string    `json:"markdown"`
+}
+
+// componentIndex serves the components of the working tree. Templates are
+// parsed again on every lookup, through an in-memory cache, so answers
+// follow edits without reparsing unchanged files.
+type componentIndex struct {
+	settings Settings
+	jobs     int
+	cache    *parseCache
+}
+
+func newComponentIndex(settings Settings, jobs int) *componentIndex {
+	return &componentIndex{settings: settings, jobs: jobs, cache: &parseCache{entries: map[string]cacheEntry{}, used: map[string]bool{}}}
+}
+
+// components returns the current components. Templates that fail to parse
+// are left out; the error says which.
+func (x *componentIndex) components() ([]ComponentData, error) {
+	templates, err := findTemplates(x.settings.TemplatesDir, x.settings.ignoreRules())
+	if err != nil {
+		return nil, err
+	}
+	return parseSelected(x.settings, templates, x.jobs, nil, x.cache)
+}
+
+// lookup returns the component a name or reference designates: a reference
+// such as $CI_SERVER_FQDN/group/project/aws/deploy@1.0.0 designates the
+// component whose name ends it.
+func (x *componentIndex) lookup(ref string) (ComponentData, error) {
+	components, parseErr := x.components()
+	if ref == "" {
+		return ComponentData{}, &rpcError{rpcInvalidParams, "missing component"}
+	}
+	ref = strings.SplitN(ref, "@", 2)[0]
+	found, matched := ComponentData{}, 0
+	for _, c := range components {
+		if c.Name == ref {
+			return c, nil
+		}
+		if strings.HasSuffix(ref, "/"+c.Name) && len(c.Name) > matched {
+			found, matched = c, len(c.Name)
+		}
+	}
+	if matched > 0 {
+		return found, nil
+	}
+	message := fmt.Sprintf("unknown component %q", ref)
+	if parseErr != nil {
+		message += fmt.Sprintf(" (%v)", parseErr)
+	}
+	return ComponentData{}, &rpcError{rpcInvalidParams, message}
+}
+
+// componentMarkdown summarizes a component for a hover: its title, the
+// first paragraph of its description and its inputs.
+func componentMarkdown(c ComponentData) string {
+	title := c.Title
+	if title == "" {
+		title = c.Name
+	}
+	parts := []string{"**" + title + "**"}
+	if c.Deprecated {
+		parts = append(parts, "_"+c.DeprecationNotice()+"_")
+	}
+	if description := strings.TrimSpace(c.Description); description != "" {
+		parts = append(parts, strings.SplitN(description, "\n\n", 2)[0])
+	}
+	if len(c.Inputs) > 0 {
+		var lines []string
+		for _, input := range c.Inputs {
+			line := "- " + codeSpan(input.Name)
+			if input.Required {
+				line += " (required)"
+			}
+			if input.Description != "" {
+				line += ": " + strings.SplitN(input.Description, "\n", 2)[0]
+			}
+			lines = append(lines, line)
+		}
+		parts = append(parts, "Inputs:\n"+strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// inputMarkdown describes an input for a hover.
+func inputMarkdown(component string, input InputData) string {
+	header := fmt.Sprintf("**%s** (%s", input.Name, codeSpan(input.Type))
+	if input.Required {
+		header += ", required"
+	}
+	parts := []string{header + ") of " + codeSpan(component)}
+	if input.Deprecated {
+		notice := "Deprecated."
+		if input.DeprecationNote != "" {
+			notice = "Deprecated: " + input.DeprecationNote
+		}
+		parts = append(parts, "_"+notice+"_")
+	}
+	if input.Description != "" {
+		parts = append(parts, input.Description)
+	}
+	if !input.Required {
+		parts = append(parts, "Default: "+codeList([]string{input.Default}))
+	}
+	if len(input.Options) > 0 {
+		parts = append(parts, "Options: "+codeList(input.Options))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// handle answers one request.
+func (x *componentIndex) handle(method string, raw json.RawMessage) (interface{}, error) {
+	var params describeParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("invalid params: %v", err)}
+		}
+	}
+	switch method {
+	case "components":
+		components, err := x.components()
+		names := []string{}
+		for _, c := range components {
+			names = append(names, c.Name)
+		}
+		if err != nil && len(names) == 0 {
+			return nil, err
+		}
+		return names, nil
+	case "describeComponent":
+		c, err := x.lookup(params.Component)
+		if err != nil {
+			return nil, err
+		}
+		return ComponentDescription{Component: c, Markdown: componentMarkdown(c)}, nil
+	case "describeInput":
+		c, err := x.lookup(params.Component)
+		if err != nil {
+			return nil, err
+		}
+		for _, input := range c.Inputs {
+			if input.Name == params.Input {
+				return InputDescription{Component: c.Name, Input: input, Markdown: inputMarkdown(c.Name, input)}, nil
+			}
+		}
+		return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("component %s has no input %q", c.Name, params.Input)}
+	}
+	return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method %q", method)}
+}
+
+// serveJSONRPC answers JSON-RPC 2.0 requests read from r, one per line, with
+// one response per line on w, until r ends. Notifications (requests without
+// an id) get no response.
+func (x *componentIndex) serveJSONRPC(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		response := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+		var request rpcRequest
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			response.Error = &rpcError{rpcParseError, fmt.Sprintf("parse error: %v", err)}
+		} else if request.JSONRPC != "2.0" || request.Method == "" {
+			if request.ID != nil {
+				response.ID = request.ID
+			}
+			response.Error = &rpcError{rpcInvalidRequest, "invalid request: expected a JSON-RPC 2.0 request with a method"}
+		} else {
+			result, err := x.handle(request.Method, request.Params)
+			if request.ID == nil {
+				continue
+			}
+			response.ID = request.ID
+			var rpcErr *rpcError
+			switch {
+			case errors.As(err, &rpcErr):
+				response.Error = rpcErr
+			case err != nil:
+				response.Error = &rpcError{rpcInvalidParams, err.Error()}
+			default:
+				response.Result = result
+			}
+		}
+		if err := encoder.Encode(response); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// runServe answers editor requests about the components over stdio, or
+// serves a live preview of the documentation over HTTP.
+func runServe(args []string, stdout io.Writer) error {
+	flags := newFlagSet("serve")
+	jsonRPC := flags.Bool("json-rpc", false, "Answer JSON-RPC 2.0 requests on stdin, one per line, with responses on stdout")
+	addr := flags.String("http", "", "Serve a live preview of the documentation on this address (e.g. :8080)")
+	projectPath := flags.String("project-path", "", "With --http, the GitLab project path (e.g. group/project)")
+	version := flags.String("version", "", "With --http, the component version (e.g. 1.0.0)")
+	remote := flags.String("remote", "", "Git remote used to detect the project path (default \"origin\")")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "With --http, the README template path (default depends on --format)")
+	flags.StringVar(&overrides.Format, "format", "", "With --http, the output format: "+strings.Join(outputFormats, ", ")+" (default \"markdown\")")
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory containing component descriptions (default \"docs\")")
+	flags.StringVar(&overrides.ExamplesDir, "examples-dir", "", "Directory containing usage examples, one subdirectory per component (default \"examples\")")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if flags.NArg() > 0 {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+	if *jsonRPC == (*addr != "") {
+		return withExitCode(exitConfig, errors.New("serve: choose one of --json-rpc or --http ADDRESS"))
+	}
+	if *jobs < 1 {
+		return withExitCode(exitConfig, fmt.Errorf("invalid --jobs %d: must be at least 1", *jobs))
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	index := newComponentIndex(settings, *jobs)
+	if *jsonRPC {
+		if err := index.serveJSONRPC(stdinInput, stdout); err != nil {
+			return withExitCode(exitFailure, fmt.Errorf("serve: %w", err))
+		}
+		return nil
+	}
+
+	gitRemote := resolveRemote(*remote)
+	project, err := resolveProject(config.Mirrors, *projectPath, *version, gitRemote)
+	if err != nil {
+		return err
+	}
+	preview := &previewServer{index: index, settings: settings, project: project}
+	if settings.SourceLinks {
+		preview.linkBase = sourceBaseURL(gitRemote, project.ProjectPath, project.DefaultBranch)
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return withExitCode(exitConfig, fmt.Errorf("serve: %w", err))
+	}
+	log := newLogger(stdout, logLevelFor(*quiet, false, false), "text")
+	log.Infof("Previewing %s on http://%s (Ctrl+C to stop)", settings.Output, listener.Addr())
+	if err := http.Serve(listener, preview.handler()); err != nil {
+		return withExitCode(exitFailure, fmt.Errorf("serve: %w", err))
+	}
+	return nil
+}
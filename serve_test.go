@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInputMarkdown(t *testing.T) {
+	input := InputData{Name: "level", Type: "string", Default: "info", Options: []string{"debug", "info"}, Description: "Log level."}
+	want := "**level** (`string`) of `deploy`\n\nLog level.\n\nDefault: `info`\n\nOptions: `debug`, `info`"
+	if got := inputMarkdown("deploy", input); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	required := InputData{Name: "token", Type: "string", Required: true, Deprecated: true, DeprecationNote: "use auth"}
+	want = "**token** (`string`, required) of `deploy`\n\n_Deprecated: use auth_"
+	if got := inputMarkdown("deploy", required); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestComponentMarkdown(t *testing.T) {
+	c := ComponentData{
+		Name:        "deploy",
+		Description: "Deploys the application.\n\nMore details.",
+		Inputs:      []InputData{{Name: "environment", Required: true, Description: "Target\nenvironment"}, {Name: "level"}},
+	}
+	want := "**deploy**\n\nDeploys the application.\n\nInputs:\n- `environment` (required): Target\n- `level`"
+	if got := componentMarkdown(c); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestServeJSONRPC(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "aws"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs:\n    level:\n      description: Log level.\n      default: info\n---\ndeploy:\n  script: echo $[[ inputs.level ]]\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "aws", "deploy.yml"), []byte("spec:\n  inputs:\n    region:\n      default: eu-west-1\n"), 0644)
+	settings, _ := resolveSettings(ProjectConfig{TemplatesDir: filepath.Join(dir, "templates")}, Settings{})
+	index := newComponentIndex(settings, 2)
+
+	requests := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"components"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"describeInput","params":{"component":"$CI_SERVER_FQDN/group/project/deploy@1.0.0","input":"level"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"describeComponent","params":{"component":"gitlab.com/group/project/aws/deploy@~latest"}}`,
+		`{"jsonrpc":"2.0","method":"components"}`,
+		``,
+		`{"jsonrpc":"2.0","id":4,"method":"describeInput","params":{"component":"deploy","input":"missing"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"describeComponent","params":{"component":"unknown"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"hover"}`,
+		`{"id":7}`,
+		`not json`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := index.serveJSONRPC(strings.NewReader(requests), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("expected 8 responses (none for the notification), got %d:\n%s", len(lines), out.String())
+	}
+	var responses []map[string]interface{}
+	for _, line := range lines {
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		responses = append(responses, response)
+	}
+	if names, _ := json.Marshal(responses[0]["result"]); string(names) != `["aws/deploy","deploy"]` {
+		t.Errorf("unexpected components %s", names)
+	}
+	input, _ := responses[1]["result"].(map[string]interface{})
+	if input["component"] != "deploy" || !strings.Contains(input["markdown"].(string), "Log level.") {
+		t.Errorf("unexpected input description %v", responses[1])
+	}
+	component, _ := responses[2]["result"].(map[string]interface{})
+	if !strings.HasPrefix(component["markdown"].(string), "**aws/deploy**") {
+		t.Errorf("expected the longest matching component, got %v", responses[2])
+	}
+	for i, code := range map[int]float64{3: rpcInvalidParams, 4: rpcInvalidParams, 5: rpcMethodNotFound, 6: rpcInvalidRequest, 7: rpcParseError} {
+		rpcErr, _ := responses[i]["error"].(map[string]interface{})
+		if rpcErr == nil || rpcErr["code"] != code {
+			t.Errorf("response %d: expected error code %v, got %v", i, code, responses[i])
+		}
+	}
+	if responses[7]["id"] != nil {
+		t.Errorf("expected a null id for a parse error, got %v", responses[7]["id"])
+	}
+}
+
+func TestRun_Serve(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { stdinInput = os.Stdin }()
+
+	stdinInput = strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"describeInput","params":{"component":"build","input":"stage"}}` + "\n")
+	var out bytes.Buffer
+	if err := run([]string{"serve", "--json-rpc"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"markdown":"**stage** (`+"`string`"+`) of `+"`build`"+`\n\nDefault: `+"`build`"+`"`) {
+		t.Errorf("unexpected response %s", out.String())
+	}
+	if got := exitCode(run([]string{"serve"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without a protocol, got %d", exitConfig, got)
+	}
+}
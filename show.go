@@ -0,0 +1,612 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// alertStyles are the colors of GitHub-style alerts in the terminal.
+var alertStyles = map[string]string{
+	"alert-note":      ansiBlue,
+	"alert-tip":       ansiGreen,
+	"alert-important": ansiMagenta,
+	"alert-warning":   ansiYellow,
+	"alert-caution":   ansiRed,
+}
+
+// defaultPager pages the docs when PAGER is unset: less, keeping the colors
+// and exiting right away when the docs fit on the screen.
+const defaultPager = "less -FRX"
+
+// runShow implements the `show` subcommand: it renders the docs of one
+// component, as the README has them, for reading in a terminal.
+func runShow(args []string, stdout io.Writer) error {
+	flags := newFlagSet("show")
+	projectPath := flags.String("project-path", "", "GitLab project path used in the include snippet (e.g. group/project)")
+	version := flags.String("version", "", "Component version used in the include snippet (e.g. 1.0.0)")
+	remote := flags.String("remote", "", "Git remote used to detect the project path (default \"origin\")")
+	noPager := flags.Bool("no-pager", false, "Print the docs instead of opening them in $PAGER")
+	noColor := flags.Bool("no-color", false, "Print the docs without ANSI colors and styles (also with NO_COLOR set)")
+	width := flags.Int("width", 0, "Wrap the docs at this many columns (default: $COLUMNS, else 80)")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "README template path (default \"README.md.tmpl\")")
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory containing component descriptions (default \"docs\")")
+	flags.StringVar(&overrides.ExamplesDir, "examples-dir", "", "Directory containing usage examples, one subdirectory per component (default \"examples\")")
+	flags.StringVar(&overrides.Locale, "locale", "", "Language of the default template's headings and labels: "+strings.Join(localeNames(), ", ")+" (default \"en\")")
+	name, args := splitCommandArg(args)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if name == "" && flags.NArg() > 0 {
+		name = flags.Arg(0)
+	} else if flags.NArg() > 0 {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+	if name == "" {
+		return withExitCode(exitConfig, errors.New("show: expected the component to show (e.g. deploy or aws/deploy)"))
+	}
+	if *jobs < 1 {
+		return withExitCode(exitConfig, fmt.Errorf("invalid --jobs %d: must be at least 1", *jobs))
+	}
+	if *width == 0 {
+		*width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	if *width <= 0 {
+		*width = 80
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	// The viewer reads Markdown, whatever the README's format
+	useMarkdown(config, &overrides)
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings.HeaderFile, settings.FooterFile = "", ""
+	settings.Collapse = 0
+
+	component, err := newComponentIndex(settings, *jobs).lookup(name)
+	if err != nil {
+		return withExitCode(exitConfig, fmt.Errorf("show: %w", err))
+	}
+	data, err := resolveProject(config.Mirrors, *projectPath, *version, resolveRemote(*remote))
+	if err != nil {
+		return err
+	}
+	data.Components = []ComponentData{component}
+	doc, err := renderDocument(settings, data)
+	if err != nil {
+		return withExitCode(exitTemplate, err)
+	}
+	out, err := renderTerminal(string(doc), *width, colorEnabled(stdout, *noColor))
+	if err != nil {
+		return withExitCode(exitTemplate, err)
+	}
+	if err := pageOutput(out, stdout, *noPager); err != nil {
+		return withExitCode(exitFailure, fmt.Errorf("show: %w", err))
+	}
+	return nil
+}
+
+// splitCommandArg returns the leading positional argument of a command,
+// which may come before its flags, and the remaining arguments.
+func splitCommandArg(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// pageOutput writes out through the user's pager when stdout is a terminal,
+// and directly otherwise or when no pager can be started.
+func pageOutput(out string, stdout io.Writer, noPager bool) error {
+	f, ok := stdout.(*os.File)
+	if noPager || !ok || !isTerminal(f) || os.Getenv("CI") != "" {
+		_, err := io.WriteString(stdout, out)
+		return err
+	}
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = strings.Fields(defaultPager)
+	}
+	if _, err := exec.LookPath(pager[0]); err != nil {
+		_, err := io.WriteString(stdout, out)
+		return err
+	}
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(out)
+	cmd.Stdout = f
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// termSpan is a run of text in one style: a sequence of ANSI escapes, empty
+// for plain text.
+type termSpan struct {
+	text  string
+	style string
+	brk   bool // a forced line break
+}
+
+// termWidth returns the number of columns text takes. An emoji variation
+// selector makes the character before it wide; joiners take no room.
+func termWidth(text string) int {
+	n := 0
+	for _, r := range text {
+		if r != '\u200D' {
+			n++
+		}
+	}
+	return n
+}
+
+// terminal renders the HTML of a document as text for a terminal, man-page
+// style: section headings on the left margin, their content indented.
+type terminal struct {
+	b     strings.Builder
+	width int
+	color bool
+	gap   bool // a blank line is due before the next block
+}
+
+// renderTerminal renders a Markdown document for a terminal width columns
+// wide, with ANSI styles when color is set.
+func renderTerminal(markdown string, width int, color bool) (string, error) {
+	root, err := parseHTML(markdownHTML(markdown))
+	if err != nil {
+		return "", err
+	}
+	t := &terminal{width: width, color: color}
+	if len(root.children) > 0 {
+		t.blocks(root.children[0], "", 0, true)
+	}
+	return strings.TrimRight(t.b.String(), "\n") + "\n", nil
+}
+
+// paint applies a style to text.
+func (t *terminal) paint(style, text string) string {
+	return paint(t.color, style, text)
+}
+
+// line writes a line of spans after prefix.
+func (t *terminal) line(prefix string, spans []termSpan) {
+	t.b.WriteString(prefix)
+	for _, s := range spans {
+		t.b.WriteString(t.paint(s.style, s.text))
+	}
+	t.b.WriteString("\n")
+}
+
+// blank writes the blank line due before a block.
+func (t *terminal) blank(prefix string) {
+	if t.gap && t.b.Len() > 0 {
+		t.b.WriteString(strings.TrimRight(prefix, " ") + "\n")
+	}
+	t.gap = false
+}
+
+// termSpans returns the inline content of nodes as spans.
+func termSpans(nodes []*htmlNode, style string) []termSpan {
+	var spans []termSpan
+	for _, n := range nodes {
+		inner := style
+		switch n.tag {
+		case "":
+			spans = append(spans, termSpan{text: n.text, style: style})
+			continue
+		case "br":
+			spans = append(spans, termSpan{brk: true})
+			continue
+		case "img":
+			spans = append(spans, termSpan{text: "[" + n.attrs["alt"] + "]", style: style + ansiItalic})
+			continue
+		case "input":
+			box := "[ ] "
+			if _, checked := n.attrs["checked"]; checked {
+				box = "[x] "
+			}
+			spans = append(spans, termSpan{text: box, style: style})
+			continue
+		case "a":
+			spans = append(spans, termSpans(n.children, style+ansiBlue+ansiUnderline)...)
+			// Anchors lead nowhere outside the README
+			if href := n.attrs["href"]; href != "" && !strings.HasPrefix(href, "#") && href != n.textContent() {
+				spans = append(spans, termSpan{text: " <" + href + ">", style: style + ansiMuted})
+			}
+			continue
+		case "strong", "b":
+			inner += ansiBold
+		case "em", "i":
+			inner += ansiItalic
+		case "code", "kbd":
+			inner += ansiCyan
+		case "del", "s":
+			inner += ansiStrike
+		}
+		spans = append(spans, termSpans(n.children, inner)...)
+	}
+	return spans
+}
+
+// termWrap breaks spans into lines of at most width columns. Words longer
+// than a line are broken anywhere.
+func termWrap(spans []termSpan, width int) [][]termSpan {
+	width = max(width, 1)
+	type word struct {
+		pieces []termSpan
+		space  bool // preceded by whitespace
+		brk    bool
+	}
+	var words []word
+	space := false
+	for _, s := range spans {
+		if s.brk {
+			words = append(words, word{brk: true})
+			space = false
+			continue
+		}
+		text := strings.Join(strings.Fields(s.text), " ")
+		if text == "" {
+			space = space || s.text != ""
+			continue
+		}
+		leading := strings.TrimLeft(s.text, " \t\n") != s.text
+		for i, part := range strings.Split(text, " ") {
+			piece := termSpan{text: part, style: s.style}
+			if i == 0 && !leading && !space && len(words) > 0 && !words[len(words)-1].brk {
+				last := &words[len(words)-1]
+				last.pieces = append(last.pieces, piece)
+				continue
+			}
+			words = append(words, word{pieces: []termSpan{piece}, space: true})
+		}
+		space = strings.TrimRight(s.text, " \t\n") != s.text
+	}
+
+	var lines [][]termSpan
+	var current []termSpan
+	used := 0
+	flush := func() {
+		var merged []termSpan
+		for _, s := range current {
+			if n := len(merged); n > 0 && merged[n-1].style == s.style {
+				merged[n-1].text += s.text
+				continue
+			}
+			merged = append(merged, s)
+		}
+		lines = append(lines, merged)
+		current, used = nil, 0
+	}
+	for _, w := range words {
+		if w.brk {
+			flush()
+			continue
+		}
+		wordWidth := 0
+		for _, p := range w.pieces {
+			wordWidth += termWidth(p.text)
+		}
+		if len(current) > 0 && w.space {
+			if used+1+wordWidth > width {
+				flush()
+			} else {
+				// Spaces inside a styled run keep its style, e.g. in links
+				style := ""
+				if last := current[len(current)-1]; last.style == w.pieces[0].style {
+					style = last.style
+				}
+				current = append(current, termSpan{text: " ", style: style})
+				used++
+			}
+		}
+		for _, p := range w.pieces {
+			for termWidth(p.text) > width-used {
+				if used > 0 && termWidth(p.text) <= width {
+					flush()
+					continue
+				}
+				// Break what doesn't fit on an empty line anywhere
+				n := 0
+				for i := range p.text {
+					if termWidth(p.text[:i]) > width-used {
+						break
+					}
+					n = i
+				}
+				if n == 0 {
+					if used > 0 {
+						flush()
+						continue
+					}
+					_, n = utf8.DecodeRuneInString(p.text)
+				}
+				current = append(current, termSpan{text: p.text[:n], style: p.style})
+				flush()
+				p.text = p.text[n:]
+			}
+			current = append(current, p)
+			used += termWidth(p.text)
+		}
+	}
+	if len(current) > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// blocks renders the children of n. Lines start with prefix, indent columns
+// wide. At the top level, headings set the indentation of what follows.
+func (t *terminal) blocks(n *htmlNode, prefix string, indent int, top bool) {
+	var inline []*htmlNode
+	flush := func() {
+		text := (&htmlNode{tag: "span", children: inline}).textContent()
+		if strings.TrimSpace(text) != "" || hasTag(inline, "img", "input") {
+			t.paragraph(termSpans(inline, ""), prefix, indent)
+		}
+		inline = nil
+	}
+	if top {
+		prefix, indent = "    ", 4
+	}
+	for _, child := range n.children {
+		if !contains(blockTags, child.tag) {
+			inline = append(inline, child)
+			continue
+		}
+		flush()
+		switch child.tag {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			t.heading(child, prefix, top)
+		case "p":
+			style := ""
+			if child.attrs["class"] == "alert-title" {
+				style = ansiBold
+			}
+			t.paragraph(termSpans(child.children, style), prefix, indent)
+			// The title of an alert sits on top of its text
+			t.gap = t.gap && style == ""
+		case "summary":
+			t.paragraph(termSpans(child.children, ansiBold), prefix, indent)
+		case "pre":
+			t.code(child.textContent(), prefix)
+		case "ul", "ol":
+			t.list(child, prefix, indent)
+		case "table":
+			t.table(child, prefix, indent)
+		case "blockquote", "div":
+			style, ok := alertStyles[strings.TrimPrefix(child.attrs["class"], "alert ")]
+			if !ok {
+				style = ansiMuted
+			}
+			t.blank(prefix)
+			t.blocks(child, prefix+t.paint(style, "│")+" ", indent+2, false)
+			t.gap = true
+		case "hr":
+			t.blank(prefix)
+			t.line(prefix, []termSpan{{text: strings.Repeat("─", max(t.width-indent, 1)), style: ansiMuted}})
+			t.gap = true
+		default:
+			t.blocks(child, prefix, indent, false)
+		}
+	}
+	flush()
+}
+
+// heading renders a heading. At the top level, the document and component
+// titles are on the left margin and subsections slightly indented, like the
+// sections of a man page.
+func (t *terminal) heading(n *htmlNode, prefix string, top bool) {
+	level := int(n.tag[1] - '0')
+	spans := termSpans(n.children, ansiBold)
+	if level <= 2 {
+		for i := range spans {
+			spans[i].style += ansiCyan
+		}
+	}
+	if top {
+		prefix = map[bool]string{true: "", false: "  "}[level <= 2]
+	}
+	t.gap = t.b.Len() > 0
+	t.blank(prefix)
+	for _, line := range termWrap(spans, t.width-termWidth(prefix)) {
+		t.line(prefix, line)
+	}
+	// Top-level content follows its heading directly
+	t.gap = !top
+}
+
+// paragraph renders wrapped text.
+func (t *terminal) paragraph(spans []termSpan, prefix string, indent int) {
+	t.blank(prefix)
+	for _, line := range termWrap(spans, t.width-indent) {
+		t.line(prefix, line)
+	}
+	t.gap = true
+}
+
+// code renders a code block as is, indented further.
+func (t *terminal) code(text, prefix string) {
+	t.blank(prefix)
+	for _, line := range splitLines(text) {
+		t.line(prefix+"  ", []termSpan{{text: line, style: ansiCyan}})
+	}
+	t.gap = true
+}
+
+// list renders a bulleted or numbered list, items without blank lines
+// between them.
+func (t *terminal) list(n *htmlNode, prefix string, indent int) {
+	t.blank(prefix)
+	number := 1
+	if start, err := strconv.Atoi(n.attrs["start"]); err == nil {
+		number = start
+	}
+	for _, item := range n.children {
+		if item.tag != "li" {
+			continue
+		}
+		marker := "• "
+		if n.tag == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		pad := strings.Repeat(" ", termWidth(marker))
+		mark := &terminal{width: t.width, color: t.color}
+		mark.blocks(item, prefix+pad, indent+len(pad), false)
+		lines := splitLines(mark.b.String())
+		for i, line := range lines {
+			if i == 0 {
+				line = prefix + marker + strings.TrimPrefix(line, prefix+pad)
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			t.b.WriteString(line + "\n")
+		}
+	}
+	t.gap = true
+}
+
+// table renders a table with box-drawing borders, wrapping the cells of the
+// widest columns to fit the terminal.
+func (t *terminal) table(n *htmlNode, prefix string, indent int) {
+	var rows [][][]termSpan
+	var header bool
+	var walk func(*htmlNode)
+	walk = func(node *htmlNode) {
+		for _, child := range node.children {
+			if child.tag != "tr" {
+				walk(child)
+				continue
+			}
+			var row [][]termSpan
+			for _, cell := range child.children {
+				switch cell.tag {
+				case "th":
+					header = len(rows) == 0
+					row = append(row, termSpans(cell.children, ansiBold))
+				case "td":
+					row = append(row, termSpans(cell.children, ""))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return
+	}
+
+	// Natural widths, then shrink the columns that can wrap without breaking
+	// words, the widest first, down to what fits
+	widths, minimums := make([]int, columns), make([]int, columns)
+	for _, row := range rows {
+		for i, cell := range row {
+			for _, line := range termWrap(cell, 1<<20) {
+				w := 0
+				for _, s := range line {
+					w += termWidth(s.text)
+					for _, word := range strings.Fields(s.text) {
+						minimums[i] = max(minimums[i], min(termWidth(word), 16))
+					}
+				}
+				widths[i] = max(widths[i], w)
+			}
+		}
+	}
+	for total := sumInts(widths); total > t.width-indent-3*columns-1; total-- {
+		shrink := -1
+		for i, w := range widths {
+			if w > minimums[i] && (shrink < 0 || w-minimums[i] > widths[shrink]-minimums[shrink]) {
+				shrink = i
+			}
+		}
+		if shrink < 0 {
+			for i, w := range widths {
+				if w > 6 && (shrink < 0 || w > widths[shrink]) {
+					shrink = i
+				}
+			}
+		}
+		if shrink < 0 {
+			break
+		}
+		widths[shrink]--
+	}
+
+	border := func(left, middle, right string) {
+		var b strings.Builder
+		b.WriteString(left)
+		for i, w := range widths {
+			if i > 0 {
+				b.WriteString(middle)
+			}
+			b.WriteString(strings.Repeat("─", w+2))
+		}
+		b.WriteString(right)
+		t.line(prefix, []termSpan{{text: b.String(), style: ansiMuted}})
+	}
+	bar := termSpan{text: "│", style: ansiMuted}
+
+	t.blank(prefix)
+	border("┌", "┬", "┐")
+	for r, row := range rows {
+		cells := make([][][]termSpan, columns)
+		height := 1
+		for i := range cells {
+			if i < len(row) {
+				cells[i] = termWrap(row[i], widths[i])
+			}
+			height = max(height, len(cells[i]))
+		}
+		for l := 0; l < height; l++ {
+			spans := []termSpan{bar}
+			for i, cell := range cells {
+				spans = append(spans, termSpan{text: " "})
+				used := 0
+				if l < len(cell) {
+					spans = append(spans, cell[l]...)
+					for _, s := range cell[l] {
+						used += termWidth(s.text)
+					}
+				}
+				spans = append(spans, termSpan{text: strings.Repeat(" ", max(widths[i]-used, 0)+1)}, bar)
+			}
+			t.line(prefix, spans)
+		}
+		if r == 0 && header && len(rows) > 1 {
+			border("├", "┼", "┤")
+		}
+	}
+	border("└", "┴", "┘")
+	t.gap = true
+}
+
+func sumInts(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
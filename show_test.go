@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTerminal(t *testing.T) {
+	doc := "## deploy\n\nDeploys **fast**, see [docs](https://example.com).\n\n### Inputs\n\n| Name | Default |\n|------|---------|\n| `stage` | deploy |\n\n- one\n- two\n\n> [!WARNING]\n> Careful.\n\n```yaml\ninclude: x\n```\n"
+	got, err := renderTerminal(doc, 80, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `deploy
+    Deploys fast, see docs <https://example.com>.
+
+  Inputs
+    ┌───────┬─────────┐
+    │ Name  │ Default │
+    ├───────┼─────────┤
+    │ stage │ deploy  │
+    └───────┴─────────┘
+
+    • one
+    • two
+
+    │ Warning
+    │ Careful.
+
+      include: x
+`
+	if got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	colored, _ := renderTerminal(doc, 80, true)
+	for _, want := range []string{ansiBold + ansiCyan + "deploy" + ansiReset, ansiBold + "fast" + ansiReset, ansiCyan + "stage" + ansiReset, ansiYellow + "│" + ansiReset} {
+		if !strings.Contains(colored, want) {
+			t.Errorf("expected %q in:\n%s", want, colored)
+		}
+	}
+}
+
+func TestTermWrap(t *testing.T) {
+	text := func(lines [][]termSpan) string {
+		var out []string
+		for _, line := range lines {
+			var b strings.Builder
+			for _, s := range line {
+				b.WriteString(s.text)
+			}
+			out = append(out, b.String())
+		}
+		return strings.Join(out, "|")
+	}
+	spans := []termSpan{{text: "Use the "}, {text: "deploy", style: ansiCyan}, {text: ", then wait"}}
+	if got := text(termWrap(spans, 14)); got != "Use the|deploy, then|wait" {
+		t.Errorf("expected the comma to stay with its word, got %q", got)
+	}
+	if got := text(termWrap([]termSpan{{text: "abcdefghij"}}, 4)); got != "abcd|efgh|ij" {
+		t.Errorf("expected a long word to be broken, got %q", got)
+	}
+	if got := termWidth("⚠️ x"); got != 4 {
+		t.Errorf("expected the emoji to be wide, got %d", got)
+	}
+}
+
+func TestTerminalTable_Shrinks(t *testing.T) {
+	doc := "| Name | Description |\n|------|-------------|\n| stage | The stage the job runs in, which the pipeline must define. |\n"
+	got, _ := renderTerminal(doc, 40, false)
+	for _, line := range splitLines(got) {
+		if termWidth(line) > 40 {
+			t.Errorf("expected lines of at most 40 columns, got %q", line)
+		}
+	}
+	if !strings.Contains(got, "│ stage │") {
+		t.Errorf("expected the short column to keep its width, got:\n%s", got)
+	}
+}
+
+func TestRun_Show(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "aws"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "aws", "deploy.yml"), []byte("spec:\n  inputs:\n    region:\n      description: AWS region.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("format: asciidoc\nheader_file: header.md\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"show", "gitlab.com/g/p/aws/deploy@1.0.0", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := out.String()
+	if !strings.HasPrefix(got, "aws/deploy\n") || !strings.Contains(got, "│ region │ AWS region.") || !strings.Contains(got, "component: $CI_SERVER_FQDN/g/p/aws/deploy@1.0.0") {
+		t.Errorf("expected the component's docs, got:\n%s", got)
+	}
+	if strings.Contains(got, "stage") || strings.Contains(got, "\033[") {
+		t.Errorf("expected only this component, without colors, got:\n%s", got)
+	}
+
+	if code := exitCode(run([]string{"show", "unknown"}, io.Discard)); code != exitConfig {
+		t.Errorf("expected exit code %d for an unknown component, got %d", exitConfig, code)
+	}
+	if code := exitCode(run([]string{"show"}, io.Discard)); code != exitConfig {
+		t.Errorf("expected exit code %d without a component, got %d", exitConfig, code)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sourceScheme prefixes --source values.
+const sourceScheme = "gitlab://"
+
+// remoteSource is a project whose component files are fetched with --source.
+type remoteSource struct {
+	Project string // path_with_namespace
+	Ref     string // branch, tag or commit; empty means the default branch
+}
+
+// parseSource parses a --source value: gitlab://group/project[@ref].
+func parseSource(value string) (remoteSource, error) {
+	rest := strings.TrimPrefix(value, sourceScheme)
+	if rest == value {
+		return remoteSource{}, fmt.Errorf("invalid source %q: expected %sgroup/project[@ref]", value, sourceScheme)
+	}
+	var src remoteSource
+	src.Project, src.Ref, _ = strings.Cut(rest, "@")
+	project, err := normalizeProjectPath(src.Project)
+	if err != nil {
+		return remoteSource{}, fmt.Errorf("invalid source %q: %w", value, err)
+	}
+	src.Project = project
+	return src, nil
+}
+
+// treeEntry is an item of the repository tree API.
+type treeEntry struct {
+	Type string `json:"type"` // "blob" or "tree"
+	Path string `json:"path"`
+}
+
+// fetchSource downloads the templates, descriptions and examples directories
+// of a remote project into dir, keeping their layout, and returns settings
+// pointing at the downloaded copies, along with what the API tells about the
+// project. The API is CI_API_V4_URL, the git remote's host or gitlab.com; a
+// token is only needed for private projects.
+func fetchSource(src remoteSource, settings Settings, remote, dir string, log *logger) (Settings, gitlabProject, error) {
+	baseURL := gitlabBaseURL(remote)
+	if baseURL == "" {
+		baseURL = "https://gitlab.com/api/v4"
+	}
+	header, token := gitlabToken()
+	project, err := fetchGitLabProject(baseURL, header, token, src.Project)
+	if err != nil {
+		return settings, project, fmt.Errorf("error fetching project %s: %w", src.Project, err)
+	}
+	projectPath := "/projects/" + url.PathEscape(src.Project)
+
+	fetched := 0
+	for _, remoteDir := range []string{settings.TemplatesDir, settings.DocsDir, settings.ExamplesDir} {
+		remoteDir = path.Clean(filepath.ToSlash(remoteDir))
+		if remoteDir == "." || strings.HasPrefix(remoteDir, "../") || path.IsAbs(remoteDir) {
+			return settings, project, fmt.Errorf("cannot fetch %q from %s: directories must be relative to the repository root", remoteDir, src.Project)
+		}
+		entries, err := fetchTree(baseURL, header, token, projectPath, remoteDir, src.Ref)
+		if err != nil {
+			return settings, project, fmt.Errorf("error listing %s in %s: %w", remoteDir, src.Project, err)
+		}
+		for _, entry := range entries {
+			if entry.Type != "blob" {
+				continue
+			}
+			target := filepath.Join(dir, filepath.FromSlash(path.Clean(entry.Path)))
+			if rel, err := filepath.Rel(dir, target); err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			filePath := projectPath + "/repository/files/" + url.PathEscape(entry.Path) + "/raw"
+			if src.Ref != "" {
+				filePath += "?ref=" + url.QueryEscape(src.Ref)
+			}
+			var data []byte
+			if err := gitlabRequest(baseURL, header, token, http.MethodGet, filePath, nil, &data); err != nil {
+				return settings, project, fmt.Errorf("error fetching %s from %s: %w", entry.Path, src.Project, err)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return settings, project, err
+			}
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				return settings, project, err
+			}
+			log.Debugf("Fetched %s", entry.Path)
+			fetched++
+		}
+	}
+	log.Verbosef("Fetched %d file(s) from %s%s", fetched, sourceScheme, src.Project)
+
+	settings.RootDir = dir
+	settings.TemplatesDir = filepath.Join(dir, settings.TemplatesDir)
+	settings.DocsDir = filepath.Join(dir, settings.DocsDir)
+	settings.ExamplesDir = filepath.Join(dir, settings.ExamplesDir)
+	return settings, project, nil
+}
+
+// fetchTree lists the files under dir in the remote repository, recursively.
+// A missing directory yields no entries.
+func fetchTree(baseURL, header, token, projectPath, dir, ref string) ([]treeEntry, error) {
+	query := url.Values{"path": {dir}, "recursive": {"true"}, "per_page": {"100"}}
+	if ref != "" {
+		query.Set("ref", ref)
+	}
+	var all []treeEntry
+	for page := 1; ; page++ {
+		query.Set("page", fmt.Sprint(page))
+		var entries []treeEntry
+		err := gitlabRequest(baseURL, header, token, http.MethodGet, projectPath+"/repository/tree?"+query.Encode(), nil, &entries)
+		var apiErr *gitlabError
+		if errors.As(err, &apiErr) && apiErr.code == http.StatusNotFound && page == 1 {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+		if len(entries) < 100 {
+			return all, nil
+		}
+	}
+}
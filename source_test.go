@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sourceTestServer serves a public project holding the given files at ref.
+func sourceTestServer(t *testing.T, files map[string]string, ref string) *httptest.Server {
+	t.Helper()
+	const prefix = "/api/v4/projects/platform%2Fcatalog"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escaped := r.URL.EscapedPath()
+		switch {
+		case escaped == prefix:
+			w.Write([]byte(`{"path_with_namespace": "platform/catalog", "default_branch": "main"}`))
+		case escaped == prefix+"/releases":
+			w.Write([]byte(`[{"tag_name": "v3.0.0"}]`))
+		case escaped == prefix+"/repository/tree":
+			if r.URL.Query().Get("ref") != ref {
+				t.Errorf("expected ref %q, got %q", ref, r.URL.RawQuery)
+			}
+			dir := r.URL.Query().Get("path")
+			var entries []treeEntry
+			for p := range files {
+				if strings.HasPrefix(p, dir+"/") {
+					entries = append(entries, treeEntry{Type: "blob", Path: p})
+				}
+			}
+			if entries == nil {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(entries)
+		case strings.HasPrefix(escaped, prefix+"/repository/files/") && strings.HasSuffix(escaped, "/raw"):
+			p, _ := url.PathUnescape(strings.TrimSuffix(strings.TrimPrefix(escaped, prefix+"/repository/files/"), "/raw"))
+			content, ok := files[p]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    remoteSource
+		wantErr bool
+	}{
+		{"gitlab://group/project@v1.0.0", remoteSource{Project: "group/project", Ref: "v1.0.0"}, false},
+		{"gitlab://group/sub/project", remoteSource{Project: "group/sub/project"}, false},
+		{"group/project", remoteSource{}, true},
+		{"gitlab://project", remoteSource{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSource(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.value, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestRun_Source(t *testing.T) {
+	server := sourceTestServer(t, map[string]string{
+		"templates/build.yml":       "spec:\n  inputs:\n    stage:\n      default: build\n",
+		"templates/aws/deploy.yml":  "spec:\n  inputs: {}\n",
+		"docs/build.md":             "Builds the project remotely.\n",
+		"templates/../../escape.md": "outside",
+	}, "v2.0.0")
+	setGitLabEnv(t, server.URL)
+
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--source", "gitlab://platform/catalog@v2.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"$CI_SERVER_FQDN/platform/catalog/aws/deploy@v2.0.0",
+		"$CI_SERVER_FQDN/platform/catalog/build@v2.0.0",
+		"Builds the project remotely.",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected README.md to contain %q, got:\n%s", want, data)
+		}
+	}
+	if _, err := os.Stat("templates"); !os.IsNotExist(err) {
+		t.Error("expected fetched files not to be written to the working directory")
+	}
+	if _, err := os.Stat(cacheFile); !os.IsNotExist(err) {
+		t.Error("expected no cache file for a remote source")
+	}
+}
+
+func TestRun_SourceLatestRelease(t *testing.T) {
+	server := sourceTestServer(t, map[string]string{"templates/build.yml": "spec:\n  inputs: {}\n"}, "")
+	setGitLabEnv(t, server.URL)
+
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--format", "json", "--source", "gitlab://platform/catalog"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var data TemplateData
+	raw, _ := os.ReadFile("components.json")
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Version != "v3.0.0" || data.ProjectPath != "platform/catalog" || len(data.Components) != 1 {
+		t.Errorf("expected the source's latest release and path, got %+v", data)
+	}
+}
+
+func TestRun_SourceErrors(t *testing.T) {
+	server := sourceTestServer(t, nil, "")
+	setGitLabEnv(t, server.URL)
+
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"--source", "group/project"}, exitConfig},
+		{[]string{"--source", "gitlab://platform/catalog", "--hook"}, exitConfig},
+		{[]string{"--source", "gitlab://other/project"}, exitParse},
+		{[]string{"--source", "gitlab://platform/catalog"}, exitNoInput},
+	}
+	for _, tt := range tests {
+		if got := exitCode(run(append([]string{"--quiet"}, tt.args...), io.Discard)); got != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d", tt.args, tt.code, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be written on failure")
+	}
+}
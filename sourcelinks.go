@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// sourceLocation is the 1-based line and column of a YAML node.
+type sourceLocation struct {
+	Line, Column int
+}
+
+// inputLocations maps each input of spec:inputs to the location of its key.
+func inputLocations(file *ast.File) map[string]sourceLocation {
+	locations := make(map[string]sourceLocation)
+	for _, input := range mappingValues(mappingValue(mappingValue(specDocument(file), "spec"), "inputs")) {
+		token := input.Key.GetToken()
+		locations[token.Value] = sourceLocation{Line: token.Position.Line, Column: token.Position.Column}
+	}
+	return locations
+}
+
+// sourceBaseURL returns the URL template paths are appended to in source
+// links: the project's files on its default branch (HEAD, which GitLab
+// resolves to it, when unknown). The server is CI_SERVER_URL or the git
+// remote's host. It is empty when the server or the project is unknown.
+func sourceBaseURL(remote, projectPath, branch string) string {
+	server := strings.TrimRight(os.Getenv("CI_SERVER_URL"), "/")
+	if server == "" {
+		if host := parseGitRemoteHost(gitRemoteURL(remote)); host != "" {
+			server = "https://" + host
+		}
+	}
+	if server == "" || projectPath == "" || projectPath == projectPathPlaceholder {
+		return ""
+	}
+	if branch == "" {
+		branch = "HEAD"
+	}
+	return fmt.Sprintf("%s/%s/-/blob/%s/", server, projectPath, branch)
+}
+
+// linkSources sets the source link of every input: its line in the template
+// under baseURL, or relative to the output file when baseURL is empty.
+// Templates outside the working directory (e.g. of --source runs) get none.
+func linkSources(components []ComponentData, baseURL, output string) {
+	for i := range components {
+		c := &components[i]
+		if c.path == "" || filepath.IsAbs(c.path) || strings.HasPrefix(filepath.Clean(c.path), "..") {
+			continue
+		}
+		target := filepath.ToSlash(filepath.Clean(c.path))
+		if baseURL != "" {
+			target = baseURL + target
+		} else if rel, err := filepath.Rel(filepath.Dir(output), c.path); err == nil {
+			target = filepath.ToSlash(rel)
+		}
+		for j := range c.Inputs {
+			if line := c.Inputs[j].Line; line > 0 {
+				c.Inputs[j].SourceURL = fmt.Sprintf("%s#L%d", target, line)
+			}
+		}
+	}
+}
+
+// sourceLink returns text linked to url in the dialect (or AsciiDoc), or
+// text itself without a url.
+func sourceLink(dialect, text, url string) string {
+	switch {
+	case url == "":
+		return text
+	case dialect == "asciidoc":
+		return "link:" + url + "[" + text + "]"
+	}
+	return "[" + text + "](" + url + ")"
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate_InputLocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploy.yml")
+	os.WriteFile(path, []byte("spec:\n  inputs:\n    stage:\n      default: deploy\n    environment:\n      description: Target\n"), 0644)
+	c, err := parseTemplate(path, ParseOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]sourceLocation{"environment": {Line: 5, Column: 5}, "stage": {Line: 3, Column: 5}}
+	for _, input := range c.Inputs {
+		if got := (sourceLocation{input.Line, input.Column}); got != want[input.Name] {
+			t.Errorf("%s: got %+v, want %+v", input.Name, got, want[input.Name])
+		}
+	}
+}
+
+func TestSourceBaseURL(t *testing.T) {
+	t.Setenv("CI_SERVER_URL", "https://gitlab.example.com/")
+	if got := sourceBaseURL("origin", "group/project", "main"); got != "https://gitlab.example.com/group/project/-/blob/main/" {
+		t.Errorf("unexpected URL %q", got)
+	}
+	if got := sourceBaseURL("origin", "group/project", ""); got != "https://gitlab.example.com/group/project/-/blob/HEAD/" {
+		t.Errorf("expected HEAD without a default branch, got %q", got)
+	}
+	if got := sourceBaseURL("origin", projectPathPlaceholder, "main"); got != "" {
+		t.Errorf("expected no URL without a project path, got %q", got)
+	}
+}
+
+func TestLinkSources(t *testing.T) {
+	components := []ComponentData{
+		{path: filepath.Join("templates", "deploy.yml"), Inputs: []InputData{{Name: "stage", Line: 3}, {Name: "undeclared"}}},
+		{path: filepath.Join("..", "elsewhere", "build.yml"), Inputs: []InputData{{Name: "stage", Line: 3}}},
+	}
+	linkSources(components, "https://gitlab.example.com/group/project/-/blob/main/", "README.md")
+	if got := components[0].Inputs[0].SourceURL; got != "https://gitlab.example.com/group/project/-/blob/main/templates/deploy.yml#L3" {
+		t.Errorf("unexpected link %q", got)
+	}
+	if components[0].Inputs[1].SourceURL != "" || components[1].Inputs[0].SourceURL != "" {
+		t.Error("expected no link without a line or outside the working directory")
+	}
+
+	linkSources(components, "", filepath.Join("docs", "README.md"))
+	if got := components[0].Inputs[0].SourceURL; got != "../templates/deploy.yml#L3" {
+		t.Errorf("expected a link relative to the output, got %q", got)
+	}
+}
+
+func TestSourceLink(t *testing.T) {
+	if got := sourceLink("gitlab", "stage", "t.yml#L3"); got != "[stage](t.yml#L3)" {
+		t.Errorf("unexpected Markdown link %q", got)
+	}
+	if got := sourceLink("asciidoc", "stage", "t.yml#L3"); got != "link:t.yml#L3[stage]" {
+		t.Errorf("unexpected AsciiDoc link %q", got)
+	}
+	if got := sourceLink("gitlab", "stage", ""); got != "stage" {
+		t.Errorf("expected no link without a URL, got %q", got)
+	}
+}
+
+func TestRun_SourceLinks(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: deploy\n"), 0644)
+	t.Setenv("CI_SERVER_URL", "https://gitlab.example.com")
+	t.Setenv("CI_DEFAULT_BRANCH", "main")
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "group/project", "--version", "1.0.0", "--source-links"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	if !strings.Contains(string(data), "| [stage](https://gitlab.example.com/group/project/-/blob/main/templates/deploy.yml#L3) |") {
+		t.Errorf("expected a linked input name, got:\n%s", data)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/goccy/go-yaml"
+)
+
+// sprigFuncs returns the subset of the sprig template library advanced
+// templates commonly reach for, with sprig's names, argument order and
+// semantics, so snippets from Helm charts and the like work unchanged.
+func sprigFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default": func(fallback interface{}, value ...interface{}) interface{} {
+			if len(value) == 0 || isEmptyValue(value[0]) {
+				return fallback
+			}
+			return value[0]
+		},
+		"ternary": func(whenTrue, whenFalse interface{}, condition bool) interface{} {
+			if condition {
+				return whenTrue
+			}
+			return whenFalse
+		},
+		"indent":  indentLines,
+		"nindent": func(spaces int, text string) string { return "\n" + indentLines(spaces, text) },
+		"replace": func(old, new, text string) string { return strings.ReplaceAll(text, old, new) },
+		"regexReplaceAll": func(pattern, text, replacement string) (string, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+			}
+			return re.ReplaceAllString(text, replacement), nil
+		},
+		"toYaml": func(v interface{}) (string, error) {
+			out, err := yaml.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(string(out), "\n"), nil
+		},
+		"toJson": func(v interface{}) (string, error) {
+			out, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(out), nil
+		},
+	}
+}
+
+// indentLines prefixes every line of text, the first included, with spaces.
+func indentLines(spaces int, text string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(text, "\n", "\n"+pad)
+}
+
+// isEmptyValue reports whether sprig's default would replace v: nil, false,
+// zero numbers and empty strings, slices and maps. Structs are never empty.
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
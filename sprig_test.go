@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestSprigFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"empty": "",
+		"name":  "build",
+		"zero":  0,
+		"list":  []string{"a", "b"},
+		"none":  []string{},
+		"on":    true,
+		"text":  "line one\nline two",
+		"map":   map[string]interface{}{"stage": "test", "needs": []string{"build"}},
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`{{ .empty | default "fallback" }}`, "fallback"},
+		{`{{ .name | default "fallback" }}`, "build"},
+		{`{{ .zero | default 5 }}`, "5"},
+		{`{{ .none | default "none" }}`, "none"},
+		{`{{ .missing | default "unset" }}`, "unset"},
+		{`{{ ternary "yes" "no" .on }}`, "yes"},
+		{`{{ .on | not | ternary "yes" "no" }}`, "no"},
+		{`{{ .text | indent 2 }}`, "  line one\n  line two"},
+		{`-{{ .text | nindent 4 }}`, "-\n    line one\n    line two"},
+		{`{{ .name | replace "ui" "ee" }}`, "beeld"},
+		{`{{ regexReplaceAll "[0-9]+" "v1.20.3" "N" }}`, "vN.N.N"},
+		{`{{ regexReplaceAll "(\\w+)@(\\w+)" "user@host" "${2}:${1}" }}`, "host:user"},
+		{`{{ .map | toYaml }}`, "needs:\n- build\nstage: test"},
+		{`{{ .list | toJson }}`, `["a","b"]`},
+		{`{{ .map | toJson }}`, `{"needs":["build"],"stage":"test"}`},
+	}
+	for _, tt := range tests {
+		tmpl, err := template.New("t").Funcs(sprigFuncs()).Parse(tt.template)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", tt.template, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.template, err)
+			continue
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.template, out.String(), tt.want)
+		}
+	}
+
+	tmpl := template.Must(template.New("t").Funcs(sprigFuncs()).Parse(`{{ regexReplaceAll "(" "text" "" }}`))
+	if err := tmpl.Execute(&bytes.Buffer{}, nil); err == nil || !strings.Contains(err.Error(), "invalid regular expression") {
+		t.Errorf("expected an invalid regular expression error, got %v", err)
+	}
+}
+
+func TestTemplateFuncs_Sprig(t *testing.T) {
+	funcs := templateFuncs(Settings{})
+	for _, name := range []string{"default", "ternary", "indent", "nindent", "replace", "regexReplaceAll", "toYaml", "toJson"} {
+		if _, ok := funcs[name]; !ok {
+			t.Errorf("expected %s among the template functions", name)
+		}
+	}
+}
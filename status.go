@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fileStatus is what a run did to a file it writes, reported on a status
+// line of its own so that multi-component runs can be audited.
+type fileStatus string
+
+const (
+	statusCreated   fileStatus = "created"   // the file did not exist
+	statusUpdated   fileStatus = "updated"   // its content changed
+	statusUnchanged fileStatus = "unchanged" // it already had this content
+	statusSkipped   fileStatus = "skipped"   // a template that failed to parse, left out
+)
+
+// statusOrder is the order of the counts in the summary of a run.
+var statusOrder = []fileStatus{statusCreated, statusUpdated, statusUnchanged, statusSkipped}
+
+// statusStyles are the colors of the statuses in terminals.
+var statusStyles = map[fileStatus]string{
+	statusCreated:   ansiGreen,
+	statusUpdated:   ansiYellow,
+	statusUnchanged: ansiMuted,
+	statusSkipped:   ansiRed,
+}
+
+// writtenFile is a file written by a run, with its status.
+type writtenFile struct {
+	path   string
+	status fileStatus
+}
+
+// writeStatus returns the status writing content to path will have. It is
+// called before writing.
+func writeStatus(path string, content []byte) fileStatus {
+	current, err := os.ReadFile(path)
+	switch {
+	case err != nil:
+		return statusCreated
+	case bytes.Equal(current, content):
+		return statusUnchanged
+	}
+	return statusUpdated
+}
+
+// runStatuses prints the status line of every file of a run and counts
+// them for its summary.
+type runStatuses struct {
+	log    *logger
+	counts map[fileStatus]int
+}
+
+func newRunStatuses(log *logger) *runStatuses {
+	return &runStatuses{log: log, counts: make(map[fileStatus]int)}
+}
+
+// add reports the status of a file.
+func (r *runStatuses) add(status fileStatus, path string) {
+	r.counts[status]++
+	r.log.Status(status, path)
+}
+
+// addFiles reports the status of written files.
+func (r *runStatuses) addFiles(files []writtenFile) {
+	for _, f := range files {
+		r.add(f.status, f.path)
+	}
+}
+
+// summary counts the files per status, e.g. "1 updated, 12 unchanged".
+func (r *runStatuses) summary() string {
+	var parts []string
+	for _, status := range statusOrder {
+		if n := r.counts[status]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	if got := writeStatus(path, []byte("a\n")); got != statusCreated {
+		t.Errorf("expected %q for a missing file, got %q", statusCreated, got)
+	}
+	os.WriteFile(path, []byte("a\n"), 0644)
+	if got := writeStatus(path, []byte("a\n")); got != statusUnchanged {
+		t.Errorf("expected %q for the same content, got %q", statusUnchanged, got)
+	}
+	if got := writeStatus(path, []byte("b\n")); got != statusUpdated {
+		t.Errorf("expected %q for new content, got %q", statusUpdated, got)
+	}
+}
+
+func TestRunStatuses(t *testing.T) {
+	var out bytes.Buffer
+	statuses := newRunStatuses(newLogger(&out, levelInfo, "text"))
+	statuses.add(statusSkipped, "templates/broken.yml")
+	statuses.addFiles([]writtenFile{{"README.md", statusUpdated}, {"docs/build.md", statusUnchanged}, {"docs/deploy.md", statusUnchanged}})
+
+	want := "skipped   templates/broken.yml\nupdated   README.md\nunchanged docs/build.md\nunchanged docs/deploy.md\n"
+	if out.String() != want {
+		t.Errorf("expected status lines %q, got %q", want, out.String())
+	}
+	if got, want := statuses.summary(), "1 updated, 2 unchanged, 1 skipped"; got != want {
+		t.Errorf("expected summary %q, got %q", want, got)
+	}
+}
+
+func TestRun_StatusLines(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "broken.yml"), []byte("spec:\n  inputs: [\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--no-cache", "--project-path", "g/p", "--version", "1.0.0", "--exclude", "broken", "--emit-schema", "schemas"}
+	generate := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := run(args, &out); err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, out.String())
+		}
+		return out.String()
+	}
+
+	out := generate(args...)
+	for _, want := range []string{
+		"created   README.md\n",
+		"created   " + filepath.Join("schemas", "build.schema.json") + "\n",
+		"created   " + filepath.Join("schemas", "deploy.schema.json") + "\n",
+		"Documentation generated: 3 created\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q on the first run, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no ANSI colors when not writing to a terminal, got:\n%s", out)
+	}
+
+	out = generate(append(args, "--version", "2.0.0")...)
+	for _, want := range []string{"updated   README.md\n", "Documentation generated: 1 updated, 2 unchanged\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q after a version bump, got:\n%s", want, out)
+		}
+	}
+
+	// The streamed README is compared like the rendered one
+	out = generate(append(args, "--version", "2.0.0")...)
+	if !strings.Contains(out, "unchanged README.md\n") {
+		t.Errorf("expected the README unchanged on a rerun, got:\n%s", out)
+	}
+
+	// Templates that failed to parse are reported as skipped
+	var buf bytes.Buffer
+	if code := exitCode(run([]string{"--no-cache", "--project-path", "g/p", "--version", "2.0.0", "--keep-going"}, &buf)); code != exitParse {
+		t.Fatalf("expected exit code %d with a broken template, got %d", exitParse, code)
+	}
+	for _, want := range []string{"skipped   " + filepath.Join("templates", "broken.yml") + "\n", "Documentation generated (1 unchanged, 1 skipped), without"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q with --keep-going, got:\n%s", want, buf.String())
+		}
+	}
+}
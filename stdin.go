@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// stdinInput is where --stdin reads the component template from.
+var stdinInput io.Reader = os.Stdin
+
+// stdinTemplate writes the template read from r to <dir>/<name>.yml, so it
+// parses like a file of a templates directory named dir and gets the
+// component name (and the description, examples and per-component template)
+// it would have in the repository.
+func stdinTemplate(r io.Reader, name, dir string) error {
+	clean := path.Clean(strings.TrimSuffix(name, ".yml"))
+	if name == "" || clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("invalid component name %q", name)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading the template from stdin: %w", err)
+	}
+	target := filepath.Join(dir, filepath.FromSlash(clean)+".yml")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, content, 0644)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdinTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := stdinTemplate(strings.NewReader("spec:\n  inputs: {}\n"), "aws/deploy", dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "aws", "deploy.yml")); string(data) != "spec:\n  inputs: {}\n" {
+		t.Errorf("expected the template under its name, got %q", data)
+	}
+	for _, name := range []string{"", "../deploy", "/deploy", "."} {
+		if err := stdinTemplate(strings.NewReader(""), name, dir); err == nil {
+			t.Errorf("expected an error for the name %q", name)
+		}
+	}
+}
+
+func TestRun_Stdin(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { stdinInput = os.Stdin }()
+
+	os.MkdirAll("docs", 0755)
+	os.WriteFile(filepath.Join("docs", "deploy.md"), []byte("Deploys the application.\n"), 0644)
+	stdinInput = strings.NewReader("spec:\n  inputs:\n    environment:\n      default: staging\n---\ndeploy:\n  script: make deploy\n")
+
+	var out bytes.Buffer
+	if err := run([]string{"generate", "--stdin", "--name", "deploy", "--project-path", "group/project", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"## deploy", "group/project/deploy@1.0.0", "Deploys the application.", "| environment |"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected no output file to be written")
+	}
+
+	stdinInput = strings.NewReader("spec: [\n")
+	if got := exitCode(run([]string{"--stdin", "--name", "deploy"}, io.Discard)); got != exitParse {
+		t.Errorf("expected exit code %d for an invalid template, got %d", exitParse, got)
+	}
+	if got := exitCode(run([]string{"--stdin"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without --name, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"--stdin", "--name", "deploy", "--check"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d with --check, got %d", exitConfig, got)
+	}
+	if got := exitCode(run([]string{"--name", "deploy"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for --name without --stdin, got %d", exitConfig, got)
+	}
+}
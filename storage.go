@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// largeArtifactPatterns match the base name of artifact paths that are known
+// to get big: dependency trees, build output directories and archives.
+var largeArtifactPatterns = []string{
+	"node_modules", "vendor", ".m2", ".gradle", ".venv", "venv", "target", "build", "dist",
+	"*.tar", "*.tar.gz", "*.tgz", "*.zip", "*.7z", "*.iso", "*.img", "*.qcow2", "*.jar", "*.war", "*.whl",
+}
+
+// ArtifactData describes the artifacts uploaded by a job, for the storage
+// impact note.
+type ArtifactData struct {
+	Job      string   `json:"job"`
+	ExpireIn string   `json:"expire_in,omitempty"` // empty means the instance default
+	Paths    []string `json:"paths"`
+	Large    []string `json:"large,omitempty"` // paths likely to be large
+}
+
+// Expiry returns the artifact expiration for display.
+func (a ArtifactData) Expiry() string {
+	if a.ExpireIn == "" {
+		return "instance default (30 days)"
+	}
+	return a.ExpireIn
+}
+
+// NeverExpires reports whether the artifacts are kept forever.
+func (a ArtifactData) NeverExpires() bool {
+	return strings.EqualFold(strings.TrimSpace(a.ExpireIn), "never")
+}
+
+// isLargeArtifact reports whether an artifact path matches a known large output.
+func isLargeArtifact(p string) bool {
+	p = strings.TrimSuffix(p, "/**")
+	p = strings.TrimSuffix(p, "/*")
+	base := path.Base(strings.TrimRight(p, "/"))
+	for _, pattern := range largeArtifactPatterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// artifactUsage lists the jobs that upload artifacts (`artifacts:paths` or
+// `artifacts:untracked`), sorted by job name. Hidden jobs are templates and
+// don't upload anything themselves; `default:artifacts` is reported as "default".
+func artifactUsage(body []map[string]interface{}) []ArtifactData {
+	var artifacts []ArtifactData
+	for _, doc := range body {
+		for key, value := range doc {
+			if strings.HasPrefix(key, ".") || (globalKeywords[key] && key != "default") {
+				continue
+			}
+			job, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			spec, ok := job["artifacts"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			data := ArtifactData{Job: key}
+			if expire, ok := spec["expire_in"]; ok && expire != nil {
+				data.ExpireIn = fmt.Sprintf("%v", expire)
+			}
+			if paths, ok := spec["paths"].([]interface{}); ok {
+				for _, p := range paths {
+					data.Paths = append(data.Paths, fmt.Sprintf("%v", p))
+				}
+			}
+			if untracked, _ := spec["untracked"].(bool); untracked {
+				data.Paths = append(data.Paths, "untracked files")
+			}
+			if len(data.Paths) == 0 {
+				continue
+			}
+			for _, p := range data.Paths {
+				if p == "untracked files" || isLargeArtifact(p) {
+					data.Large = append(data.Large, p)
+				}
+			}
+			artifacts = append(artifacts, data)
+		}
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Job < artifacts[j].Job
+	})
+	return artifacts
+}
+
+// StorageWarnings returns the storage notes of the component's artifacts:
+// potentially large outputs and artifacts that never expire, in job order.
+func (c ComponentData) StorageWarnings() []string {
+	var warnings []string
+	for _, a := range c.Artifacts {
+		if len(a.Large) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s uploads potentially large outputs: %s", codeSpan(a.Job), codeList(a.Large)))
+		}
+		if a.NeverExpires() {
+			warnings = append(warnings, fmt.Sprintf("%s artifacts never expire and count against storage until deleted", codeSpan(a.Job)))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArtifactUsage(t *testing.T) {
+	body, err := decodeBody([]byte(`spec:
+  inputs: {}
+---
+.base:
+  artifacts:
+    paths: [hidden]
+default:
+  artifacts:
+    paths: [logs/]
+build:
+  artifacts:
+    expire_in: never
+    paths:
+      - node_modules/
+      - report.txt
+test:
+  artifacts:
+    reports:
+      junit: report.xml
+pack:
+  artifacts:
+    expire_in: 1 week
+    untracked: true
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	artifacts := artifactUsage(body)
+	var jobs []string
+	for _, a := range artifacts {
+		jobs = append(jobs, a.Job)
+	}
+	if strings.Join(jobs, ",") != "build,default,pack" {
+		t.Fatalf("expected jobs [build default pack], got %v", jobs)
+	}
+
+	build := artifacts[0]
+	if !build.NeverExpires() || build.Expiry() != "never" {
+		t.Errorf("expected build artifacts to never expire, got %q", build.ExpireIn)
+	}
+	if strings.Join(build.Large, ",") != "node_modules/" {
+		t.Errorf("expected node_modules/ to be flagged as large, got %v", build.Large)
+	}
+
+	def := artifacts[1]
+	if def.Expiry() != "instance default (30 days)" || def.NeverExpires() || len(def.Large) != 0 {
+		t.Errorf("unexpected default artifacts: %+v", def)
+	}
+
+	pack := artifacts[2]
+	if strings.Join(pack.Paths, ",") != "untracked files" || len(pack.Large) != 1 {
+		t.Errorf("expected untracked files to be flagged as large, got %+v", pack)
+	}
+}
+
+func TestIsLargeArtifact(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"node_modules/", true},
+		{"frontend/node_modules/**", true},
+		{"dist", true},
+		{"out/image.tar.gz", true},
+		{"app.jar", true},
+		{"coverage.xml", false},
+		{"reports/*", false},
+	}
+	for _, tt := range tests {
+		if got := isLargeArtifact(tt.path); got != tt.expected {
+			t.Errorf("isLargeArtifact(%q) = %v, want %v", tt.path, got, tt.expected)
+		}
+	}
+}
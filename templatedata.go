@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+
+	"github.com/goccy/go-yaml"
+)
+
+// templateDataVersion is the version of the data model README templates are
+// executed with, available as .DataVersion. Within a version fields are only
+// added; a field renamed or removed bumps it, and the old name keeps working
+// through templateFieldAliases.
+const templateDataVersion = 1
+
+// templateFieldAliases maps the old names of renamed fields, by
+// "Type.OldName", to their current name. Templates using an old name are
+// rewritten when parsed, and `template lint` reports them.
+var templateFieldAliases = map[string]string{}
+
+// applyFieldAliases rewrites the old field names of a parsed template to the
+// current ones, for a template executed with data of type data.
+func applyFieldAliases(tmpl *template.Template, funcs template.FuncMap, data reflect.Type) {
+	if len(templateFieldAliases) == 0 {
+		return
+	}
+	l := &templateLinter{funcs: funcs, tmpl: tmpl, walked: make(map[string]bool)}
+	l.walkTemplate(tmpl.Name(), data)
+}
+
+// writeTemplateData prints the data a README template is executed with as
+// YAML, keyed by the field names templates use (e.g. ProjectPath), with
+// empty fields included so every available field shows.
+func writeTemplateData(w io.Writer, data TemplateData) error {
+	data.DataVersion = templateDataVersion
+	out, err := yaml.Marshal(templateDataTree(reflect.ValueOf(data)))
+	if err != nil {
+		return fmt.Errorf("error encoding the template data: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// templateDataTree converts a value of the data model to ordered YAML
+// values, with structs keyed by Go field name, then by method name for the
+// methods templates can call without arguments. Embedded structs are
+// flattened, like templates see them.
+func templateDataTree(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		var fields yaml.MapSlice
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Anonymous {
+				if embedded, ok := templateDataTree(v.Field(i)).(yaml.MapSlice); ok {
+					fields = append(fields, embedded...)
+				}
+				continue
+			}
+			fields = append(fields, yaml.MapItem{Key: field.Name, Value: templateDataTree(v.Field(i))})
+		}
+		// Methods without arguments (e.g. HasOptions) read like fields
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		for i := 0; i < ptr.NumMethod(); i++ {
+			method := ptr.Type().Method(i)
+			if method.Type.NumIn() == 1 && method.Type.NumOut() == 1 && method.Name != "String" {
+				fields = append(fields, yaml.MapItem{Key: method.Name, Value: templateDataTree(ptr.Method(i).Call(nil)[0])})
+			}
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = templateDataTree(v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() {
+			return map[string]interface{}{}
+		}
+		entries := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			entries[fmt.Sprint(key.Interface())] = templateDataTree(v.MapIndex(key))
+		}
+		return entries
+	}
+	return v.Interface()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteTemplateData(t *testing.T) {
+	data := TemplateData{
+		ProjectPath: "group/project",
+		Version:     "1.0.0",
+		Components:  []ComponentData{{Name: "build", Inputs: []InputData{{Name: "stage", Default: "build"}}}},
+	}
+	var out bytes.Buffer
+	if err := writeTemplateData(&out, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"DataVersion: 1\n", "ProjectPath: group/project\n", "- Name: build\n", "DefaultBranch: \"\"\n", "HasOptions: false\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+	if !strings.HasPrefix(out.String(), "DataVersion: 1\n") {
+		t.Errorf("expected the data version first, got:\n%s", out.String())
+	}
+}
+
+func TestFieldAliases(t *testing.T) {
+	templateFieldAliases["ComponentData.Title2"] = "Title"
+	defer delete(templateFieldAliases, "ComponentData.Title2")
+
+	settings := Settings{Format: "markdown", Template: filepath.Join(t.TempDir(), "README.md.tmpl")}
+	content := "{{ range .Components }}{{ .Title2 }}{{ end }}"
+	os.WriteFile(settings.Template, []byte(content), 0644)
+	doc, err := renderDocument(settings, TemplateData{Components: []ComponentData{{Name: "build", Title: "Build"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(doc)) != "Build" {
+		t.Errorf("expected the old name to render the new field, got %q", doc)
+	}
+
+	issues, err := lintTemplate("README.md.tmpl", content, templateFuncs(settings), reflect.TypeOf(TemplateData{}), "markdown", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 1 || issues[0].String() != "README.md.tmpl:1:26: warning: .Title2 was renamed to .Title; the old name still works for now" {
+		t.Errorf("expected a rename warning, got %v", issues)
+	}
+}
+
+func TestRun_TemplateDataDump(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n---\ncompile:\n  stage: $[[ inputs.stage ]]\n  script: make\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"--template-data-dump", "--project-path", "group/project", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "ProjectPath: group/project\n") || !strings.Contains(out.String(), "- Name: stage\n") {
+		t.Errorf("unexpected dump:\n%s", out.String())
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected no output file to be written")
+	}
+}
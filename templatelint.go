@@ -0,0 +1,404 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateFieldNotes lists the data model fields a template should no longer
+// use, by "Type.Field", with what to use instead. They still render, so
+// using them is a warning.
+var templateFieldNotes = map[string]string{
+	"ComponentData.DeprecationNotice": "it is always in English; use {{ deprecationNotice . }}, which follows the locale",
+}
+
+// templateIssue is a problem found in a README or per-component template.
+type templateIssue struct {
+	Location string // file:line:column
+	Severity string
+	Message  string
+}
+
+func (i templateIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Location, i.Severity, i.Message)
+}
+
+// templateLinter checks the fields a template references against the data
+// model, following the type of dot through range and with, variables and
+// the templates it calls.
+type templateLinter struct {
+	funcs  template.FuncMap
+	tmpl   *template.Template
+	issues []templateIssue
+	walked map[string]bool // "template|type" pairs already checked
+}
+
+// lintTemplate parses a template with the README template functions and the
+// partials of format, and checks it against data, the value it is executed
+// with.
+func lintTemplate(name, content string, funcs template.FuncMap, data reflect.Type, format, partialsDir string) ([]templateIssue, error) {
+	tmpl, err := newTemplate(name, content, funcs, format, partialsDir)
+	if err != nil {
+		return nil, err
+	}
+	l := &templateLinter{funcs: funcs, tmpl: tmpl, walked: make(map[string]bool)}
+	l.walkTemplate(name, data)
+	sort.SliceStable(l.issues, func(i, j int) bool { return lessLocation(l.issues[i].Location, l.issues[j].Location) })
+	return l.issues, nil
+}
+
+// lessLocation orders file:line:column locations by line, then column.
+func lessLocation(a, b string) bool {
+	var al, ac, bl, bc int
+	fmt.Sscanf(a[strings.Index(a, ":")+1:], "%d:%d", &al, &ac)
+	fmt.Sscanf(b[strings.Index(b, ":")+1:], "%d:%d", &bl, &bc)
+	if al != bl {
+		return al < bl
+	}
+	return ac < bc
+}
+
+// walkTemplate checks a named template executed with dot of type dot.
+func (l *templateLinter) walkTemplate(name string, dot reflect.Type) {
+	t := l.tmpl.Lookup(name)
+	key := name + "|" + fmt.Sprint(dot)
+	if t == nil || t.Tree == nil || l.walked[key] {
+		return
+	}
+	l.walked[key] = true
+	l.walkList(t.Tree, t.Tree.Root, dot, map[string]reflect.Type{"$": dot})
+}
+
+func (l *templateLinter) walkList(tree *parse.Tree, list *parse.ListNode, dot reflect.Type, vars map[string]reflect.Type) {
+	if list == nil {
+		return
+	}
+	// Variables declared in a list are visible until its end
+	scope := make(map[string]reflect.Type, len(vars))
+	for k, v := range vars {
+		scope[k] = v
+	}
+	for _, node := range list.Nodes {
+		l.walkNode(tree, node, dot, scope)
+	}
+}
+
+func (l *templateLinter) walkNode(tree *parse.Tree, node parse.Node, dot reflect.Type, vars map[string]reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		l.pipeType(tree, n.Pipe, dot, vars)
+	case *parse.IfNode:
+		l.pipeType(tree, n.Pipe, dot, vars)
+		l.walkList(tree, n.List, dot, vars)
+		l.walkList(tree, n.ElseList, dot, vars)
+	case *parse.WithNode:
+		inner := l.pipeType(tree, n.Pipe, dot, vars)
+		l.walkList(tree, n.List, inner, vars)
+		l.walkList(tree, n.ElseList, dot, vars)
+	case *parse.RangeNode:
+		collection := l.pipeType(tree, n.Pipe, dot, vars)
+		key, elem := rangeTypes(collection)
+		inner := make(map[string]reflect.Type, len(vars)+2)
+		for k, v := range vars {
+			inner[k] = v
+		}
+		switch decl := n.Pipe.Decl; len(decl) {
+		case 1:
+			inner[decl[0].Ident[0]] = elem
+		case 2:
+			inner[decl[0].Ident[0]], inner[decl[1].Ident[0]] = key, elem
+		}
+		l.walkList(tree, n.List, elem, inner)
+		l.walkList(tree, n.ElseList, dot, vars)
+	case *parse.TemplateNode:
+		arg := l.pipeType(tree, n.Pipe, dot, vars)
+		if n.Pipe == nil {
+			arg = nil
+		}
+		if l.tmpl.Lookup(n.Name) == nil {
+			location, _ := tree.ErrorContext(n)
+			l.issues = append(l.issues, templateIssue{location, severityError, fmt.Sprintf("no template or partial named %q", n.Name)})
+			return
+		}
+		l.walkTemplate(n.Name, arg)
+	case *parse.ListNode:
+		l.walkList(tree, n, dot, vars)
+	}
+}
+
+// pipeType checks a pipeline and returns the type of its value, nil when
+// unknown. Variables it declares are added to vars.
+func (l *templateLinter) pipeType(tree *parse.Tree, pipe *parse.PipeNode, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var typ reflect.Type
+	for _, cmd := range pipe.Cmds {
+		typ = l.commandType(tree, cmd, dot, vars)
+	}
+	for _, decl := range pipe.Decl {
+		if !pipe.IsAssign || vars[decl.Ident[0]] == nil {
+			vars[decl.Ident[0]] = typ
+		}
+	}
+	return typ
+}
+
+func (l *templateLinter) commandType(tree *parse.Tree, cmd *parse.CommandNode, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	var typ reflect.Type
+	for i, arg := range cmd.Args {
+		t := l.argType(tree, arg, dot, vars)
+		if i == 0 {
+			typ = t
+		}
+	}
+	return typ
+}
+
+func (l *templateLinter) argType(tree *parse.Tree, arg parse.Node, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	switch a := arg.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return l.fieldType(tree, a, dot, a.Ident)
+	case *parse.VariableNode:
+		typ, ok := vars[a.Ident[0]]
+		if !ok {
+			return nil
+		}
+		return l.fieldType(tree, a, typ, a.Ident[1:])
+	case *parse.ChainNode:
+		return l.fieldType(tree, a, l.argType(tree, a.Node, dot, vars), a.Field)
+	case *parse.PipeNode:
+		return l.pipeType(tree, a, dot, vars)
+	case *parse.IdentifierNode:
+		if fn := reflect.ValueOf(l.funcs[a.Ident]); fn.IsValid() && fn.Type().NumOut() > 0 {
+			return fn.Type().Out(0)
+		}
+	}
+	return nil
+}
+
+// fieldType resolves a chain of fields (or methods) from typ, reporting the
+// unknown ones and the ones with a note. It returns nil when typ is unknown
+// or a field is missing.
+func (l *templateLinter) fieldType(tree *parse.Tree, node parse.Node, typ reflect.Type, fields []string) reflect.Type {
+	for i, name := range fields {
+		if typ == nil {
+			return nil
+		}
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		var next reflect.Type
+		owner := typ.Name()
+		switch typ.Kind() {
+		case reflect.Interface:
+			return nil
+		case reflect.Map:
+			// Map keys (e.g. .Extensions.owner) are data, not the model
+			next = typ.Elem()
+		case reflect.Struct:
+			if field, ok := typ.FieldByName(name); ok && field.IsExported() {
+				next = field.Type
+				// Promoted fields belong to the embedded struct
+				if len(field.Index) > 1 {
+					owner = typ.FieldByIndex(field.Index[:len(field.Index)-1]).Type.Name()
+				}
+			} else if alias, ok := templateFieldAliases[typ.Name()+"."+name]; ok {
+				// Renamed field: rewrite the node, so the template renders
+				if field, ok := typ.FieldByName(alias); ok {
+					fields[i], next = alias, field.Type
+					location, _ := tree.ErrorContext(node)
+					l.issues = append(l.issues, templateIssue{location, severityWarning, fmt.Sprintf(".%s was renamed to .%s; the old name still works for now", name, alias)})
+				}
+			} else if method, ok := reflect.PointerTo(typ).MethodByName(name); ok && method.Type.NumOut() > 0 {
+				next = method.Type.Out(0)
+			}
+		}
+		location, _ := tree.ErrorContext(node)
+		if next == nil {
+			l.issues = append(l.issues, templateIssue{location, severityError, fmt.Sprintf("unknown field .%s in %s%s", name, typ.Name(), fieldHint(typ, name))})
+			return nil
+		}
+		if note, ok := templateFieldNotes[owner+"."+name]; ok {
+			l.issues = append(l.issues, templateIssue{location, severityWarning, fmt.Sprintf(".%s: %s", name, note)})
+		}
+		typ = next
+	}
+	return typ
+}
+
+// fieldHint suggests the field of typ closest to a misspelled name, or
+// returns "" when none is close.
+func fieldHint(typ reflect.Type, name string) string {
+	if typ.Kind() != reflect.Struct {
+		return fmt.Sprintf(" (a %s has no fields)", typ)
+	}
+	best, bestDistance := "", 3
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if d := editDistance(strings.ToLower(field.Name), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = field.Name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean .%s?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// rangeTypes returns the key and element types of ranging over typ.
+func rangeTypes(typ reflect.Type) (reflect.Type, reflect.Type) {
+	if typ == nil {
+		return nil, nil
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0), typ.Elem()
+	case reflect.Map:
+		return typ.Key(), typ.Elem()
+	}
+	return nil, nil
+}
+
+// runTemplate implements `template lint`: check the README template and the
+// per-component templates against the data model, so fields renamed or
+// removed by an upgrade don't silently render empty sections.
+func runTemplate(args []string, stdout io.Writer) error {
+	if len(args) == 0 || (args[0] != "lint" && !isHelpFlag(args[0])) {
+		return withExitCode(exitConfig, errors.New("usage: gitlab-component-docs-gen template lint [flags] [FILE...]"))
+	}
+
+	flags := newFlagSet("template")
+	quiet := flags.Bool("quiet", false, "Suppress informational output (errors are still reported)")
+	var overrides Settings
+	flags.StringVar(&overrides.Template, "template", "", "README template path (default depends on --format)")
+	flags.StringVar(&overrides.PartialsDir, "partials-dir", "", "Directory of partials replacing sections of the templates (default depends on --format)")
+	flags.StringVar(&overrides.Format, "format", "", "Output format of the templates: markdown or asciidoc (default \"markdown\")")
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	flags.StringVar(&overrides.DocsDir, "docs-dir", "", "Directory containing component descriptions (default \"docs\")")
+	if args[0] == "lint" {
+		args = args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	log := newLogger(stdout, logLevelFor(*quiet, false, false), "text")
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	if settings.Format == "json" {
+		return withExitCode(exitConfig, errors.New("template lint: the json format has no template"))
+	}
+
+	// The README template, then the per-component ones (or the given files)
+	type lintTarget struct {
+		path    string
+		data    reflect.Type
+		builtin []byte // content of the built-in README template, when path doesn't exist
+	}
+	var targets []lintTarget
+	if flags.NArg() > 0 {
+		for _, path := range flags.Args() {
+			data := reflect.TypeOf(TemplateData{})
+			if strings.HasSuffix(path, overrideExtensions[settings.Format]) && filepath.Base(path) != filepath.Base(settings.Template) {
+				data = reflect.TypeOf(ComponentTemplateData{})
+			}
+			targets = append(targets, lintTarget{path, data, nil})
+		}
+	} else {
+		partials, _ := filepath.Glob(filepath.Join(settings.PartialsDir, "*.tmpl"))
+		if _, err := os.Stat(settings.Template); err == nil {
+			targets = append(targets, lintTarget{settings.Template, reflect.TypeOf(TemplateData{}), nil})
+		} else if len(partials) > 0 {
+			// The partials are checked where the built-in template calls them
+			targets = append(targets, lintTarget{settings.Template, reflect.TypeOf(TemplateData{}), defaultTemplates[settings.Format]})
+		} else {
+			log.Infof("%s does not exist; the built-in template is used", settings.Template)
+		}
+		for _, dir := range []string{settings.TemplatesDir, settings.DocsDir} {
+			matches, _ := filepath.Glob(filepath.Join(dir, "*"+overrideExtensions[settings.Format]))
+			for _, path := range matches {
+				targets = append(targets, lintTarget{path, reflect.TypeOf(ComponentTemplateData{}), nil})
+			}
+		}
+	}
+
+	funcs := templateFuncs(settings)
+	funcs["override"] = func(c ComponentData) (string, error) { return "", nil }
+	var issues []templateIssue
+	for _, target := range targets {
+		content := target.builtin
+		if content == nil {
+			var err error
+			if content, err = os.ReadFile(target.path); err != nil {
+				return withExitCode(exitConfig, fmt.Errorf("error reading template file: %w", err))
+			}
+			if content, err = decodeSource(target.path, content); err != nil {
+				return withExitCode(exitTemplate, err)
+			}
+		}
+		found, err := lintTemplate(target.path, string(content), funcs, target.data, settings.Format, settings.PartialsDir)
+		if err != nil {
+			return withExitCode(exitTemplate, fmt.Errorf("error parsing template file: %w", err))
+		}
+		issues = append(issues, found...)
+	}
+	errorCount := 0
+	for _, issue := range issues {
+		fmt.Fprintln(stdout, issue)
+		if issue.Severity == severityError {
+			errorCount++
+		}
+	}
+	if errorCount > 0 {
+		return withExitCode(exitTemplate, fmt.Errorf("template lint failed: %d error(s)", errorCount))
+	}
+	if len(targets) > 0 {
+		log.Infof("Checked %d template(s)", len(targets))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintTemplate(t *testing.T) {
+	content := `{{ range .Components }}{{ .Nmae }}
+{{ range $i, $input := .Inputs }}{{ $input.Name }} {{ $input.Descripton }}{{ end }}
+{{ with .Requirements }}{{ .Stages }}{{ end }}{{ range .Jobs }}{{ .Needs }}{{ end }}
+{{ .DeprecationNotice }}{{ .Extensions.owner }}{{ (index .Inputs 0).Whatever }}{{ end }}
+{{ define "footer" }}{{ .Version.Major }}{{ end }}{{ template "footer" . }}{{ $.ProjectPath }}`
+	issues, err := lintTemplate("README.md.tmpl", content, templateFuncs(Settings{}), reflect.TypeOf(TemplateData{}), "markdown", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		"README.md.tmpl:1:26: error: unknown field .Nmae in ComponentData (did you mean .Name?)",
+		"README.md.tmpl:2:60: error: unknown field .Descripton in InputData (did you mean .Description?)",
+		"README.md.tmpl:4:3: warning: .DeprecationNotice: it is always in English; use {{ deprecationNotice . }}, which follows the locale",
+		"README.md.tmpl:4:38: error: unknown field .Extensions in ComponentData",
+		"README.md.tmpl:5:32: error: unknown field .Major in string (a string has no fields)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := lintTemplate("README.md.tmpl", "{{ nosuchfunc . }}", templateFuncs(Settings{}), reflect.TypeOf(TemplateData{}), "markdown", ""); err == nil {
+		t.Error("expected a parse error for an unknown function")
+	}
+}
+
+func TestLintTemplate_Defaults(t *testing.T) {
+	for format, content := range defaultTemplates {
+		funcs := templateFuncs(Settings{Format: format})
+		funcs["override"] = func(c ComponentData) (string, error) { return "", nil }
+		issues, err := lintTemplate(format, string(content), funcs, reflect.TypeOf(TemplateData{}), format, "")
+		if err != nil || len(issues) > 0 {
+			t.Errorf("expected the default %s template to lint clean, got %v %v", format, err, issues)
+		}
+	}
+}
+
+func TestRun_TemplateLint(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.md.tmpl"), []byte("## {{ .Name }} for {{ .ProjectPath }}\n{{ .Stage }}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if got := exitCode(run([]string{"template", "lint"}, &out)); got != exitTemplate {
+		t.Errorf("expected exit code %d, got %d", exitTemplate, got)
+	}
+	if !strings.Contains(out.String(), "templates/build.md.tmpl:2:3: error: unknown field .Stage in ComponentTemplateData") {
+		t.Errorf("expected the per-component template issue, got:\n%s", out.String())
+	}
+
+	os.WriteFile(filepath.Join("templates", "build.md.tmpl"), []byte("## {{ .Name }}\n"), 0644)
+	os.WriteFile("README.md.tmpl", []byte("{{ range .Components }}{{ override . }}{{ end }}\n"), 0644)
+	if err := run([]string{"template", "lint", "--quiet"}, io.Discard); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := exitCode(run([]string{"template"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d without a subcommand, got %d", exitConfig, got)
+	}
+}
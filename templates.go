@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// componentName derives the component name from the template path relative
+// to the templates directory, without the extension, matching how GitLab
+// includes it: templates/deploy.yml is "deploy", templates/aws/deploy.yml is
+// "aws/deploy" and templates/deploy/template.yml is "deploy".
+func componentName(templatesDir, path string) string {
+	rel, err := filepath.Rel(templatesDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+	rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+	if dir, file := filepath.Split(rel); file == "template" && dir != "" {
+		rel = strings.TrimSuffix(dir, "/")
+	}
+	return rel
+}
+
+// findTemplates returns the component templates (*.yml) under dir,
+// including subdirectories, sorted by path, without those the ignore rules
+// skip. Two templates resolving to the same component name (e.g. deploy.yml
+// and deploy/template.yml) are an error.
+func findTemplates(dir string, ignore ignoreRules) ([]string, error) {
+	var templates []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".yml" {
+			templates = append(templates, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding template files: %w", err)
+	}
+	templates = ignore.filter(dir, templates)
+	sort.Strings(templates)
+
+	seen := make(map[string]string, len(templates))
+	for _, t := range templates {
+		name := componentName(dir, t)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("component name collision: %s and %s both define component %q", other, t, name)
+		}
+		seen[name] = t
+	}
+	return templates, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComponentName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"templates/deploy.yml", "deploy"},
+		{"templates/aws/deploy.yml", "aws/deploy"},
+		{"templates/aws/ecs/deploy.yml", "aws/ecs/deploy"},
+		{"templates/deploy/template.yml", "deploy"},
+		{"templates/template.yml", "template"},
+		{"elsewhere/lint.yml", "lint"},
+	}
+	for _, tt := range tests {
+		if got := componentName("templates", tt.path); got != tt.want {
+			t.Errorf("componentName(%q): expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestFindTemplates(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"build.yml", "aws/deploy.yml", "gcp/deploy.yml", "lint/template.yml", "notes.md"} {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("spec:\n  inputs: {}\n"), 0644)
+	}
+
+	templates, err := findTemplates(dir, ignoreRules{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, componentName(dir, tmpl))
+	}
+	if got := strings.Join(names, ","); got != "aws/deploy,build,gcp/deploy,lint" {
+		t.Errorf("expected same-basename templates in different directories to be distinct, got %s", got)
+	}
+
+	if templates, err := findTemplates(filepath.Join(dir, "missing"), ignoreRules{}); err != nil || len(templates) != 0 {
+		t.Errorf("expected no templates and no error for a missing directory, got %v, %v", templates, err)
+	}
+}
+
+func TestFindTemplates_Collision(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "deploy"), 0755)
+	os.WriteFile(filepath.Join(dir, "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "deploy", "template.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+
+	_, err := findTemplates(dir, ignoreRules{})
+	if err == nil || !strings.Contains(err.Error(), `both define component "deploy"`) {
+		t.Errorf("expected a collision error, got %v", err)
+	}
+}
+
+func TestRun_NestedComponents(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "aws"), 0755)
+	os.MkdirAll(filepath.Join(dir, "templates", "gcp"), 0755)
+	os.MkdirAll(filepath.Join(dir, "docs", "aws"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "aws", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "gcp", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "aws", "deploy.md"), []byte("Deploys to AWS.\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--include", "aws/*"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	doc := string(data)
+	if !strings.Contains(doc, "component: $CI_SERVER_FQDN/g/p/aws/deploy@1.0.0") || !strings.Contains(doc, "Deploys to AWS.") {
+		t.Errorf("expected the nested component with its description, got:\n%s", doc)
+	}
+	if strings.Contains(doc, "gcp/deploy") {
+		t.Errorf("expected gcp/deploy to be excluded, got:\n%s", doc)
+	}
+
+	os.MkdirAll(filepath.Join("templates", "aws", "deploy"), 0755)
+	os.WriteFile(filepath.Join("templates", "aws", "deploy", "template.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	if got := exitCode(run([]string{"--quiet", "--project-path", "g/p"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for colliding component names, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// tocMarker is emitted by the `toc` template function and replaced with the
+// table of contents once the whole document has been rendered, so that the
+// links always match the headings the template actually produced.
+const tocMarker = "<!-- gitlab-component-docs-gen:toc -->"
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	nonWordPattern   = regexp.MustCompile(`[^\p{L}\p{N}_\- ]`)
+	multiDashPattern = regexp.MustCompile(`-{2,}`)
+)
+
+// tocFunc is the `toc` template function.
+func tocFunc() string {
+	return tocMarker
+}
+
+// anchorSlugger generates heading anchors the way GitLab does: lowercase,
+// punctuation removed, spaces turned into dashes, and an incrementing suffix
+// for repeated headings.
+type anchorSlugger struct {
+	seen map[string]int
+}
+
+func newAnchorSlugger() *anchorSlugger {
+	return &anchorSlugger{seen: make(map[string]int)}
+}
+
+func (s *anchorSlugger) slug(heading string) string {
+	slug := strings.ToLower(strings.TrimSpace(heading))
+	slug = nonWordPattern.ReplaceAllString(slug, "")
+	slug = strings.ReplaceAll(slug, " ", "-")
+	slug = multiDashPattern.ReplaceAllString(slug, "-")
+
+	n, exists := s.seen[slug]
+	s.seen[slug] = n + 1
+	if exists {
+		return fmt.Sprintf("%s-%d", slug, n)
+	}
+	return slug
+}
+
+// insertTOC replaces the toc marker in a rendered Markdown document with a
+// nested list linking every second-level heading (one per component in the
+// default template) and the inputs heading (in the run's locale) below it.
+func insertTOC(doc []byte, inputsHeading string) []byte {
+	if !bytes.Contains(doc, []byte(tocMarker)) {
+		return doc
+	}
+	contents := newTOCContents(inputsHeading)
+	for _, line := range strings.Split(string(doc), "\n") {
+		contents.line(line)
+	}
+	return bytes.ReplaceAll(doc, []byte(tocMarker), contents.list())
+}
+
+// tocContents collects the entries of the table of contents from the lines
+// of a document, in order.
+type tocContents struct {
+	inputsHeading string
+	slugger       *anchorSlugger
+	inFence       bool
+	toc           strings.Builder
+}
+
+func newTOCContents(inputsHeading string) *tocContents {
+	return &tocContents{inputsHeading: inputsHeading, slugger: newAnchorSlugger()}
+}
+
+func (c *tocContents) line(line string) {
+	if strings.HasPrefix(strings.TrimSpace(line), "```") {
+		c.inFence = !c.inFence
+		return
+	}
+	if c.inFence {
+		return
+	}
+	m := headingPattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	anchor := c.slugger.slug(m[2])
+	switch {
+	case len(m[1]) == 2:
+		fmt.Fprintf(&c.toc, "- [%s](#%s)\n", m[2], anchor)
+	case m[2] == c.inputsHeading:
+		fmt.Fprintf(&c.toc, "  - [%s](#%s)\n", m[2], anchor)
+	}
+}
+
+// list returns the table of contents as a Markdown list.
+func (c *tocContents) list() []byte {
+	return []byte(strings.TrimSuffix(c.toc.String(), "\n"))
+}
+
+// tocWriter is the streaming form of insertTOC: lines before the toc marker
+// go straight through to w, while their headings are collected; from the
+// marker on, the document is buffered until Close, as the table of contents
+// needs the headings below it too. Without a marker nothing is buffered
+// beyond the current line.
+type tocWriter struct {
+	w        io.Writer
+	contents *tocContents
+	line     []byte
+	rest     *bytes.Buffer // the document from the line with the marker on
+	err      error
+}
+
+func newTOCWriter(w io.Writer, inputsHeading string) *tocWriter {
+	return &tocWriter{w: w, contents: newTOCContents(inputsHeading)}
+}
+
+func (t *tocWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 && t.rest == nil {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			t.line = append(t.line, p...)
+			return n, t.err
+		}
+		t.line = append(t.line, p[:i+1]...)
+		p = p[i+1:]
+		t.endLine()
+	}
+	if t.rest != nil {
+		t.rest.Write(p)
+	}
+	return n, t.err
+}
+
+// endLine writes the current line, or starts buffering if it has the marker.
+func (t *tocWriter) endLine() {
+	if bytes.Contains(t.line, []byte(tocMarker)) {
+		t.rest = getBuffer()
+		t.rest.Write(t.line)
+	} else {
+		t.contents.line(string(bytes.TrimSuffix(t.line, []byte("\n"))))
+		t.write(t.line)
+	}
+	t.line = t.line[:0]
+}
+
+// Close writes the last line and the buffered rest of the document, with
+// the table of contents in place of the marker. It does not close the
+// underlying writer.
+func (t *tocWriter) Close() error {
+	if t.rest == nil {
+		if len(t.line) > 0 {
+			t.endLine()
+		}
+		if t.rest == nil {
+			return t.err
+		}
+	}
+	defer putBuffer(t.rest)
+	rest := t.rest.Bytes()
+	for _, line := range strings.Split(string(rest), "\n") {
+		t.contents.line(line)
+	}
+	t.write(bytes.ReplaceAll(rest, []byte(tocMarker), t.contents.list()))
+	return t.err
+}
+
+func (t *tocWriter) write(p []byte) {
+	if t.err == nil {
+		_, t.err = t.w.Write(p)
+	}
+}
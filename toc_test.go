@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnchorSlugger(t *testing.T) {
+	slugger := newAnchorSlugger()
+	tests := []struct {
+		heading  string
+		expected string
+	}{
+		{"build", "build"},
+		{"Inputs", "inputs"},
+		{"Inputs", "inputs-1"},
+		{"Docker Build & Push", "docker-build-push"},
+		{"deploy_aws-eks", "deploy_aws-eks"},
+		{"Inputs", "inputs-2"},
+	}
+	for _, tt := range tests {
+		if got := slugger.slug(tt.heading); got != tt.expected {
+			t.Errorf("slug(%q) = %q, want %q", tt.heading, got, tt.expected)
+		}
+	}
+}
+
+func TestInsertTOC(t *testing.T) {
+	doc := `# Catalog
+
+` + tocMarker + `
+
+## build
+
+` + "```yaml\n## not a heading\n```" + `
+
+### Inputs
+
+### Environment variables
+
+## deploy
+
+### Inputs
+`
+	got := string(insertTOC([]byte(doc), "Inputs"))
+
+	expected := `- [build](#build)
+  - [Inputs](#inputs)
+- [deploy](#deploy)
+  - [Inputs](#inputs-1)`
+	if !strings.Contains(got, expected) {
+		t.Errorf("expected TOC:\n%s\ngot document:\n%s", expected, got)
+	}
+	if strings.Contains(got, tocMarker) {
+		t.Error("expected TOC marker to be replaced")
+	}
+	if strings.Contains(got, "not-a-heading") {
+		t.Error("expected headings inside code fences to be ignored")
+	}
+}
+
+func TestInsertTOC_NoMarker(t *testing.T) {
+	doc := []byte("## build\n\n### Inputs\n")
+	if got := insertTOC(doc, "Inputs"); string(got) != string(doc) {
+		t.Errorf("expected document without marker to be unchanged, got %q", got)
+	}
+}
+
+func TestTOCWriter(t *testing.T) {
+	doc := "## intro\n\n" + tocMarker + "\n\n## build\n\n### Inputs\n\n## build\n"
+	for _, size := range []int{1, 7, len(doc)} {
+		var out bytes.Buffer
+		w := newTOCWriter(&out, "Inputs")
+		for rest := doc; rest != ""; {
+			n := min(size, len(rest))
+			w.Write([]byte(rest[:n]))
+			rest = rest[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := string(insertTOC([]byte(doc), "Inputs")); out.String() != want {
+			t.Errorf("expected %q with writes of %d bytes, got %q", want, size, out.String())
+		}
+	}
+	if !strings.Contains(string(insertTOC([]byte(doc), "Inputs")), "- [intro](#intro)\n- [build](#build)\n  - [Inputs](#inputs)\n- [build](#build-1)") {
+		t.Error("expected the headings above and below the marker")
+	}
+
+	var out bytes.Buffer
+	w := newTOCWriter(&out, "Inputs")
+	w.Write([]byte("## build\n\ncontent"))
+	if out.String() != "## build\n\n" {
+		t.Errorf("expected the lines without a marker to go through before Close, got %q", out.String())
+	}
+	w.Close()
+	if out.String() != "## build\n\ncontent" {
+		t.Errorf("expected the document unchanged without a marker, got %q", out.String())
+	}
+}
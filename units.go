@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// UnitConfig is a documentation unit of a monorepo: a group of components
+// documented in its own output file, e.g. the aws/ and gcp/ subtrees each
+// with their own README. Unset directories and template fall back to the
+// top-level settings; include/exclude replace the top-level ones.
+type UnitConfig struct {
+	Name         string   `yaml:"name"`
+	TemplatesDir string   `yaml:"templates_dir"`
+	DocsDir      string   `yaml:"docs_dir"`
+	ExamplesDir  string   `yaml:"examples_dir"`
+	Template     string   `yaml:"template"`
+	Output       string   `yaml:"output"`
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude"`
+}
+
+// validateUnits checks the `units` setting: every unit has a unique name and
+// its own output file.
+func validateUnits(units []UnitConfig) error {
+	names := make(map[string]bool, len(units))
+	outputs := make(map[string]string, len(units))
+	for i, u := range units {
+		if u.Name == "" {
+			return fmt.Errorf("units[%d]: missing name", i)
+		}
+		if names[u.Name] {
+			return fmt.Errorf("duplicate unit %q", u.Name)
+		}
+		names[u.Name] = true
+		if u.Output == "" {
+			return fmt.Errorf("unit %q: missing output", u.Name)
+		}
+		if other, ok := outputs[u.Output]; ok {
+			return fmt.Errorf("units %q and %q both write %s", other, u.Name, u.Output)
+		}
+		outputs[u.Output] = u.Name
+	}
+	return nil
+}
+
+// selectUnits returns the units named by --unit, in config order, or all of
+// them when none is named.
+func selectUnits(units []UnitConfig, names []string) ([]UnitConfig, error) {
+	if len(names) == 0 {
+		return units, nil
+	}
+	var known []string
+	for _, u := range units {
+		known = append(known, u.Name)
+	}
+	for _, name := range names {
+		if !contains(known, name) {
+			return nil, fmt.Errorf("unknown unit %q (expected one of: %s)", name, strings.Join(known, ", "))
+		}
+	}
+	var selected []UnitConfig
+	for _, u := range units {
+		if contains(names, u.Name) {
+			selected = append(selected, u)
+		}
+	}
+	return selected, nil
+}
+
+// unitArgs returns the flags running a command on a unit. The templates
+// directory is always given, which keeps the nested run from expanding into
+// units again. `validate` writes nothing, so it takes no template or output.
+func unitArgs(command string, u UnitConfig, defaultTemplatesDir string) []string {
+	var args []string
+	add := func(flag, value string) {
+		if value != "" {
+			args = append(args, "--"+flag, value)
+		}
+	}
+	if u.TemplatesDir == "" {
+		u.TemplatesDir = defaultTemplatesDir
+	}
+	add("templates-dir", u.TemplatesDir)
+	add("docs-dir", u.DocsDir)
+	add("examples-dir", u.ExamplesDir)
+	if command != "validate" {
+		add("template", u.Template)
+		add("output", u.Output)
+	}
+	for _, pattern := range u.Include {
+		add("include", pattern)
+	}
+	for _, pattern := range u.Exclude {
+		add("exclude", pattern)
+	}
+	return args
+}
+
+// runUnits runs a command once per selected unit, with the unit's settings
+// before the command line's flags (so these win). The units share the parse
+// cache, which the caller saves; the entries of the units that did not run
+// are kept. The first failing unit stops the run; its error carries its exit
+// code.
+func runUnits(command string, args []string, config ProjectConfig, names []string, cache *parseCache, stdout io.Writer) error {
+	units := config.Units
+	if err := validateUnits(units); err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	selected, err := selectUnits(units, names)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	templatesDir := config.TemplatesDir
+	if templatesDir == "" {
+		templatesDir = "templates"
+	}
+	if len(selected) < len(units) {
+		cache.keepUnused()
+	}
+	for _, u := range selected {
+		if err := runGenerateShared(command, append(unitArgs(command, u, templatesDir), args...), stdout, cache); err != nil {
+			cache.keepUnused()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateUnits(t *testing.T) {
+	if err := validateUnits([]UnitConfig{{Name: "aws", Output: "aws/README.md"}, {Name: "gcp", Output: "gcp/README.md"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, units := range [][]UnitConfig{
+		{{Output: "README.md"}},
+		{{Name: "aws"}},
+		{{Name: "aws", Output: "a.md"}, {Name: "aws", Output: "b.md"}},
+		{{Name: "aws", Output: "README.md"}, {Name: "gcp", Output: "README.md"}},
+	} {
+		if err := validateUnits(units); err == nil {
+			t.Errorf("expected an error for %+v", units)
+		}
+	}
+}
+
+func TestSelectUnits(t *testing.T) {
+	units := []UnitConfig{{Name: "aws"}, {Name: "gcp"}, {Name: "azure"}}
+	if got, _ := selectUnits(units, nil); len(got) != 3 {
+		t.Errorf("expected every unit, got %v", got)
+	}
+	got, err := selectUnits(units, []string{"azure", "aws"})
+	if err != nil || len(got) != 2 || got[0].Name != "aws" || got[1].Name != "azure" {
+		t.Errorf("expected aws and azure in config order, got %v, %v", got, err)
+	}
+	if _, err := selectUnits(units, []string{"oci"}); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
+
+func TestUnitArgs(t *testing.T) {
+	u := UnitConfig{Name: "aws", DocsDir: "docs/aws", Output: "aws/README.md", Include: []string{"aws-*"}}
+	want := []string{"--templates-dir", "templates", "--docs-dir", "docs/aws", "--output", "aws/README.md", "--include", "aws-*"}
+	if got := unitArgs("generate", u, "templates"); !reflect.DeepEqual(got, want) {
+		t.Errorf("unitArgs(generate) = %v, want %v", got, want)
+	}
+	want = []string{"--templates-dir", "templates", "--docs-dir", "docs/aws", "--include", "aws-*"}
+	if got := unitArgs("validate", u, "templates"); !reflect.DeepEqual(got, want) {
+		t.Errorf("unitArgs(validate) = %v, want %v", got, want)
+	}
+}
+
+func TestRun_Units(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates", "aws"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "aws", "s3.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "gcp-gcs.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "gcp-gke.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte(`project_path: group/project
+version: 1.0.0
+units:
+  - name: aws
+    templates_dir: templates/aws
+    output: aws/README.md
+  - name: gcp
+    include: [gcp-*]
+    output: gcp/README.md
+`), 0644)
+	os.MkdirAll(filepath.Join(dir, "aws"), 0755)
+	os.MkdirAll(filepath.Join(dir, "gcp"), 0755)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--unit", "gcp"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("aws", "README.md")); !os.IsNotExist(err) {
+		t.Error("expected --unit gcp to leave the aws unit alone")
+	}
+
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	aws, _ := os.ReadFile(filepath.Join("aws", "README.md"))
+	if !strings.Contains(string(aws), "group/project/s3@1.0.0") || strings.Contains(string(aws), "gcp-") {
+		t.Errorf("unexpected aws docs:\n%s", aws)
+	}
+	gcp, _ := os.ReadFile(filepath.Join("gcp", "README.md"))
+	if !strings.Contains(string(gcp), "gcp-gcs") || !strings.Contains(string(gcp), "gcp-gke") || strings.Contains(string(gcp), "s3") {
+		t.Errorf("unexpected gcp docs:\n%s", gcp)
+	}
+	if _, err := os.Stat("README.md"); !os.IsNotExist(err) {
+		t.Error("expected no top-level README with units")
+	}
+	// The units share the cache: none drops the others' entries
+	if entries := loadParseCache(cacheFile).entries; len(entries) != 3 {
+		t.Errorf("expected the templates of every unit cached, got %v", entries)
+	}
+	if err := run([]string{"--quiet", "--unit", "aws"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries := loadParseCache(cacheFile).entries; len(entries) != 3 {
+		t.Errorf("expected --unit aws to keep the gcp entries, got %v", entries)
+	}
+
+	if err := run([]string{"check", "--quiet"}, io.Discard); err != nil {
+		t.Errorf("expected every unit to be up to date, got %v", err)
+	}
+	if err := run([]string{"validate", "--quiet"}, io.Discard); err != nil {
+		t.Errorf("unexpected validate error: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"--unit", "oci"},
+		{"--include", "gcp-gke"},
+		{"--watch"},
+	} {
+		if got := exitCode(run(args, io.Discard)); got != exitConfig {
+			t.Errorf("expected exit code %d for %v, got %d", exitConfig, args, got)
+		}
+	}
+}
+
+func TestRun_UnitWithoutUnits(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if got := exitCode(run([]string{"--unit", "aws"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// promptOutput receives the questions of `use`, whose stdout is the include
+// block, so that it can be redirected to a file.
+var promptOutput io.Writer = os.Stderr
+
+// runUse implements the `use` subcommand: it asks for the inputs of a
+// component, checking each answer like GitLab would, and prints the
+// `include:` block using it.
+func runUse(args []string, stdout io.Writer) error {
+	flags := newFlagSet("use")
+	projectPath := flags.String("project-path", "", "GitLab project path of the component (e.g. group/project)")
+	version := flags.String("version", "", "Component version to include (e.g. 1.0.0)")
+	remote := flags.String("remote", "", "Git remote used to detect the project path (default \"origin\")")
+	all := flags.Bool("all", false, "Also ask for the optional inputs, offering their default")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "Maximum number of component templates parsed concurrently")
+	var overrides Settings
+	flags.StringVar(&overrides.TemplatesDir, "templates-dir", "", "Directory containing component templates (default \"templates\")")
+	name, args := splitCommandArg(args)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	if name == "" && flags.NArg() > 0 {
+		name = flags.Arg(0)
+	} else if flags.NArg() > 0 {
+		return withExitCode(exitConfig, fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+	if name == "" {
+		return withExitCode(exitConfig, errors.New("use: expected the component to include (e.g. deploy or aws/deploy)"))
+	}
+	if *jobs < 1 {
+		return withExitCode(exitConfig, fmt.Errorf("invalid --jobs %d: must be at least 1", *jobs))
+	}
+
+	config, err := readProjectConfig(configFile)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	settings, err := resolveSettings(config, overrides)
+	if err != nil {
+		return withExitCode(exitConfig, err)
+	}
+	component, err := newComponentIndex(settings, *jobs).lookup(name)
+	if err != nil {
+		return withExitCode(exitConfig, fmt.Errorf("use: %w", err))
+	}
+	decls, err := readInputDeclarations(component.path)
+	if err != nil {
+		return withExitCode(exitParse, err)
+	}
+	data, err := resolveProject(config.Mirrors, *projectPath, *version, resolveRemote(*remote))
+	if err != nil {
+		return err
+	}
+
+	if component.Deprecated {
+		fmt.Fprintf(promptOutput, "Warning: %s\n", component.DeprecationNotice())
+	}
+	values, err := askInputs(bufio.NewReader(stdinInput), promptOutput, component, decls, *all)
+	if err != nil {
+		return withExitCode(exitConfig, fmt.Errorf("use: %w", err))
+	}
+	out, err := includeBlock(fmt.Sprintf("$CI_SERVER_FQDN/%s/%s@%s", data.ProjectPath, component.Name, data.Version), values)
+	if err != nil {
+		return withExitCode(exitFailure, err)
+	}
+	_, err = stdout.Write(out)
+	return err
+}
+
+// askInputs asks for the required inputs of a component, and the optional
+// ones with all, until each answer is valid. An empty answer keeps the
+// default of an optional input, which is then left out.
+func askInputs(in *bufio.Reader, out io.Writer, c ComponentData, decls map[string]Inputs, all bool) (yaml.MapSlice, error) {
+	var values yaml.MapSlice
+	for _, input := range c.Inputs {
+		if !input.Required && !all {
+			continue
+		}
+		decl := decls[input.Name]
+		fmt.Fprintln(out)
+		if input.Description != "" {
+			fmt.Fprintf(out, "%s: %s\n", input.Name, strings.SplitN(input.Description, "\n", 2)[0])
+		}
+		if len(decl.Options) > 0 {
+			fmt.Fprintf(out, "One of: %s\n", strings.Join(input.Options, ", "))
+		}
+		if input.Sensitive {
+			fmt.Fprintf(out, "Sensitive: prefer a masked CI/CD variable, e.g. $%s\n", strings.ToUpper(input.Name))
+		}
+		var notes []string
+		notes = append(notes, input.Type)
+		if input.Required {
+			notes = append(notes, "required")
+		}
+		if input.Deprecated {
+			notes = append(notes, "deprecated")
+		}
+		prompt := fmt.Sprintf("%s (%s)", input.Name, strings.Join(notes, ", "))
+		if !input.Required && !input.Sensitive {
+			prompt += fmt.Sprintf(" [%s]", formatInputValue(decl.Default))
+		}
+		for {
+			fmt.Fprintf(out, "%s: ", prompt)
+			line, err := in.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				if !input.Required {
+					break
+				}
+				if err == io.EOF {
+					return nil, fmt.Errorf("no value for the required input %s", input.Name)
+				}
+				fmt.Fprintln(out, "A value is required.")
+				continue
+			}
+			value, verr := parseInputValue(input.Type, decl, answer)
+			if verr == nil {
+				values = append(values, yaml.MapItem{Key: input.Name, Value: value})
+				break
+			}
+			if err == io.EOF {
+				return nil, fmt.Errorf("invalid value for %s: %w", input.Name, verr)
+			}
+			fmt.Fprintf(out, "Invalid value: %v.\n", verr)
+		}
+	}
+	return values, nil
+}
+
+// parseInputValue converts an answer to the input's type, and checks it
+// against the input's options and regex.
+func parseInputValue(typ string, decl Inputs, answer string) (interface{}, error) {
+	var value interface{} = answer
+	switch typ {
+	case "boolean":
+		b, err := strconv.ParseBool(answer)
+		if err != nil || (answer != "true" && answer != "false") {
+			return nil, errors.New("expected true or false")
+		}
+		value = b
+	case "number":
+		n, err := strconv.ParseFloat(answer, 64)
+		if err != nil {
+			return nil, errors.New("expected a number")
+		}
+		value = n
+		if n == float64(int64(n)) {
+			value = int64(n)
+		}
+	case "array":
+		var list []interface{}
+		if err := yaml.Unmarshal([]byte(answer), &list); err != nil || !strings.HasPrefix(answer, "[") {
+			return nil, errors.New("expected an array, e.g. [a, b]")
+		}
+		value = list
+	}
+	if len(decl.Options) > 0 {
+		found := false
+		for _, option := range decl.Options {
+			found = found || fmt.Sprint(option) == fmt.Sprint(value)
+		}
+		if !found {
+			var options []string
+			for _, option := range decl.Options {
+				options = append(options, fmt.Sprint(option))
+			}
+			return nil, fmt.Errorf("expected one of: %s", strings.Join(options, ", "))
+		}
+	}
+	if decl.Regex != "" && typ == "string" {
+		// GitLab writes the pattern between slashes; one Go cannot compile is
+		// left to GitLab
+		pattern := strings.TrimSuffix(strings.TrimPrefix(decl.Regex, "/"), "/")
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(answer) {
+			return nil, fmt.Errorf("expected a match of %s", decl.Regex)
+		}
+	}
+	return value, nil
+}
+
+// formatInputValue formats a default for a prompt, as YAML flow.
+func formatInputValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	out, err := yaml.MarshalWithOptions(value, yaml.Flow(true))
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// includeBlock returns the `include:` block of a component reference, with
+// its inputs when there are any.
+func includeBlock(ref string, values yaml.MapSlice) ([]byte, error) {
+	entry := yaml.MapSlice{{Key: "component", Value: ref}}
+	if len(values) > 0 {
+		entry = append(entry, yaml.MapItem{Key: "inputs", Value: values})
+	}
+	out, err := yaml.MarshalWithOptions(yaml.MapSlice{{Key: "include", Value: []interface{}{entry}}}, yaml.IndentSequence(true))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the include block: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseInputValue(t *testing.T) {
+	tests := []struct {
+		typ    string
+		decl   Inputs
+		answer string
+		want   interface{}
+		ok     bool
+	}{
+		{"string", Inputs{}, "eu-west-1", "eu-west-1", true},
+		{"string", Inputs{Regex: `/^[a-z]{2}-[a-z]+-\d$/`}, "eu-west-1", "eu-west-1", true},
+		{"string", Inputs{Regex: `/^[a-z]{2}-[a-z]+-\d$/`}, "EU", nil, false},
+		{"string", Inputs{Options: []interface{}{"staging", "production"}}, "production", "production", true},
+		{"string", Inputs{Options: []interface{}{"staging", "production"}}, "dev", nil, false},
+		{"number", Inputs{}, "3", int64(3), true},
+		{"number", Inputs{}, "0.5", 0.5, true},
+		{"number", Inputs{}, "three", nil, false},
+		{"number", Inputs{Options: []interface{}{uint64(1), uint64(3)}}, "3", int64(3), true},
+		{"boolean", Inputs{}, "true", true, true},
+		{"boolean", Inputs{}, "1", nil, false},
+		{"array", Inputs{}, "[a, b]", []interface{}{"a", "b"}, true},
+		{"array", Inputs{}, "a", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := parseInputValue(tt.typ, tt.decl, tt.answer)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s %q: unexpected error %v", tt.typ, tt.answer, err)
+			continue
+		}
+		if tt.ok && formatInputValue(got) != formatInputValue(tt.want) {
+			t.Errorf("%s %q: expected %v, got %v", tt.typ, tt.answer, tt.want, got)
+		}
+	}
+}
+
+func TestRun_Use(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte(`spec:
+  inputs:
+    region:
+      description: AWS region.
+      regex: /^[a-z]{2}-[a-z]+-\d$/
+    replicas:
+      type: number
+      default: 1
+    tags:
+      type: array
+---
+deploy:
+  script: echo $[[ inputs.region ]] $[[ inputs.replicas ]] $[[ inputs.tags ]]
+`), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	defer func() { stdinInput, promptOutput = os.Stdin, os.Stderr }()
+
+	var prompts, out bytes.Buffer
+	promptOutput = &prompts
+	stdinInput = strings.NewReader("EU\neu-west-1\n\n[a, b]\n")
+	if err := run([]string{"use", "deploy", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "include:\n  - component: $CI_SERVER_FQDN/g/p/deploy@1.0.0\n    inputs:\n      region: eu-west-1\n      tags:\n        - a\n        - b\n"
+	if out.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out.String())
+	}
+	if !strings.Contains(prompts.String(), "region: AWS region.") || !strings.Contains(prompts.String(), "Invalid value: expected a match of") {
+		t.Errorf("expected the description and the validation error, got:\n%s", prompts.String())
+	}
+	if strings.Contains(prompts.String(), "replicas") {
+		t.Errorf("expected optional inputs to be skipped, got:\n%s", prompts.String())
+	}
+
+	out.Reset()
+	stdinInput = strings.NewReader("eu-west-1\n[a]\n2\n")
+	if err := run([]string{"use", "deploy", "--all", "--project-path", "g/p", "--version", "1.0.0"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "      replicas: 2\n") || !strings.Contains(prompts.String(), "replicas (number) [1]: ") {
+		t.Errorf("expected --all to ask for the optional inputs, got:\n%s", out.String())
+	}
+
+	stdinInput = strings.NewReader("EU\n")
+	if code := exitCode(run([]string{"use", "deploy"}, io.Discard)); code != exitConfig {
+		t.Errorf("expected exit code %d without a valid answer, got %d", exitConfig, code)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Issue severities reported by --validate. Only errors fail validation.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// Issue is a problem found while validating a component template.
+type Issue struct {
+	Severity  string `json:"severity"`
+	Component string `json:"component"`
+	Input     string `json:"input,omitempty"`
+	Message   string `json:"message"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"` // 1-based, 0 when unknown
+}
+
+func (i Issue) String() string {
+	if i.Input != "" {
+		return fmt.Sprintf("%s: %s: input %q: %s", i.Severity, i.Component, i.Input, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Component, i.Message)
+}
+
+// validateComponent checks a parsed component for inconsistencies.
+func validateComponent(c ComponentData) []Issue {
+	var issues []Issue
+	for _, name := range c.Undeclared {
+		issues = append(issues, Issue{
+			Severity:  severityError,
+			Component: c.Name,
+			Input:     name,
+			Message:   "used in the jobs but not declared in spec:inputs",
+		})
+	}
+	referenced := make(map[string]bool)
+	for _, input := range c.Inputs {
+		for _, ref := range input.References {
+			referenced[ref] = true
+		}
+	}
+	for _, input := range c.Inputs {
+		if c.HasBody && len(input.UsedIn) == 0 && !referenced[input.Name] {
+			issues = append(issues, Issue{
+				Severity:  severityWarning,
+				Component: c.Name,
+				Input:     input.Name,
+				Message:   "declared but never used in the jobs",
+			})
+		}
+		if input.Shorthand && strings.TrimSpace(input.Description) == "" {
+			issues = append(issues, Issue{
+				Severity:  severityWarning,
+				Component: c.Name,
+				Input:     input.Name,
+				Message:   "declared without a body (a required string input); add a description",
+			})
+		}
+		if !input.Required && len(input.Options) > 0 && !contains(input.Options, input.Default) {
+			issues = append(issues, Issue{
+				Severity:  severityError,
+				Component: c.Name,
+				Input:     input.Name,
+				Message:   fmt.Sprintf("default %q is not one of the allowed options (%s)", input.Default, strings.Join(input.Options, ", ")),
+			})
+		}
+	}
+	for i := range issues {
+		issues[i].File = c.path
+		issues[i].Line = c.lines[issues[i].Input]
+	}
+	return issues
+}
+
+// countErrors returns the number of error-severity issues.
+func countErrors(issues []Issue) int {
+	n := 0
+	for _, issue := range issues {
+		if issue.Severity == severityError {
+			n++
+		}
+	}
+	return n
+}
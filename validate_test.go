@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateComponent_Options(t *testing.T) {
+	component := ComponentData{
+		Name: "deploy",
+		Inputs: []InputData{
+			{Name: "level", Default: "trace", Options: []string{"debug", "info", "warn"}},
+			{Name: "mode", Default: "fast", Options: []string{"fast", "safe"}},
+			{Name: "target", Required: true, Options: []string{"staging", "production"}},
+		},
+	}
+
+	issues := validateComponent(component)
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d: %v", len(issues), issues)
+	}
+	if issues[0].Severity != severityError || issues[0].Input != "level" {
+		t.Errorf("unexpected issue: %+v", issues[0])
+	}
+	if !strings.Contains(issues[0].String(), `input "level"`) {
+		t.Errorf("expected issue text to name the input, got %q", issues[0].String())
+	}
+}
+
+func TestRun_Validate(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	yamlContent := `spec:
+  inputs:
+    log_level:
+      default: trace
+      options: [debug, info, warn]
+`
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte(yamlContent), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	err := run([]string{"--validate"}, &out)
+	if exitCode(err) != exitInvalid {
+		t.Fatalf("expected exit code %d, got %d (%v)", exitInvalid, exitCode(err), err)
+	}
+	if !strings.Contains(out.String(), "not one of the allowed options") {
+		t.Errorf("expected issue in output, got %q", out.String())
+	}
+	for _, file := range []string{"README.md", "README.md.tmpl"} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("expected --validate not to create %s", file)
+		}
+	}
+
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte(strings.Replace(yamlContent, "trace", "info", 1)), 0644)
+	if err := run([]string{"--validate", "--quiet"}, &out); err != nil {
+		t.Errorf("expected validation to pass, got %v", err)
+	}
+}
+
+func TestValidateComponent_Usage(t *testing.T) {
+	component := ComponentData{
+		Name:       "build",
+		HasBody:    true,
+		Undeclared: []string{"typo_name"},
+		Inputs: []InputData{
+			{Name: "app_name", UsedIn: []string{"build"}},
+			{Name: "base", Required: true},
+			{Name: "cache_key", References: []string{"base"}, UsedIn: []string{"build"}},
+			{Name: "unused", Required: true},
+		},
+	}
+
+	issues := validateComponent(component)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Severity+":"+issue.Input)
+	}
+	if strings.Join(got, ",") != "error:typo_name,warning:unused" {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+}
+
+func TestValidateComponent_ShorthandInputs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.yml")
+	os.WriteFile(path, []byte(`spec:
+  inputs:
+    app_name:
+    region: # Region to deploy to.
+    stage: {}
+    replicas:
+      default: 1
+---
+deploy:
+  stage: $[[ inputs.stage ]]
+  script: deploy $[[ inputs.app_name ]] $[[ inputs.region ]] $[[ inputs.replicas ]]
+`), 0644)
+	c, err := parseTemplate(path, ParseOptions{TemplatesDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Inputs) != 4 {
+		t.Fatalf("expected the shorthand inputs to be kept, got %+v", c.Inputs)
+	}
+	for _, input := range c.Inputs {
+		if shorthand := input.Name != "replicas"; input.Shorthand != shorthand || input.Required != shorthand {
+			t.Errorf("expected %s to be shorthand and required: %v, got %+v", input.Name, shorthand, input)
+		}
+		if input.Shorthand && input.Type != "string" {
+			t.Errorf("expected a shorthand input to be a string, got %+v", input)
+		}
+	}
+
+	issues := validateComponent(c)
+	var inputs []string
+	for _, issue := range issues {
+		if issue.Severity != severityWarning || !strings.Contains(issue.Message, "add a description") || issue.Line == 0 {
+			t.Errorf("unexpected issue: %+v", issue)
+		}
+		inputs = append(inputs, issue.Input)
+	}
+	if strings.Join(inputs, ",") != "app_name,stage" {
+		t.Errorf("expected warnings for the undescribed shorthand inputs, got %v", issues)
+	}
+}
+
+func TestRun_ValidateFlowShorthand(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: { app_name: }\n---\nbuild:\n  script: make $[[ inputs.app_name ]]\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	var out bytes.Buffer
+	if err := run([]string{"--validate"}, &out); err != nil {
+		t.Fatalf("expected warnings not to fail validation, got %v", err)
+	}
+	if want := `warning: build: input "app_name": declared without a body (a required string input); add a description`; !strings.Contains(out.String(), want) {
+		t.Errorf("expected %q in:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VariableData is a CI/CD variable defined by a component's `variables:`
+// blocks, the interface of components that predate spec:inputs.
+type VariableData struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"` // formatted like input defaults
+	Description string `json:"description,omitempty"`
+	Scope       string `json:"scope"` // "global" or the job name
+}
+
+// componentVariables lists the global and job-level `variables:` of the body
+// documents, hidden jobs included since other jobs inherit them through
+// `extends`. Variables are sorted by name, then scope (global first).
+func componentVariables(body []map[string]interface{}) []VariableData {
+	var variables []VariableData
+	collect := func(scope string, block interface{}) {
+		vars, ok := block.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for name, value := range vars {
+			v := VariableData{Name: name, Scope: scope}
+			// Variables can be plain values or {value:, description:} maps
+			if m, ok := value.(map[string]interface{}); ok {
+				value = m["value"]
+				if description, ok := m["description"]; ok && description != nil {
+					v.Description = strings.TrimSpace(fmt.Sprintf("%v", description))
+				}
+			}
+			v.Value = formatDefault(value)
+			variables = append(variables, v)
+		}
+	}
+
+	for _, doc := range body {
+		for key, value := range doc {
+			if key == "variables" {
+				collect("global", value)
+				continue
+			}
+			if globalKeywords[key] {
+				continue
+			}
+			if job, ok := value.(map[string]interface{}); ok {
+				collect(key, job["variables"])
+			}
+		}
+	}
+
+	sort.Slice(variables, func(i, j int) bool {
+		a, b := variables[i], variables[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if (a.Scope == "global") != (b.Scope == "global") {
+			return a.Scope == "global"
+		}
+		return a.Scope < b.Scope
+	})
+	return variables
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComponentVariables(t *testing.T) {
+	body, err := decodeBody([]byte(`variables:
+  DEPLOY_ENV:
+    value: staging
+    description: Target environment
+  DEBUG: false
+build:
+  variables:
+    DEPLOY_ENV: production
+    IMAGE: $CI_REGISTRY_IMAGE:$[[ inputs.tag ]]
+  script: echo
+.base:
+  variables:
+    EMPTY: ""
+stages: [build]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, v := range componentVariables(body) {
+		got = append(got, strings.Join([]string{v.Name, v.Value, v.Scope, v.Description}, "|"))
+	}
+	want := []string{
+		"DEBUG|false|global|",
+		"DEPLOY_ENV|staging|global|Target environment",
+		"DEPLOY_ENV|production|build|",
+		"EMPTY|`\"\"`|.base|",
+		"IMAGE|`$CI_REGISTRY_IMAGE:$[[ inputs.tag ]]`|build|",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected variables:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRun_VariablesSection(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "legacy.yml"), []byte("variables:\n  LINT_PATHS:\n    value: src\n    description: Paths to lint | comma-separated\nlint:\n  script: lint $LINT_PATHS\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "modern.yml"), []byte("spec:\n  inputs: {}\n---\nbuild:\n  script: echo\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	if !strings.Contains(string(data), "### Variables\n\n| Variable | Default | Defined in | Description |\n|----------|---------|------------|-------------|\n| `LINT_PATHS` | src | global | Paths to lint \\| comma-separated |\n") {
+		t.Errorf("expected a variables table, got:\n%s", data)
+	}
+	if strings.Count(string(data), "### Variables") != 1 {
+		t.Errorf("expected a variables table only for the component defining variables, got:\n%s", data)
+	}
+
+	if err := run([]string{"--quiet", "--format", "asciidoc"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile("README.adoc"); !strings.Contains(string(data), "| `LINT_PATHS` | src | global | Paths to lint \\| comma-separated\n") {
+		t.Errorf("expected an AsciiDoc variables table, got:\n%s", data)
+	}
+}
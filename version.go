@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime/debug"
+)
+
+// Build metadata, set at build time with
+// -ldflags "-X main.buildVersion=1.2.3 -X main.buildCommit=<sha> -X main.buildDate=<RFC 3339>".
+// Without them, the module version and VCS stamp recorded by `go build` or
+// `go install` are used when available.
+var (
+	buildVersion = "dev"
+	buildCommit  = ""
+	buildDate    = ""
+)
+
+// footerPattern matches the footer line added by --footer, whatever the
+// version, so --check can ignore it.
+var footerPattern = regexp.MustCompile(`\n\n_Generated by gitlab-component-docs-gen [^\n_]+_\n$`)
+
+// buildInfo returns the tool's version, commit and build date.
+func buildInfo() (version, commit, date string) {
+	version, commit, date = buildVersion, buildCommit, buildDate
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version, commit, date
+	}
+	if version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && commit == "":
+			commit = setting.Value
+		case setting.Key == "vcs.time" && date == "":
+			date = setting.Value
+		}
+	}
+	return version, commit, date
+}
+
+// toolVersion returns the tool's version for display: "v1.2.3" for
+// releases, as is otherwise (e.g. "dev", or a commit from git describe).
+func toolVersion() string {
+	version, _, _ := buildInfo()
+	if version != "" && version[0] >= '0' && version[0] <= '9' {
+		version = "v" + version
+	}
+	return version
+}
+
+// runVersion implements the `version` subcommand.
+func runVersion(args []string, stdout io.Writer) error {
+	flags := newFlagSet("version")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return withExitCode(exitConfig, err)
+	}
+	_, commit, date := buildInfo()
+	fmt.Fprintf(stdout, "gitlab-component-docs-gen %s\n", toolVersion())
+	if commit != "" {
+		fmt.Fprintf(stdout, "commit: %s\n", commit)
+	}
+	if date != "" {
+		fmt.Fprintf(stdout, "built: %s\n", date)
+	}
+	return nil
+}
+
+// generatedFooter returns the "generated by" line ending a rendered Markdown
+// or AsciiDoc document (both use `_..._` for emphasis), after a blank line.
+func generatedFooter() string {
+	return fmt.Sprintf("\n\n_Generated by gitlab-component-docs-gen %s_\n", toolVersion())
+}
+
+// stripFooter removes the footer added by generatedFooter, if any.
+func stripFooter(doc []byte) []byte {
+	if loc := footerPattern.FindIndex(doc); loc != nil {
+		return append(doc[:loc[0]:loc[0]], '\n')
+	}
+	return doc
+}
+
+// sameDocument reports whether two renderings of the output are equal,
+// ignoring the footer and normalized: upgrading the tool alone, or checking
+// out the docs with CRLF line endings, doesn't make them outdated.
+func sameDocument(a, b []byte) bool {
+	return bytes.Equal(stripFooter(normalizeDocument(a)), stripFooter(normalizeDocument(b)))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunVersion(t *testing.T) {
+	defer func(version, commit, date string) {
+		buildVersion, buildCommit, buildDate = version, commit, date
+	}(buildVersion, buildCommit, buildDate)
+	buildVersion, buildCommit, buildDate = "1.2.3", "0123abc", "2026-01-02T03:04:05Z"
+
+	var out bytes.Buffer
+	if err := run([]string{"version"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "gitlab-component-docs-gen v1.2.3\ncommit: 0123abc\nbuilt: 2026-01-02T03:04:05Z\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestFooter(t *testing.T) {
+	defer func(version string) { buildVersion = version }(buildVersion)
+
+	appendFooter := func(doc []byte) []byte {
+		return append(bytes.TrimRight(doc, "\n"), generatedFooter()...)
+	}
+	buildVersion = "1.0.0"
+	doc := []byte("# Components\n\ncontent\n")
+	withFooter := appendFooter(doc)
+	if !strings.HasSuffix(string(withFooter), "content\n\n_Generated by gitlab-component-docs-gen v1.0.0_\n") {
+		t.Errorf("expected the footer at the end, got %q", withFooter)
+	}
+	if string(stripFooter(withFooter)) != string(doc) {
+		t.Errorf("expected stripping the footer to restore the document, got %q", stripFooter(withFooter))
+	}
+
+	buildVersion = "1.1.0"
+	if !sameDocument(withFooter, appendFooter(doc)) {
+		t.Error("expected documents differing only by the footer version to be the same")
+	}
+	if !sameDocument(doc, appendFooter(doc)) {
+		t.Error("expected enabling the footer alone not to make the document outdated")
+	}
+	if sameDocument(withFooter, appendFooter([]byte("# Components\n\nchanged\n"))) {
+		t.Error("expected a content change to be detected")
+	}
+}
+
+func TestRun_FooterIgnoredByCheck(t *testing.T) {
+	defer func(version string) { buildVersion = version }(buildVersion)
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "build.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	args := []string{"--quiet", "--footer", "--project-path", "group/project", "--version", "1.0.0"}
+	buildVersion = "1.0.0"
+	if err := run(args, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	if !strings.Contains(string(data), "_Generated by gitlab-component-docs-gen v1.0.0_") {
+		t.Errorf("expected the footer in README.md, got:\n%s", data)
+	}
+
+	buildVersion = "2.0.0"
+	if err := run(append([]string{"check"}, args[1:]...), io.Discard); err != nil {
+		t.Errorf("expected check to ignore the footer version, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// webhookFormats are the payload formats of the `webhook.format` setting.
+var webhookFormats = []string{"slack", "json"}
+
+// webhookTimeout bounds the notification request.
+const webhookTimeout = 10 * time.Second
+
+// WebhookConfig configures the notification posted when a run changes the
+// components' public contract. Environment variables in the URL are
+// expanded, so the secret part can stay in a masked CI/CD variable.
+type WebhookConfig struct {
+	URL    string `yaml:"url"`
+	Format string `yaml:"format"` // slack (default) or json
+	Base   string `yaml:"base"`   // git ref holding the previous contract
+}
+
+// ContractChanges summarizes how the components' inputs differ between two
+// versions of the templates.
+type ContractChanges struct {
+	Added   []string          `json:"added_components,omitempty"`
+	Removed []string          `json:"removed_components,omitempty"`
+	Changed []ComponentChange `json:"changed_components,omitempty"`
+}
+
+// ComponentChange lists the inputs of a component that were added, removed
+// or changed (type, required flag, default or options).
+type ComponentChange struct {
+	Name          string   `json:"name"`
+	AddedInputs   []string `json:"added_inputs,omitempty"`
+	RemovedInputs []string `json:"removed_inputs,omitempty"`
+	ChangedInputs []string `json:"changed_inputs,omitempty"`
+}
+
+// Empty reports whether the contract did not change.
+func (c ContractChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// contractChanges groups the interface changes between two versions of the
+// components by component.
+func contractChanges(before, after []ComponentData) ContractChanges {
+	var changes ContractChanges
+	for _, c := range interfaceChanges(before, after) {
+		switch c.Kind {
+		case changeComponentAdded:
+			changes.Added = append(changes.Added, c.Component)
+			continue
+		case changeComponentRemoved:
+			changes.Removed = append(changes.Removed, c.Component)
+			continue
+		}
+		if n := len(changes.Changed); n == 0 || changes.Changed[n-1].Name != c.Component {
+			changes.Changed = append(changes.Changed, ComponentChange{Name: c.Component})
+		}
+		change := &changes.Changed[len(changes.Changed)-1]
+		switch c.Kind {
+		case changeInputAdded:
+			change.AddedInputs = append(change.AddedInputs, c.Input)
+		case changeInputRemoved:
+			change.RemovedInputs = append(change.RemovedInputs, c.Input)
+		default:
+			if n := len(change.ChangedInputs); n == 0 || change.ChangedInputs[n-1] != c.Input {
+				change.ChangedInputs = append(change.ChangedInputs, c.Input)
+			}
+		}
+	}
+	return changes
+}
+
+// summary describes the changes as a short plain-text message.
+func (c ContractChanges) summary(project, version, base string) string {
+	lines := []string{fmt.Sprintf("%s@%s: component contract changed since %s", project, version, base)}
+	for _, name := range c.Added {
+		lines = append(lines, fmt.Sprintf("• Added component %s", codeSpan(name)))
+	}
+	for _, name := range c.Removed {
+		lines = append(lines, fmt.Sprintf("• Removed component %s", codeSpan(name)))
+	}
+	for _, change := range c.Changed {
+		var parts []string
+		for _, part := range []struct {
+			label  string
+			inputs []string
+		}{{"added inputs", change.AddedInputs}, {"removed inputs", change.RemovedInputs}, {"changed inputs", change.ChangedInputs}} {
+			if len(part.inputs) > 0 {
+				parts = append(parts, part.label+" "+codeList(part.inputs))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %s", codeSpan(change.Name), strings.Join(parts, "; ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// webhookPayload returns the request body: Slack's `{"text": ...}` (also
+// understood by Mattermost and most chat tools), or the structured changes
+// with the same text.
+func webhookPayload(format, project, version, base string, changes ContractChanges) ([]byte, error) {
+	text := changes.summary(project, version, base)
+	if format == "json" {
+		return json.Marshal(struct {
+			Project string `json:"project"`
+			Version string `json:"version"`
+			Base    string `json:"base"`
+			ContractChanges
+			Text string `json:"text"`
+		}{project, version, base, changes, text})
+	}
+	return json.Marshal(map[string]string{"text": text})
+}
+
+// postWebhook posts payload to url as JSON.
+func postWebhook(url string, payload []byte) error {
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error posting to the webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error posting to the webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// resolveWebhookBase returns the git ref holding the previous contract: the
+// flag or config value, then the commit before the pipeline's push
+// (CI_COMMIT_BEFORE_SHA, unset or zeros for new branches), then HEAD.
+func resolveWebhookBase(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	if sha := os.Getenv("CI_COMMIT_BEFORE_SHA"); strings.Trim(sha, "0") != "" {
+		return sha
+	}
+	return "HEAD"
+}
+
+// notifyWebhook posts the contract changes since base, if any. It reports
+// whether a notification was sent.
+func notifyWebhook(config WebhookConfig, data TemplateData, settings Settings, jobs int) (bool, error) {
+	before, err := componentsAt(config.Base, settings, jobs)
+	if err != nil {
+		return false, fmt.Errorf("error reading the components at %s: %w", config.Base, err)
+	}
+	changes := contractChanges(before, data.Components)
+	if changes.Empty() {
+		return false, nil
+	}
+	payload, err := webhookPayload(config.Format, data.ProjectPath, data.Version, config.Base, changes)
+	if err != nil {
+		return false, err
+	}
+	return true, postWebhook(os.ExpandEnv(config.URL), payload)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContractChanges(t *testing.T) {
+	before := []ComponentData{
+		{Name: "build", Inputs: []InputData{{Name: "stage", Type: "string", Default: "build"}, {Name: "image", Type: "string"}}},
+		{Name: "lint", Inputs: []InputData{{Name: "stage", Type: "string"}}},
+		{Name: "test", Inputs: []InputData{{Name: "stage", Type: "string"}}},
+	}
+	after := []ComponentData{
+		{Name: "build", Inputs: []InputData{{Name: "stage", Type: "string", Default: "test"}, {Name: "cache", Type: "boolean"}}},
+		{Name: "deploy", Inputs: []InputData{{Name: "env", Type: "string"}}},
+		{Name: "test", Inputs: []InputData{{Name: "stage", Type: "string", Description: "Reworded"}}},
+	}
+	got := contractChanges(before, after)
+	want := ContractChanges{
+		Added:   []string{"deploy"},
+		Removed: []string{"lint"},
+		Changed: []ComponentChange{{Name: "build", AddedInputs: []string{"cache"}, RemovedInputs: []string{"image"}, ChangedInputs: []string{"stage"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\n%+v\nwant:\n%+v", got, want)
+	}
+	wantSummary := "group/project@1.0.0: component contract changed since HEAD\n" +
+		"• Added component `deploy`\n" +
+		"• Removed component `lint`\n" +
+		"• `build`: added inputs `cache`; removed inputs `image`; changed inputs `stage`"
+	if summary := got.summary("group/project", "1.0.0", "HEAD"); summary != wantSummary {
+		t.Errorf("unexpected summary:\n%s\nwant:\n%s", summary, wantSummary)
+	}
+	if !contractChanges(before, before).Empty() {
+		t.Error("expected no changes between identical components")
+	}
+}
+
+func TestResolveWebhookBase(t *testing.T) {
+	t.Setenv("CI_COMMIT_BEFORE_SHA", "0000000000000000000000000000000000000000")
+	if got := resolveWebhookBase(""); got != "HEAD" {
+		t.Errorf("expected HEAD for a new branch, got %q", got)
+	}
+	t.Setenv("CI_COMMIT_BEFORE_SHA", "abc123")
+	if got := resolveWebhookBase(""); got != "abc123" {
+		t.Errorf("expected the commit before the push, got %q", got)
+	}
+	if got := resolveWebhookBase("v1.0.0"); got != "v1.0.0" {
+		t.Errorf("expected the explicit ref, got %q", got)
+	}
+}
+
+func TestRun_Webhook(t *testing.T) {
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+	t.Setenv("CI_COMMIT_BEFORE_SHA", "")
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	var payloads []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+	t.Setenv("WEBHOOK_URL", server.URL)
+
+	os.MkdirAll("templates", 0755)
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n"), 0644)
+	os.WriteFile(configFile, []byte("project_path: group/project\nversion: 1.0.0\nwebhook:\n  url: ${WEBHOOK_URL}\n"), 0644)
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payloads) != 0 {
+		t.Fatalf("expected no notification without contract changes, got %v", payloads)
+	}
+
+	os.WriteFile(filepath.Join("templates", "build.yml"), []byte("spec:\n  inputs:\n    stage:\n      default: build\n    image:\n      default: alpine\n"), 0644)
+	os.WriteFile(filepath.Join("templates", "deploy.yml"), []byte("spec:\n  inputs: {}\n"), 0644)
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("expected one notification, got %v", payloads)
+	}
+	want := "group/project@1.0.0: component contract changed since HEAD\n• Added component `deploy`\n• `build`: added inputs `image`"
+	if payloads[0]["text"] != want {
+		t.Errorf("unexpected text %q, want %q", payloads[0]["text"], want)
+	}
+
+	if err := run([]string{"check", "--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payloads) != 1 {
+		t.Errorf("expected check not to notify, got %v", payloads)
+	}
+
+	os.WriteFile(configFile, []byte("project_path: group/project\nversion: 1.0.0\nwebhook:\n  url: ${WEBHOOK_URL}\n  format: json\n"), 0644)
+	if err := run([]string{"--quiet"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(payloads) != 2 || !reflect.DeepEqual(payloads[1]["added_components"], []interface{}{"deploy"}) || payloads[1]["base"] != "HEAD" {
+		t.Errorf("expected a structured payload, got %v", payloads)
+	}
+
+	// A failing webhook is reported without failing the run
+	if err := run([]string{"--quiet", "--webhook", server.URL + "/missing\x7f"}, io.Discard); err != nil {
+		t.Errorf("expected a webhook failure not to fail the run, got %v", err)
+	}
+
+	os.WriteFile(configFile, []byte("webhook:\n  format: teams\n"), 0644)
+	if got := exitCode(run([]string{"--quiet"}, io.Discard)); got != exitConfig {
+		t.Errorf("expected exit code %d for an unknown webhook format, got %d", exitConfig, got)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+)
+
+// complexDefaultModes lists the supported values of `complex_defaults`: array
+// and map defaults inline as JSON, or as YAML blocks below the inputs table.
+var complexDefaultModes = []string{"json", "yaml"}
+
+// defaultBlocks reads the spec header and returns, per input, its array or
+// map default as a YAML block, keys in the order they were written. Empty
+// collections read well inline, and are left out.
+func defaultBlocks(file *ast.File) map[string]string {
+	spec := mappingValue(specDocument(file), "spec")
+	inputs := mappingValue(spec, "inputs")
+
+	blocks := make(map[string]string)
+	for _, input := range mappingValues(inputs) {
+		node := unwrapAnchor(mappingValue(input.Value, "default"))
+		switch n := node.(type) {
+		case *ast.SequenceNode:
+			if len(n.Values) == 0 {
+				continue
+			}
+		case *ast.MappingNode:
+			if len(n.Values) == 0 {
+				continue
+			}
+		case *ast.MappingValueNode:
+		default:
+			continue
+		}
+		var value interface{}
+		if err := yaml.NodeToValue(node, &value, yaml.UseOrderedMap()); err != nil {
+			continue
+		}
+		out, err := yaml.MarshalWithOptions(value, yaml.IndentSequence(true))
+		if err != nil {
+			continue
+		}
+		block := strings.TrimRight(string(out), "\n")
+		if _, ok := value.([]interface{}); ok {
+			// Indented sequences indent the top-level one too
+			block = strings.ReplaceAll(strings.TrimPrefix(block, "  "), "\n  ", "\n")
+		}
+		blocks[input.Key.GetToken().Value] = block
+	}
+	return blocks
+}
+
+// applyDefaultNotes numbers the inputs whose default is rendered below the
+// table, in the order the tables list them.
+func applyDefaultNotes(mode string, order []string, components []ComponentData) {
+	if mode != "yaml" {
+		return
+	}
+	for i := range components {
+		c := &components[i]
+		numbers := make(map[string]int)
+		for _, group := range inputGroups(*c, order) {
+			for _, input := range group.Inputs {
+				if input.DefaultYAML != "" {
+					numbers[input.Name] = len(numbers) + 1
+				}
+			}
+		}
+		if len(numbers) == 0 {
+			continue
+		}
+		// The inputs may be shared with the parse cache: modify a copy
+		c.Inputs = append([]InputData(nil), c.Inputs...)
+		for j := range c.Inputs {
+			c.Inputs[j].defaultNote = numbers[c.Inputs[j].Name]
+		}
+	}
+}
+
+// noteRef returns the footnote reference of a default rendered below the
+// table: a superscript number.
+func noteRef(dialect string, n int) string {
+	if dialect == "asciidoc" {
+		return fmt.Sprintf("^%d^", n)
+	}
+	return fmt.Sprintf("<sup>%d</sup>", n)
+}
+
+// defaultNotes returns the YAML blocks of a component's numbered defaults,
+// each after its reference and input name, for below the inputs table.
+func defaultNotes(dialect string, c ComponentData, order []string) string {
+	var notes []string
+	for _, group := range inputGroups(c, order) {
+		for _, input := range group.Inputs {
+			if input.defaultNote == 0 {
+				continue
+			}
+			note := noteRef(dialect, input.defaultNote) + " " + codeSpan(input.Name) + ":\n\n"
+			if dialect == "asciidoc" {
+				note += "[source,yaml]\n----\n" + input.DefaultYAML + "\n----"
+			} else {
+				note += "```yaml\n" + input.DefaultYAML + "\n```"
+			}
+			notes = append(notes, note)
+		}
+	}
+	return strings.Join(notes, "\n\n")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const complexDefaultsTemplate = `spec:
+  inputs:
+    rules:
+      type: array
+      default:
+        - if: $CI_COMMIT_BRANCH == "main"
+          when: always
+        - when: never
+    tags:
+      type: array
+      default: []
+    vars:
+      type: object
+      default: {zeta: 1, alpha: [x]}
+---
+deploy:
+  script: echo $[[ inputs.rules ]] $[[ inputs.tags ]] $[[ inputs.vars ]]
+`
+
+func TestParseTemplate_DefaultYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.yml")
+	os.WriteFile(path, []byte(complexDefaultsTemplate), 0644)
+	c, err := parseTemplate(path, ParseOptions{TemplatesDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blocks := map[string]string{}
+	for _, input := range c.Inputs {
+		blocks[input.Name] = input.DefaultYAML
+	}
+	if want := "- if: $CI_COMMIT_BRANCH == \"main\"\n  when: always\n- when: never"; blocks["rules"] != want {
+		t.Errorf("expected %q, got %q", want, blocks["rules"])
+	}
+	if want := "zeta: 1\nalpha:\n  - x"; blocks["vars"] != want {
+		t.Errorf("expected the keys in source order, got %q", blocks["vars"])
+	}
+	if blocks["tags"] != "" {
+		t.Errorf("expected no block for an empty default, got %q", blocks["tags"])
+	}
+}
+
+func TestRun_ComplexDefaultsYAML(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "deploy.yml"), []byte(complexDefaultsTemplate), 0644)
+	os.WriteFile(filepath.Join(dir, configFile), []byte("complex_defaults: yaml\n"), 0644)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile("README.md")
+	readme := string(data)
+	for _, want := range []string{
+		"| rules |  | array | false | <sup>1</sup> |",
+		"| tags |  | array | false | `[]` |",
+		"| vars |  | object | false | <sup>2</sup> |",
+		"<sup>1</sup> `rules`:\n\n```yaml\n- if: $CI_COMMIT_BRANCH == \"main\"\n  when: always\n- when: never\n```\n\n<sup>2</sup> `vars`:",
+	} {
+		if !strings.Contains(readme, want) {
+			t.Errorf("expected %q in:\n%s", want, readme)
+		}
+	}
+
+	if err := run([]string{"--quiet", "--project-path", "g/p", "--version", "1.0.0", "--format", "asciidoc"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile("README.adoc")
+	if !strings.Contains(string(data), "| ^1^ |") || !strings.Contains(string(data), "^1^ `rules`:\n\n[source,yaml]\n----\n- if:") {
+		t.Errorf("expected AsciiDoc footnotes and source blocks, got:\n%s", data)
+	}
+}
+
+func TestResolveSettings_ComplexDefaults(t *testing.T) {
+	if settings, err := resolveSettings(ProjectConfig{}, Settings{}); err != nil || settings.ComplexDefaults != "json" {
+		t.Errorf("expected json by default, got %q, %v", settings.ComplexDefaults, err)
+	}
+	if _, err := resolveSettings(ProjectConfig{ComplexDefaults: "toml"}, Settings{}); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// snippetContext is the number of lines shown above the offending line.
+const snippetContext = 2
+
+// YAMLError is an error located in a YAML file. It reads like a compiler
+// error, `file:line:column: message`, followed by the offending line and the
+// ones above it, with a caret under the column.
+type YAMLError struct {
+	Path    string
+	Line    int // 1-based
+	Column  int // 1-based, 0 when unknown
+	Message string
+	Snippet string
+	Err     error // the underlying error, if any
+}
+
+func (e *YAMLError) Error() string {
+	location := fmt.Sprintf("%s:%d", e.Path, e.Line)
+	if e.Column > 0 {
+		location += fmt.Sprintf(":%d", e.Column)
+	}
+	if e.Snippet == "" {
+		return location + ": " + e.Message
+	}
+	return location + ": " + e.Message + "\n" + e.Snippet
+}
+
+func (e *YAMLError) Unwrap() error { return e.Err }
+
+// locatedError returns a YAMLError at the given position of data.
+func locatedError(path string, data []byte, line, column int, message string) *YAMLError {
+	return &YAMLError{Path: path, Line: line, Column: column, Message: message, Snippet: sourceSnippet(data, line, column)}
+}
+
+// yamlError wraps an error of the YAML library into a YAMLError when it
+// carries the position of the offending token. Other errors are wrapped as
+// "error parsing YAML file".
+func yamlError(path string, data []byte, err error) error {
+	var located yaml.Error
+	if !errors.As(err, &located) || located.GetToken() == nil {
+		return fmt.Errorf("error parsing YAML file %s: %w", path, err)
+	}
+	position := located.GetToken().Position
+	e := locatedError(path, data, position.Line, position.Column, located.GetMessage())
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && typeErr.DstType != nil && typeErr.SrcType != nil {
+		e.Message = fmt.Sprintf("expected %s, got %s", yamlKind(typeErr.DstType), yamlKind(typeErr.SrcType))
+	}
+	e.Err = err
+	return e
+}
+
+// yamlKind describes a Go type as the YAML value decoding into it.
+func yamlKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a sequence"
+	case reflect.Map, reflect.Struct:
+		return "a mapping"
+	}
+	return "a " + t.String()
+}
+
+// sourceSnippet returns the line of data at line (1-based) and the
+// snippetContext lines above it, numbered, with a caret under column. Tabs
+// before the column are kept so the caret lines up.
+func sourceSnippet(data []byte, line, column int) string {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	width := len(fmt.Sprint(line))
+	var b strings.Builder
+	for n := max(1, line-snippetContext); n <= line; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", marker, width, n, strings.TrimRight(lines[n-1], " \t"))
+	}
+	if text := []rune(lines[line-1]); column > 0 && column <= len(text)+1 {
+		indent := strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return r
+			}
+			return ' '
+		}, string(text[:column-1]))
+		fmt.Fprintf(&b, "  %s | %s^\n", strings.Repeat(" ", width), indent)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceSnippet(t *testing.T) {
+	data := []byte("spec:\n  inputs:\n    stage:\n      default: [test\n")
+	want := "  2 |   inputs:\n  3 |     stage:\n> 4 |       default: [test\n    |                ^"
+	if got := sourceSnippet(data, 4, 16); got != want {
+		t.Errorf("unexpected snippet:\n%s\nwant:\n%s", got, want)
+	}
+	want = "   8 | h\n   9 | i\n> 10 | \tj: é\n     | \t   ^"
+	if got := sourceSnippet([]byte("a\nb\nc\nd\ne\nf\ng\nh\ni\n\tj: é\n"), 10, 5); got != want {
+		t.Errorf("expected aligned line numbers and a caret after the tab, got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := sourceSnippet(data, 9, 1); got != "" {
+		t.Errorf("expected no snippet past the end, got %q", got)
+	}
+}
+
+func TestParseTemplate_ErrorFormat(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{
+			"spec:\n  inputs:\n    stage:\n      default: [test\n    name: x\n",
+			"deploy.yml:5:5: ',' or ']' must be specified\n  3 |     stage:\n  4 |       default: [test\n> 5 |     name: x\n    |     ^",
+		},
+		{
+			"spec:\n  inputs:\n    stage:\n      description: [a]\n",
+			"deploy.yml:4:20: expected a string, got a sequence\n  2 |   inputs:\n  3 |     stage:\n> 4 |       description: [a]\n    |                    ^",
+		},
+		{
+			"spec:\n  inputs:\n    stage:\n      x-deprecated: 3\n",
+			"deploy.yml:3:5: input \"stage\": invalid x-deprecated: expected a migration note or a boolean\n  1 | spec:\n  2 |   inputs:\n> 3 |     stage:\n    |     ^",
+		},
+	}
+	dir := t.TempDir()
+	origDir, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(origDir)
+	for _, tt := range tests {
+		os.WriteFile(filepath.Join(dir, "deploy.yml"), []byte(tt.content), 0644)
+		_, err := parseTemplate("deploy.yml", ParseOptions{})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("unexpected error for %q:\n%v\nwant:\n%s", tt.content, err, tt.want)
+		}
+		var located *YAMLError
+		if !errors.As(err, &located) || located.Path != "deploy.yml" || located.Line == 0 {
+			t.Errorf("expected a located error, got %#v", err)
+		}
+	}
+}